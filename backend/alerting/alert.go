@@ -0,0 +1,190 @@
+package alerting
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/devops-kubeadjust/backend/k8s"
+	"github.com/devops-kubeadjust/backend/prometheus"
+	"github.com/devops-kubeadjust/backend/resources"
+)
+
+// Alert conditions.
+const (
+	ConditionMemoryLimit        = "MemoryNearLimit"         // working set ≥ danger threshold of the memory limit
+	ConditionMemoryTrend        = "MemoryTrendExceedsLimit" // predicted to reach the memory limit within trendHours
+	ConditionPVCFull            = "PVCNearlyFull"           // used ≥ pvcThreshold of capacity
+	ConditionNodeMemoryPressure = "NodeMemoryPressure"      // node condition MemoryPressure=True
+)
+
+// Alert is one firing (or resolved) condition on one object.
+type Alert struct {
+	Condition string    `json:"condition"`
+	Cluster   string    `json:"cluster"`
+	Namespace string    `json:"namespace,omitempty"`
+	Object    string    `json:"object"` // pod, PVC or node name
+	Container string    `json:"container,omitempty"`
+	Value     float64   `json:"value"` // used / limit (or capacity), predicted for trends
+	Message   string    `json:"message"`
+	Status    string    `json:"status"` // firing | resolved
+	StartsAt  time.Time `json:"startsAt"`
+}
+
+// Key identifies an alert across evaluations for deduplication.
+func (a Alert) Key() string {
+	return strings.Join([]string{a.Cluster, a.Condition, a.Namespace, a.Object, a.Container}, "/")
+}
+
+// containerKey identifies a container of a pod: "namespace/pod/container".
+func containerKey(namespace, pod, container string) string {
+	return namespace + "/" + pod + "/" + container
+}
+
+// memoryLimits returns the memory limit in bytes of every limited container of pods.
+func memoryLimits(pods []k8s.Pod) map[string]int64 {
+	limits := make(map[string]int64)
+	for _, p := range pods {
+		for _, c := range p.Spec.Containers {
+			if b := resources.ParseMemoryBytes(c.Resources.Limits["memory"]); b > 0 {
+				limits[containerKey(p.Metadata.Namespace, p.Metadata.Name, c.Name)] = b
+			}
+		}
+	}
+	return limits
+}
+
+// memoryAlerts flags containers whose working set reached danger(namespace) of their memory
+// limit: the next allocation spike OOMKills them.
+func memoryAlerts(cluster string, pods []k8s.Pod, metrics []k8s.PodMetrics, danger func(namespace string) float64) []Alert {
+	limits := memoryLimits(pods)
+	var out []Alert
+	for _, m := range metrics {
+		ns, pod := m.Metadata.Namespace, m.Metadata.Name
+		for _, c := range m.Containers {
+			limit := limits[containerKey(ns, pod, c.Name)]
+			if limit == 0 {
+				continue
+			}
+			ratio := float64(resources.ParseMemoryBytes(c.Usage["memory"])) / float64(limit)
+			if ratio < danger(ns) {
+				continue
+			}
+			out = append(out, Alert{
+				Condition: ConditionMemoryLimit,
+				Cluster:   cluster,
+				Namespace: ns,
+				Object:    pod,
+				Container: c.Name,
+				Value:     ratio,
+				Message:   fmt.Sprintf("%s/%s container %s uses %.0f%% of its %s memory limit", ns, pod, c.Name, ratio*100, formatBytes(limit)),
+			})
+		}
+	}
+	sortAlerts(out)
+	return out
+}
+
+// trendAlerts flags containers whose predicted working set in the given horizon reaches
+// their memory limit. Containers already in current (memory alerts) are skipped.
+func trendAlerts(cluster string, pods []k8s.Pod, predictions []prometheus.MemoryPrediction, hours float64, current []Alert) []Alert {
+	limits := memoryLimits(pods)
+	firing := make(map[string]bool, len(current))
+	for _, a := range current {
+		firing[containerKey(a.Namespace, a.Object, a.Container)] = true
+	}
+	var out []Alert
+	for _, p := range predictions {
+		key := containerKey(p.Namespace, p.Pod, p.Container)
+		limit := limits[key]
+		if limit == 0 || firing[key] || p.Bytes < float64(limit) {
+			continue
+		}
+		out = append(out, Alert{
+			Condition: ConditionMemoryTrend,
+			Cluster:   cluster,
+			Namespace: p.Namespace,
+			Object:    p.Pod,
+			Container: p.Container,
+			Value:     p.Bytes / float64(limit),
+			Message: fmt.Sprintf("%s/%s container %s is predicted to reach its %s memory limit within %gh",
+				p.Namespace, p.Pod, p.Container, formatBytes(limit), hours),
+		})
+	}
+	sortAlerts(out)
+	return out
+}
+
+// pvcAlerts flags PVCs filled to threshold or more. A PVC mounted by several pods is
+// reported once.
+func pvcAlerts(cluster string, summaries []*k8s.NodeSummary, threshold float64) []Alert {
+	seen := make(map[string]bool)
+	var out []Alert
+	for _, s := range summaries {
+		for _, p := range s.Pods {
+			for _, v := range p.Volumes {
+				if v.PVCRef == nil || v.CapacityBytes <= 0 {
+					continue
+				}
+				key := v.PVCRef.Namespace + "/" + v.PVCRef.Name
+				ratio := float64(v.UsedBytes) / float64(v.CapacityBytes)
+				if seen[key] || ratio < threshold {
+					continue
+				}
+				seen[key] = true
+				out = append(out, Alert{
+					Condition: ConditionPVCFull,
+					Cluster:   cluster,
+					Namespace: v.PVCRef.Namespace,
+					Object:    v.PVCRef.Name,
+					Value:     ratio,
+					Message: fmt.Sprintf("PVC %s is %.0f%% full (%s of %s)",
+						key, ratio*100, formatBytes(v.UsedBytes), formatBytes(v.CapacityBytes)),
+				})
+			}
+		}
+	}
+	sortAlerts(out)
+	return out
+}
+
+// nodeAlerts flags nodes reporting MemoryPressure: the kubelet is about to evict pods.
+func nodeAlerts(cluster string, nodes []k8s.Node) []Alert {
+	var out []Alert
+	for _, n := range nodes {
+		for _, c := range n.Status.Conditions {
+			if c.Type == "MemoryPressure" && c.Status == "True" {
+				out = append(out, Alert{
+					Condition: ConditionNodeMemoryPressure,
+					Cluster:   cluster,
+					Object:    n.Metadata.Name,
+					Value:     1,
+					Message:   fmt.Sprintf("node %s reports MemoryPressure: pods may be evicted", n.Metadata.Name),
+				})
+			}
+		}
+	}
+	sortAlerts(out)
+	return out
+}
+
+func sortAlerts(alerts []Alert) {
+	sort.Slice(alerts, func(i, j int) bool { return alerts[i].Key() < alerts[j].Key() })
+}
+
+// formatBytes renders a byte count with a binary unit, e.g. "512Mi" or "1.5Gi".
+func formatBytes(b int64) string {
+	const (
+		mi = 1 << 20
+		gi = 1 << 30
+	)
+	switch {
+	case b >= gi:
+		return fmt.Sprintf("%gGi", float64(b*10/gi)/10)
+	case b >= mi:
+		return fmt.Sprintf("%dMi", b/mi)
+	default:
+		return fmt.Sprintf("%dB", b)
+	}
+}
@@ -0,0 +1,353 @@
+package alerting
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/devops-kubeadjust/backend/k8s"
+	"github.com/devops-kubeadjust/backend/prometheus"
+	"github.com/devops-kubeadjust/backend/suggestions"
+)
+
+func TestParse(t *testing.T) {
+	c, err := Parse([]byte(`{"interval": "1m", "trendHours": 4, "webhooks": [{"type": "slack", "url": "https://hooks.example.com/T/B/x"}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Interval.Duration != time.Minute || c.RepeatInterval.Duration != 4*time.Hour || c.PVCThreshold != 0.9 || c.TrendHours != 4 {
+		t.Errorf("unexpected defaults: %+v", c)
+	}
+	for _, bad := range []string{
+		`{"webhooks": []}`,
+		`{"interval": "soon", "webhooks": [{"type": "json", "url": "http://x"}]}`,
+		`{"interval": "1h", "repeatInterval": "5m", "webhooks": [{"type": "json", "url": "http://x"}]}`,
+		`{"pvcThreshold": 1.5, "webhooks": [{"type": "json", "url": "http://x"}]}`,
+		`{"webhooks": [{"type": "pagerduty", "url": "http://x"}]}`,
+		`{"webhooks": [{"type": "json", "url": "ftp://x"}]}`,
+	} {
+		if _, err := Parse([]byte(bad)); err == nil {
+			t.Errorf("expected error for %s", bad)
+		}
+	}
+}
+
+func pod(ns, name, container, memLimit string) k8s.Pod {
+	var p k8s.Pod
+	p.Metadata.Namespace, p.Metadata.Name = ns, name
+	c := k8s.Container{Name: container}
+	if memLimit != "" {
+		c.Resources.Limits = map[string]string{"memory": memLimit}
+	}
+	p.Spec.Containers = []k8s.Container{c}
+	return p
+}
+
+func podMetrics(ns, name, container, mem string) k8s.PodMetrics {
+	var m k8s.PodMetrics
+	m.Metadata.Namespace, m.Metadata.Name = ns, name
+	m.Containers = []k8s.ContainerUsage{{Name: container, Usage: map[string]string{"memory": mem}}}
+	return m
+}
+
+func TestConditions(t *testing.T) {
+	pods := []k8s.Pod{
+		pod("shop", "api-1", "api", "1Gi"),
+		pod("shop", "cache-1", "redis", "1Gi"),
+		pod("batch", "job-1", "worker", "1Gi"),
+		pod("shop", "free-1", "app", ""),
+	}
+	metrics := []k8s.PodMetrics{
+		podMetrics("shop", "api-1", "api", "950Mi"),     // 93%: danger
+		podMetrics("shop", "cache-1", "redis", "512Mi"), // 50%
+		podMetrics("batch", "job-1", "worker", "950Mi"), // 93% but batch tolerates 95%
+		podMetrics("shop", "free-1", "app", "8Gi"),      // no limit
+	}
+	danger := func(ns string) float64 {
+		if ns == "batch" {
+			return 0.95
+		}
+		return 0.9
+	}
+	mem := memoryAlerts("prod", pods, metrics, danger)
+	if len(mem) != 1 || mem[0].Object != "api-1" || mem[0].Container != "api" || mem[0].Condition != ConditionMemoryLimit {
+		t.Fatalf("memory alerts: %+v", mem)
+	}
+	if !strings.Contains(mem[0].Message, "93%") || !strings.Contains(mem[0].Message, "1Gi") {
+		t.Errorf("memory message: %q", mem[0].Message)
+	}
+
+	// cache-1 trends past its limit; api-1 already alerts on usage and is not repeated.
+	trends := trendAlerts("prod", pods, []prometheus.MemoryPrediction{
+		{Namespace: "shop", Pod: "api-1", Container: "api", Bytes: 2 << 30},
+		{Namespace: "shop", Pod: "cache-1", Container: "redis", Bytes: 1.2 * (1 << 30)},
+		{Namespace: "batch", Pod: "job-1", Container: "worker", Bytes: 0.96 * (1 << 30)},
+	}, 4, mem)
+	if len(trends) != 1 || trends[0].Object != "cache-1" || trends[0].Condition != ConditionMemoryTrend {
+		t.Fatalf("trend alerts: %+v", trends)
+	}
+
+	volume := func(ns, pvc string, used, capacity int64) k8s.VolumeStatsSummary {
+		return k8s.VolumeStatsSummary{Name: "data", PVCRef: &k8s.PVCRef{Namespace: ns, Name: pvc}, UsedBytes: used, CapacityBytes: capacity}
+	}
+	summaries := []*k8s.NodeSummary{
+		{Pods: []k8s.PodStatsSummary{{Volumes: []k8s.VolumeStatsSummary{volume("shop", "db", 95, 100), volume("shop", "logs", 10, 100), {Name: "tmp", UsedBytes: 99, CapacityBytes: 100}}}}},
+		{Pods: []k8s.PodStatsSummary{{Volumes: []k8s.VolumeStatsSummary{volume("shop", "db", 95, 100)}}}}, // same PVC, other pod
+	}
+	pvcs := pvcAlerts("prod", summaries, 0.9)
+	if len(pvcs) != 1 || pvcs[0].Object != "db" || pvcs[0].Namespace != "shop" {
+		t.Fatalf("pvc alerts: %+v", pvcs)
+	}
+
+	var ok, pressured k8s.Node
+	ok.Metadata.Name, pressured.Metadata.Name = "node-a", "node-b"
+	ok.Status.Conditions = []k8s.NodeCondition{{Type: "MemoryPressure", Status: "False"}}
+	pressured.Status.Conditions = []k8s.NodeCondition{{Type: "Ready", Status: "True"}, {Type: "MemoryPressure", Status: "True"}}
+	nodes := nodeAlerts("prod", []k8s.Node{ok, pressured})
+	if len(nodes) != 1 || nodes[0].Object != "node-b" {
+		t.Fatalf("node alerts: %+v", nodes)
+	}
+}
+
+// receiver records the JSON bodies posted to it.
+type receiver struct {
+	mu     sync.Mutex
+	paths  []string
+	bodies []string
+}
+
+func newReceiver(t *testing.T) (*receiver, *httptest.Server) {
+	rec := &receiver{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		rec.mu.Lock()
+		defer rec.mu.Unlock()
+		rec.paths = append(rec.paths, r.URL.Path)
+		rec.bodies = append(rec.bodies, string(b))
+	}))
+	t.Cleanup(srv.Close)
+	return rec, srv
+}
+
+func (r *receiver) take() (paths, bodies []string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	paths, bodies = r.paths, r.bodies
+	r.paths, r.bodies = nil, nil
+	return paths, bodies
+}
+
+func TestNotifierDedup(t *testing.T) {
+	slack, slackSrv := newReceiver(t)
+	generic, genericSrv := newReceiver(t)
+	am, amSrv := newReceiver(t)
+	n := NewNotifier(&Config{
+		Interval:       Duration{time.Minute},
+		RepeatInterval: Duration{time.Hour},
+		Webhooks: []Webhook{
+			{Type: WebhookSlack, URL: slackSrv.URL + "/services/T/B/x"},
+			{Type: WebhookJSON, URL: genericSrv.URL + "/hook"},
+			{Type: WebhookAlertmanager, URL: amSrv.URL},
+		},
+	})
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	n.now = func() time.Time { return now }
+	ctx := context.Background()
+	node := Alert{Condition: ConditionNodeMemoryPressure, Cluster: "prod", Object: "node-b", Value: 1, Message: "node node-b reports MemoryPressure"}
+	pvc := Alert{Condition: ConditionPVCFull, Cluster: "prod", Namespace: "shop", Object: "db", Value: 0.95, Message: "PVC shop/db is 95% full"}
+
+	// First evaluation: both alerts are new.
+	if err := n.Notify(ctx, []Alert{node, pvc}); err != nil {
+		t.Fatal(err)
+	}
+	_, bodies := slack.take()
+	if len(bodies) != 1 || strings.Count(bodies[0], "[FIRING]") != 2 {
+		t.Fatalf("slack: %v", bodies)
+	}
+	_, bodies = generic.take()
+	var payload jsonPayload
+	if len(bodies) != 1 || json.Unmarshal([]byte(bodies[0]), &payload) != nil || len(payload.Alerts) != 2 || payload.Alerts[0].Status != StatusFiring {
+		t.Fatalf("json: %v", bodies)
+	}
+	paths, bodies := am.take()
+	var amAlerts []alertmanagerAlert
+	if len(paths) != 1 || paths[0] != "/api/v2/alerts" || json.Unmarshal([]byte(bodies[0]), &amAlerts) != nil || len(amAlerts) != 2 {
+		t.Fatalf("alertmanager: %v %v", paths, bodies)
+	}
+	if a := amAlerts[0]; a.Labels["alertname"] != ConditionNodeMemoryPressure || a.Labels["node"] != "node-b" || !a.EndsAt.Equal(now.Add(3*time.Minute)) {
+		t.Errorf("alertmanager alert: %+v", a)
+	}
+
+	// Still firing within repeatInterval: chat and JSON receivers are not notified again,
+	// Alertmanager gets the refresh it needs to keep them active.
+	now = now.Add(5 * time.Minute)
+	if err := n.Notify(ctx, []Alert{node, pvc}); err != nil {
+		t.Fatal(err)
+	}
+	if _, bodies := slack.take(); len(bodies) != 0 {
+		t.Errorf("slack re-notified within repeatInterval: %v", bodies)
+	}
+	if _, bodies := generic.take(); len(bodies) != 0 {
+		t.Errorf("json re-notified within repeatInterval: %v", bodies)
+	}
+	if _, bodies := am.take(); len(bodies) != 1 {
+		t.Errorf("alertmanager not refreshed: %v", bodies)
+	}
+
+	// The node recovers; the PVC is repeated once repeatInterval elapsed.
+	now = now.Add(time.Hour)
+	if err := n.Notify(ctx, []Alert{pvc}); err != nil {
+		t.Fatal(err)
+	}
+	_, bodies = slack.take()
+	if len(bodies) != 1 || !strings.Contains(bodies[0], "[FIRING] PVCNearlyFull") || !strings.Contains(bodies[0], "[RESOLVED] NodeMemoryPressure") {
+		t.Fatalf("slack: %v", bodies)
+	}
+	_, bodies = am.take()
+	amAlerts = nil
+	if len(bodies) != 1 || json.Unmarshal([]byte(bodies[0]), &amAlerts) != nil || len(amAlerts) != 2 {
+		t.Fatalf("alertmanager: %v", bodies)
+	}
+	for _, a := range amAlerts {
+		if a.Labels["alertname"] == ConditionNodeMemoryPressure && !a.EndsAt.Equal(now) {
+			t.Errorf("resolved alert should end now: %+v", a)
+		}
+		if a.Labels["alertname"] == ConditionPVCFull && !a.StartsAt.Equal(now.Add(-65*time.Minute)) {
+			t.Errorf("startsAt should be kept from the first evaluation: %+v", a)
+		}
+	}
+	generic.take()
+
+	// Nothing firing, nothing to resolve: no request at all.
+	now = now.Add(time.Minute)
+	_ = n.Notify(ctx, []Alert{pvc})
+	_ = n.Notify(ctx, nil)
+	_ = n.Notify(ctx, nil)
+	if _, bodies := generic.take(); len(bodies) != 1 || !strings.Contains(bodies[0], `"status":"resolved"`) {
+		t.Errorf("json: %v", bodies)
+	}
+}
+
+func TestNotifierError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "invalid_token", http.StatusForbidden)
+	}))
+	defer srv.Close()
+	n := NewNotifier(&Config{Interval: Duration{time.Minute}, RepeatInterval: Duration{time.Hour}, Webhooks: []Webhook{{Type: WebhookSlack, URL: srv.URL + "/services/secret"}}})
+	err := n.Notify(context.Background(), []Alert{{Condition: ConditionNodeMemoryPressure, Cluster: "prod", Object: "node-b"}})
+	if err == nil || !strings.Contains(err.Error(), "403 invalid_token") || strings.Contains(err.Error(), "secret") {
+		t.Errorf("got %v", err)
+	}
+}
+
+// fakeAPIServer serves the Kubernetes API paths read by the evaluator from static JSON.
+// Paths for which down returns true answer 404.
+func fakeAPIServer(t *testing.T, down func(path string) bool) *httptest.Server {
+	routes := map[string]string{
+		"/api/v1/pods": `{"items": [{"metadata": {"namespace": "shop", "name": "api-1"}, "spec": {"nodeName": "node-a",
+			"containers": [{"name": "api", "resources": {"limits": {"memory": "1Gi"}}},
+				{"name": "worker", "resources": {"limits": {"memory": "1Gi"}}}]}}]}`,
+		"/apis/metrics.k8s.io/v1beta1/pods": `{"items": [{"metadata": {"namespace": "shop", "name": "api-1"},
+			"containers": [{"name": "api", "usage": {"memory": "1000Mi"}}, {"name": "worker", "usage": {"memory": "100Mi"}}]}]}`,
+		"/api/v1/nodes":      `{"items": [{"metadata": {"name": "node-a"}, "status": {"conditions": [{"type": "MemoryPressure", "status": "False"}]}}]}`,
+		"/api/v1/namespaces": `{"items": [{"metadata": {"name": "shop"}}]}`,
+		"/api/v1/nodes/node-a/proxy/stats/summary": `{"pods": [{"podRef": {"namespace": "shop", "name": "api-1"},
+			"volume": [{"name": "data", "pvcRef": {"namespace": "shop", "name": "data"}, "usedBytes": 92, "capacityBytes": 100}]}]}`,
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := routes[r.URL.Path]
+		if down(r.URL.Path) || !ok {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestEvaluator(t *testing.T) {
+	var down atomic.Bool
+	api := fakeAPIServer(t, func(string) bool { return down.Load() })
+	rec, hook := newReceiver(t)
+	cfg, err := Parse([]byte(`{"interval": "1m", "webhooks": [{"type": "json", "url": "` + hook.URL + `"}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	target := k8s.Target{Name: "prod", Client: func() (*k8s.Client, error) { return k8s.New("token", api.URL), nil }}
+	e := NewEvaluator(cfg, []k8s.Target{target}, nil, "", suggestions.DefaultConfig())
+
+	alerts := e.Evaluate(context.Background())
+	if len(alerts) != 2 || alerts[0].Condition != ConditionMemoryLimit || alerts[1].Condition != ConditionPVCFull {
+		t.Fatalf("alerts: %+v", alerts)
+	}
+	if err := e.notifier.Notify(context.Background(), alerts); err != nil {
+		t.Fatal(err)
+	}
+	if _, bodies := rec.take(); len(bodies) != 1 || !strings.Contains(bodies[0], `"object":"api-1"`) {
+		t.Fatalf("webhook: %v", bodies)
+	}
+
+	// An unreachable API server keeps the previous alerts instead of resolving them.
+	down.Store(true)
+	if again := e.Evaluate(context.Background()); len(again) != 2 {
+		t.Errorf("alerts during outage: %+v", again)
+	}
+}
+
+func TestEvaluatorSourceFailures(t *testing.T) {
+	// Pod metrics and node summaries are cached per API server, so the outage is
+	// simulated by switching to a second server.
+	var degraded atomic.Bool
+	healthy := fakeAPIServer(t, func(string) bool { return false })
+	broken := fakeAPIServer(t, func(path string) bool {
+		return path == "/apis/metrics.k8s.io/v1beta1/pods" || strings.HasSuffix(path, "/stats/summary")
+	})
+	prom := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if degraded.Load() {
+			http.Error(w, "query timed out", http.StatusBadRequest)
+			return
+		}
+		_, _ = w.Write([]byte(`{"status": "success", "data": {"resultType": "vector", "result": [
+			{"metric": {"namespace": "shop", "pod": "api-1", "container": "worker"}, "value": [0, "2147483648"]}]}}`))
+	}))
+	defer prom.Close()
+	cfg, err := Parse([]byte(`{"interval": "1m", "trendHours": 4, "webhooks": [{"type": "json", "url": "http://127.0.0.1:1"}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	target := k8s.Target{Name: "prod", Client: func() (*k8s.Client, error) {
+		if degraded.Load() {
+			return k8s.New("token", broken.URL), nil
+		}
+		return k8s.New("token", healthy.URL), nil
+	}}
+	e := NewEvaluator(cfg, []k8s.Target{target}, prometheus.NewWithURL(prom.URL), "", suggestions.DefaultConfig())
+
+	conditions := func(alerts []Alert) []string {
+		var out []string
+		for _, a := range alerts {
+			out = append(out, a.Condition+" "+a.Object+" "+a.Container)
+		}
+		return out
+	}
+	want := []string{ConditionMemoryLimit + " api-1 api", ConditionMemoryTrend + " api-1 worker", ConditionPVCFull + " data "}
+	if got := conditions(e.Evaluate(context.Background())); !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	// metrics-server, Prometheus and the kubelets fail while the API server answers:
+	// each condition keeps its previous alerts instead of resolving them.
+	degraded.Store(true)
+	if got := conditions(e.Evaluate(context.Background())); !reflect.DeepEqual(got, want) {
+		t.Errorf("during partial outage got %v, want %v", got, want)
+	}
+}
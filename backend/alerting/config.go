@@ -0,0 +1,118 @@
+// Package alerting periodically evaluates danger-level sizing conditions (memory close to
+// the limit, full PVCs, node memory pressure, memory trending past the limit) on the
+// configured clusters and sends deduplicated notifications to webhooks.
+package alerting
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// Supported webhook types.
+const (
+	WebhookSlack        = "slack"        // Slack-compatible incoming webhook ({"text": ...}), also Mattermost/Rocket.Chat
+	WebhookJSON         = "json"         // generic JSON: {"alerts": [...]}
+	WebhookAlertmanager = "alertmanager" // Alertmanager API v2 (/api/v2/alerts)
+)
+
+// Config is the alerting configuration.
+type Config struct {
+	Interval       Duration `json:"interval,omitempty"`       // evaluation period, "5m" if unset
+	RepeatInterval Duration `json:"repeatInterval,omitempty"` // re-notify a still-firing alert after, "4h" if unset
+	// Clusters to evaluate (CLUSTERS names, "default" for the single-cluster setup).
+	// Defaults to every cluster the backend holds an SA token for.
+	Clusters []string `json:"clusters,omitempty"`
+	// TrendHours alerts when memory is predicted to exceed the limit within that many hours
+	// (0 disables trend alerts, which also need PROMETHEUS_URL).
+	TrendHours   float64   `json:"trendHours,omitempty"`
+	PVCThreshold float64   `json:"pvcThreshold,omitempty"` // used/capacity ratio, 0.9 if unset
+	Webhooks     []Webhook `json:"webhooks"`
+}
+
+// Webhook is one notification receiver.
+type Webhook struct {
+	Type string `json:"type"` // slack | json | alertmanager
+	URL  string `json:"url"`
+}
+
+// Duration is a time.Duration decoded from a Go duration string ("5m", "4h").
+type Duration struct {
+	time.Duration
+}
+
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return fmt.Errorf("duration must be a string like \"5m\": %w", err)
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	d.Duration = v
+	return nil
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+// Load reads the configuration from the file at ALERTS_CONFIG.
+// Returns nil (and no error) when ALERTS_CONFIG is unset: alerting is then disabled.
+func Load() (*Config, error) {
+	path := os.Getenv("ALERTS_CONFIG")
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading alerts config: %w", err)
+	}
+	return Parse(data)
+}
+
+// Parse decodes and validates a JSON alerting config, applying defaults.
+func Parse(data []byte) (*Config, error) {
+	var c Config
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("parsing alerts config: %w", err)
+	}
+	if c.Interval.Duration == 0 {
+		c.Interval.Duration = 5 * time.Minute
+	}
+	if c.RepeatInterval.Duration == 0 {
+		c.RepeatInterval.Duration = 4 * time.Hour
+	}
+	if c.PVCThreshold == 0 {
+		c.PVCThreshold = 0.9
+	}
+	if c.Interval.Duration < 10*time.Second {
+		return nil, fmt.Errorf("alerts config: interval must be at least 10s")
+	}
+	if c.RepeatInterval.Duration < c.Interval.Duration {
+		return nil, fmt.Errorf("alerts config: repeatInterval must not be shorter than interval")
+	}
+	if c.PVCThreshold <= 0 || c.PVCThreshold > 1 {
+		return nil, fmt.Errorf("alerts config: pvcThreshold must be in (0, 1]")
+	}
+	if c.TrendHours < 0 {
+		return nil, fmt.Errorf("alerts config: trendHours must not be negative")
+	}
+	if len(c.Webhooks) == 0 {
+		return nil, fmt.Errorf("alerts config: at least one webhook is required")
+	}
+	for i, w := range c.Webhooks {
+		switch w.Type {
+		case WebhookSlack, WebhookJSON, WebhookAlertmanager:
+		default:
+			return nil, fmt.Errorf("alerts config: webhooks[%d]: unsupported type %q (slack, json or alertmanager)", i, w.Type)
+		}
+		if !strings.HasPrefix(w.URL, "http://") && !strings.HasPrefix(w.URL, "https://") {
+			return nil, fmt.Errorf("alerts config: webhooks[%d]: url must be http(s)", i)
+		}
+	}
+	return &c, nil
+}
@@ -0,0 +1,185 @@
+package alerting
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/devops-kubeadjust/backend/k8s"
+	"github.com/devops-kubeadjust/backend/prometheus"
+	"github.com/devops-kubeadjust/backend/suggestions"
+)
+
+// trendWindow is the usage history predict_linear extrapolates from.
+const trendWindow = 6 * time.Hour
+
+// Evaluator periodically evaluates the alert conditions on its targets and hands the
+// result to a Notifier.
+type Evaluator struct {
+	cfg               *Config
+	targets           []k8s.Target
+	prom              *prometheus.Client // nil: trend alerts disabled
+	prometheusCluster string             // the cluster prom scrapes, the only one trends are evaluated on
+	thresholds        *suggestions.Config
+	notifier          *Notifier
+
+	last map[string][]Alert // per cluster, carried over when an evaluation fails
+}
+
+// NewEvaluator returns an Evaluator for targets. prom may be nil; prometheusCluster is the
+// cluster it scrapes, defaulting to the only target (trends are not evaluated when ambiguous).
+func NewEvaluator(cfg *Config, targets []k8s.Target, prom *prometheus.Client, prometheusCluster string, thresholds *suggestions.Config) *Evaluator {
+	if prometheusCluster == "" && len(targets) == 1 {
+		prometheusCluster = targets[0].Name
+	}
+	return &Evaluator{
+		cfg:               cfg,
+		targets:           targets,
+		prom:              prom,
+		prometheusCluster: prometheusCluster,
+		thresholds:        thresholds,
+		notifier:          NewNotifier(cfg),
+		last:              make(map[string][]Alert),
+	}
+}
+
+// Run evaluates immediately, then every cfg.Interval until ctx is cancelled.
+func (e *Evaluator) Run(ctx context.Context) {
+	ticker := time.NewTicker(e.cfg.Interval.Duration)
+	defer ticker.Stop()
+	for {
+		if err := e.notifier.Notify(ctx, e.Evaluate(ctx)); err != nil {
+			log.Printf("alerting: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Evaluate returns the alerts firing on all targets. A cluster that cannot be evaluated
+// keeps its previous alerts so an API outage does not resolve them.
+func (e *Evaluator) Evaluate(ctx context.Context) []Alert {
+	var all []Alert
+	for _, t := range e.targets {
+		alerts, err := e.evaluateCluster(ctx, t)
+		if err != nil {
+			log.Printf("alerting: cluster %s: %v", t.Name, err)
+			alerts = e.last[t.Name]
+		}
+		e.last[t.Name] = alerts
+		all = append(all, alerts...)
+	}
+	return all
+}
+
+func (e *Evaluator) evaluateCluster(ctx context.Context, t k8s.Target) ([]Alert, error) {
+	ctx, cancel := context.WithTimeout(ctx, e.cfg.Interval.Duration)
+	defer cancel()
+	client, err := t.Client()
+	if err != nil {
+		return nil, err
+	}
+	pods, err := client.ListAllPods(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("listing pods: %w", err)
+	}
+	nodes, err := client.ListNodes(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("listing nodes: %w", err)
+	}
+
+	// When the source of a condition fails, its previous alerts are carried over, as
+	// Evaluate does for a whole cluster, so the outage does not resolve them.
+	var alerts []Alert
+	metrics, err := client.ListAllPodMetrics(ctx)
+	if err != nil {
+		log.Printf("alerting: cluster %s: metrics-server unavailable, keeping previous memory alerts: %v", t.Name, err)
+		alerts = append(alerts, e.carryOver(t.Name, ConditionMemoryLimit, nil)...)
+	} else {
+		alerts = append(alerts, memoryAlerts(t.Name, pods.Items, metrics.Items, e.dangerThresholds(ctx, t.Name, client))...)
+	}
+	if e.prom != nil && e.cfg.TrendHours > 0 && t.Name == e.prometheusCluster {
+		ahead := time.Duration(e.cfg.TrendHours * float64(time.Hour))
+		predictions, err := e.prom.PredictMemory(trendWindow, ahead)
+		if err != nil {
+			log.Printf("alerting: cluster %s: memory trend query failed, keeping previous trend alerts: %v", t.Name, err)
+			firing := make(map[string]bool, len(alerts))
+			for _, a := range alerts {
+				firing[containerKey(a.Namespace, a.Object, a.Container)] = true
+			}
+			alerts = append(alerts, e.carryOver(t.Name, ConditionMemoryTrend, func(a Alert) bool {
+				return !firing[containerKey(a.Namespace, a.Object, a.Container)]
+			})...)
+		} else {
+			alerts = append(alerts, trendAlerts(t.Name, pods.Items, predictions, e.cfg.TrendHours, alerts)...)
+		}
+	}
+	summaries := client.GetNodeSummaries(ctx, nodes.Items)
+	alerts = append(alerts, pvcAlerts(t.Name, summaries, e.cfg.PVCThreshold)...)
+	if len(summaries) < len(nodes.Items) {
+		// Some kubelets did not answer: keep the alerts of the PVCs no summary reported.
+		log.Printf("alerting: cluster %s: %d of %d node summaries unavailable, keeping their previous PVC alerts", t.Name, len(nodes.Items)-len(summaries), len(nodes.Items))
+		reported := reportedPVCs(summaries)
+		alerts = append(alerts, e.carryOver(t.Name, ConditionPVCFull, func(a Alert) bool {
+			return !reported[a.Namespace+"/"+a.Object]
+		})...)
+	}
+	alerts = append(alerts, nodeAlerts(t.Name, nodes.Items)...)
+	return alerts, nil
+}
+
+// carryOver returns the previous alerts of condition on cluster accepted by keep (all
+// when nil).
+func (e *Evaluator) carryOver(cluster, condition string, keep func(Alert) bool) []Alert {
+	var out []Alert
+	for _, a := range e.last[cluster] {
+		if a.Condition == condition && (keep == nil || keep(a)) {
+			out = append(out, a)
+		}
+	}
+	return out
+}
+
+// reportedPVCs returns the "namespace/name" of every PVC mounted in summaries.
+func reportedPVCs(summaries []*k8s.NodeSummary) map[string]bool {
+	out := make(map[string]bool)
+	for _, s := range summaries {
+		for _, p := range s.Pods {
+			for _, v := range p.Volumes {
+				if v.PVCRef != nil {
+					out[v.PVCRef.Namespace+"/"+v.PVCRef.Name] = true
+				}
+			}
+		}
+	}
+	return out
+}
+
+// dangerThresholds returns the danger threshold of each namespace, honoring the
+// kubeadjust.io/thresholds annotation. Falls back to the cluster's when namespaces
+// cannot be listed.
+func (e *Evaluator) dangerThresholds(ctx context.Context, cluster string, client *k8s.Client) func(string) float64 {
+	th, _ := e.thresholds.ForCluster(cluster)
+	perNamespace := map[string]float64{}
+	if list, err := client.ListNamespaces(ctx); err != nil {
+		log.Printf("alerting: cluster %s: listing namespaces for thresholds: %v", cluster, err)
+	} else {
+		for _, ns := range list.Items {
+			nsTh, _, err := e.thresholds.ForNamespace(cluster, ns.Metadata.Annotations)
+			if err != nil {
+				log.Printf("alerting: cluster %s: namespace %s: %v", cluster, ns.Metadata.Name, err)
+			}
+			perNamespace[ns.Metadata.Name] = nsTh.Danger
+		}
+	}
+	return func(namespace string) float64 {
+		if d, ok := perNamespace[namespace]; ok {
+			return d
+		}
+		return th.Danger
+	}
+}
@@ -0,0 +1,221 @@
+package alerting
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Alert statuses.
+const (
+	StatusFiring   = "firing"
+	StatusResolved = "resolved"
+)
+
+// Notifier deduplicates alerts across evaluations and delivers them to the webhooks:
+// a new alert is sent once, re-sent every repeatInterval while it keeps firing, and a
+// resolved notification is sent when it disappears.
+type Notifier struct {
+	webhooks   []Webhook
+	interval   time.Duration
+	repeat     time.Duration
+	httpClient *http.Client
+	now        func() time.Time
+
+	active map[string]*tracked // keyed by Alert.Key()
+}
+
+type tracked struct {
+	alert    Alert
+	lastSent time.Time
+}
+
+// NewNotifier returns a Notifier for the webhooks of cfg.
+func NewNotifier(cfg *Config) *Notifier {
+	return &Notifier{
+		webhooks:   cfg.Webhooks,
+		interval:   cfg.Interval.Duration,
+		repeat:     cfg.RepeatInterval.Duration,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+		now:        time.Now,
+		active:     make(map[string]*tracked),
+	}
+}
+
+// Notify takes the alerts firing at this evaluation and sends what changed since the
+// previous one. Delivery errors are returned joined; the dedup state is updated regardless
+// so a failing receiver does not cause a notification storm on the others.
+func (n *Notifier) Notify(ctx context.Context, alerts []Alert) error {
+	now := n.now()
+	current := make(map[string]bool, len(alerts))
+	var firing, toSend, resolved []Alert
+	for _, a := range alerts {
+		key := a.Key()
+		if current[key] {
+			continue
+		}
+		current[key] = true
+		a.Status = StatusFiring
+		t, ok := n.active[key]
+		if ok {
+			a.StartsAt = t.alert.StartsAt
+		} else {
+			a.StartsAt = now
+			t = &tracked{}
+			n.active[key] = t
+		}
+		t.alert = a
+		firing = append(firing, a)
+		if !ok || now.Sub(t.lastSent) >= n.repeat {
+			t.lastSent = now
+			toSend = append(toSend, a)
+		}
+	}
+	for key, t := range n.active {
+		if !current[key] {
+			a := t.alert
+			a.Status = StatusResolved
+			resolved = append(resolved, a)
+			delete(n.active, key)
+		}
+	}
+	sortAlerts(resolved)
+
+	var errs []error
+	for _, w := range n.webhooks {
+		var err error
+		switch w.Type {
+		case WebhookAlertmanager:
+			// Alertmanager expects every active alert to be re-posted before it expires
+			// and does its own deduplication and grouping.
+			if len(firing) > 0 || len(resolved) > 0 {
+				err = n.post(ctx, alertmanagerURL(w.URL), alertmanagerPayload(firing, resolved, now, 3*n.interval))
+			}
+		case WebhookSlack:
+			if len(toSend) > 0 || len(resolved) > 0 {
+				err = n.post(ctx, w.URL, slackPayload(toSend, resolved))
+			}
+		default:
+			if len(toSend) > 0 || len(resolved) > 0 {
+				err = n.post(ctx, w.URL, jsonPayload{Alerts: append(toSend, resolved...)})
+			}
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s webhook %s: %w", w.Type, redactURL(w.URL), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (n *Notifier) post(ctx context.Context, url string, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := n.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%d %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// redactURL drops the path of a webhook URL from logs: Slack-style URLs embed the secret.
+func redactURL(u string) string {
+	if i := strings.Index(u, "://"); i >= 0 {
+		if j := strings.Index(u[i+3:], "/"); j >= 0 {
+			return u[:i+3+j] + "/…"
+		}
+	}
+	return u
+}
+
+// jsonPayload is the body of generic JSON webhooks.
+type jsonPayload struct {
+	Alerts []Alert `json:"alerts"`
+}
+
+// slackPayload renders alerts as a Slack incoming-webhook message, one line per alert.
+func slackPayload(firing, resolved []Alert) map[string]string {
+	var b strings.Builder
+	for _, a := range firing {
+		fmt.Fprintf(&b, ":rotating_light: *[FIRING] %s* (%s) %s\n", a.Condition, a.Cluster, a.Message)
+	}
+	for _, a := range resolved {
+		fmt.Fprintf(&b, ":white_check_mark: *[RESOLVED] %s* (%s) %s\n", a.Condition, a.Cluster, a.Message)
+	}
+	return map[string]string{"text": strings.TrimSuffix(b.String(), "\n")}
+}
+
+// alertmanagerAlert is an alert of the Alertmanager API v2 (POST /api/v2/alerts).
+type alertmanagerAlert struct {
+	Labels      map[string]string `json:"labels"`
+	Annotations map[string]string `json:"annotations"`
+	StartsAt    time.Time         `json:"startsAt"`
+	EndsAt      time.Time         `json:"endsAt"`
+}
+
+// alertmanagerPayload posts firing alerts with an end time of now+ttl (they expire unless
+// re-posted) and resolved alerts with an end time of now.
+func alertmanagerPayload(firing, resolved []Alert, now time.Time, ttl time.Duration) []alertmanagerAlert {
+	out := make([]alertmanagerAlert, 0, len(firing)+len(resolved))
+	add := func(a Alert, endsAt time.Time) {
+		labels := map[string]string{
+			"alertname": a.Condition,
+			"severity":  "critical",
+			"source":    "kubeadjust",
+			"cluster":   a.Cluster,
+		}
+		if a.Namespace != "" {
+			labels["namespace"] = a.Namespace
+		}
+		switch a.Condition {
+		case ConditionPVCFull:
+			labels["persistentvolumeclaim"] = a.Object
+		case ConditionNodeMemoryPressure:
+			labels["node"] = a.Object
+		default:
+			labels["pod"] = a.Object
+		}
+		if a.Container != "" {
+			labels["container"] = a.Container
+		}
+		out = append(out, alertmanagerAlert{
+			Labels:      labels,
+			Annotations: map[string]string{"summary": a.Message, "value": fmt.Sprintf("%.2f", a.Value)},
+			StartsAt:    a.StartsAt,
+			EndsAt:      endsAt,
+		})
+	}
+	for _, a := range firing {
+		add(a, now.Add(ttl))
+	}
+	for _, a := range resolved {
+		add(a, now)
+	}
+	return out
+}
+
+// alertmanagerURL appends the API path to an Alertmanager base URL unless already present.
+func alertmanagerURL(u string) string {
+	u = strings.TrimRight(u, "/")
+	if strings.HasSuffix(u, "/api/v2/alerts") {
+		return u
+	}
+	return u + "/api/v2/alerts"
+}
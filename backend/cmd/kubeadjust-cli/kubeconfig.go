@@ -0,0 +1,350 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/devops-kubeadjust/backend/k8s"
+)
+
+// kubeconfig is the subset of a kubeconfig file needed to reach the API server.
+type kubeconfig struct {
+	CurrentContext string        `json:"current-context"`
+	Clusters       []kubeCluster `json:"clusters"`
+	Users          []kubeUser    `json:"users"`
+	Contexts       []kubeContext `json:"contexts"`
+}
+
+type kubeCluster struct {
+	Name    string `json:"name"`
+	Cluster struct {
+		Server                   string `json:"server"`
+		CertificateAuthority     string `json:"certificate-authority"`
+		CertificateAuthorityData string `json:"certificate-authority-data"`
+		InsecureSkipTLSVerify    bool   `json:"insecure-skip-tls-verify"`
+		TLSServerName            string `json:"tls-server-name"`
+	} `json:"cluster"`
+}
+
+type kubeUser struct {
+	Name string       `json:"name"`
+	User kubeAuthInfo `json:"user"`
+}
+
+type kubeContext struct {
+	Name    string `json:"name"`
+	Context struct {
+		Cluster   string `json:"cluster"`
+		User      string `json:"user"`
+		Namespace string `json:"namespace"`
+	} `json:"context"`
+}
+
+type kubeAuthInfo struct {
+	Token                 string      `json:"token"`
+	TokenFile             string      `json:"tokenFile"`
+	ClientCertificate     string      `json:"client-certificate"`
+	ClientCertificateData string      `json:"client-certificate-data"`
+	ClientKey             string      `json:"client-key"`
+	ClientKeyData         string      `json:"client-key-data"`
+	Exec                  *execConfig `json:"exec"`
+}
+
+// execConfig is a client-go credential plugin (aws eks get-token, gke-gcloud-auth-plugin, kubelogin…).
+type execConfig struct {
+	APIVersion string   `json:"apiVersion"`
+	Command    string   `json:"command"`
+	Args       []string `json:"args"`
+	Env        []struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+	} `json:"env"`
+}
+
+// execTimeout bounds how long a credential plugin may run (it may open a browser for SSO).
+const execTimeout = 2 * time.Minute
+
+// clusterConfig is a resolved kubeconfig context.
+type clusterConfig struct {
+	Context   string
+	Server    string
+	Namespace string
+	Token     string
+	TLS       *tls.Config
+}
+
+// Client returns a Kubernetes client for the context.
+func (c *clusterConfig) Client() *k8s.Client {
+	return k8s.NewWithTransport(c.Token, c.Server, &http.Transport{
+		Proxy:               http.ProxyFromEnvironment,
+		DialContext:         (&net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}).DialContext,
+		TLSClientConfig:     c.TLS,
+		MaxIdleConnsPerHost: 20,
+		IdleConnTimeout:     90 * time.Second,
+	})
+}
+
+// kubeconfigPaths returns the explicit path, else the entries of $KUBECONFIG, else ~/.kube/config.
+func kubeconfigPaths(explicit string) []string {
+	if explicit != "" {
+		return []string{explicit}
+	}
+	var paths []string
+	for _, p := range filepath.SplitList(os.Getenv("KUBECONFIG")) {
+		if p != "" && !slices.Contains(paths, p) {
+			paths = append(paths, p)
+		}
+	}
+	if len(paths) > 0 {
+		return paths
+	}
+	home, _ := os.UserHomeDir()
+	return []string{filepath.Join(home, ".kube", "config")}
+}
+
+// loadKubeconfig merges the files at paths and resolves contextName (the current context
+// if empty). As with kubectl, the first file to set current-context or to define a
+// cluster, user or context name wins, and missing files of a list are skipped.
+func loadKubeconfig(ctx context.Context, paths []string, contextName string) (*clusterConfig, error) {
+	merged := &kubeconfig{}
+	read := 0
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if errors.Is(err, fs.ErrNotExist) && len(paths) > 1 {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading kubeconfig: %w", err)
+		}
+		kc, err := parseKubeconfig(data)
+		if err != nil {
+			return nil, fmt.Errorf("parsing kubeconfig %s: %w", path, err)
+		}
+		kc.resolvePaths(filepath.Dir(path))
+		merged.merge(kc)
+		read++
+	}
+	if read == 0 {
+		return nil, fmt.Errorf("reading kubeconfig: none of %s exists", strings.Join(paths, ", "))
+	}
+	return merged.resolve(ctx, contextName)
+}
+
+// parseKubeconfig decodes a YAML or JSON kubeconfig (JSON being a subset of YAML).
+func parseKubeconfig(data []byte) (*kubeconfig, error) {
+	var doc any
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	// Re-encode as JSON so the json field tags apply, the way kubectl reads kubeconfigs.
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	var kc kubeconfig
+	if err := json.Unmarshal(data, &kc); err != nil {
+		return nil, err
+	}
+	return &kc, nil
+}
+
+// resolvePaths makes the file references of kc absolute, relative to dir (the directory
+// of the file kc was read from), so they survive merging with other files.
+func (kc *kubeconfig) resolvePaths(dir string) {
+	for i := range kc.Clusters {
+		c := &kc.Clusters[i].Cluster
+		c.CertificateAuthority = resolvePath(c.CertificateAuthority, dir)
+	}
+	for i := range kc.Users {
+		u := &kc.Users[i].User
+		u.TokenFile = resolvePath(u.TokenFile, dir)
+		u.ClientCertificate = resolvePath(u.ClientCertificate, dir)
+		u.ClientKey = resolvePath(u.ClientKey, dir)
+	}
+}
+
+// merge adds the entries of other that kc does not define yet.
+func (kc *kubeconfig) merge(other *kubeconfig) {
+	if kc.CurrentContext == "" {
+		kc.CurrentContext = other.CurrentContext
+	}
+	kc.Clusters = mergeNamed(kc.Clusters, other.Clusters, func(c kubeCluster) string { return c.Name })
+	kc.Users = mergeNamed(kc.Users, other.Users, func(u kubeUser) string { return u.Name })
+	kc.Contexts = mergeNamed(kc.Contexts, other.Contexts, func(c kubeContext) string { return c.Name })
+}
+
+func mergeNamed[T any](dst, src []T, name func(T) string) []T {
+	for _, e := range src {
+		if !slices.ContainsFunc(dst, func(d T) bool { return name(d) == name(e) }) {
+			dst = append(dst, e)
+		}
+	}
+	return dst
+}
+
+// resolve builds the connection settings of a context.
+func (kc *kubeconfig) resolve(ctx context.Context, contextName string) (*clusterConfig, error) {
+	if contextName == "" {
+		contextName = kc.CurrentContext
+	}
+	if contextName == "" {
+		return nil, fmt.Errorf("no current-context set; pass --context")
+	}
+	out := &clusterConfig{Context: contextName}
+	var clusterName, userName string
+	found := false
+	for _, c := range kc.Contexts {
+		if c.Name == contextName {
+			clusterName, userName, out.Namespace, found = c.Context.Cluster, c.Context.User, c.Context.Namespace, true
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("context %q not found", contextName)
+	}
+
+	out.TLS = &tls.Config{MinVersion: tls.VersionTLS12}
+	found = false
+	for _, c := range kc.Clusters {
+		if c.Name != clusterName {
+			continue
+		}
+		found = true
+		out.Server = strings.TrimSuffix(c.Cluster.Server, "/")
+		out.TLS.InsecureSkipVerify = c.Cluster.InsecureSkipTLSVerify
+		out.TLS.ServerName = c.Cluster.TLSServerName
+		ca, err := dataOrFile(c.Cluster.CertificateAuthorityData, c.Cluster.CertificateAuthority)
+		if err != nil {
+			return nil, fmt.Errorf("cluster %q certificate authority: %w", clusterName, err)
+		}
+		if ca != nil {
+			pool := x509.NewCertPool()
+			if !pool.AppendCertsFromPEM(ca) {
+				return nil, fmt.Errorf("cluster %q: no valid certificate in certificate authority", clusterName)
+			}
+			out.TLS.RootCAs = pool
+		}
+	}
+	if !found || out.Server == "" {
+		return nil, fmt.Errorf("cluster %q not found or has no server", clusterName)
+	}
+
+	for _, u := range kc.Users {
+		if u.Name == userName {
+			if err := u.User.apply(ctx, out); err != nil {
+				return nil, fmt.Errorf("user %q: %w", userName, err)
+			}
+		}
+	}
+	return out, nil
+}
+
+// apply sets the bearer token and/or client certificate of out.
+func (a kubeAuthInfo) apply(ctx context.Context, out *clusterConfig) error {
+	out.Token = a.Token
+	if out.Token == "" && a.TokenFile != "" {
+		b, err := os.ReadFile(a.TokenFile)
+		if err != nil {
+			return err
+		}
+		out.Token = strings.TrimSpace(string(b))
+	}
+	cert, err := dataOrFile(a.ClientCertificateData, a.ClientCertificate)
+	if err != nil {
+		return err
+	}
+	key, err := dataOrFile(a.ClientKeyData, a.ClientKey)
+	if err != nil {
+		return err
+	}
+	if a.Exec != nil && out.Token == "" && cert == nil {
+		if out.Token, cert, key, err = a.Exec.run(ctx); err != nil {
+			return err
+		}
+	}
+	if cert != nil {
+		pair, err := tls.X509KeyPair(cert, key)
+		if err != nil {
+			return fmt.Errorf("client certificate: %w", err)
+		}
+		out.TLS.Certificates = []tls.Certificate{pair}
+	}
+	return nil
+}
+
+// run executes the credential plugin and returns its token or client certificate.
+func (e *execConfig) run(ctx context.Context) (token string, cert, key []byte, err error) {
+	ctx, cancel := context.WithTimeout(ctx, execTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, e.Command, e.Args...)
+	cmd.Env = os.Environ()
+	for _, kv := range e.Env {
+		cmd.Env = append(cmd.Env, kv.Name+"="+kv.Value)
+	}
+	info, _ := json.Marshal(map[string]any{
+		"apiVersion": e.APIVersion,
+		"kind":       "ExecCredential",
+		"spec":       map[string]any{"interactive": false},
+	})
+	cmd.Env = append(cmd.Env, "KUBERNETES_EXEC_INFO="+string(info))
+	cmd.Stderr = os.Stderr
+	stdout, err := cmd.Output()
+	if err != nil {
+		return "", nil, nil, fmt.Errorf("running credential plugin %s: %w", e.Command, err)
+	}
+	var cred struct {
+		Status struct {
+			Token                 string `json:"token"`
+			ClientCertificateData string `json:"clientCertificateData"`
+			ClientKeyData         string `json:"clientKeyData"`
+		} `json:"status"`
+	}
+	if err := json.Unmarshal(stdout, &cred); err != nil {
+		return "", nil, nil, fmt.Errorf("decoding credential plugin output: %w", err)
+	}
+	if cred.Status.ClientCertificateData != "" {
+		cert, key = []byte(cred.Status.ClientCertificateData), []byte(cred.Status.ClientKeyData)
+	}
+	return cred.Status.Token, cert, key, nil
+}
+
+// dataOrFile returns base64-decoded inline data, else the content of file, else nil.
+func dataOrFile(data, file string) ([]byte, error) {
+	if data != "" {
+		return base64.StdEncoding.DecodeString(data)
+	}
+	if file != "" {
+		return os.ReadFile(file)
+	}
+	return nil, nil
+}
+
+func resolvePath(p, dir string) string {
+	if p == "" {
+		return ""
+	}
+	if strings.HasPrefix(p, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, p[2:])
+		}
+	}
+	if filepath.IsAbs(p) {
+		return p
+	}
+	return filepath.Join(dir, p)
+}
@@ -0,0 +1,320 @@
+// Command kubeadjust prints workloads, nodes and right-sizing suggestions in a terminal,
+// straight from a kubeconfig — no need to deploy the web app:
+//
+//	kubeadjust report -n payments
+//	kubeadjust suggestions -n payments -o csv > payments.csv
+//	kubeadjust nodes -o json
+//	kubeadjust check -A -o junit > kubeadjust.xml
+//
+// Build with: go build -o kubeadjust ./cmd/kubeadjust-cli
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+
+	"github.com/devops-kubeadjust/backend/k8s"
+	"github.com/devops-kubeadjust/backend/policy"
+	"github.com/devops-kubeadjust/backend/prometheus"
+	"github.com/devops-kubeadjust/backend/report"
+	"github.com/devops-kubeadjust/backend/resources"
+	"github.com/devops-kubeadjust/backend/suggestions"
+)
+
+const usageText = `Usage: kubeadjust <command> [flags]
+
+Commands:
+  report        workloads of a namespace with their suggested requests/limits
+  workloads     workloads of a namespace with requests, limits and live usage
+  suggestions   containers whose requests/limits should change (current → suggested)
+  nodes         node capacity, requested and used resources
+  check         fail (exit code 3) when workloads break the sizing policy
+
+Flags:
+  --kubeconfig PATH      kubeconfig file (default the files of $KUBECONFIG, else ~/.kube/config)
+  --context NAME         kubeconfig context (default current-context)
+  -n, --namespace NAME   namespace (default: the context's namespace, else "default")
+  -o, --output FORMAT    table, json or csv (default table); check: table, json, junit or sarif
+  --prometheus-url URL   Prometheus for P95-based suggestions (default $PROMETHEUS_URL)
+  --range RANGE          Prometheus window: 1h, 6h, 24h or 7d (default 7d)
+
+Check flags:
+  -n, --namespace LIST   comma-separated namespaces
+  -A, --all-namespaces   check every namespace
+  --policy FILE          JSON policy overriding the defaults below
+  --max-request-p95 N    fail when a request exceeds N × its P95 usage (default 3, 0 disables)
+  --max-limit-ratio N    fail when a limit exceeds N × its request (default 4, 0 disables)
+`
+
+// errUsage is returned for invalid invocations; main prints the usage text.
+var errUsage = errors.New("invalid usage")
+
+// errViolations is returned by check when a workload breaks the policy; main exits with 3.
+var errViolations = errors.New("policy violations found")
+
+type options struct {
+	kubeconfig    string
+	context       string
+	namespace     string
+	output        string
+	prometheusURL string
+	rangeParam    string
+
+	// check
+	allNamespaces bool
+	policyFile    string
+	maxRequestP95 float64
+	maxLimitRatio float64
+}
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("kubeadjust: warning: ")
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	err := run(ctx, os.Args[1:], os.Stdout)
+	if errors.Is(err, errUsage) {
+		fmt.Fprint(os.Stderr, usageText)
+		os.Exit(2)
+	}
+	if errors.Is(err, errViolations) {
+		fmt.Fprintln(os.Stderr, "kubeadjust:", err)
+		os.Exit(3)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "kubeadjust:", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, args []string, out io.Writer) error {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		return errUsage
+	}
+	cmd := args[0]
+	var o options
+	fs := flag.NewFlagSet(cmd, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.StringVar(&o.kubeconfig, "kubeconfig", "", "")
+	fs.StringVar(&o.context, "context", "", "")
+	fs.StringVar(&o.namespace, "n", "", "")
+	fs.StringVar(&o.namespace, "namespace", "", "")
+	fs.StringVar(&o.output, "o", formatTable, "")
+	fs.StringVar(&o.output, "output", formatTable, "")
+	fs.StringVar(&o.prometheusURL, "prometheus-url", os.Getenv("PROMETHEUS_URL"), "")
+	fs.StringVar(&o.rangeParam, "range", report.DefaultRange, "")
+	fs.BoolVar(&o.allNamespaces, "A", false, "")
+	fs.BoolVar(&o.allNamespaces, "all-namespaces", false, "")
+	fs.StringVar(&o.policyFile, "policy", "", "")
+	fs.Float64Var(&o.maxRequestP95, "max-request-p95", -1, "")
+	fs.Float64Var(&o.maxLimitRatio, "max-limit-ratio", -1, "")
+	if err := fs.Parse(args[1:]); err != nil {
+		return fmt.Errorf("%w: %v", errUsage, err)
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("%w: unexpected argument %q", errUsage, fs.Arg(0))
+	}
+	if cmd == "check" {
+		if !slices.Contains([]string{formatTable, formatJSON, formatJUnit, formatSARIF}, o.output) {
+			return fmt.Errorf("%w: output must be table, json, junit or sarif", errUsage)
+		}
+	} else if !slices.Contains([]string{formatTable, formatJSON, formatCSV}, o.output) {
+		return fmt.Errorf("%w: output must be table, json or csv", errUsage)
+	}
+	if !slices.Contains(prometheus.TimeRanges, o.rangeParam) {
+		return fmt.Errorf("%w: range must be 1h, 6h, 24h or 7d", errUsage)
+	}
+
+	switch cmd {
+	case "report", "workloads", "suggestions":
+		return namespaceCommand(ctx, cmd, o, out)
+	case "nodes":
+		return nodesCommand(ctx, o, out)
+	case "check":
+		return checkCommand(ctx, o, out)
+	}
+	return fmt.Errorf("%w: unknown command %q", errUsage, cmd)
+}
+
+func namespaceCommand(ctx context.Context, cmd string, o options, out io.Writer) error {
+	cfg, err := loadKubeconfig(ctx, kubeconfigPaths(o.kubeconfig), o.context)
+	if err != nil {
+		return err
+	}
+	ns := o.namespace
+	if ns == "" {
+		ns = cfg.Namespace
+	}
+	if ns == "" {
+		ns = "default"
+	}
+	client := cfg.Client()
+	n, err := report.Collect(ctx, client, prometheus.NewWithURL(o.prometheusURL), ns, o.rangeParam, namespaceThresholds(ctx, client, ns))
+	if err != nil {
+		return fmt.Errorf("collecting %s: %w", ns, err)
+	}
+
+	switch {
+	case o.output == formatJSON && cmd == "suggestions":
+		changed := *n
+		changed.Workloads = n.Changed()
+		return writeJSON(out, changed)
+	case o.output == formatJSON:
+		return writeJSON(out, n)
+	case cmd == "workloads":
+		return workloadsTable(n, false).write(out, o.output)
+	case cmd == "suggestions":
+		return suggestionsTable(n).write(out, o.output)
+	case o.output == formatCSV: // report: one row per container with suggested values
+		return workloadsTable(n, true).write(out, o.output)
+	}
+
+	// report as tables: summary, workloads, then suggestions
+	fmt.Fprintf(out, "Namespace %s (context %s) — %d workload(s), usage from %s\n\n", ns, cfg.Context, len(n.Workloads), n.UsageSource)
+	if err := workloadsTable(n, false).write(out, formatTable); err != nil {
+		return err
+	}
+	s := suggestionsTable(n)
+	if len(s.rows) == 0 {
+		_, err := fmt.Fprintln(out, "\nNo changes suggested.")
+		return err
+	}
+	fmt.Fprintf(out, "\nSuggestions (%d container(s)):\n\n", len(s.rows))
+	return s.write(out, formatTable)
+}
+
+func nodesCommand(ctx context.Context, o options, out io.Writer) error {
+	cfg, err := loadKubeconfig(ctx, kubeconfigPaths(o.kubeconfig), o.context)
+	if err != nil {
+		return err
+	}
+	client := cfg.Client()
+	nodes, err := client.ListNodes(ctx)
+	if err != nil {
+		return fmt.Errorf("listing nodes: %w", err)
+	}
+	pods, err := client.ListAllPods(ctx)
+	if err != nil {
+		return fmt.Errorf("listing pods: %w", err)
+	}
+	metrics, err := client.ListNodeMetrics(ctx)
+	if err != nil {
+		log.Printf("metrics-server unavailable: %v", err)
+		metrics = nil
+	}
+	overviews := resources.BuildNodeOverviews(nodes, pods, metrics)
+	if o.output == formatJSON {
+		return writeJSON(out, overviews)
+	}
+	return nodesTable(overviews).write(out, o.output)
+}
+
+func checkCommand(ctx context.Context, o options, out io.Writer) error {
+	p := policy.DefaultPolicy()
+	if o.policyFile != "" {
+		data, err := os.ReadFile(o.policyFile)
+		if err != nil {
+			return err
+		}
+		if p, err = policy.Parse(data); err != nil {
+			return err
+		}
+	}
+	if o.maxRequestP95 >= 0 {
+		p.MaxRequestToP95 = o.maxRequestP95
+	}
+	if o.maxLimitRatio >= 0 {
+		p.MaxLimitToRequest = o.maxLimitRatio
+	}
+
+	cfg, err := loadKubeconfig(ctx, kubeconfigPaths(o.kubeconfig), o.context)
+	if err != nil {
+		return err
+	}
+	client := cfg.Client()
+	var names []string
+	switch {
+	case o.allNamespaces:
+		list, err := client.ListNamespaces(ctx)
+		if err != nil {
+			return fmt.Errorf("listing namespaces: %w", err)
+		}
+		for _, ns := range list.Items {
+			names = append(names, ns.Metadata.Name)
+		}
+	case o.namespace != "":
+		for ns := range strings.SplitSeq(o.namespace, ",") {
+			if ns = strings.TrimSpace(ns); ns != "" {
+				names = append(names, ns)
+			}
+		}
+	case cfg.Namespace != "":
+		names = []string{cfg.Namespace}
+	default:
+		names = []string{"default"}
+	}
+
+	prom := prometheus.NewWithURL(o.prometheusURL)
+	var namespaces []*report.Namespace
+	findings := []policy.Finding{}
+	for _, ns := range names {
+		n, err := report.Collect(ctx, client, prom, ns, o.rangeParam, namespaceThresholds(ctx, client, ns))
+		if err != nil {
+			return fmt.Errorf("collecting %s: %w", ns, err)
+		}
+		namespaces = append(namespaces, n)
+		findings = append(findings, policy.Evaluate(p, n)...)
+	}
+
+	var data []byte
+	switch o.output {
+	case formatJSON:
+		err = writeJSON(out, findings)
+	case formatJUnit:
+		if data, err = policy.JUnit(namespaces, findings); err == nil {
+			_, err = out.Write(data)
+		}
+	case formatSARIF:
+		if data, err = policy.SARIF(findings); err == nil {
+			_, err = out.Write(data)
+		}
+	default:
+		if len(findings) == 0 {
+			_, err = fmt.Fprintf(out, "No policy violations in %d namespace(s).\n", len(names))
+		} else {
+			err = findingsTable(findings).write(out, formatTable)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	if len(findings) > 0 {
+		return fmt.Errorf("%w: %d in %d namespace(s)", errViolations, len(findings), len(names))
+	}
+	return nil
+}
+
+// namespaceThresholds applies the namespace's kubeadjust.io/thresholds annotation on top of
+// the default thresholds, like the backend does.
+func namespaceThresholds(ctx context.Context, client *k8s.Client, namespace string) suggestions.Thresholds {
+	cfg := suggestions.DefaultConfig()
+	ns, err := client.GetNamespace(ctx, namespace)
+	if err != nil {
+		log.Printf("reading namespace %s: %v", namespace, err)
+		return cfg.Global
+	}
+	th, _, err := cfg.ForNamespace("", ns.Metadata.Annotations)
+	if err != nil {
+		log.Printf("namespace %s: %v", namespace, err)
+	}
+	return th
+}
@@ -0,0 +1,272 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const kubectlConfig = `apiVersion: v1
+clusters:
+- cluster:
+    certificate-authority-data: ""
+    server: https://prod.example.com:6443
+  name: prod
+- cluster:
+    insecure-skip-tls-verify: true
+    server: "https://staging.example.com"   # quoted
+  name: staging
+contexts:
+- context:
+    cluster: prod
+    namespace: payments
+    user: oncall
+  name: prod
+current-context: prod
+kind: Config
+preferences: {}
+users:
+- name: oncall
+  user:
+    exec:
+      apiVersion: client.authentication.k8s.io/v1beta1
+      args: ["eks", "get-token", "--cluster-name", 'prod', "--role=a,b"]
+      command: aws
+      env:
+      - name: AWS_PROFILE
+        value: oncall
+    token: &token |
+      line one
+      line two
+- name: ci
+  user:
+    token: *token
+`
+
+func TestParseKubeconfig(t *testing.T) {
+	kc, err := parseKubeconfig([]byte(kubectlConfig))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(kc.Clusters) != 2 || kc.Contexts[0].Context.Namespace != "payments" || kc.CurrentContext != "prod" {
+		t.Errorf("got %+v", kc)
+	}
+	if staging := kc.Clusters[1].Cluster; staging.Server != "https://staging.example.com" || !staging.InsecureSkipTLSVerify {
+		t.Errorf("staging cluster: %+v", staging)
+	}
+	exec := kc.Users[0].User.Exec
+	if exec.Command != "aws" || !reflect.DeepEqual(exec.Args, []string{"eks", "get-token", "--cluster-name", "prod", "--role=a,b"}) {
+		t.Errorf("exec: %+v", exec)
+	}
+	if len(exec.Env) != 1 || exec.Env[0].Name != "AWS_PROFILE" || exec.Env[0].Value != "oncall" {
+		t.Errorf("exec env: %+v", exec.Env)
+	}
+	if want := "line one\nline two\n"; kc.Users[0].User.Token != want || kc.Users[1].User.Token != want {
+		t.Errorf("block scalar token through an alias: %q, %q", kc.Users[0].User.Token, kc.Users[1].User.Token)
+	}
+
+	for _, bad := range []string{"key: \"unterminated", "a: 1\n  b: 2", "just a scalar line\nkey: v"} {
+		if _, err := parseKubeconfig([]byte(bad)); err == nil {
+			t.Errorf("expected error for %q", bad)
+		}
+	}
+	if kc, err := parseKubeconfig([]byte(`{"current-context": "json"}`)); err != nil || kc.CurrentContext != "json" {
+		t.Errorf("JSON kubeconfig: %+v, %v", kc, err)
+	}
+	cfg, err := kc.resolve(t.Context(), "staging-missing")
+	if err == nil {
+		t.Errorf("unknown context should fail, got %+v", cfg)
+	}
+}
+
+func TestLoadKubeconfigMerge(t *testing.T) {
+	first, second := t.TempDir(), t.TempDir()
+	write := func(dir, name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	a := write(first, "config", "current-context: prod\nclusters:\n- name: prod\n  cluster: {server: https://prod.example.com}\n")
+	write(second, "token", "s3cret\n")
+	b := write(second, "config", `current-context: other
+clusters:
+- name: prod
+  cluster: {server: https://shadowed.example.com}
+contexts:
+- name: prod
+  context: {cluster: prod, user: oncall}
+users:
+- name: oncall
+  user: {tokenFile: token}
+`)
+	t.Setenv("KUBECONFIG", strings.Join([]string{a, filepath.Join(first, "missing"), b, a}, string(filepath.ListSeparator)))
+
+	paths := kubeconfigPaths("")
+	if len(paths) != 3 {
+		t.Errorf("paths: %v", paths)
+	}
+	cfg, err := loadKubeconfig(t.Context(), paths, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// current-context and the cluster come from the first file; the token file is
+	// relative to the second.
+	if cfg.Context != "prod" || cfg.Server != "https://prod.example.com" || cfg.Token != "s3cret" {
+		t.Errorf("got %+v", cfg)
+	}
+	if _, err := loadKubeconfig(t.Context(), []string{filepath.Join(first, "missing")}, ""); err == nil {
+		t.Error("a missing explicit kubeconfig should fail")
+	}
+}
+
+// fakeAPIServer serves a namespace with one over-provisioned Deployment.
+func fakeAPIServer(t *testing.T) *httptest.Server {
+	objects := map[string]any{
+		"/api/v1/namespaces": map[string]any{"items": []any{map[string]any{"metadata": map[string]any{"name": "payments"}}}},
+		"/apis/apps/v1/namespaces/payments/deployments": map[string]any{"items": []any{map[string]any{
+			"metadata": map[string]any{"name": "api", "namespace": "payments"},
+			"spec": map[string]any{"replicas": 1, "template": map[string]any{"spec": map[string]any{"containers": []any{map[string]any{
+				"name":      "app",
+				"resources": map[string]any{"requests": map[string]string{"cpu": "1", "memory": "1Gi"}, "limits": map[string]string{"memory": "1Gi"}},
+			}}}}},
+			"status": map[string]any{"readyReplicas": 1, "availableReplicas": 1},
+		}}},
+		"/apis/apps/v1/namespaces/payments/replicasets": map[string]any{"items": []any{map[string]any{
+			"metadata": map[string]any{"name": "api-5d8f", "ownerReferences": []any{map[string]any{"kind": "Deployment", "name": "api"}}},
+		}}},
+		"/api/v1/namespaces/payments/pods": map[string]any{"items": []any{map[string]any{
+			"metadata": map[string]any{"name": "api-5d8f-x1", "namespace": "payments", "ownerReferences": []any{map[string]any{"kind": "ReplicaSet", "name": "api-5d8f"}}},
+			"spec": map[string]any{"containers": []any{map[string]any{
+				"name":      "app",
+				"resources": map[string]any{"requests": map[string]string{"cpu": "1", "memory": "1Gi"}, "limits": map[string]string{"memory": "1Gi"}},
+			}}},
+			"status": map[string]any{"phase": "Running"},
+		}}},
+		"/apis/metrics.k8s.io/v1beta1/namespaces/payments/pods": map[string]any{"items": []any{map[string]any{
+			"metadata":   map[string]any{"name": "api-5d8f-x1"},
+			"containers": []any{map[string]any{"name": "app", "usage": map[string]string{"cpu": "100m", "memory": "700Mi"}}},
+		}}},
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer s3cret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		obj, ok := objects[r.URL.Path]
+		if !ok {
+			obj = map[string]any{"items": []any{}}
+		}
+		_ = json.NewEncoder(w).Encode(obj)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// writeKubeconfig points a kubeconfig at the fake API server, with the token in a file.
+func writeKubeconfig(t *testing.T, srv *httptest.Server) string {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "token"), []byte("s3cret\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	kubeconfig := filepath.Join(dir, "config")
+	cfg := `{"current-context": "test",
+		"clusters": [{"name": "c", "cluster": {"server": "` + srv.URL + `"}}],
+		"users": [{"name": "u", "user": {"tokenFile": "token"}}],
+		"contexts": [{"name": "test", "context": {"cluster": "c", "user": "u", "namespace": "payments"}}]}`
+	if err := os.WriteFile(kubeconfig, []byte(cfg), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PROMETHEUS_URL", "")
+	return kubeconfig
+}
+
+func TestRunReport(t *testing.T) {
+	kubeconfig := writeKubeconfig(t, fakeAPIServer(t))
+
+	var out bytes.Buffer
+	if err := run(t.Context(), []string{"suggestions", "--kubeconfig", kubeconfig}, &out); err != nil {
+		t.Fatal(err)
+	}
+	// 100m × 1.3 → 150m request, unset CPU limit → 150m; 700Mi × 1.3 fits the 1Gi request.
+	if !strings.Contains(out.String(), "Deployment  api   app        1 → 150m") {
+		t.Errorf("suggestions table:\n%s", out.String())
+	}
+
+	out.Reset()
+	if err := run(t.Context(), []string{"report", "--kubeconfig", kubeconfig, "-o", "csv"}, &out); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 || lines[1] != "Deployment,api,1/1,app,1,-,100m,1Gi,1Gi,700Mi,150m,150m,1Gi,1Gi" {
+		t.Errorf("report csv:\n%s", out.String())
+	}
+
+	out.Reset()
+	if err := run(t.Context(), []string{"workloads", "--kubeconfig", kubeconfig, "-n", "payments", "-o", "json"}, &out); err != nil {
+		t.Fatal(err)
+	}
+	var n struct {
+		Namespace   string `json:"namespace"`
+		UsageSource string `json:"usageSource"`
+		Workloads   []struct {
+			Name            string `json:"name"`
+			Recommendations []struct {
+				CPU struct {
+					Request int64 `json:"request"`
+				} `json:"cpu"`
+			} `json:"recommendations"`
+		} `json:"workloads"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &n); err != nil {
+		t.Fatal(err)
+	}
+	if n.Namespace != "payments" || n.UsageSource != "metrics-server" || len(n.Workloads) != 1 || n.Workloads[0].Recommendations[0].CPU.Request != 150 {
+		t.Errorf("json: %s", out.String())
+	}
+
+	for _, args := range [][]string{{}, {"bogus"}, {"report", "-o", "yaml"}, {"report", "extra"}} {
+		if err := run(t.Context(), args, &out); err == nil || !strings.Contains(err.Error(), errUsage.Error()) {
+			t.Errorf("%v: got %v, want usage error", args, err)
+		}
+	}
+}
+
+func TestRunCheck(t *testing.T) {
+	kubeconfig := writeKubeconfig(t, fakeAPIServer(t))
+
+	// a 1-core request against 100m of usage is above the default 3× P95
+	var out bytes.Buffer
+	err := run(t.Context(), []string{"check", "--kubeconfig", kubeconfig, "-A"}, &out)
+	if !errors.Is(err, errViolations) {
+		t.Fatalf("got %v, want policy violations", err)
+	}
+	if !strings.Contains(out.String(), "payments   Deployment  api   app        request-over-p95") {
+		t.Errorf("check table:\n%s", out.String())
+	}
+
+	out.Reset()
+	err = run(t.Context(), []string{"check", "--kubeconfig", kubeconfig, "-n", "payments", "-o", "junit"}, &out)
+	if !errors.Is(err, errViolations) || !strings.Contains(out.String(), `<testsuite name="payments" tests="1" failures="1">`) {
+		t.Errorf("junit (%v):\n%s", err, out.String())
+	}
+
+	out.Reset()
+	if err := run(t.Context(), []string{"check", "--kubeconfig", kubeconfig, "--max-request-p95", "20"}, &out); err != nil {
+		t.Errorf("relaxed policy: got %v\n%s", err, out.String())
+	}
+
+	for _, args := range [][]string{{"check", "-o", "csv"}, {"report", "-o", "sarif"}} {
+		if err := run(t.Context(), args, &out); !errors.Is(err, errUsage) {
+			t.Errorf("%v: got %v, want usage error", args, err)
+		}
+	}
+}
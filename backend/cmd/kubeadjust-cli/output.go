@@ -0,0 +1,187 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/devops-kubeadjust/backend/k8s"
+	"github.com/devops-kubeadjust/backend/policy"
+	"github.com/devops-kubeadjust/backend/report"
+	"github.com/devops-kubeadjust/backend/resources"
+	"github.com/devops-kubeadjust/backend/suggestions"
+)
+
+// Output formats.
+const (
+	formatTable = "table"
+	formatJSON  = "json"
+	formatCSV   = "csv"
+	formatJUnit = "junit" // check only
+	formatSARIF = "sarif" // check only
+)
+
+// table is a list of rows rendered as aligned columns or CSV.
+type table struct {
+	header []string
+	rows   [][]string
+}
+
+func (t *table) add(cells ...string) {
+	t.rows = append(t.rows, cells)
+}
+
+func (t *table) write(w io.Writer, format string) error {
+	if format == formatCSV {
+		cw := csv.NewWriter(w)
+		if err := cw.Write(t.header); err != nil {
+			return err
+		}
+		return cw.WriteAll(t.rows) // flushes
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(t.header, "\t"))
+	for _, r := range t.rows {
+		fmt.Fprintln(tw, strings.Join(r, "\t"))
+	}
+	return tw.Flush()
+}
+
+func writeJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// qty formats millicores or bytes the way manifests spell them; observed memory usage is
+// rounded up to the next MiB. Unset values print as "-".
+func qty(v int64, isCPU bool) string {
+	const mib = 1 << 20
+	switch {
+	case v <= 0:
+		return "-"
+	case isCPU, v%mib == 0, v < mib:
+		return resources.FmtQuantity(v, isCPU)
+	default:
+		return resources.FmtQuantity((v+mib-1)/mib*mib, false)
+	}
+}
+
+// change formats a current → suggested pair, or the current value when unchanged.
+func change(cur, rec int64, isCPU bool) string {
+	if cur == rec {
+		return qty(cur, isCPU)
+	}
+	return qty(cur, isCPU) + " → " + qty(rec, isCPU)
+}
+
+func percent(v, total int64) string {
+	if total <= 0 {
+		return ""
+	}
+	return fmt.Sprintf(" (%d%%)", v*100/total)
+}
+
+// containerRequests returns the requests and limits of a pod template container.
+func containerRequests(c k8s.Container) (cpuReq, cpuLim, memReq, memLim int64) {
+	return resources.ParseCPUMillicores(c.Resources.Requests["cpu"]), resources.ParseCPUMillicores(c.Resources.Limits["cpu"]),
+		resources.ParseMemoryBytes(c.Resources.Requests["memory"]), resources.ParseMemoryBytes(c.Resources.Limits["memory"])
+}
+
+// peakUsage returns the highest live usage of a container across the workload's pods.
+func peakUsage(w report.Workload, container string) (cpu, mem int64) {
+	for _, p := range w.Pods {
+		for _, c := range p.Containers {
+			if c.Name == container && c.Usage != nil {
+				cpu = max(cpu, c.Usage.CPU.Millicores)
+				mem = max(mem, c.Usage.Memory.Bytes)
+			}
+		}
+	}
+	return cpu, mem
+}
+
+func recommendationFor(w report.Workload, container string) (suggestions.ContainerRecommendation, bool) {
+	for _, r := range w.Recommendations {
+		if r.Container == container {
+			return r, true
+		}
+	}
+	return suggestions.ContainerRecommendation{}, false
+}
+
+// workloadsTable lists every pod template container with its requests, limits and peak
+// live usage. With suggested, the recommended values are appended (report CSV).
+func workloadsTable(n *report.Namespace, suggested bool) *table {
+	t := &table{header: []string{"KIND", "NAME", "READY", "CONTAINER", "CPU REQ", "CPU LIM", "CPU USE", "MEM REQ", "MEM LIM", "MEM USE"}}
+	if suggested {
+		t.header = append(t.header, "SUGGESTED CPU REQ", "SUGGESTED CPU LIM", "SUGGESTED MEM REQ", "SUGGESTED MEM LIM")
+	}
+	for _, w := range n.Workloads {
+		ready := fmt.Sprintf("%d/%d", w.ReadyReplicas, w.Replicas)
+		for _, c := range w.Spec.Containers {
+			cpuReq, cpuLim, memReq, memLim := containerRequests(c)
+			cpuUse, memUse := peakUsage(w, c.Name)
+			row := []string{w.Kind, w.Name, ready, c.Name,
+				qty(cpuReq, true), qty(cpuLim, true), qty(cpuUse, true),
+				qty(memReq, false), qty(memLim, false), qty(memUse, false)}
+			if suggested {
+				r, _ := recommendationFor(w, c.Name)
+				row = append(row, qty(r.CPU.Request, true), qty(r.CPU.Limit, true), qty(r.Memory.Request, false), qty(r.Memory.Limit, false))
+			}
+			t.add(row...)
+		}
+	}
+	return t
+}
+
+// suggestionsTable lists the containers with a recommended change, as current → suggested.
+func suggestionsTable(n *report.Namespace) *table {
+	t := &table{header: []string{"KIND", "NAME", "CONTAINER", "CPU REQ", "CPU LIM", "MEM REQ", "MEM LIM"}}
+	for _, w := range n.Changed() {
+		for _, c := range w.Spec.Containers {
+			r, ok := recommendationFor(w, c.Name)
+			if !ok || !r.Changed() {
+				continue
+			}
+			cpuReq, cpuLim, memReq, memLim := containerRequests(c)
+			t.add(w.Kind, w.Name, c.Name,
+				change(cpuReq, r.CPU.Request, true), change(cpuLim, r.CPU.Limit, true),
+				change(memReq, r.Memory.Request, false), change(memLim, r.Memory.Limit, false))
+		}
+	}
+	return t
+}
+
+// nodesTable lists nodes with allocatable resources, requested and used share.
+func nodesTable(nodes []resources.NodeOverview) *table {
+	t := &table{header: []string{"NAME", "STATUS", "ROLES", "PODS", "CPU ALLOC", "CPU REQ", "CPU USE", "MEM ALLOC", "MEM REQ", "MEM USE"}}
+	for _, n := range nodes {
+		cpuAlloc, memAlloc := n.Allocatable.CPU.Millicores, n.Allocatable.Memory.Bytes
+		cpuUse, memUse := "-", "-"
+		if n.Usage != nil {
+			cpuUse = qty(n.Usage.CPU.Millicores, true) + percent(n.Usage.CPU.Millicores, cpuAlloc)
+			memUse = qty(n.Usage.Memory.Bytes, false) + percent(n.Usage.Memory.Bytes, memAlloc)
+		}
+		status := n.Status
+		if n.Unschedulable {
+			status += ",SchedulingDisabled"
+		}
+		t.add(n.Name, status, strings.Join(n.Roles, ","), fmt.Sprintf("%d/%d", n.PodCount, n.MaxPods),
+			qty(cpuAlloc, true), qty(n.Requested.CPU.Millicores, true)+percent(n.Requested.CPU.Millicores, cpuAlloc), cpuUse,
+			qty(memAlloc, false), qty(n.Requested.Memory.Bytes, false)+percent(n.Requested.Memory.Bytes, memAlloc), memUse)
+	}
+	return t
+}
+
+// findingsTable lists policy violations, one per row.
+func findingsTable(findings []policy.Finding) *table {
+	t := &table{header: []string{"NAMESPACE", "KIND", "NAME", "CONTAINER", "RULE", "MESSAGE"}}
+	for _, f := range findings {
+		t.add(f.Namespace, f.Kind, f.Workload, f.Container, f.Rule, f.Message)
+	}
+	return t
+}
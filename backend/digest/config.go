@@ -0,0 +1,137 @@
+// Package digest renders a weekly HTML and plain-text sizing digest per namespace owner —
+// top over-provisioned workloads, containers without requests, PVCs near full and the
+// change since the previous digest — and delivers it over SMTP on a cron schedule.
+package digest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/mail"
+	"os"
+	"time"
+)
+
+// OwnerAnnotation lists the comma-separated e-mail addresses that receive the digest of a
+// namespace, e.g. kubeadjust.io/owner: "payments-leads@example.com".
+const OwnerAnnotation = "kubeadjust.io/owner"
+
+// Config is the digest configuration.
+type Config struct {
+	SMTP         SMTP                     `json:"smtp"`
+	DashboardURL string                   `json:"dashboardURL,omitempty"` // linked from the digest when set
+	PVCThreshold float64                  `json:"pvcThreshold,omitempty"` // used/capacity ratio, 0.8 if unset
+	StateFile    string                   `json:"stateFile,omitempty"`    // totals of the previous digest; kept in memory if unset
+	Clusters     map[string]ClusterConfig `json:"clusters"`               // keyed by cluster name ("default" for single-cluster)
+}
+
+// SMTP is the mail server used to send digests.
+type SMTP struct {
+	Host     string `json:"host"`
+	Port     int    `json:"port,omitempty"` // 587 if unset
+	Username string `json:"username,omitempty"`
+	From     string `json:"from"`
+	// TLS uses implicit TLS (SMTPS, usually port 465). Otherwise STARTTLS is used when
+	// the server offers it.
+	TLS bool `json:"tls,omitempty"`
+
+	Password string `json:"-"` // from DIGEST_SMTP_PASSWORD, never read from the file
+}
+
+// ClusterConfig schedules the digest of one cluster.
+type ClusterConfig struct {
+	Schedule string `json:"schedule"`           // cron expression, e.g. "0 8 * * MON"
+	Timezone string `json:"timezone,omitempty"` // IANA name, server local time if unset
+	// Recipients adds owners per namespace, on top of the OwnerAnnotation.
+	Recipients map[string][]string `json:"recipients,omitempty"`
+	// DefaultRecipients receive the namespaces without any owner; such namespaces are
+	// skipped when empty.
+	DefaultRecipients []string `json:"defaultRecipients,omitempty"`
+
+	schedule *Schedule
+	location *time.Location
+}
+
+// Load reads the configuration from the environment:
+//   - DIGEST_CONFIG        → path to a JSON file {"smtp", "dashboardURL", "pvcThreshold", "stateFile", "clusters"}
+//   - DIGEST_SMTP_PASSWORD → SMTP password for smtp.username
+//
+// Returns nil (and no error) when DIGEST_CONFIG is unset: digests are then disabled.
+func Load() (*Config, error) {
+	path := os.Getenv("DIGEST_CONFIG")
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading digest config: %w", err)
+	}
+	c, err := Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	c.SMTP.Password = os.Getenv("DIGEST_SMTP_PASSWORD")
+	if c.SMTP.Username != "" && c.SMTP.Password == "" {
+		return nil, fmt.Errorf("digest config: smtp.username is set but DIGEST_SMTP_PASSWORD is empty")
+	}
+	return c, nil
+}
+
+// Parse decodes and validates a JSON digest config, applying defaults.
+func Parse(data []byte) (*Config, error) {
+	var c Config
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("parsing digest config: %w", err)
+	}
+	if c.SMTP.Host == "" {
+		return nil, fmt.Errorf("digest config: smtp.host is required")
+	}
+	if c.SMTP.Port == 0 {
+		c.SMTP.Port = 587
+	}
+	if _, err := mail.ParseAddress(c.SMTP.From); err != nil {
+		return nil, fmt.Errorf("digest config: smtp.from: %w", err)
+	}
+	if c.PVCThreshold == 0 {
+		c.PVCThreshold = 0.8
+	}
+	if c.PVCThreshold <= 0 || c.PVCThreshold > 1 {
+		return nil, fmt.Errorf("digest config: pvcThreshold must be in (0, 1]")
+	}
+	if len(c.Clusters) == 0 {
+		return nil, fmt.Errorf("digest config: at least one cluster is required")
+	}
+	for name, cc := range c.Clusters {
+		var err error
+		if cc.schedule, err = ParseSchedule(cc.Schedule); err != nil {
+			return nil, fmt.Errorf("digest config: clusters.%s.schedule: %w", name, err)
+		}
+		cc.location = time.Local
+		if cc.Timezone != "" {
+			if cc.location, err = time.LoadLocation(cc.Timezone); err != nil {
+				return nil, fmt.Errorf("digest config: clusters.%s.timezone: %w", name, err)
+			}
+		}
+		for _, addrs := range append([][]string{cc.DefaultRecipients}, mapValues(cc.Recipients)...) {
+			for _, a := range addrs {
+				if _, err := mail.ParseAddress(a); err != nil {
+					return nil, fmt.Errorf("digest config: clusters.%s: recipient %q: %w", name, a, err)
+				}
+			}
+		}
+		c.Clusters[name] = cc
+	}
+	return &c, nil
+}
+
+// Next returns the next digest time of cc strictly after t.
+func (cc ClusterConfig) Next(t time.Time) time.Time {
+	return cc.schedule.Next(t.In(cc.location))
+}
+
+func mapValues(m map[string][]string) [][]string {
+	out := make([][]string, 0, len(m))
+	for _, v := range m {
+		out = append(out, v)
+	}
+	return out
+}
@@ -0,0 +1,44 @@
+package digest
+
+import (
+	"testing"
+	"time"
+)
+
+func TestScheduleNext(t *testing.T) {
+	ist := time.FixedZone("IST", 5*3600+1800)
+	cases := []struct {
+		expr string
+		from time.Time
+		want time.Time
+	}{
+		{"0 8 * * MON", time.Date(2026, 10, 18, 10, 0, 0, 0, time.UTC), time.Date(2026, 10, 19, 8, 0, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2026, 10, 18, 10, 7, 30, 0, time.UTC), time.Date(2026, 10, 18, 10, 15, 0, 0, time.UTC)},
+		// Both day fields restricted: either matches (Friday the 9th before the 15th).
+		{"0 0 1,15 * FRI", time.Date(2026, 10, 3, 0, 0, 0, 0, time.UTC), time.Date(2026, 10, 9, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1,15 * FRI", time.Date(2026, 10, 10, 0, 0, 0, 0, time.UTC), time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC), time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 9 * * 7", time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC), time.Date(2026, 10, 18, 9, 0, 0, 0, time.UTC)},
+		{"0-10/5 6 * JAN-MAR *", time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC), time.Date(2027, 1, 1, 6, 0, 0, 0, time.UTC)},
+		// Strictly after: a time on the schedule yields the next occurrence, in a half-hour zone.
+		{"30 9 * * *", time.Date(2026, 10, 18, 9, 30, 0, 0, ist), time.Date(2026, 10, 19, 9, 30, 0, 0, ist)},
+		{"0 0 30 2 *", time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC), time.Time{}},
+	}
+	for _, c := range cases {
+		s, err := ParseSchedule(c.expr)
+		if err != nil {
+			t.Fatalf("%q: %v", c.expr, err)
+		}
+		if got := s.Next(c.from); !got.Equal(c.want) {
+			t.Errorf("%q from %s: got %s, want %s", c.expr, c.from, got, c.want)
+		}
+	}
+}
+
+func TestParseScheduleErrors(t *testing.T) {
+	for _, expr := range []string{"", "* * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "5-1 * * * *", "*/0 * * * *", "0 0 * * FUNDAY", "@yearly"} {
+		if _, err := ParseSchedule(expr); err == nil {
+			t.Errorf("%q: expected an error", expr)
+		}
+	}
+}
@@ -0,0 +1,223 @@
+package digest
+
+import (
+	"cmp"
+	"slices"
+	"time"
+
+	"github.com/devops-kubeadjust/backend/k8s"
+	"github.com/devops-kubeadjust/backend/pricing"
+	"github.com/devops-kubeadjust/backend/report"
+	"github.com/devops-kubeadjust/backend/resources"
+)
+
+// topWorkloads is the number of over-provisioned workloads listed in a digest.
+const topWorkloads = 10
+
+// memoryGiBPerCore weighs memory against CPU to rank workloads when no pricing is
+// configured: 1 core ≈ 4 GiB, the usual vCPU:memory ratio of general-purpose instances.
+const memoryGiBPerCore = 4
+
+const gib = 1 << 30
+
+// Workload is an over-provisioned workload: its requests (summed over containers, per
+// replica) are above the suggested ones.
+type Workload struct {
+	Namespace         string
+	Kind              string
+	Name              string
+	Replicas          int32
+	CPURequest        int64 // millicores, per replica
+	CPUSuggested      int64
+	MemoryRequest     int64 // bytes, per replica
+	MemorySuggested   int64
+	ReclaimableCPU    int64   // millicores, all replicas
+	ReclaimableMemory int64   // bytes, all replicas
+	MonthlyCost       float64 // of the reclaimable requests, when pricing is configured
+	score             float64
+}
+
+// MissingRequest is a container without CPU and/or memory request.
+type MissingRequest struct {
+	Namespace string
+	Kind      string
+	Workload  string
+	Container string
+	Missing   []string // cpu, memory
+}
+
+// PVC is a PersistentVolumeClaim filled above the digest threshold.
+type PVC struct {
+	Namespace string
+	Name      string
+	Used      int64
+	Capacity  int64
+}
+
+// Ratio is the used fraction of the PVC.
+func (p PVC) Ratio() float64 { return float64(p.Used) / float64(p.Capacity) }
+
+// Totals summarise a namespace (or a digest) for the change since the previous digest.
+type Totals struct {
+	OverProvisioned   int   `json:"overProvisioned"`
+	ReclaimableCPU    int64 `json:"reclaimableCPU"`    // millicores
+	ReclaimableMemory int64 `json:"reclaimableMemory"` // bytes
+	MissingRequests   int   `json:"missingRequests"`
+	PVCsNearFull      int   `json:"pvcsNearFull"`
+}
+
+func (t *Totals) add(o Totals) {
+	t.OverProvisioned += o.OverProvisioned
+	t.ReclaimableCPU += o.ReclaimableCPU
+	t.ReclaimableMemory += o.ReclaimableMemory
+	t.MissingRequests += o.MissingRequests
+	t.PVCsNearFull += o.PVCsNearFull
+}
+
+// NamespaceSummary holds the findings of one namespace.
+type NamespaceSummary struct {
+	Namespace       string
+	Workloads       []Workload // over-provisioned, any order
+	MissingRequests []MissingRequest
+	PVCs            []PVC
+	Totals          Totals
+}
+
+// Summarize extracts the findings of a namespace report. pvcs are the namespace's PVCs
+// above the threshold; prices may be nil. Workloads annotated kubeadjust.io/ignore are
+// skipped, as in suggestions.
+func Summarize(r *report.Namespace, pvcs []PVC, prices *pricing.Table) NamespaceSummary {
+	s := NamespaceSummary{Namespace: r.Namespace, PVCs: pvcs}
+	for _, w := range r.Workloads {
+		if w.Sizing != nil && w.Sizing.Ignore {
+			continue
+		}
+		wl := Workload{Namespace: r.Namespace, Kind: w.Kind, Name: w.Name, Replicas: w.Replicas}
+		for i, c := range w.Spec.Containers {
+			cpuReq := resources.ParseCPUMillicores(c.Resources.Requests["cpu"])
+			memReq := resources.ParseMemoryBytes(c.Resources.Requests["memory"])
+			var missing []string
+			if cpuReq == 0 {
+				missing = append(missing, "cpu")
+			}
+			if memReq == 0 {
+				missing = append(missing, "memory")
+			}
+			if len(missing) > 0 {
+				s.MissingRequests = append(s.MissingRequests, MissingRequest{
+					Namespace: r.Namespace, Kind: w.Kind, Workload: w.Name, Container: c.Name, Missing: missing,
+				})
+			}
+			cpuSug, memSug := cpuReq, memReq
+			if i < len(w.Recommendations) {
+				rec := w.Recommendations[i]
+				if rec.CPU.Request > 0 && rec.CPU.Request < cpuReq {
+					cpuSug = rec.CPU.Request
+				}
+				if rec.Memory.Request > 0 && rec.Memory.Request < memReq {
+					memSug = rec.Memory.Request
+				}
+			}
+			wl.CPURequest += cpuReq
+			wl.CPUSuggested += cpuSug
+			wl.MemoryRequest += memReq
+			wl.MemorySuggested += memSug
+		}
+		// Scaled-down workloads (and CronJobs between runs) hold no requests right now.
+		if wl.Replicas <= 0 || (wl.CPUSuggested == wl.CPURequest && wl.MemorySuggested == wl.MemoryRequest) {
+			continue
+		}
+		wl.ReclaimableCPU = (wl.CPURequest - wl.CPUSuggested) * int64(wl.Replicas)
+		wl.ReclaimableMemory = (wl.MemoryRequest - wl.MemorySuggested) * int64(wl.Replicas)
+		if prices != nil {
+			wl.MonthlyCost = prices.Cost(prices.Default, wl.ReclaimableCPU, wl.ReclaimableMemory, 0, 0, false).Requested
+			wl.score = wl.MonthlyCost
+		} else {
+			wl.score = float64(wl.ReclaimableCPU)/1000 + float64(wl.ReclaimableMemory)/gib/memoryGiBPerCore
+		}
+		s.Workloads = append(s.Workloads, wl)
+		s.Totals.ReclaimableCPU += wl.ReclaimableCPU
+		s.Totals.ReclaimableMemory += wl.ReclaimableMemory
+	}
+	s.Totals.OverProvisioned = len(s.Workloads)
+	s.Totals.MissingRequests = len(s.MissingRequests)
+	s.Totals.PVCsNearFull = len(pvcs)
+	return s
+}
+
+// NearFullPVCs returns the PVCs filled to threshold or more from kubelet summaries, keyed by
+// namespace. A PVC mounted by several pods is reported once.
+func NearFullPVCs(summaries []*k8s.NodeSummary, threshold float64) map[string][]PVC {
+	seen := make(map[string]bool)
+	out := make(map[string][]PVC)
+	for _, s := range summaries {
+		for _, p := range s.Pods {
+			for _, v := range p.Volumes {
+				if v.PVCRef == nil || v.CapacityBytes <= 0 {
+					continue
+				}
+				key := v.PVCRef.Namespace + "/" + v.PVCRef.Name
+				if seen[key] || float64(v.UsedBytes)/float64(v.CapacityBytes) < threshold {
+					continue
+				}
+				seen[key] = true
+				out[v.PVCRef.Namespace] = append(out[v.PVCRef.Namespace], PVC{
+					Namespace: v.PVCRef.Namespace, Name: v.PVCRef.Name, Used: v.UsedBytes, Capacity: v.CapacityBytes,
+				})
+			}
+		}
+	}
+	return out
+}
+
+// Digest is the content of one e-mail: the findings of the namespaces of one recipient.
+type Digest struct {
+	Cluster         string
+	Namespaces      []string
+	GeneratedAt     time.Time
+	Currency        string // set when pricing is configured
+	DashboardURL    string
+	TopWorkloads    []Workload // by reclaimable requests, at most topWorkloads
+	MoreWorkloads   int        // over-provisioned workloads not listed
+	MissingRequests []MissingRequest
+	PVCs            []PVC
+	Totals          Totals
+	Previous        *Totals // nil on the first digest of these namespaces
+}
+
+// Compose merges the summaries of a recipient's namespaces. previous holds the totals of
+// the last digest per namespace; namespaces missing from it are left out of Previous.
+func Compose(cluster string, summaries []NamespaceSummary, previous map[string]Totals, now time.Time) Digest {
+	d := Digest{Cluster: cluster, GeneratedAt: now}
+	var workloads []Workload
+	var prev Totals
+	hasPrev := false
+	for _, s := range summaries {
+		d.Namespaces = append(d.Namespaces, s.Namespace)
+		workloads = append(workloads, s.Workloads...)
+		d.MissingRequests = append(d.MissingRequests, s.MissingRequests...)
+		d.PVCs = append(d.PVCs, s.PVCs...)
+		d.Totals.add(s.Totals)
+		if p, ok := previous[s.Namespace]; ok {
+			prev.add(p)
+			hasPrev = true
+		}
+	}
+	if hasPrev {
+		d.Previous = &prev
+	}
+	slices.Sort(d.Namespaces)
+	slices.SortStableFunc(workloads, func(a, b Workload) int {
+		return cmp.Or(cmp.Compare(b.score, a.score), cmp.Compare(a.Namespace, b.Namespace), cmp.Compare(a.Name, b.Name))
+	})
+	if len(workloads) > topWorkloads {
+		d.MoreWorkloads = len(workloads) - topWorkloads
+		workloads = workloads[:topWorkloads]
+	}
+	d.TopWorkloads = workloads
+	slices.SortFunc(d.MissingRequests, func(a, b MissingRequest) int {
+		return cmp.Or(cmp.Compare(a.Namespace, b.Namespace), cmp.Compare(a.Workload, b.Workload), cmp.Compare(a.Container, b.Container))
+	})
+	slices.SortFunc(d.PVCs, func(a, b PVC) int { return cmp.Compare(b.Ratio(), a.Ratio()) })
+	return d
+}
@@ -0,0 +1,395 @@
+package digest
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/mail"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/devops-kubeadjust/backend/k8s"
+	"github.com/devops-kubeadjust/backend/pricing"
+	"github.com/devops-kubeadjust/backend/report"
+	"github.com/devops-kubeadjust/backend/resources"
+	"github.com/devops-kubeadjust/backend/suggestions"
+)
+
+func TestParse(t *testing.T) {
+	cfg, err := Parse([]byte(`{"smtp": {"host": "smtp.example.com", "from": "KubeAdjust <kubeadjust@example.com>"},
+		"clusters": {"prod": {"schedule": "0 8 * * MON", "timezone": "Europe/Paris", "defaultRecipients": ["ops@example.com"]}}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.SMTP.Port != 587 || cfg.PVCThreshold != 0.8 {
+		t.Errorf("defaults: %+v", cfg)
+	}
+	from := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	if got := cfg.Clusters["prod"].Next(from); got.Format(time.RFC3339) != "2026-10-19T08:00:00+02:00" {
+		t.Errorf("next: %s", got)
+	}
+
+	for _, bad := range []string{
+		`{"smtp": {"from": "a@example.com"}, "clusters": {"prod": {"schedule": "@weekly"}}}`,
+		`{"smtp": {"host": "h", "from": "not an address"}, "clusters": {"prod": {"schedule": "@weekly"}}}`,
+		`{"smtp": {"host": "h", "from": "a@example.com"}, "clusters": {}}`,
+		`{"smtp": {"host": "h", "from": "a@example.com"}, "clusters": {"prod": {"schedule": "every monday"}}}`,
+		`{"smtp": {"host": "h", "from": "a@example.com"}, "clusters": {"prod": {"schedule": "@weekly", "timezone": "Mars/Olympus"}}}`,
+		`{"smtp": {"host": "h", "from": "a@example.com"}, "clusters": {"prod": {"schedule": "@weekly", "recipients": {"shop": ["nope"]}}}}`,
+		`{"smtp": {"host": "h", "from": "a@example.com"}, "pvcThreshold": 1.5, "clusters": {"prod": {"schedule": "@weekly"}}}`,
+	} {
+		if _, err := Parse([]byte(bad)); err == nil {
+			t.Errorf("expected an error for %s", bad)
+		}
+	}
+}
+
+func container(name, cpu, memory string) k8s.Container {
+	c := k8s.Container{Name: name, Resources: k8s.ResourceRequire{Requests: map[string]string{}}}
+	if cpu != "" {
+		c.Resources.Requests["cpu"] = cpu
+	}
+	if memory != "" {
+		c.Resources.Requests["memory"] = memory
+	}
+	return c
+}
+
+func rec(cpu, memory int64) suggestions.ContainerRecommendation {
+	return suggestions.ContainerRecommendation{CPU: suggestions.Recommendation{Request: cpu}, Memory: suggestions.Recommendation{Request: memory}}
+}
+
+func TestSummarize(t *testing.T) {
+	const mi = 1 << 20
+	r := &report.Namespace{Namespace: "shop", Workloads: []report.Workload{
+		{
+			DeploymentDetail: resources.DeploymentDetail{Kind: "Deployment", Name: "web", Replicas: 3},
+			Spec:             k8s.PodSpec{Containers: []k8s.Container{container("app", "500m", "512Mi"), container("proxy", "", "")}},
+			Recommendations:  []suggestions.ContainerRecommendation{rec(200, 256*mi), rec(0, 0)},
+		},
+		{
+			DeploymentDetail: resources.DeploymentDetail{Kind: "Deployment", Name: "batch", Replicas: 1},
+			Spec:             k8s.PodSpec{Containers: []k8s.Container{container("app", "2", "4Gi")}},
+			Recommendations:  []suggestions.ContainerRecommendation{rec(1000, 4*gib)},
+		},
+		{
+			DeploymentDetail: resources.DeploymentDetail{Kind: "Deployment", Name: "ignored", Replicas: 1, Sizing: &resources.SizingPolicy{Ignore: true}},
+			Spec:             k8s.PodSpec{Containers: []k8s.Container{container("app", "", "")}},
+			Recommendations:  []suggestions.ContainerRecommendation{rec(100, mi)},
+		},
+		{
+			DeploymentDetail: resources.DeploymentDetail{Kind: "Deployment", Name: "idle", Replicas: 0},
+			Spec:             k8s.PodSpec{Containers: []k8s.Container{container("app", "1", "1Gi")}},
+			Recommendations:  []suggestions.ContainerRecommendation{rec(100, mi)},
+		},
+	}}
+	pvcs := []PVC{{Namespace: "shop", Name: "data", Used: 90, Capacity: 100}}
+
+	s := Summarize(r, pvcs, nil)
+	want := Totals{OverProvisioned: 2, ReclaimableCPU: 1900, ReclaimableMemory: 768 * mi, MissingRequests: 1, PVCsNearFull: 1}
+	if s.Totals != want {
+		t.Errorf("totals: got %+v, want %+v", s.Totals, want)
+	}
+	if len(s.MissingRequests) != 1 || s.MissingRequests[0].Container != "proxy" || strings.Join(s.MissingRequests[0].Missing, ",") != "cpu,memory" {
+		t.Errorf("missing requests: %+v", s.MissingRequests)
+	}
+	// Without pricing 1 core weighs 4 GiB: web (0.9 core + 768Mi) ranks above batch (1 core).
+	if d := Compose("prod", []NamespaceSummary{s}, nil, time.Now()); d.TopWorkloads[0].Name != "web" || d.Previous != nil {
+		t.Errorf("unpriced ranking: %+v", d.TopWorkloads)
+	}
+
+	// With pricing the ranking follows the monthly cost: memory is cheap, batch comes first.
+	prices := &pricing.Table{Currency: "EUR", Default: pricing.Rate{CPUCoreHour: 0.04, MemoryGiBHour: 0.001}}
+	s = Summarize(r, pvcs, prices)
+	d := Compose("prod", []NamespaceSummary{s}, nil, time.Now())
+	if d.TopWorkloads[0].Name != "batch" || d.TopWorkloads[0].MonthlyCost != 0.04*730 {
+		t.Errorf("priced ranking: %+v", d.TopWorkloads)
+	}
+}
+
+func TestCompose(t *testing.T) {
+	var summaries []NamespaceSummary
+	for _, ns := range []string{"shop", "billing"} {
+		s := NamespaceSummary{Namespace: ns, Totals: Totals{OverProvisioned: 6, ReclaimableCPU: 600}}
+		for i := range 6 {
+			s.Workloads = append(s.Workloads, Workload{Namespace: ns, Name: fmt.Sprintf("w%d", i), ReclaimableCPU: 100, score: float64(i)})
+		}
+		s.PVCs = []PVC{{Namespace: ns, Name: "data", Used: 80 + int64(len(ns)), Capacity: 100}}
+		summaries = append(summaries, s)
+	}
+	d := Compose("prod", summaries, map[string]Totals{"shop": {OverProvisioned: 4, ReclaimableCPU: 500}}, time.Now())
+
+	if strings.Join(d.Namespaces, ",") != "billing,shop" || d.Totals.OverProvisioned != 12 || d.Totals.ReclaimableCPU != 1200 {
+		t.Errorf("digest: %+v", d)
+	}
+	if len(d.TopWorkloads) != topWorkloads || d.MoreWorkloads != 2 || d.TopWorkloads[0].Namespace != "billing" || d.TopWorkloads[0].Name != "w5" {
+		t.Errorf("top workloads (%d more): %+v", d.MoreWorkloads, d.TopWorkloads)
+	}
+	if d.PVCs[0].Namespace != "billing" {
+		t.Errorf("PVCs should be sorted by fill ratio: %+v", d.PVCs)
+	}
+	// Only shop has a previous digest: the comparison covers what is known.
+	if d.Previous == nil || d.Previous.OverProvisioned != 4 {
+		t.Errorf("previous: %+v", d.Previous)
+	}
+
+	html, text, err := d.Render()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"Over-provisioned workloads:  12 (+8)", "Reclaimable CPU requests:    1.2 (+700m)", "...and 2 more.", "billing/data: 87%"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("text digest lacks %q:\n%s", want, text)
+		}
+	}
+	if !strings.Contains(html, "<td>billing/w5 <span") || !strings.Contains(html, "vs. previous digest") {
+		t.Errorf("html digest:\n%s", html)
+	}
+	if got := d.Subject(); got != "KubeAdjust digest — prod: billing, shop" {
+		t.Errorf("subject: %q", got)
+	}
+}
+
+func TestRecipients(t *testing.T) {
+	ns := func(name, owner string) k8s.Namespace {
+		n := k8s.Namespace{Metadata: k8s.ObjectMeta{Name: name}}
+		if owner != "" {
+			n.Metadata.Annotations = map[string]string{OwnerAnnotation: owner}
+		}
+		return n
+	}
+	cc := ClusterConfig{
+		Recipients:        map[string][]string{"shop": {"finops@example.com"}},
+		DefaultRecipients: []string{"ops@example.com"},
+	}
+	got := recipients(cc, []k8s.Namespace{
+		ns("shop", "Shop Leads <Leads@example.com>, finops@example.com"),
+		ns("billing", "leads@example.com, not-an-address"),
+		ns("kube-system", ""),
+	})
+	want := map[string]string{
+		"leads@example.com":  "shop,billing",
+		"finops@example.com": "shop",
+		"ops@example.com":    "kube-system",
+	}
+	if len(got) != len(want) {
+		t.Errorf("got %v", got)
+	}
+	for addr, nss := range want {
+		if strings.Join(got[addr], ",") != nss {
+			t.Errorf("%s: got %v, want %s", addr, got[addr], nss)
+		}
+	}
+}
+
+// smtpSink is a minimal SMTP server that records the messages it receives.
+type smtpSink struct {
+	mu       sync.Mutex
+	messages map[string]string // recipient → DATA
+}
+
+func newSMTPSink(t *testing.T) (*smtpSink, int) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	sink := &smtpSink{messages: make(map[string]string)}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go sink.serve(conn)
+		}
+	}()
+	return sink, ln.Addr().(*net.TCPAddr).Port
+}
+
+func (s *smtpSink) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(line string) { fmt.Fprintf(conn, "%s\r\n", line) }
+	reply("220 sink ready")
+	var rcpt string
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		cmd := strings.ToUpper(strings.TrimSpace(line))
+		switch {
+		case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+			reply("250 sink")
+		case strings.HasPrefix(cmd, "RCPT TO:"):
+			rcpt = strings.Trim(strings.TrimSpace(line)[len("RCPT TO:"):], "<>")
+			reply("250 OK")
+		case cmd == "DATA":
+			reply("354 go ahead")
+			var data strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				data.WriteString(strings.TrimPrefix(l, "."))
+			}
+			s.mu.Lock()
+			s.messages[rcpt] = data.String()
+			s.mu.Unlock()
+			reply("250 queued")
+		case cmd == "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("250 OK")
+		}
+	}
+}
+
+func (s *smtpSink) take() map[string]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := s.messages
+	s.messages = make(map[string]string)
+	return out
+}
+
+// fakeAPIServer serves a payments namespace owned by leads@example.com with one
+// over-provisioned Deployment, a sidecar without requests and a nearly full PVC.
+func fakeAPIServer(t *testing.T) *httptest.Server {
+	routes := map[string]string{
+		"/api/v1/namespaces": `{"items": [{"metadata": {"name": "payments", "annotations": {"kubeadjust.io/owner": "leads@example.com"}}},
+			{"metadata": {"name": "scratch"}}]}`,
+		"/apis/apps/v1/namespaces/payments/deployments": `{"items": [{"metadata": {"name": "api", "namespace": "payments"},
+			"spec": {"replicas": 2, "template": {"spec": {"containers": [
+				{"name": "app", "resources": {"requests": {"cpu": "1", "memory": "1Gi"}, "limits": {"memory": "1Gi"}}},
+				{"name": "sidecar", "resources": {}}]}}},
+			"status": {"readyReplicas": 2, "availableReplicas": 2}}]}`,
+		"/apis/apps/v1/namespaces/payments/replicasets": `{"items": [{"metadata": {"name": "api-5d8f", "ownerReferences": [{"kind": "Deployment", "name": "api"}]}}]}`,
+		"/api/v1/namespaces/payments/pods": `{"items": [{"metadata": {"name": "api-5d8f-x1", "namespace": "payments",
+			"ownerReferences": [{"kind": "ReplicaSet", "name": "api-5d8f"}]},
+			"spec": {"nodeName": "node-a", "containers": [{"name": "app", "resources": {"requests": {"cpu": "1", "memory": "1Gi"}}}, {"name": "sidecar"}]},
+			"status": {"phase": "Running"}}]}`,
+		"/apis/metrics.k8s.io/v1beta1/namespaces/payments/pods": `{"items": [{"metadata": {"name": "api-5d8f-x1"},
+			"containers": [{"name": "app", "usage": {"cpu": "100m", "memory": "700Mi"}}, {"name": "sidecar", "usage": {"cpu": "5m", "memory": "20Mi"}}]}]}`,
+		"/api/v1/nodes": `{"items": [{"metadata": {"name": "node-a"}}]}`,
+		"/api/v1/nodes/node-a/proxy/stats/summary": `{"pods": [{"podRef": {"namespace": "payments", "name": "api-5d8f-x1"},
+			"volume": [{"name": "data", "pvcRef": {"namespace": "payments", "name": "ledger"}, "usedBytes": 90, "capacityBytes": 100}]}]}`,
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := routes[r.URL.Path]
+		if !ok {
+			body = `{"items": []}`
+		}
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// parts decodes the text/plain and text/html parts of a multipart/alternative message.
+func parts(t *testing.T, raw string) (*mail.Message, map[string]string) {
+	msg, err := mail.ReadMessage(strings.NewReader(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := make(map[string]string)
+	mr := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		p, err := mr.NextRawPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, err := io.ReadAll(quotedprintable.NewReader(p))
+		if err != nil {
+			t.Fatal(err)
+		}
+		ct, _, _ := mime.ParseMediaType(p.Header.Get("Content-Type"))
+		out[ct] = string(body)
+	}
+	return msg, out
+}
+
+func TestSend(t *testing.T) {
+	sink, port := newSMTPSink(t)
+	api := fakeAPIServer(t)
+	cfg, err := Parse([]byte(fmt.Sprintf(`{"smtp": {"host": "127.0.0.1", "port": %d, "from": "KubeAdjust <kubeadjust@example.com>"},
+		"dashboardURL": "https://kubeadjust.example.com", "stateFile": %q,
+		"clusters": {"prod": {"schedule": "0 8 * * MON", "timezone": "UTC"}}}`, port, filepath.Join(t.TempDir(), "state.json"))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	target := k8s.Target{Name: "prod", Client: func() (*k8s.Client, error) { return k8s.New("token", api.URL), nil }}
+	s, err := NewScheduler(cfg, []k8s.Target{target}, nil, "", nil, suggestions.DefaultConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.prometheusCluster != "prod" {
+		t.Errorf("prometheus cluster should default to the only target, got %q", s.prometheusCluster)
+	}
+
+	if err := s.Send(t.Context(), target); err != nil {
+		t.Fatal(err)
+	}
+	// scratch has no owner and there are no default recipients: one digest only.
+	got := sink.take()
+	if len(got) != 1 || got["leads@example.com"] == "" {
+		t.Fatalf("messages: %v", got)
+	}
+	msg, bodies := parts(t, got["leads@example.com"])
+	if subject, _ := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject")); subject != "KubeAdjust digest — prod: payments" {
+		t.Errorf("subject: %q", subject)
+	}
+	// 100m × 1.3 → 150m: 850m reclaimable per replica, 2 replicas.
+	for _, want := range []string{
+		"payments/api (Deployment, 2 replicas): CPU 1 -> 150m",
+		"reclaimable 1.7 CPU",
+		"payments/api (Deployment) container sidecar: no cpu or memory request",
+		"payments/ledger: 90%",
+		"Dashboard: https://kubeadjust.example.com",
+	} {
+		if !strings.Contains(bodies["text/plain"], want) {
+			t.Errorf("text part lacks %q:\n%s", want, bodies["text/plain"])
+		}
+	}
+	if !strings.Contains(bodies["text/html"], `<a href="https://kubeadjust.example.com">`) {
+		t.Errorf("html part:\n%s", bodies["text/html"])
+	}
+	if strings.Contains(bodies["text/plain"], "previous digest") {
+		t.Errorf("first digest should not compare:\n%s", bodies["text/plain"])
+	}
+
+	// The totals survive a restart through the state file.
+	s, err = NewScheduler(cfg, []k8s.Target{target}, nil, "", nil, suggestions.DefaultConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Send(t.Context(), target); err != nil {
+		t.Fatal(err)
+	}
+	_, bodies = parts(t, sink.take()["leads@example.com"])
+	if !strings.Contains(bodies["text/plain"], "SUMMARY (vs. previous digest)") || !strings.Contains(bodies["text/plain"], "Over-provisioned workloads:  1 (=)") {
+		t.Errorf("second digest:\n%s", bodies["text/plain"])
+	}
+}
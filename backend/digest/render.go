@@ -0,0 +1,151 @@
+package digest
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+)
+
+// Subject is the e-mail subject of d.
+func (d Digest) Subject() string {
+	ns := strings.Join(d.Namespaces, ", ")
+	if len(d.Namespaces) > 3 {
+		ns = fmt.Sprintf("%s and %d more", strings.Join(d.Namespaces[:3], ", "), len(d.Namespaces)-3)
+	}
+	return fmt.Sprintf("KubeAdjust digest — %s: %s", d.Cluster, ns)
+}
+
+// Render returns the HTML and plain-text bodies of d.
+func (d Digest) Render() (html, text string, err error) {
+	var h, t bytes.Buffer
+	if err := htmlTemplate.Execute(&h, d); err != nil {
+		return "", "", fmt.Errorf("rendering html digest: %w", err)
+	}
+	if err := textTemplate.Execute(&t, d); err != nil {
+		return "", "", fmt.Errorf("rendering text digest: %w", err)
+	}
+	return h.String(), t.String(), nil
+}
+
+var funcs = map[string]any{
+	"cpu": formatCPU,
+	"mem": formatBytes,
+	"pct": func(v float64) string { return fmt.Sprintf("%.0f%%", v*100) },
+	"money": func(v float64, currency string) string {
+		return fmt.Sprintf("%.2f %s", v, currency)
+	},
+	"join": strings.Join,
+	// delta renders the change of a count or quantity since the previous digest, e.g. "+3".
+	"delta": func(cur, prev int64, unit string) string {
+		d := cur - prev
+		switch {
+		case d == 0:
+			return "="
+		case unit == "cpu" && d > 0:
+			return "+" + formatCPU(d)
+		case unit == "cpu":
+			return "−" + formatCPU(-d)
+		case unit == "mem" && d > 0:
+			return "+" + formatBytes(d)
+		case unit == "mem":
+			return "−" + formatBytes(-d)
+		case d > 0:
+			return fmt.Sprintf("+%d", d)
+		default:
+			return fmt.Sprintf("−%d", -d)
+		}
+	},
+	"i64": func(v int) int64 { return int64(v) },
+}
+
+// formatCPU renders millicores like Kubernetes quantities: "250m", "2", "1.5".
+func formatCPU(m int64) string {
+	if m%1000 == 0 {
+		return fmt.Sprintf("%d", m/1000)
+	}
+	if m < 1000 {
+		return fmt.Sprintf("%dm", m)
+	}
+	return strings.TrimRight(strings.TrimRight(fmt.Sprintf("%.2f", float64(m)/1000), "0"), ".")
+}
+
+// formatBytes renders a byte count with a binary unit: "512Mi", "1.5Gi".
+func formatBytes(b int64) string {
+	const mi = 1 << 20
+	switch {
+	case b >= gib:
+		return strings.TrimRight(strings.TrimRight(fmt.Sprintf("%.1f", float64(b)/gib), "0"), ".") + "Gi"
+	case b >= mi:
+		return fmt.Sprintf("%dMi", b/mi)
+	default:
+		return fmt.Sprintf("%dB", b)
+	}
+}
+
+var htmlTemplate = htmltemplate.Must(htmltemplate.New("digest").Funcs(funcs).Parse(`<!DOCTYPE html>
+<html><body style="font-family:-apple-system,Segoe UI,Helvetica,Arial,sans-serif;color:#1f2937;max-width:760px;margin:0 auto;padding:16px">
+<h2 style="margin:0 0 4px">KubeAdjust digest</h2>
+<p style="margin:0 0 16px;color:#6b7280">Cluster <b>{{.Cluster}}</b> · {{join .Namespaces ", "}} · {{.GeneratedAt.Format "Mon 2 Jan 2006"}}</p>
+
+<table cellpadding="6" style="border-collapse:collapse;margin-bottom:20px">
+<tr style="background:#f3f4f6"><th align="left">Summary</th><th align="right">Now</th>{{if .Previous}}<th align="right">vs. previous digest</th>{{end}}</tr>
+<tr><td>Over-provisioned workloads</td><td align="right">{{.Totals.OverProvisioned}}</td>{{with .Previous}}<td align="right">{{delta (i64 $.Totals.OverProvisioned) (i64 .OverProvisioned) ""}}</td>{{end}}</tr>
+<tr><td>Reclaimable CPU requests</td><td align="right">{{cpu .Totals.ReclaimableCPU}}</td>{{with .Previous}}<td align="right">{{delta $.Totals.ReclaimableCPU .ReclaimableCPU "cpu"}}</td>{{end}}</tr>
+<tr><td>Reclaimable memory requests</td><td align="right">{{mem .Totals.ReclaimableMemory}}</td>{{with .Previous}}<td align="right">{{delta $.Totals.ReclaimableMemory .ReclaimableMemory "mem"}}</td>{{end}}</tr>
+<tr><td>Containers without requests</td><td align="right">{{.Totals.MissingRequests}}</td>{{with .Previous}}<td align="right">{{delta (i64 $.Totals.MissingRequests) (i64 .MissingRequests) ""}}</td>{{end}}</tr>
+<tr><td>PVCs near full</td><td align="right">{{.Totals.PVCsNearFull}}</td>{{with .Previous}}<td align="right">{{delta (i64 $.Totals.PVCsNearFull) (i64 .PVCsNearFull) ""}}</td>{{end}}</tr>
+</table>
+
+<h3>Top over-provisioned workloads</h3>
+{{if .TopWorkloads}}<table cellpadding="6" style="border-collapse:collapse;width:100%">
+<tr style="background:#f3f4f6"><th align="left">Workload</th><th align="right">Replicas</th><th align="right">CPU request</th><th align="right">Memory request</th><th align="right">Reclaimable</th></tr>
+{{range .TopWorkloads}}<tr style="border-top:1px solid #e5e7eb">
+<td>{{.Namespace}}/{{.Name}} <span style="color:#6b7280">{{.Kind}}</span></td>
+<td align="right">{{.Replicas}}</td>
+<td align="right">{{cpu .CPURequest}} → <b>{{cpu .CPUSuggested}}</b></td>
+<td align="right">{{mem .MemoryRequest}} → <b>{{mem .MemorySuggested}}</b></td>
+<td align="right">{{cpu .ReclaimableCPU}} CPU, {{mem .ReclaimableMemory}}{{if $.Currency}}<br><b>{{money .MonthlyCost $.Currency}}/month</b>{{end}}</td>
+</tr>{{end}}
+</table>{{if .MoreWorkloads}}<p style="color:#6b7280">…and {{.MoreWorkloads}} more.</p>{{end}}
+{{else}}<p style="color:#059669">No over-provisioned workload.</p>{{end}}
+
+<h3>Containers without requests</h3>
+{{if .MissingRequests}}<ul>{{range .MissingRequests}}<li>{{.Namespace}}/{{.Workload}} <span style="color:#6b7280">{{.Kind}}</span> container <b>{{.Container}}</b>: no {{join .Missing " or "}} request</li>{{end}}</ul>
+{{else}}<p style="color:#059669">Every container sets CPU and memory requests.</p>{{end}}
+
+<h3>PVCs near full</h3>
+{{if .PVCs}}<ul>{{range .PVCs}}<li>{{.Namespace}}/{{.Name}}: <b style="color:#dc2626">{{pct .Ratio}}</b> ({{mem .Used}} of {{mem .Capacity}})</li>{{end}}</ul>
+{{else}}<p style="color:#059669">No PVC near full.</p>{{end}}
+
+{{if .DashboardURL}}<p><a href="{{.DashboardURL}}">Open the KubeAdjust dashboard</a></p>{{end}}
+<p style="color:#9ca3af;font-size:12px">Suggested requests follow the dashboard: usage × 1.3 (or the workload's kubeadjust.io/headroom). Workloads annotated kubeadjust.io/ignore are not listed.</p>
+</body></html>
+`))
+
+var textTemplate = texttemplate.Must(texttemplate.New("digest").Funcs(funcs).Parse(`KubeAdjust digest — cluster {{.Cluster}}, {{.GeneratedAt.Format "Mon 2 Jan 2006"}}
+Namespaces: {{join .Namespaces ", "}}
+
+SUMMARY{{if .Previous}} (vs. previous digest){{end}}
+  Over-provisioned workloads:  {{.Totals.OverProvisioned}}{{with .Previous}} ({{delta (i64 $.Totals.OverProvisioned) (i64 .OverProvisioned) ""}}){{end}}
+  Reclaimable CPU requests:    {{cpu .Totals.ReclaimableCPU}}{{with .Previous}} ({{delta $.Totals.ReclaimableCPU .ReclaimableCPU "cpu"}}){{end}}
+  Reclaimable memory requests: {{mem .Totals.ReclaimableMemory}}{{with .Previous}} ({{delta $.Totals.ReclaimableMemory .ReclaimableMemory "mem"}}){{end}}
+  Containers without requests: {{.Totals.MissingRequests}}{{with .Previous}} ({{delta (i64 $.Totals.MissingRequests) (i64 .MissingRequests) ""}}){{end}}
+  PVCs near full:              {{.Totals.PVCsNearFull}}{{with .Previous}} ({{delta (i64 $.Totals.PVCsNearFull) (i64 .PVCsNearFull) ""}}){{end}}
+
+TOP OVER-PROVISIONED WORKLOADS
+{{range .TopWorkloads}}  - {{.Namespace}}/{{.Name}} ({{.Kind}}, {{.Replicas}} replicas): CPU {{cpu .CPURequest}} -> {{cpu .CPUSuggested}}, memory {{mem .MemoryRequest}} -> {{mem .MemorySuggested}}; reclaimable {{cpu .ReclaimableCPU}} CPU, {{mem .ReclaimableMemory}}{{if $.Currency}} ({{money .MonthlyCost $.Currency}}/month){{end}}
+{{else}}  None.
+{{end}}{{if .MoreWorkloads}}  ...and {{.MoreWorkloads}} more.
+{{end}}
+CONTAINERS WITHOUT REQUESTS
+{{range .MissingRequests}}  - {{.Namespace}}/{{.Workload}} ({{.Kind}}) container {{.Container}}: no {{join .Missing " or "}} request
+{{else}}  None.
+{{end}}
+PVCS NEAR FULL
+{{range .PVCs}}  - {{.Namespace}}/{{.Name}}: {{pct .Ratio}} ({{mem .Used}} of {{mem .Capacity}})
+{{else}}  None.
+{{end}}{{if .DashboardURL}}
+Dashboard: {{.DashboardURL}}
+{{end}}`))
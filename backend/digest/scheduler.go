@@ -0,0 +1,282 @@
+package digest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+
+	"github.com/devops-kubeadjust/backend/k8s"
+	"github.com/devops-kubeadjust/backend/pricing"
+	"github.com/devops-kubeadjust/backend/prometheus"
+	"github.com/devops-kubeadjust/backend/report"
+	"github.com/devops-kubeadjust/backend/suggestions"
+)
+
+// Scheduler sends the digest of each target cluster on the cluster's schedule.
+type Scheduler struct {
+	cfg               *Config
+	targets           []k8s.Target // one per cfg.Clusters entry
+	prom              *prometheus.Client
+	prometheusCluster string         // the cluster prom scrapes; others use metrics-server
+	prices            *pricing.Table // nil: no cost column
+	thresholds        *suggestions.Config
+
+	mu       sync.Mutex
+	previous map[string]map[string]Totals // cluster → namespace → totals of the last digest
+}
+
+// NewScheduler returns a Scheduler for targets, each of which must be configured in
+// cfg.Clusters. prometheusCluster is the cluster prom scrapes, defaulting to the only
+// target. The previous totals are read from cfg.StateFile when it exists.
+func NewScheduler(cfg *Config, targets []k8s.Target, prom *prometheus.Client, prometheusCluster string, prices *pricing.Table, thresholds *suggestions.Config) (*Scheduler, error) {
+	if prometheusCluster == "" && len(targets) == 1 {
+		prometheusCluster = targets[0].Name
+	}
+	s := &Scheduler{
+		cfg:               cfg,
+		targets:           targets,
+		prom:              prom,
+		prometheusCluster: prometheusCluster,
+		prices:            prices,
+		thresholds:        thresholds,
+		previous:          make(map[string]map[string]Totals),
+	}
+	if cfg.StateFile != "" {
+		data, err := os.ReadFile(cfg.StateFile)
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+		case err != nil:
+			return nil, fmt.Errorf("reading digest state: %w", err)
+		default:
+			if err := json.Unmarshal(data, &s.previous); err != nil {
+				return nil, fmt.Errorf("parsing digest state %s: %w", cfg.StateFile, err)
+			}
+		}
+	}
+	return s, nil
+}
+
+// Run waits for the next scheduled time of each cluster and sends its digests, until ctx
+// is cancelled.
+func (s *Scheduler) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, t := range s.targets {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			cc := s.cfg.Clusters[t.Name]
+			for {
+				next := cc.Next(time.Now())
+				if next.IsZero() {
+					log.Printf("digest: cluster %s: schedule %q never fires", t.Name, cc.Schedule)
+					return
+				}
+				timer := time.NewTimer(time.Until(next))
+				select {
+				case <-ctx.Done():
+					timer.Stop()
+					return
+				case <-timer.C:
+				}
+				if err := s.Send(ctx, t); err != nil {
+					log.Printf("digest: cluster %s: %v", t.Name, err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// Send collects the findings of the owned namespaces of t and mails one digest per
+// recipient. Delivery errors do not stop the other recipients and are returned joined.
+func (s *Scheduler) Send(ctx context.Context, t k8s.Target) error {
+	client, err := t.Client()
+	if err != nil {
+		return err
+	}
+	namespaces, err := client.ListNamespaces(ctx)
+	if err != nil {
+		return err
+	}
+	byRecipient := recipients(s.cfg.Clusters[t.Name], namespaces.Items)
+	if len(byRecipient) == 0 {
+		log.Printf("digest: cluster %s: no namespace has an owner, nothing to send", t.Name)
+		return nil
+	}
+	owned := make(map[string]bool)
+	for _, nss := range byRecipient {
+		for _, ns := range nss {
+			owned[ns] = true
+		}
+	}
+
+	var pvcs map[string][]PVC
+	if nodes, err := client.ListNodes(ctx); err != nil {
+		log.Printf("digest: cluster %s: listing nodes for PVC usage: %v", t.Name, err)
+	} else {
+		pvcs = NearFullPVCs(client.GetNodeSummaries(ctx, nodes.Items), s.cfg.PVCThreshold)
+	}
+	var prom *prometheus.Client
+	if t.Name == s.prometheusCluster {
+		prom = s.prom
+	}
+
+	var mu sync.Mutex
+	summaries := make(map[string]NamespaceSummary, len(owned))
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(4) // bound concurrent namespace reports (each lists ~7 resources)
+	for _, ns := range namespaces.Items {
+		name := ns.Metadata.Name
+		if !owned[name] {
+			continue
+		}
+		th, _, err := s.thresholds.ForNamespace(t.Name, ns.Metadata.Annotations)
+		if err != nil {
+			log.Printf("digest: cluster %s: namespace %s: %v", t.Name, name, err)
+		}
+		g.Go(func() error {
+			r, err := report.Collect(gctx, client, prom, name, report.DefaultRange, th)
+			if err != nil {
+				log.Printf("digest: cluster %s: namespace %s: %v", t.Name, name, err)
+				return nil // best-effort: the namespace is left out of the digests
+			}
+			sum := Summarize(r, pvcs[name], s.prices)
+			mu.Lock()
+			summaries[name] = sum
+			mu.Unlock()
+			return nil
+		})
+	}
+	_ = g.Wait()
+
+	s.mu.Lock()
+	previous := s.previous[t.Name]
+	s.mu.Unlock()
+	now := time.Now().In(s.cfg.Clusters[t.Name].location)
+	from, _ := mail.ParseAddress(s.cfg.SMTP.From) // validated by Parse
+	var errs []error
+	sent := 0
+	for _, rcpt := range sortedRecipients(byRecipient) {
+		var mine []NamespaceSummary
+		for _, ns := range byRecipient[rcpt] {
+			if sum, ok := summaries[ns]; ok {
+				mine = append(mine, sum)
+			}
+		}
+		if len(mine) == 0 {
+			continue
+		}
+		d := Compose(t.Name, mine, previous, now)
+		d.DashboardURL = s.cfg.DashboardURL
+		if s.prices != nil {
+			d.Currency = s.prices.Currency
+		}
+		html, text, err := d.Render()
+		if err != nil {
+			return err
+		}
+		if err := s.cfg.SMTP.send(rcpt, message(from.String(), rcpt, d.Subject(), html, text, now)); err != nil {
+			errs = append(errs, fmt.Errorf("sending digest to %s: %w", rcpt, err))
+			continue
+		}
+		sent++
+	}
+	log.Printf("digest: cluster %s: sent %d digest(s) covering %d namespace(s)", t.Name, sent, len(summaries))
+
+	totals := make(map[string]Totals, len(summaries))
+	for ns, sum := range summaries {
+		totals[ns] = sum.Totals
+	}
+	if err := s.saveTotals(t.Name, totals); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// saveTotals records the totals of this digest for the next one and persists them to
+// StateFile (written to a temporary file and renamed, so a crash never truncates it).
+func (s *Scheduler) saveTotals(cluster string, totals map[string]Totals) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.previous[cluster] = totals
+	if s.cfg.StateFile == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(s.previous, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.cfg.StateFile), ".digest-state-*")
+	if err != nil {
+		return fmt.Errorf("writing digest state: %w", err)
+	}
+	defer os.Remove(tmp.Name()) // no-op after the rename
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("writing digest state: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("writing digest state: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.cfg.StateFile); err != nil {
+		return fmt.Errorf("writing digest state: %w", err)
+	}
+	return nil
+}
+
+// recipients maps each recipient address to the namespaces it owns: the OwnerAnnotation
+// and cc.Recipients of the namespace, or cc.DefaultRecipients when it has neither.
+// Invalid annotation addresses are logged and ignored.
+func recipients(cc ClusterConfig, namespaces []k8s.Namespace) map[string][]string {
+	out := make(map[string][]string)
+	for _, ns := range namespaces {
+		name := ns.Metadata.Name
+		var owners []string
+		if v := ns.Metadata.Annotations[OwnerAnnotation]; v != "" {
+			for a := range strings.SplitSeq(v, ",") {
+				if a = strings.TrimSpace(a); a != "" {
+					owners = append(owners, a)
+				}
+			}
+		}
+		owners = append(owners, cc.Recipients[name]...)
+		if len(owners) == 0 {
+			owners = cc.DefaultRecipients
+		}
+		seen := make(map[string]bool, len(owners))
+		for _, o := range owners {
+			addr, err := mail.ParseAddress(o)
+			if err != nil {
+				log.Printf("digest: namespace %s: ignoring owner %q: %v", name, o, err)
+				continue
+			}
+			key := strings.ToLower(addr.Address)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			out[key] = append(out[key], name)
+		}
+	}
+	return out
+}
+
+func sortedRecipients(m map[string][]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,133 @@
+// Package gitops opens pull/merge requests that apply resource suggestions to the Git
+// repository a workload is deployed from (Helm values files or Kustomize overlays).
+// The cluster itself is never modified: changes go through the repository's review flow.
+package gitops
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/devops-kubeadjust/backend/patch"
+)
+
+// Supported providers.
+const (
+	ProviderGitHub = "github"
+	ProviderGitLab = "gitlab"
+	ProviderGitea  = "gitea"
+)
+
+// Config is the GitOps integration configuration.
+type Config struct {
+	Provider   string    `json:"provider"`             // github | gitlab | gitea
+	APIURL     string    `json:"apiURL,omitempty"`     // defaults to the public GitHub / GitLab API; required for Gitea
+	Repository string    `json:"repository"`           // "owner/name" (GitLab: full project path)
+	BaseBranch string    `json:"baseBranch,omitempty"` // "main" if unset
+	Workloads  []Mapping `json:"workloads"`
+
+	Token string `json:"-"` // from GITOPS_TOKEN, never read from the file
+}
+
+// Mapping locates the manifests of one or more workloads in the repository.
+type Mapping struct {
+	Cluster   string `json:"cluster,omitempty"` // X-Cluster name; empty matches any cluster
+	Namespace string `json:"namespace"`
+	Kind      string `json:"kind,omitempty"` // empty matches any kind
+	Name      string `json:"name"`           // "*" matches any name
+	Format    string `json:"format"`         // helm-values | kustomize
+	// Path is the values file (helm-values) or the overlay directory holding
+	// kustomization.yaml (kustomize).
+	Path string `json:"path"`
+	// ValuesKey is the dotted key under which the workload's values live in a shared
+	// values file (e.g. "api" or "workers.billing"); empty for the file root.
+	ValuesKey string `json:"valuesKey,omitempty"`
+}
+
+// Load reads the configuration from the environment:
+//   - GITOPS_CONFIG → path to a JSON file {"provider", "apiURL", "repository", "baseBranch", "workloads"}
+//   - GITOPS_TOKEN  → API token with permission to push branches and open pull requests
+//
+// Returns nil (and no error) when GITOPS_CONFIG is unset: the integration is then disabled.
+func Load() (*Config, error) {
+	path := os.Getenv("GITOPS_CONFIG")
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading gitops config: %w", err)
+	}
+	c, err := Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	c.Token = os.Getenv("GITOPS_TOKEN")
+	if c.Token == "" {
+		return nil, fmt.Errorf("GITOPS_CONFIG is set but GITOPS_TOKEN is empty")
+	}
+	return c, nil
+}
+
+// Parse decodes and validates a JSON GitOps config, applying defaults.
+func Parse(data []byte) (*Config, error) {
+	var c Config
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("parsing gitops config: %w", err)
+	}
+	switch c.Provider {
+	case ProviderGitHub:
+		if c.APIURL == "" {
+			c.APIURL = "https://api.github.com"
+		}
+	case ProviderGitLab:
+		if c.APIURL == "" {
+			c.APIURL = "https://gitlab.com/api/v4"
+		}
+	case ProviderGitea:
+		if c.APIURL == "" {
+			return nil, fmt.Errorf("gitops config: apiURL is required for gitea")
+		}
+	default:
+		return nil, fmt.Errorf("gitops config: provider must be github, gitlab or gitea, got %q", c.Provider)
+	}
+	c.APIURL = strings.TrimSuffix(c.APIURL, "/")
+	if c.Provider != ProviderGitLab && strings.Count(c.Repository, "/") != 1 {
+		return nil, fmt.Errorf("gitops config: repository must be owner/name, got %q", c.Repository)
+	}
+	if c.Repository == "" {
+		return nil, fmt.Errorf("gitops config: repository is required")
+	}
+	if c.BaseBranch == "" {
+		c.BaseBranch = "main"
+	}
+	for i, m := range c.Workloads {
+		if m.Namespace == "" || m.Name == "" || m.Path == "" {
+			return nil, fmt.Errorf("gitops config: workloads[%d]: namespace, name and path are required", i)
+		}
+		if m.Format != patch.FormatHelmValues && m.Format != patch.FormatKustomize {
+			return nil, fmt.Errorf("gitops config: workloads[%d]: format must be helm-values or kustomize, got %q", i, m.Format)
+		}
+		if strings.HasPrefix(m.Path, "/") || strings.Contains(m.Path, "..") {
+			return nil, fmt.Errorf("gitops config: workloads[%d]: path must be relative to the repository root", i)
+		}
+	}
+	return &c, nil
+}
+
+// Lookup returns the first mapping matching the workload. Exact names are tried before "*"
+// so a namespace-wide default can be overridden for individual workloads.
+func (c *Config) Lookup(cluster, namespace, kind, name string) (Mapping, bool) {
+	for _, exact := range []bool{true, false} {
+		for _, m := range c.Workloads {
+			if m.Namespace != namespace || (m.Cluster != "" && m.Cluster != cluster) || (m.Kind != "" && m.Kind != kind) {
+				continue
+			}
+			if (exact && m.Name == name) || (!exact && m.Name == "*") {
+				return m, true
+			}
+		}
+	}
+	return Mapping{}, false
+}
@@ -0,0 +1,222 @@
+package gitops
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/devops-kubeadjust/backend/patch"
+)
+
+// The editors below work on the text of block-style YAML rather than a decoded tree, so
+// comments, key order and formatting of the rest of the file survive the change and the
+// pull request diff only touches the resources blocks.
+
+// yamlLine is one line of a document with its indentation (-1 for blank and comment lines).
+type yamlLine struct {
+	text   string
+	indent int
+}
+
+func splitYAML(doc []byte) []yamlLine {
+	s := strings.TrimSuffix(string(doc), "\n")
+	if s == "" {
+		return nil
+	}
+	raw := strings.Split(s, "\n")
+	lines := make([]yamlLine, len(raw))
+	for i, t := range raw {
+		trimmed := strings.TrimLeft(t, " ")
+		indent := len(t) - len(trimmed)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			indent = -1
+		}
+		lines[i] = yamlLine{text: t, indent: indent}
+	}
+	return lines
+}
+
+func joinYAML(lines []yamlLine) []byte {
+	var b strings.Builder
+	for _, l := range lines {
+		b.WriteString(l.text)
+		b.WriteByte('\n')
+	}
+	return []byte(b.String())
+}
+
+// isKey reports whether l is the mapping key "key:" (with or without an inline value).
+func (l yamlLine) isKey(key string) bool {
+	rest, ok := strings.CutPrefix(l.text[l.indent:], key+":")
+	return ok && (rest == "" || rest[0] == ' ')
+}
+
+// blockEnd returns the index just past the block of children of the key at lines[i],
+// ignoring trailing blank lines and comments. Sequence items may sit at the key's own
+// indentation ("patches:\n- path: …").
+func blockEnd(lines []yamlLine, i int) int {
+	end := i + 1
+	for j := i + 1; j < len(lines); j++ {
+		if lines[j].indent == -1 {
+			continue
+		}
+		if lines[j].indent < lines[i].indent || (lines[j].indent == lines[i].indent && !strings.HasPrefix(lines[j].text[lines[j].indent:], "-")) {
+			break
+		}
+		end = j + 1
+	}
+	return end
+}
+
+// childIndent returns the indentation of the first child in lines[start:end], or
+// def when the block has no children yet.
+func childIndent(lines []yamlLine, start, end, def int) int {
+	for j := start; j < end; j++ {
+		if lines[j].indent >= 0 {
+			return lines[j].indent
+		}
+	}
+	return def
+}
+
+// setBlock replaces the value of the key at path with block (lines rendered at indentation
+// 0, starting with the key itself), creating missing parent keys. It fails when a parent
+// holds an inline scalar, since that cannot be extended without changing its meaning.
+func setBlock(doc []byte, path []string, block string) ([]byte, error) {
+	lines := splitYAML(doc)
+	start, end, parentIndent := 0, len(lines), -2
+	for depth, key := range path {
+		indent := childIndent(lines, start, end, parentIndent+2)
+		found := -1
+		for j := start; j < end; j++ {
+			if lines[j].indent == indent && lines[j].isKey(key) {
+				found = j
+				break
+			}
+		}
+		if found < 0 {
+			// Append the missing keys (and the block) at the end of the parent.
+			var b strings.Builder
+			for k, missing := range path[depth:] {
+				if k == len(path[depth:])-1 {
+					break
+				}
+				fmt.Fprintf(&b, "%s%s:\n", strings.Repeat(" ", indent+2*k), missing)
+			}
+			b.WriteString(indentBlock(block, indent+2*(len(path)-depth-1)))
+			return splice(lines, end, end, b.String()), nil
+		}
+		if depth == len(path)-1 {
+			return splice(lines, found, blockEnd(lines, found), indentBlock(block, indent)), nil
+		}
+		if rest := strings.TrimSpace(lines[found].text[indent+len(key)+1:]); rest != "" && !strings.HasPrefix(rest, "#") {
+			return nil, fmt.Errorf("key %q has an inline value, cannot set %s", key, strings.Join(path, "."))
+		}
+		start, end, parentIndent = found+1, blockEnd(lines, found), indent
+	}
+	return joinYAML(lines), nil
+}
+
+func indentBlock(block string, n int) string {
+	var b strings.Builder
+	pad := strings.Repeat(" ", n)
+	for line := range strings.Lines(block) {
+		b.WriteString(pad + line)
+	}
+	return b.String()
+}
+
+// splice replaces lines[from:to] with text.
+func splice(lines []yamlLine, from, to int, text string) []byte {
+	var b strings.Builder
+	b.Write(joinYAML(lines[:from]))
+	b.WriteString(text)
+	b.Write(joinYAML(lines[to:]))
+	return []byte(b.String())
+}
+
+// SetHelmResources writes the changes into a Helm values document, following the layout of
+// patch.FormatHelmValues: "resources" at valuesKey for single-container workloads,
+// "<container>.resources" otherwise.
+func SetHelmResources(doc []byte, valuesKey string, w patch.Workload, changes []patch.Change) ([]byte, error) {
+	var prefix []string
+	if valuesKey != "" {
+		prefix = strings.Split(valuesKey, ".")
+	}
+	single := len(w.Containers) <= 1 && len(changes) == 1
+	for _, c := range changes {
+		path := append(append([]string{}, prefix...), "resources")
+		if !single {
+			path = append(append([]string{}, prefix...), c.Container, "resources")
+		}
+		var err error
+		if doc, err = setBlock(doc, path, patch.ResourcesYAML("", c)); err != nil {
+			return nil, err
+		}
+	}
+	return doc, nil
+}
+
+// EnsureKustomizePatch adds "- path: file" to the patches of a kustomization document.
+// It reports false when the file is already referenced.
+func EnsureKustomizePatch(doc []byte, file string) ([]byte, bool) {
+	lines := splitYAML(doc)
+	if referencesPatch(lines, file) {
+		return doc, false
+	}
+	for i, l := range lines {
+		if l.indent == 0 && l.isKey("patches") {
+			end := blockEnd(lines, i)
+			indent := childIndent(lines, i+1, end, 2)
+			if end == i+1 {
+				// "patches:" with no items yet (or an inline "[]").
+				return splice(lines, i, end, fmt.Sprintf("patches:\n%s- path: %s\n", strings.Repeat(" ", indent), file)), true
+			}
+			return splice(lines, end, end, fmt.Sprintf("%s- path: %s\n", strings.Repeat(" ", indent), file)), true
+		}
+	}
+	return append(joinYAML(lines), fmt.Sprintf("patches:\n  - path: %s\n", file)...), true
+}
+
+// referencesPatch reports whether the patches (or legacy patchesStrategicMerge) list
+// already names file, either as "- path: file" or as a bare "- file" item.
+func referencesPatch(lines []yamlLine, file string) bool {
+	for i, l := range lines {
+		if l.indent != 0 || !(l.isKey("patches") || l.isKey("patchesStrategicMerge")) {
+			continue
+		}
+		for _, item := range lines[i+1 : blockEnd(lines, i)] {
+			if item.indent == -1 {
+				continue
+			}
+			text := item.text[item.indent:]
+			rest, isItem := strings.CutPrefix(text, "- ")
+			if value, ok := strings.CutPrefix(rest, "path:"); ok {
+				if scalarValue(value) == file {
+					return true
+				}
+			} else if isItem && scalarValue(rest) == file {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// scalarValue returns a plain or quoted inline YAML scalar without its trailing comment.
+func scalarValue(s string) string {
+	s = strings.TrimSpace(s)
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') {
+		if end := strings.IndexByte(s[1:], s[0]); end >= 0 {
+			return s[1 : end+1]
+		}
+	}
+	if i := strings.Index(s, " #"); i >= 0 {
+		s = s[:i]
+	}
+	return strings.TrimSpace(s)
+}
+
+// overlayPatchFile is the name of the patch file written next to kustomization.yaml.
+func overlayPatchFile(w patch.Workload) string {
+	return fmt.Sprintf("kubeadjust-%s-%s.yaml", strings.ToLower(w.Kind), w.Name)
+}
@@ -0,0 +1,147 @@
+package gitops
+
+import (
+	"testing"
+
+	"github.com/devops-kubeadjust/backend/k8s"
+	"github.com/devops-kubeadjust/backend/patch"
+)
+
+var sidecar = patch.Change{
+	Container: "app",
+	Requests:  map[string]string{"cpu": "50m", "memory": "64Mi"},
+	Limits:    map[string]string{"memory": "128Mi"},
+}
+
+func TestSetHelmResources(t *testing.T) {
+	single := patch.Workload{Kind: "Deployment", Name: "api", Containers: []k8s.Container{{Name: "app"}}}
+	tests := []struct {
+		name      string
+		valuesKey string
+		doc       string
+		want      string
+	}{
+		{
+			name: "replaces existing block, keeps the rest",
+			doc: `# api chart values
+replicaCount: 2
+resources:
+  requests:
+    cpu: 1 # too much
+    memory: 1Gi
+
+image:
+  tag: v1
+`,
+			want: `# api chart values
+replicaCount: 2
+resources:
+  requests:
+    cpu: "50m"
+    memory: "64Mi"
+  limits:
+    memory: "128Mi"
+
+image:
+  tag: v1
+`,
+		},
+		{
+			name: "replaces inline empty map",
+			doc:  "resources: {}\nimage:\n  tag: v1\n",
+			want: "resources:\n  requests:\n    cpu: \"50m\"\n    memory: \"64Mi\"\n  limits:\n    memory: \"128Mi\"\nimage:\n  tag: v1\n",
+		},
+		{
+			name:      "nested key with 4-space indent",
+			valuesKey: "workers.api",
+			doc:       "workers:\n    api:\n        replicas: 1\n    billing:\n        replicas: 1\n",
+			want:      "workers:\n    api:\n        replicas: 1\n        resources:\n          requests:\n            cpu: \"50m\"\n            memory: \"64Mi\"\n          limits:\n            memory: \"128Mi\"\n    billing:\n        replicas: 1\n",
+		},
+		{
+			name:      "creates missing parents",
+			valuesKey: "api",
+			doc:       "global: {}\n",
+			want:      "global: {}\napi:\n  resources:\n    requests:\n      cpu: \"50m\"\n      memory: \"64Mi\"\n    limits:\n      memory: \"128Mi\"\n",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := SetHelmResources([]byte(tc.doc), tc.valuesKey, single, []patch.Change{sidecar})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tc.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tc.want)
+			}
+		})
+	}
+
+	multi := patch.Workload{Kind: "Deployment", Name: "api", Containers: []k8s.Container{{Name: "app"}, {Name: "proxy"}}}
+	got, err := SetHelmResources([]byte("app:\n  image: x\nproxy:\n  image: y\n"), "", multi, []patch.Change{sidecar})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "app:\n  image: x\n  resources:\n    requests:\n      cpu: \"50m\"\n      memory: \"64Mi\"\n    limits:\n      memory: \"128Mi\"\nproxy:\n  image: y\n"
+	if string(got) != want {
+		t.Errorf("multi-container: got:\n%s\nwant:\n%s", got, want)
+	}
+
+	if _, err := SetHelmResources([]byte("api: enabled\n"), "api", single, []patch.Change{sidecar}); err == nil {
+		t.Error("expected error when a parent key holds a scalar")
+	}
+}
+
+func TestEnsureKustomizePatch(t *testing.T) {
+	tests := []struct {
+		name, doc, want string
+		changed         bool
+	}{
+		{
+			name:    "no patches yet",
+			doc:     "resources:\n  - ../../base\n",
+			want:    "resources:\n  - ../../base\npatches:\n  - path: p.yaml\n",
+			changed: true,
+		},
+		{
+			name:    "items at the key's indentation",
+			doc:     "patches:\n- path: replicas.yaml\nnamespace: prod\n",
+			want:    "patches:\n- path: replicas.yaml\n- path: p.yaml\nnamespace: prod\n",
+			changed: true,
+		},
+		{
+			name:    "empty inline list",
+			doc:     "patches: []\n",
+			want:    "patches:\n  - path: p.yaml\n",
+			changed: true,
+		},
+		{
+			name: "already referenced",
+			doc:  "patches:\n  - path: p.yaml\n",
+			want: "patches:\n  - path: p.yaml\n",
+		},
+		{
+			name: "referenced with a target",
+			doc:  "patches:\n  - target:\n      kind: Deployment\n    path: \"p.yaml\" # kubeadjust\n",
+			want: "patches:\n  - target:\n      kind: Deployment\n    path: \"p.yaml\" # kubeadjust\n",
+		},
+		{
+			name: "referenced as a strategic merge patch",
+			doc:  "patchesStrategicMerge:\n  - p.yaml\n",
+			want: "patchesStrategicMerge:\n  - p.yaml\n",
+		},
+		{
+			name:    "name only in a comment or a longer path",
+			doc:     "# see p.yaml\nresources:\n  - foo-p.yaml\npatches:\n  - path: foo-p.yaml\n",
+			want:    "# see p.yaml\nresources:\n  - foo-p.yaml\npatches:\n  - path: foo-p.yaml\n  - path: p.yaml\n",
+			changed: true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, changed := EnsureKustomizePatch([]byte(tc.doc), "p.yaml")
+			if string(got) != tc.want || changed != tc.changed {
+				t.Errorf("got (%v):\n%s\nwant (%v):\n%s", changed, got, tc.changed, tc.want)
+			}
+		})
+	}
+}
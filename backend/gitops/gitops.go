@@ -0,0 +1,189 @@
+package gitops
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/devops-kubeadjust/backend/patch"
+)
+
+// ErrNoChanges is returned when the repository already holds the suggested values.
+var ErrNoChanges = errors.New("repository already up to date")
+
+// kustomizationFiles are the file names kustomize accepts, in lookup order.
+var kustomizationFiles = []string{"kustomization.yaml", "kustomization.yml", "Kustomization"}
+
+// FileChange is one file committed on the pull request branch.
+type FileChange struct {
+	Path    string `json:"path"`
+	Created bool   `json:"created,omitempty"`
+
+	content []byte
+	sha     string
+}
+
+// PullRequest is the outcome of Propose.
+type PullRequest struct {
+	URL    string       `json:"url"`
+	Branch string       `json:"branch"`
+	Base   string       `json:"base"`
+	Files  []FileChange `json:"files"`
+	// Updated is set when an already open pull request of the workload was reused.
+	Updated bool `json:"updated,omitempty"`
+}
+
+// Propose edits the files mapped to w and commits them to the workload's branch (one per
+// workload, see Branch), then opens a pull/merge request. When one is already open from
+// that branch, the new values are committed on top of it and the same request is returned
+// instead of opening a duplicate. It returns ErrNoChanges without touching the repository
+// when the base branch already holds the suggested values.
+func Propose(ctx context.Context, c *Config, p Provider, m Mapping, w patch.Workload, changes []patch.Change) (*PullRequest, error) {
+	if len(changes) == 0 {
+		return nil, ErrNoChanges
+	}
+	files, err := editFiles(ctx, p, c.BaseBranch, m, w, changes)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, ErrNoChanges
+	}
+
+	branch := Branch(w)
+	title := fmt.Sprintf("Adjust resources of %s %s/%s", w.Kind, w.Namespace, w.Name)
+	url, err := p.FindPullRequest(ctx, branch, c.BaseBranch)
+	if err != nil {
+		return nil, fmt.Errorf("looking up pull request for %s: %w", branch, err)
+	}
+	if url != "" {
+		// Edit the files as they are on the branch, which may already hold earlier values.
+		if files, err = editFiles(ctx, p, branch, m, w, changes); err != nil {
+			return nil, err
+		}
+		if err := commitFiles(ctx, p, branch, files, title); err != nil {
+			return nil, err
+		}
+		return &PullRequest{URL: url, Branch: branch, Base: c.BaseBranch, Files: files, Updated: true}, nil
+	}
+
+	// A leftover branch (its request merged or closed) is restarted from the base branch.
+	if err := p.DeleteBranch(ctx, branch); err != nil && !errors.Is(err, ErrNotFound) {
+		return nil, fmt.Errorf("deleting stale branch %s: %w", branch, err)
+	}
+	if err := p.CreateBranch(ctx, branch, c.BaseBranch); err != nil {
+		return nil, fmt.Errorf("creating branch %s: %w", branch, err)
+	}
+	if err := commitFiles(ctx, p, branch, files, title); err != nil {
+		return nil, err
+	}
+	if url, err = p.OpenPullRequest(ctx, branch, c.BaseBranch, title, description(w, changes)); err != nil {
+		return nil, fmt.Errorf("opening pull request: %w", err)
+	}
+	return &PullRequest{URL: url, Branch: branch, Base: c.BaseBranch, Files: files}, nil
+}
+
+// Branch is the name of the branch carrying the suggestions of w. It is stable so that
+// proposing again updates the open pull request rather than opening another one.
+func Branch(w patch.Workload) string {
+	return fmt.Sprintf("kubeadjust/%s-%s-%s", w.Namespace, strings.ToLower(w.Kind), w.Name)
+}
+
+// editFiles returns the files mapped to w that change when the suggestions are applied
+// to their version on ref.
+func editFiles(ctx context.Context, p Provider, ref string, m Mapping, w patch.Workload, changes []patch.Change) ([]FileChange, error) {
+	switch m.Format {
+	case patch.FormatHelmValues:
+		return helmFiles(ctx, p, ref, m, w, changes)
+	case patch.FormatKustomize:
+		return kustomizeFiles(ctx, p, ref, m, w, changes)
+	default:
+		return nil, fmt.Errorf("unsupported mapping format %q", m.Format)
+	}
+}
+
+func commitFiles(ctx context.Context, p Provider, branch string, files []FileChange, message string) error {
+	for _, f := range files {
+		if err := p.PutFile(ctx, branch, f.Path, f.content, f.sha, message); err != nil {
+			return fmt.Errorf("committing %s: %w", f.Path, err)
+		}
+	}
+	return nil
+}
+
+func helmFiles(ctx context.Context, p Provider, ref string, m Mapping, w patch.Workload, changes []patch.Change) ([]FileChange, error) {
+	doc, sha, err := p.GetFile(ctx, ref, m.Path)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", m.Path, err)
+	}
+	updated, err := SetHelmResources(doc, m.ValuesKey, w, changes)
+	if err != nil {
+		return nil, fmt.Errorf("editing %s: %w", m.Path, err)
+	}
+	if string(updated) == string(doc) {
+		return nil, nil
+	}
+	return []FileChange{{Path: m.Path, content: updated, sha: sha}}, nil
+}
+
+// kustomizeFiles writes the strategic patch to a dedicated file of the overlay and
+// references it from the overlay's kustomization.
+func kustomizeFiles(ctx context.Context, p Provider, ref string, m Mapping, w patch.Workload, changes []patch.Change) ([]FileChange, error) {
+	var files []FileChange
+
+	name := overlayPatchFile(w)
+	patchPath := path.Join(m.Path, name)
+	content := []byte(patch.OverlayPatch(w, changes))
+	current, sha, err := p.GetFile(ctx, ref, patchPath)
+	switch {
+	case errors.Is(err, ErrNotFound):
+		files = append(files, FileChange{Path: patchPath, Created: true, content: content})
+	case err != nil:
+		return nil, fmt.Errorf("reading %s: %w", patchPath, err)
+	case string(current) != string(content):
+		files = append(files, FileChange{Path: patchPath, content: content, sha: sha})
+	}
+
+	for _, kf := range kustomizationFiles {
+		kPath := path.Join(m.Path, kf)
+		doc, sha, err := p.GetFile(ctx, ref, kPath)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading %s: %w", kPath, err)
+		}
+		if updated, changed := EnsureKustomizePatch(doc, name); changed {
+			files = append(files, FileChange{Path: kPath, content: updated, sha: sha})
+		}
+		return files, nil
+	}
+	return nil, fmt.Errorf("no kustomization file in %s", m.Path)
+}
+
+// description renders the pull request body: one row per changed container.
+func description(w patch.Workload, changes []patch.Change) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Resource requests/limits suggested by KubeAdjust for `%s` `%s/%s`, based on observed usage.\n\n", w.Kind, w.Namespace, w.Name)
+	b.WriteString("| Container | Requests | Limits |\n|---|---|---|\n")
+	for _, c := range changes {
+		fmt.Fprintf(&b, "| %s | %s | %s |\n", c.Container, quantities(c.Requests), quantities(c.Limits))
+	}
+	b.WriteString("\nReview the values before merging: the cluster is only updated once this change is deployed.\n")
+	return b.String()
+}
+
+func quantities(m map[string]string) string {
+	var parts []string
+	for _, k := range []string{"cpu", "memory"} {
+		if v, ok := m[k]; ok {
+			parts = append(parts, k+" "+v)
+		}
+	}
+	if len(parts) == 0 {
+		return "—"
+	}
+	return strings.Join(parts, ", ")
+}
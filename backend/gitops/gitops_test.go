@@ -0,0 +1,332 @@
+package gitops
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/devops-kubeadjust/backend/k8s"
+	"github.com/devops-kubeadjust/backend/patch"
+)
+
+// fakeForge is an in-memory Git host speaking the subset of the GitHub, Gitea and GitLab
+// APIs used by the providers. Files are stored per branch.
+type fakeForge struct {
+	provider string
+	mu       sync.Mutex
+	files    map[string]map[string]string // branch → path → content
+	pulls    []map[string]string
+	auth     string
+}
+
+func newFakeForge(t *testing.T, provider string, files map[string]string) (*fakeForge, *httptest.Server) {
+	f := &fakeForge{provider: provider, files: map[string]map[string]string{"main": files}}
+	srv := httptest.NewServer(http.HandlerFunc(f.serve))
+	t.Cleanup(srv.Close)
+	return f, srv
+}
+
+func (f *fakeForge) serve(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.auth = r.Header.Get("Authorization") + r.Header.Get("PRIVATE-TOKEN")
+
+	var body map[string]string
+	if r.Body != nil {
+		_ = json.NewDecoder(r.Body).Decode(&body)
+	}
+	reply := func(v any) { _ = json.NewEncoder(w).Encode(v) }
+	p := r.URL.EscapedPath()
+
+	if f.provider == ProviderGitLab {
+		p = strings.TrimPrefix(p, "/projects/acme%2Fdeploy")
+		switch {
+		case strings.HasPrefix(p, "/repository/files/"):
+			file, _ := url.PathUnescape(strings.TrimPrefix(p, "/repository/files/"))
+			if r.Method == http.MethodGet {
+				f.getFile(w, r.URL.Query().Get("ref"), file, "blob_id")
+				return
+			}
+			content, _ := base64.StdEncoding.DecodeString(body["content"])
+			f.files[body["branch"]][file] = string(content)
+		case p == "/repository/branches":
+			f.branch(w, body["branch"], body["ref"])
+		case strings.HasPrefix(p, "/repository/branches/"):
+			name, _ := url.PathUnescape(strings.TrimPrefix(p, "/repository/branches/"))
+			f.deleteBranch(w, name)
+		case p == "/merge_requests" && r.Method == http.MethodGet:
+			q := r.URL.Query()
+			var found []map[string]string
+			if pr := f.openPull(q.Get("source_branch"), q.Get("target_branch")); pr != nil && q.Get("state") == "opened" {
+				found = append(found, map[string]string{"web_url": pr["url"]})
+			}
+			reply(found)
+		case p == "/merge_requests":
+			reply(map[string]string{"web_url": f.openPR(body["source_branch"], body["target_branch"], body["title"])})
+		default:
+			http.NotFound(w, r)
+		}
+		return
+	}
+
+	p = strings.TrimPrefix(p, "/repos/acme/deploy")
+	switch {
+	case strings.HasPrefix(p, "/contents/"):
+		file := strings.TrimPrefix(p, "/contents/")
+		if r.Method == http.MethodGet {
+			f.getFile(w, r.URL.Query().Get("ref"), file, "sha")
+			return
+		}
+		_, exists := f.files[body["branch"]][file]
+		if exists != (body["sha"] != "") || (f.provider == ProviderGitea && !exists && r.Method != http.MethodPost) {
+			http.Error(w, "sha mismatch", http.StatusUnprocessableEntity)
+			return
+		}
+		content, _ := base64.StdEncoding.DecodeString(body["content"])
+		f.files[body["branch"]][file] = string(content)
+	case p == "/git/ref/heads/main":
+		reply(map[string]any{"object": map[string]string{"sha": "abc123"}})
+	case p == "/git/refs":
+		f.branch(w, strings.TrimPrefix(body["ref"], "refs/heads/"), "main")
+	case strings.HasPrefix(p, "/git/refs/heads/") && r.Method == http.MethodDelete:
+		f.deleteBranch(w, strings.TrimPrefix(p, "/git/refs/heads/"))
+	case p == "/branches":
+		f.branch(w, body["new_branch_name"], body["old_branch_name"])
+	case strings.HasPrefix(p, "/branches/") && r.Method == http.MethodDelete:
+		f.deleteBranch(w, strings.TrimPrefix(p, "/branches/"))
+	case p == "/pulls" && r.Method == http.MethodGet:
+		q := r.URL.Query()
+		var found []map[string]string
+		if pr := f.openPull(strings.TrimPrefix(q.Get("head"), "acme:"), q.Get("base")); pr != nil && q.Get("state") == "open" {
+			found = append(found, map[string]string{"html_url": pr["url"]})
+		}
+		reply(found)
+	case p == "/pulls":
+		reply(map[string]string{"html_url": f.openPR(body["head"], body["base"], body["title"])})
+	case strings.HasPrefix(p, "/pulls/main/") && f.provider == ProviderGitea:
+		// Gitea returns the latest request between the branches, whatever its state.
+		head := strings.TrimPrefix(p, "/pulls/main/")
+		for i := len(f.pulls) - 1; i >= 0; i-- {
+			if f.pulls[i]["head"] == head {
+				reply(map[string]string{"html_url": f.pulls[i]["url"], "state": f.pulls[i]["state"]})
+				return
+			}
+		}
+		http.NotFound(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (f *fakeForge) getFile(w http.ResponseWriter, branch, file, shaField string) {
+	content, ok := f.files[branch][file]
+	if !ok {
+		http.NotFound(w, nil)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(map[string]string{
+		"content": base64.StdEncoding.EncodeToString([]byte(content)),
+		shaField:  "sha-" + file,
+	})
+}
+
+func (f *fakeForge) branch(w http.ResponseWriter, name, from string) {
+	if _, exists := f.files[name]; exists {
+		http.Error(w, "branch already exists", http.StatusUnprocessableEntity)
+		return
+	}
+	files := map[string]string{}
+	for k, v := range f.files[from] {
+		files[k] = v
+	}
+	f.files[name] = files
+}
+
+func (f *fakeForge) deleteBranch(w http.ResponseWriter, name string) {
+	if _, exists := f.files[name]; !exists {
+		http.NotFound(w, nil)
+		return
+	}
+	delete(f.files, name)
+}
+
+func (f *fakeForge) openPR(head, base, title string) string {
+	url := fmt.Sprintf("https://forge/pull/%d", len(f.pulls)+1)
+	f.pulls = append(f.pulls, map[string]string{"head": head, "base": base, "title": title, "url": url, "state": "open"})
+	return url
+}
+
+func (f *fakeForge) openPull(head, base string) map[string]string {
+	for _, pr := range f.pulls {
+		if pr["head"] == head && pr["base"] == base && pr["state"] == "open" {
+			return pr
+		}
+	}
+	return nil
+}
+
+var (
+	apiWorkload = patch.Workload{Kind: "Deployment", Name: "api", Namespace: "payments", Containers: []k8s.Container{{Name: "app"}}}
+	apiChanges  = []patch.Change{sidecar}
+)
+
+func TestProposeHelmValues(t *testing.T) {
+	for _, provider := range []string{ProviderGitHub, ProviderGitea, ProviderGitLab} {
+		t.Run(provider, func(t *testing.T) {
+			forge, srv := newFakeForge(t, provider, map[string]string{
+				"charts/api/values.yaml": "replicaCount: 2\nresources: {}\n",
+			})
+			cfg := &Config{Provider: provider, APIURL: srv.URL, Repository: "acme/deploy", BaseBranch: "main", Token: "t0k"}
+			m := Mapping{Namespace: "payments", Name: "api", Format: patch.FormatHelmValues, Path: "charts/api/values.yaml"}
+
+			pr, err := Propose(t.Context(), cfg, NewProvider(cfg), m, apiWorkload, apiChanges)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if pr.Branch != "kubeadjust/payments-deployment-api" || pr.URL == "" {
+				t.Errorf("got %+v", pr)
+			}
+			if !strings.Contains(forge.auth, "t0k") {
+				t.Errorf("token not sent: %q", forge.auth)
+			}
+			got := forge.files[pr.Branch]["charts/api/values.yaml"]
+			if !strings.HasPrefix(got, "replicaCount: 2\nresources:\n  requests:\n    cpu: \"50m\"") {
+				t.Errorf("values on branch:\n%s", got)
+			}
+			if forge.files["main"]["charts/api/values.yaml"] != "replicaCount: 2\nresources: {}\n" {
+				t.Error("base branch must not be modified")
+			}
+			if len(forge.pulls) != 1 || forge.pulls[0]["head"] != pr.Branch || forge.pulls[0]["base"] != "main" {
+				t.Errorf("pull requests: %+v", forge.pulls)
+			}
+		})
+	}
+}
+
+func TestProposeReusesBranch(t *testing.T) {
+	for _, provider := range []string{ProviderGitHub, ProviderGitea, ProviderGitLab} {
+		t.Run(provider, func(t *testing.T) {
+			forge, srv := newFakeForge(t, provider, map[string]string{
+				"charts/api/values.yaml": "resources: {}\n",
+			})
+			cfg := &Config{Provider: provider, APIURL: srv.URL, Repository: "acme/deploy", BaseBranch: "main", Token: "t"}
+			m := Mapping{Namespace: "payments", Name: "api", Format: patch.FormatHelmValues, Path: "charts/api/values.yaml"}
+			first, err := Propose(t.Context(), cfg, NewProvider(cfg), m, apiWorkload, apiChanges)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// New values while the request is open: committed on the same branch and request.
+			bigger := sidecar
+			bigger.Requests = map[string]string{"cpu": "200m"}
+			second, err := Propose(t.Context(), cfg, NewProvider(cfg), m, apiWorkload, []patch.Change{bigger})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !second.Updated || second.URL != first.URL || second.Branch != first.Branch || len(forge.pulls) != 1 {
+				t.Fatalf("got %+v, pulls %+v", second, forge.pulls)
+			}
+			if got := forge.files[second.Branch]["charts/api/values.yaml"]; !strings.Contains(got, `cpu: "200m"`) {
+				t.Errorf("values on branch:\n%s", got)
+			}
+
+			// Once the request is closed, the leftover branch restarts from the base branch.
+			forge.pulls[0]["state"] = "closed"
+			forge.files[first.Branch]["stale.txt"] = "x"
+			third, err := Propose(t.Context(), cfg, NewProvider(cfg), m, apiWorkload, apiChanges)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if third.Updated || third.Branch != first.Branch || len(forge.pulls) != 2 {
+				t.Fatalf("got %+v, pulls %+v", third, forge.pulls)
+			}
+			if _, ok := forge.files[third.Branch]["stale.txt"]; ok {
+				t.Error("stale branch was not recreated from main")
+			}
+		})
+	}
+}
+
+func TestProposeKustomize(t *testing.T) {
+	forge, srv := newFakeForge(t, ProviderGitHub, map[string]string{
+		"overlays/prod/kustomization.yaml": "resources:\n  - ../../base\n",
+	})
+	cfg := &Config{Provider: ProviderGitHub, APIURL: srv.URL, Repository: "acme/deploy", BaseBranch: "main", Token: "t"}
+	m := Mapping{Namespace: "payments", Name: "*", Format: patch.FormatKustomize, Path: "overlays/prod"}
+
+	pr, err := Propose(t.Context(), cfg, NewProvider(cfg), m, apiWorkload, apiChanges)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pr.Files) != 2 || !pr.Files[0].Created {
+		t.Fatalf("files: %+v", pr.Files)
+	}
+	branch := forge.files[pr.Branch]
+	if !strings.Contains(branch["overlays/prod/kustomization.yaml"], "- path: kubeadjust-deployment-api.yaml") {
+		t.Errorf("kustomization not updated:\n%s", branch["overlays/prod/kustomization.yaml"])
+	}
+	if branch["overlays/prod/kubeadjust-deployment-api.yaml"] != patch.OverlayPatch(apiWorkload, apiChanges) {
+		t.Errorf("patch file:\n%s", branch["overlays/prod/kubeadjust-deployment-api.yaml"])
+	}
+
+	// Once merged, proposing the same values again is a no-op.
+	forge.files["main"] = branch
+	if _, err := Propose(t.Context(), cfg, NewProvider(cfg), m, apiWorkload, apiChanges); !errors.Is(err, ErrNoChanges) {
+		t.Errorf("got %v, want ErrNoChanges", err)
+	}
+}
+
+func TestProposeMissingKustomization(t *testing.T) {
+	_, srv := newFakeForge(t, ProviderGitHub, map[string]string{})
+	cfg := &Config{Provider: ProviderGitHub, APIURL: srv.URL, Repository: "acme/deploy", BaseBranch: "main", Token: "t"}
+	m := Mapping{Namespace: "payments", Name: "api", Format: patch.FormatKustomize, Path: "overlays/prod"}
+	if _, err := Propose(t.Context(), cfg, NewProvider(cfg), m, apiWorkload, apiChanges); err == nil {
+		t.Error("expected error when the overlay has no kustomization file")
+	}
+}
+
+func TestParseAndLookup(t *testing.T) {
+	cfg, err := Parse([]byte(`{
+		"provider": "github",
+		"repository": "acme/deploy",
+		"workloads": [
+			{"namespace": "payments", "name": "*", "format": "kustomize", "path": "overlays/payments"},
+			{"namespace": "payments", "kind": "Deployment", "name": "api", "format": "helm-values", "path": "charts/api/values.yaml"},
+			{"cluster": "staging", "namespace": "web", "name": "front", "format": "helm-values", "path": "staging/front.yaml"}
+		]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.APIURL != "https://api.github.com" || cfg.BaseBranch != "main" {
+		t.Errorf("defaults not applied: %+v", cfg)
+	}
+	if m, _ := cfg.Lookup("", "payments", "Deployment", "api"); m.Format != patch.FormatHelmValues {
+		t.Errorf("exact name should win over *, got %+v", m)
+	}
+	if m, _ := cfg.Lookup("", "payments", "StatefulSet", "db"); m.Format != patch.FormatKustomize {
+		t.Errorf("wildcard not matched, got %+v", m)
+	}
+	if _, ok := cfg.Lookup("prod", "web", "Deployment", "front"); ok {
+		t.Error("mapping scoped to another cluster should not match")
+	}
+
+	for _, bad := range []string{
+		`{"provider": "bitbucket", "repository": "a/b"}`,
+		`{"provider": "gitea", "repository": "a/b"}`,
+		`{"provider": "github", "repository": "a"}`,
+		`{"provider": "github", "repository": "a/b", "workloads": [{"namespace": "x", "name": "y", "format": "json", "path": "p"}]}`,
+		`{"provider": "github", "repository": "a/b", "workloads": [{"namespace": "x", "name": "y", "format": "kustomize", "path": "../etc"}]}`,
+	} {
+		if _, err := Parse([]byte(bad)); err == nil {
+			t.Errorf("expected error for %s", bad)
+		}
+	}
+}
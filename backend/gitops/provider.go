@@ -0,0 +1,297 @@
+package gitops
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ErrNotFound is returned by Provider.GetFile when the file does not exist on the branch.
+var ErrNotFound = errors.New("not found")
+
+// maxResponseBytes caps the size of Git provider API responses.
+const maxResponseBytes = 10 << 20 // 10 MB
+
+// Provider is the subset of a Git hosting API needed to propose a change.
+type Provider interface {
+	// GetFile returns the content of path on branch and the provider's revision token
+	// for that file (blob SHA), or ErrNotFound.
+	GetFile(ctx context.Context, branch, path string) (content []byte, sha string, err error)
+	// CreateBranch creates branch name from the head of base.
+	CreateBranch(ctx context.Context, name, base string) error
+	// DeleteBranch deletes branch name, or returns ErrNotFound.
+	DeleteBranch(ctx context.Context, name string) error
+	// PutFile commits content to path on branch. sha is the value returned by GetFile,
+	// or "" to create a new file.
+	PutFile(ctx context.Context, branch, path string, content []byte, sha, message string) error
+	// OpenPullRequest opens a pull/merge request from head into base and returns its web URL.
+	OpenPullRequest(ctx context.Context, head, base, title, body string) (string, error)
+	// FindPullRequest returns the web URL of the open pull/merge request from head into
+	// base, or "" when there is none.
+	FindPullRequest(ctx context.Context, head, base string) (string, error)
+}
+
+// NewProvider returns the API client for c.Provider.
+func NewProvider(c *Config) Provider {
+	api := &apiClient{baseURL: c.APIURL, httpClient: &http.Client{Timeout: 30 * time.Second}}
+	switch c.Provider {
+	case ProviderGitLab:
+		api.authHeader, api.authValue = "PRIVATE-TOKEN", c.Token
+		return &gitlab{api: api, project: url.PathEscape(c.Repository)}
+	case ProviderGitea:
+		api.authHeader, api.authValue = "Authorization", "token "+c.Token
+		return &github{api: api, repo: c.Repository, gitea: true}
+	default:
+		api.authHeader, api.authValue = "Authorization", "Bearer "+c.Token
+		return &github{api: api, repo: c.Repository}
+	}
+}
+
+// apiClient is a minimal JSON REST client shared by the providers.
+type apiClient struct {
+	baseURL    string
+	httpClient *http.Client
+	authHeader string
+	authValue  string
+}
+
+// do sends in (if non-nil) as JSON and decodes the response into out (if non-nil).
+// A 404 is returned as ErrNotFound.
+func (a *apiClient) do(ctx context.Context, method, path string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, a.baseURL+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set(a.authHeader, a.authValue)
+	req.Header.Set("Accept", "application/json")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := a.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	if err != nil {
+		return fmt.Errorf("reading %s %s response: %w", method, path, err)
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrNotFound
+	}
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("%s %s: %d %s", method, path, resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(data, out)
+}
+
+// escapePath escapes each segment of a repository file path, keeping the slashes.
+func escapePath(p string) string {
+	parts := strings.Split(p, "/")
+	for i, s := range parts {
+		parts[i] = url.PathEscape(s)
+	}
+	return strings.Join(parts, "/")
+}
+
+// github implements Provider for GitHub and Gitea, whose contents and pulls APIs match
+// closely. They differ in branch handling, in file creation (Gitea uses POST) and in
+// how a pull request is looked up by branch.
+type github struct {
+	api   *apiClient
+	repo  string // "owner/name"
+	gitea bool
+}
+
+func (g *github) prefix() string {
+	return "/repos/" + g.repo
+}
+
+func (g *github) GetFile(ctx context.Context, branch, path string) ([]byte, string, error) {
+	var f struct {
+		Content  string `json:"content"`
+		Encoding string `json:"encoding"`
+		SHA      string `json:"sha"`
+	}
+	if err := g.api.do(ctx, http.MethodGet, g.prefix()+"/contents/"+escapePath(path)+"?ref="+url.QueryEscape(branch), nil, &f); err != nil {
+		return nil, "", err
+	}
+	content, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(f.Content, "\n", ""))
+	if err != nil {
+		return nil, "", fmt.Errorf("decoding %s: %w", path, err)
+	}
+	return content, f.SHA, nil
+}
+
+func (g *github) CreateBranch(ctx context.Context, name, base string) error {
+	if g.gitea {
+		return g.api.do(ctx, http.MethodPost, g.prefix()+"/branches", map[string]string{
+			"new_branch_name": name,
+			"old_branch_name": base,
+		}, nil)
+	}
+	var ref struct {
+		Object struct {
+			SHA string `json:"sha"`
+		} `json:"object"`
+	}
+	if err := g.api.do(ctx, http.MethodGet, g.prefix()+"/git/ref/heads/"+escapePath(base), nil, &ref); err != nil {
+		return fmt.Errorf("resolving %s: %w", base, err)
+	}
+	return g.api.do(ctx, http.MethodPost, g.prefix()+"/git/refs", map[string]string{
+		"ref": "refs/heads/" + name,
+		"sha": ref.Object.SHA,
+	}, nil)
+}
+
+func (g *github) DeleteBranch(ctx context.Context, name string) error {
+	if g.gitea {
+		return g.api.do(ctx, http.MethodDelete, g.prefix()+"/branches/"+escapePath(name), nil, nil)
+	}
+	return g.api.do(ctx, http.MethodDelete, g.prefix()+"/git/refs/heads/"+escapePath(name), nil, nil)
+}
+
+func (g *github) PutFile(ctx context.Context, branch, path string, content []byte, sha, message string) error {
+	body := map[string]string{
+		"message": message,
+		"content": base64.StdEncoding.EncodeToString(content),
+		"branch":  branch,
+	}
+	method := http.MethodPut
+	if sha != "" {
+		body["sha"] = sha
+	} else if g.gitea {
+		method = http.MethodPost
+	}
+	return g.api.do(ctx, method, g.prefix()+"/contents/"+escapePath(path), body, nil)
+}
+
+func (g *github) OpenPullRequest(ctx context.Context, head, base, title, body string) (string, error) {
+	var pr struct {
+		HTMLURL string `json:"html_url"`
+	}
+	err := g.api.do(ctx, http.MethodPost, g.prefix()+"/pulls", map[string]string{
+		"title": title,
+		"head":  head,
+		"base":  base,
+		"body":  body,
+	}, &pr)
+	return pr.HTMLURL, err
+}
+
+func (g *github) FindPullRequest(ctx context.Context, head, base string) (string, error) {
+	var pr struct {
+		HTMLURL string `json:"html_url"`
+		State   string `json:"state"`
+	}
+	if g.gitea {
+		// Gitea looks a pull request up by its branches, whatever its state.
+		err := g.api.do(ctx, http.MethodGet, g.prefix()+"/pulls/"+escapePath(base)+"/"+escapePath(head), nil, &pr)
+		if errors.Is(err, ErrNotFound) {
+			return "", nil
+		}
+		if err != nil || pr.State != "open" {
+			return "", err
+		}
+		return pr.HTMLURL, nil
+	}
+	owner, _, _ := strings.Cut(g.repo, "/")
+	var prs []struct {
+		HTMLURL string `json:"html_url"`
+	}
+	q := url.Values{"state": {"open"}, "head": {owner + ":" + head}, "base": {base}}
+	if err := g.api.do(ctx, http.MethodGet, g.prefix()+"/pulls?"+q.Encode(), nil, &prs); err != nil || len(prs) == 0 {
+		return "", err
+	}
+	return prs[0].HTMLURL, nil
+}
+
+// gitlab implements Provider for the GitLab v4 API. Files are addressed by path only:
+// GitLab needs no blob SHA to update, so GetFile returns the blob ID for information.
+type gitlab struct {
+	api     *apiClient
+	project string // URL-escaped "group/project"
+}
+
+func (g *gitlab) GetFile(ctx context.Context, branch, path string) ([]byte, string, error) {
+	var f struct {
+		Content string `json:"content"`
+		BlobID  string `json:"blob_id"`
+	}
+	if err := g.api.do(ctx, http.MethodGet, "/projects/"+g.project+"/repository/files/"+url.PathEscape(path)+"?ref="+url.QueryEscape(branch), nil, &f); err != nil {
+		return nil, "", err
+	}
+	content, err := base64.StdEncoding.DecodeString(f.Content)
+	if err != nil {
+		return nil, "", fmt.Errorf("decoding %s: %w", path, err)
+	}
+	return content, f.BlobID, nil
+}
+
+func (g *gitlab) CreateBranch(ctx context.Context, name, base string) error {
+	return g.api.do(ctx, http.MethodPost, "/projects/"+g.project+"/repository/branches", map[string]string{
+		"branch": name,
+		"ref":    base,
+	}, nil)
+}
+
+func (g *gitlab) DeleteBranch(ctx context.Context, name string) error {
+	return g.api.do(ctx, http.MethodDelete, "/projects/"+g.project+"/repository/branches/"+url.PathEscape(name), nil, nil)
+}
+
+func (g *gitlab) PutFile(ctx context.Context, branch, path string, content []byte, sha, message string) error {
+	method := http.MethodPost
+	if sha != "" {
+		method = http.MethodPut
+	}
+	return g.api.do(ctx, method, "/projects/"+g.project+"/repository/files/"+url.PathEscape(path), map[string]string{
+		"branch":         branch,
+		"content":        base64.StdEncoding.EncodeToString(content),
+		"encoding":       "base64",
+		"commit_message": message,
+	}, nil)
+}
+
+func (g *gitlab) OpenPullRequest(ctx context.Context, head, base, title, body string) (string, error) {
+	var mr struct {
+		WebURL string `json:"web_url"`
+	}
+	err := g.api.do(ctx, http.MethodPost, "/projects/"+g.project+"/merge_requests", map[string]string{
+		"source_branch": head,
+		"target_branch": base,
+		"title":         title,
+		"description":   body,
+	}, &mr)
+	return mr.WebURL, err
+}
+
+func (g *gitlab) FindPullRequest(ctx context.Context, head, base string) (string, error) {
+	var mrs []struct {
+		WebURL string `json:"web_url"`
+	}
+	q := url.Values{"state": {"opened"}, "source_branch": {head}, "target_branch": {base}}
+	if err := g.api.do(ctx, http.MethodGet, "/projects/"+g.project+"/merge_requests?"+q.Encode(), nil, &mrs); err != nil || len(mrs) == 0 {
+		return "", err
+	}
+	return mrs[0].WebURL, nil
+}
@@ -8,6 +8,7 @@ require (
 	github.com/go-chi/cors v1.2.2
 	golang.org/x/oauth2 v0.36.0
 	golang.org/x/sync v0.20.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require github.com/go-jose/go-jose/v4 v4.1.4 // indirect
@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"slices"
+
+	"golang.org/x/sync/errgroup"
+
+	"github.com/devops-kubeadjust/backend/k8s"
+	"github.com/devops-kubeadjust/backend/middleware"
+	"github.com/devops-kubeadjust/backend/pricing"
+	"github.com/devops-kubeadjust/backend/resources"
+)
+
+// NewAllocationHandler returns a showback handler aggregating requests, limits, live usage and
+// (when prices is non-nil) cost across all pods, grouped by any combination of namespace, node,
+// pod labels, namespace labels and node labels:
+//
+//	GET /api/allocation?groupBy=label:team,namespaceLabel:cost-center,namespace
+func NewAllocationHandler(prices *pricing.Table) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		keys, err := resources.ParseGroupBy(r.URL.Query().Get("groupBy"))
+		if err != nil {
+			jsonError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		needs := func(source string) bool {
+			return slices.ContainsFunc(keys, func(k resources.GroupKey) bool { return k.Source == source })
+		}
+		needNodes := needs("nodeLabel") || (prices != nil && prices.ByInstanceType())
+
+		token := middleware.TokenFromContext(r.Context())
+		client := k8s.New(token, middleware.ClusterURLFromContext(r.Context()))
+
+		var (
+			allPods    *k8s.PodList
+			allMetrics *k8s.PodMetricsList
+			namespaces *k8s.NamespaceList
+			nodes      *k8s.NodeList
+		)
+		g, ctx := errgroup.WithContext(r.Context())
+		g.Go(func() error {
+			var err error
+			allPods, err = client.ListAllPods(ctx)
+			return err
+		})
+		g.Go(func() error {
+			m, err := client.ListAllPodMetrics(ctx)
+			if err != nil {
+				log.Printf("pod metrics unavailable for allocation: %v", err)
+				return nil // best-effort
+			}
+			allMetrics = m
+			return nil
+		})
+		if needs("namespaceLabel") {
+			g.Go(func() error {
+				var err error
+				namespaces, err = client.ListNamespaces(ctx)
+				return err
+			})
+		}
+		if needNodes {
+			g.Go(func() error {
+				var err error
+				nodes, err = client.ListNodes(ctx)
+				return err
+			})
+		}
+		if err := g.Wait(); err != nil {
+			log.Printf("failed to fetch cluster state for allocation: %v", err)
+			jsonError(w, "internal server error", http.StatusInternalServerError)
+			return
+		}
+
+		in := resources.AllocationInput{Pods: allPods.Items}
+		if namespaces != nil {
+			in.NamespaceLabels = make(map[string]map[string]string, len(namespaces.Items))
+			for _, ns := range namespaces.Items {
+				in.NamespaceLabels[ns.Metadata.Name] = ns.Metadata.Labels
+			}
+		}
+		if nodes != nil {
+			in.NodeLabels = make(map[string]map[string]string, len(nodes.Items))
+			for _, n := range nodes.Items {
+				in.NodeLabels[n.Metadata.Name] = n.Metadata.Labels
+			}
+		}
+		if allMetrics != nil {
+			in.Usage = snapshotUsage(allMetrics, nil)
+		}
+		if prices != nil {
+			var rates map[string]pricing.Rate
+			if nodes != nil {
+				rates = prices.NodeRates(nodes.Items)
+			}
+			in.Cost = func(pod *k8s.Pod, cpuReqM, memReqB, cpuUseM, memUseB int64, hasUsage bool) resources.Cost {
+				rate, ok := rates[pod.Spec.NodeName]
+				if !ok {
+					rate = prices.Default
+				}
+				return prices.Cost(rate, cpuReqM, memReqB, cpuUseM, memUseB, hasUsage)
+			}
+		}
+
+		groupBy := make([]string, len(keys))
+		for i, k := range keys {
+			groupBy[i] = k.String()
+		}
+		jsonOK(w, resources.AllocationResponse{
+			GroupBy:          groupBy,
+			Groups:           resources.Allocate(in, keys),
+			MetricsAvailable: allMetrics != nil,
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/devops-kubeadjust/backend/gitops"
+	"github.com/devops-kubeadjust/backend/k8s"
+	"github.com/devops-kubeadjust/backend/middleware"
+	"github.com/devops-kubeadjust/backend/prometheus"
+	"github.com/devops-kubeadjust/backend/suggestions"
+)
+
+// NewPullRequestHandler returns a handler that opens a pull/merge request applying the
+// suggested requests/limits of one workload to the Git repository it is deployed from,
+// located through the GitOps path mapping. The caller needs patch permission on the
+// workload. The cluster itself is not modified.
+func NewPullRequestHandler(promClient *prometheus.Client, cfg *gitops.Config, thresholds *suggestions.Config) http.HandlerFunc {
+	var provider gitops.Provider
+	if cfg != nil {
+		provider = gitops.NewProvider(cfg)
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		if cfg == nil {
+			jsonError(w, "gitops integration not configured", http.StatusServiceUnavailable)
+			return
+		}
+		ns := chi.URLParam(r, "namespace")
+		kind := normalizeKind(chi.URLParam(r, "kind"))
+		name := chi.URLParam(r, "name")
+		if kind == "" {
+			jsonError(w, "kind must be Deployment, StatefulSet or CronJob", http.StatusBadRequest)
+			return
+		}
+		// The pull request is opened with the backend's Git token, so only users allowed to
+		// change the workload in the cluster may propose a change to its manifests.
+		client := k8s.New(middleware.TokenFromContext(r.Context()), middleware.ClusterURLFromContext(r.Context()))
+		group, resource := workloadResource(kind)
+		allowed, err := client.CanI(r.Context(), "patch", group, resource, ns, name)
+		if err != nil {
+			log.Printf("failed to review patch access to %s %s/%s: %v", kind, ns, name, err)
+			jsonError(w, "internal server error", http.StatusInternalServerError)
+			return
+		}
+		if !allowed {
+			jsonError(w, "patch permission on this workload is required to open a pull request", http.StatusForbidden)
+			return
+		}
+
+		mapping, ok := cfg.Lookup(r.Header.Get("X-Cluster"), ns, kind, name)
+		if !ok {
+			jsonError(w, "no repository path mapped for this workload", http.StatusNotFound)
+			return
+		}
+
+		wl, changes, ok := suggestWorkload(w, r, promClient, thresholds, ns, kind, name)
+		if !ok {
+			return
+		}
+		pr, err := gitops.Propose(r.Context(), cfg, provider, mapping, wl, changes)
+		if errors.Is(err, gitops.ErrNoChanges) {
+			jsonError(w, "no resource changes to propose", http.StatusConflict)
+			return
+		}
+		if err != nil {
+			log.Printf("failed to open pull request for %s %s/%s: %v", kind, ns, name, err)
+			jsonError(w, "failed to open pull request", http.StatusBadGateway)
+			return
+		}
+		jsonOK(w, pr)
+	}
+}
+
+// workloadResource returns the API group and resource of a normalized workload kind.
+func workloadResource(kind string) (group, resource string) {
+	switch kind {
+	case "StatefulSet":
+		return "apps", "statefulsets"
+	case "CronJob":
+		return "batch", "cronjobs"
+	default:
+		return "apps", "deployments"
+	}
+}
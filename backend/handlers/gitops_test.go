@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/devops-kubeadjust/backend/gitops"
+	"github.com/devops-kubeadjust/backend/middleware"
+	"github.com/devops-kubeadjust/backend/suggestions"
+)
+
+func TestPullRequestHandlerRequiresPatch(t *testing.T) {
+	for allowed, want := range map[bool]int{false: http.StatusForbidden, true: http.StatusNotFound} {
+		var review struct {
+			Spec struct {
+				ResourceAttributes map[string]string `json:"resourceAttributes"`
+			} `json:"spec"`
+		}
+		api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodPost || r.URL.Path != "/apis/authorization.k8s.io/v1/selfsubjectaccessreviews" {
+				http.NotFound(w, r)
+				return
+			}
+			_ = json.NewDecoder(r.Body).Decode(&review)
+			_ = json.NewEncoder(w).Encode(map[string]any{"status": map[string]bool{"allowed": allowed}})
+		}))
+		// No workload is mapped: a request past the permission check gets a 404.
+		cfg, err := gitops.Parse([]byte(`{"provider": "github", "repository": "acme/deploy"}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		router := chi.NewRouter()
+		router.Post("/api/namespaces/{namespace}/workloads/{kind}/{name}/pull-request", NewPullRequestHandler(nil, cfg, suggestions.DefaultConfig()))
+		h := middleware.ClusterURL(map[string]string{"test": api.URL})(middleware.BearerToken(router))
+
+		req := httptest.NewRequest("POST", "/api/namespaces/shop/workloads/statefulsets/db/pull-request", nil)
+		req.Header.Set("Authorization", "Bearer token")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		api.Close()
+		if w.Code != want {
+			t.Errorf("allowed=%v: got %d, want %d: %s", allowed, w.Code, want, w.Body)
+		}
+		attrs := review.Spec.ResourceAttributes
+		if attrs["verb"] != "patch" || attrs["group"] != "apps" || attrs["resource"] != "statefulsets" || attrs["namespace"] != "shop" || attrs["name"] != "db" {
+			t.Errorf("allowed=%v: review attributes %v", allowed, attrs)
+		}
+	}
+}
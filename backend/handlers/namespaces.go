@@ -4,12 +4,17 @@ import (
 	"encoding/json"
 	"log"
 	"net/http"
+	"slices"
 	"sort"
+	"strings"
 	"sync"
 
 	"github.com/devops-kubeadjust/backend/k8s"
 	"github.com/devops-kubeadjust/backend/middleware"
+	"github.com/devops-kubeadjust/backend/pricing"
+	"github.com/devops-kubeadjust/backend/prometheus"
 	"github.com/devops-kubeadjust/backend/resources"
+	"github.com/devops-kubeadjust/backend/suggestions"
 	"golang.org/x/sync/errgroup"
 )
 
@@ -59,25 +64,40 @@ func ListNamespaces(w http.ResponseWriter, r *http.Request) {
 
 // NamespaceStats holds aggregated limit/request ratios and live usage for a namespace.
 type NamespaceStats struct {
-	Name          string  `json:"name"`
-	CPURequestedM int64   `json:"cpuRequestedM"`
-	CPULimitedM   int64   `json:"cpuLimitedM"`
-	MemRequestedB int64   `json:"memRequestedB"`
-	MemLimitedB   int64   `json:"memLimitedB"`
-	CPUUsageM     int64   `json:"cpuUsageM"`  // 0 if metrics-server unavailable
-	MemUsageB     int64   `json:"memUsageB"`  // 0 if metrics-server unavailable
-	CPURatio      float64 `json:"cpuRatio"` // lim/req; 0 if no requests
-	MemRatio      float64 `json:"memRatio"`
+	Name          string          `json:"name"`
+	CPURequestedM int64           `json:"cpuRequestedM"`
+	CPULimitedM   int64           `json:"cpuLimitedM"`
+	MemRequestedB int64           `json:"memRequestedB"`
+	MemLimitedB   int64           `json:"memLimitedB"`
+	CPUUsageM     int64           `json:"cpuUsageM"` // 0 if metrics-server unavailable
+	MemUsageB     int64           `json:"memUsageB"` // 0 if metrics-server unavailable
+	CPURatio      float64         `json:"cpuRatio"`  // lim/req; 0 if no requests
+	MemRatio      float64         `json:"memRatio"`
+	Cost          *resources.Cost `json:"cost,omitempty"` // set when pricing is configured
+	// Quotas and LimitRange defaults are best-effort (omitted without list permission).
+	Quotas              []resources.QuotaStatus        `json:"quotas,omitempty"`
+	LimitRanges         []resources.LimitRangeDefaults `json:"limitRanges,omitempty"`
+	DefaultedContainers int                            `json:"defaultedContainers,omitempty"` // containers with LimitRange-injected values
 }
 
-// GetNamespaceStats returns per-namespace limit/request ratios and live usage.
+// NewNamespaceStatsHandler returns a handler with per-namespace limit/request ratios, live
+// usage and, when prices is non-nil, monthly requested/used/wasted cost.
 // Pods and pod metrics are fetched concurrently; metrics are best-effort (0 if unavailable).
-func GetNamespaceStats(w http.ResponseWriter, r *http.Request) {
+func NewNamespaceStatsHandler(prices *pricing.Table) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		getNamespaceStats(w, r, prices)
+	}
+}
+
+func getNamespaceStats(w http.ResponseWriter, r *http.Request, prices *pricing.Table) {
 	token := middleware.TokenFromContext(r.Context())
 	client := k8s.New(token, middleware.ClusterURLFromContext(r.Context()))
 
 	var allPods *k8s.PodList
 	var allMetrics *k8s.PodMetricsList
+	var nodes *k8s.NodeList
+	var quotas *k8s.ResourceQuotaList
+	var limitRanges *k8s.LimitRangeList
 
 	g, ctx := errgroup.WithContext(r.Context())
 	g.Go(func() error {
@@ -97,6 +117,35 @@ func GetNamespaceStats(w http.ResponseWriter, r *http.Request) {
 		allMetrics = m
 		return nil
 	})
+	g.Go(func() error {
+		q, err := client.ListAllResourceQuotas(ctx)
+		if err != nil {
+			log.Printf("resource quotas unavailable for namespace stats: %v", err)
+			return nil // best-effort
+		}
+		quotas = q
+		return nil
+	})
+	g.Go(func() error {
+		lr, err := client.ListAllLimitRanges(ctx)
+		if err != nil {
+			log.Printf("limit ranges unavailable for namespace stats: %v", err)
+			return nil // best-effort
+		}
+		limitRanges = lr
+		return nil
+	})
+	if prices != nil && prices.ByInstanceType() {
+		g.Go(func() error {
+			nl, err := client.ListNodes(ctx)
+			if err != nil {
+				log.Printf("failed to list nodes for pricing: %v", err)
+				return nil // default rate applies
+			}
+			nodes = nl
+			return nil
+		})
+	}
 
 	if err := g.Wait(); err != nil {
 		log.Printf("failed to list pods for namespace stats: %v", err)
@@ -104,9 +153,24 @@ func GetNamespaceStats(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	type agg struct{ cpuReq, cpuLim, memReq, memLim, cpuUsage, memUsage int64 }
+	type agg struct {
+		cpuReq, cpuLim, memReq, memLim, cpuUsage, memUsage int64
+		cost                                               *resources.Cost
+		defaulted                                          int
+	}
 	nsAgg := map[string]*agg{}
 
+	var rates map[string]pricing.Rate
+	var usage usageIndex
+	if prices != nil {
+		if nodes != nil {
+			rates = prices.NodeRates(nodes.Items)
+		}
+		if allMetrics != nil {
+			usage = snapshotUsage(allMetrics, nil)
+		}
+	}
+
 	for _, pod := range allPods.Items {
 		if pod.Status.Phase == "Succeeded" || pod.Status.Phase == "Failed" {
 			continue
@@ -116,11 +180,25 @@ func GetNamespaceStats(w http.ResponseWriter, r *http.Request) {
 			nsAgg[name] = &agg{}
 		}
 		a := nsAgg[name]
+		a.defaulted += len(resources.LimitRangeDefaulted(pod))
+		rate, ok := rates[pod.Spec.NodeName]
+		if prices != nil && !ok {
+			rate = prices.Default
+		}
 		for _, c := range pod.Spec.Containers {
-			a.cpuReq += resources.ParseCPUMillicores(c.Resources.Requests["cpu"])
+			cpuReq := resources.ParseCPUMillicores(c.Resources.Requests["cpu"])
+			memReq := resources.ParseMemoryBytes(c.Resources.Requests["memory"])
+			a.cpuReq += cpuReq
 			a.cpuLim += resources.ParseCPUMillicores(c.Resources.Limits["cpu"])
-			a.memReq += resources.ParseMemoryBytes(c.Resources.Requests["memory"])
+			a.memReq += memReq
 			a.memLim += resources.ParseMemoryBytes(c.Resources.Limits["memory"])
+			if prices != nil {
+				if a.cost == nil {
+					a.cost = &resources.Cost{Currency: prices.Currency}
+				}
+				u, hasUsage := usage[name][pod.Metadata.Name][c.Name]
+				a.cost.Add(prices.Cost(rate, cpuReq, memReq, u.CPUMillicores, u.MemoryBytes, hasUsage))
+			}
 		}
 	}
 
@@ -138,6 +216,23 @@ func GetNamespaceStats(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	nsQuotas := map[string][]resources.QuotaStatus{}
+	if quotas != nil {
+		for _, q := range quotas.Items {
+			if qs, ok := resources.BuildQuotaStatus(q); ok {
+				nsQuotas[q.Metadata.Namespace] = append(nsQuotas[q.Metadata.Namespace], qs)
+			}
+		}
+	}
+	nsLimitRanges := map[string][]resources.LimitRangeDefaults{}
+	if limitRanges != nil {
+		for _, lr := range limitRanges.Items {
+			if d, ok := resources.BuildLimitRangeDefaults(lr); ok {
+				nsLimitRanges[lr.Metadata.Namespace] = append(nsLimitRanges[lr.Metadata.Namespace], d)
+			}
+		}
+	}
+
 	result := make([]NamespaceStats, 0, len(nsAgg))
 	for name, a := range nsAgg {
 		s := NamespaceStats{
@@ -148,6 +243,11 @@ func GetNamespaceStats(w http.ResponseWriter, r *http.Request) {
 			MemLimitedB:   a.memLim,
 			CPUUsageM:     a.cpuUsage,
 			MemUsageB:     a.memUsage,
+			Cost:          a.cost,
+
+			Quotas:              nsQuotas[name],
+			LimitRanges:         nsLimitRanges[name],
+			DefaultedContainers: a.defaulted,
 		}
 		if a.cpuReq > 0 {
 			s.CPURatio = float64(a.cpuLim) / float64(a.cpuReq)
@@ -161,6 +261,90 @@ func GetNamespaceStats(w http.ResponseWriter, r *http.Request) {
 	jsonOK(w, result)
 }
 
+// NewQuotaRecommendationsHandler returns a handler proposing right-sized ResourceQuota values
+// for every namespace with a CPU/memory quota. Each hard value is compared with the quota's
+// used amount (sum of requests or limits) and with the P95 of the namespace's total usage over
+// ?range (default 7d) when Prometheus is configured, else the current metrics-server usage.
+func NewQuotaRecommendationsHandler(promClient *prometheus.Client) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rangeParam := r.URL.Query().Get("range")
+		if rangeParam == "" {
+			rangeParam = defaultEstimateRange
+		}
+		if !slices.Contains(prometheus.TimeRanges, rangeParam) {
+			jsonError(w, "range must be 1h, 6h, 24h or 7d", http.StatusBadRequest)
+			return
+		}
+		token := middleware.TokenFromContext(r.Context())
+		client := k8s.New(token, middleware.ClusterURLFromContext(r.Context()))
+
+		quotas, err := client.ListAllResourceQuotas(r.Context())
+		if err != nil {
+			log.Printf("failed to list resource quotas: %v", err)
+			jsonError(w, "internal server error", http.StatusInternalServerError)
+			return
+		}
+
+		resp := suggestions.QuotaRecommendationResponse{
+			Recommendations: []suggestions.QuotaRecommendation{},
+			UsageSource:     "none",
+		}
+		usage := map[string]prometheus.NamespaceP95{}
+		if promClient != nil {
+			if p95, err := promClient.GetNamespaceTotalsP95(prometheus.ParseTimeRange(rangeParam)); err == nil {
+				usage, resp.UsageSource, resp.Range = p95, "prometheus", rangeParam
+			} else {
+				log.Printf("prometheus namespace P95 query failed: %v", err)
+			}
+		}
+		if resp.UsageSource == "none" {
+			if pm, err := client.ListAllPodMetrics(r.Context()); err == nil {
+				for ns, pods := range snapshotUsage(pm, nil) {
+					var total prometheus.NamespaceP95
+					for _, containers := range pods {
+						for _, u := range containers {
+							total.CPU += float64(u.CPUMillicores)
+							total.Memory += float64(u.MemoryBytes)
+						}
+					}
+					usage[ns] = total
+				}
+				resp.UsageSource = "metrics-server"
+			} else {
+				log.Printf("pod metrics unavailable for quota recommendations: %v", err)
+			}
+		}
+
+		for _, q := range quotas.Items {
+			qs, ok := resources.BuildQuotaStatus(q)
+			if !ok {
+				continue
+			}
+			ns := q.Metadata.Namespace
+			rec := suggestions.QuotaRecommendation{Namespace: ns, Quota: q.Metadata.Name}
+			for key, qu := range qs.Resources {
+				in := suggestions.QuotaInput{Resource: key}
+				if strings.HasSuffix(key, ".cpu") {
+					in.Hard, in.Used, in.P95 = qu.Hard.Millicores, qu.Used.Millicores, usage[ns].CPU
+				} else {
+					in.Hard, in.Used, in.P95 = qu.Hard.Bytes, qu.Used.Bytes, usage[ns].Memory
+				}
+				rec.Resources = append(rec.Resources, suggestions.RecommendQuota(in))
+			}
+			suggestions.SortQuotaResources(rec.Resources)
+			resp.Recommendations = append(resp.Recommendations, rec)
+		}
+		sort.Slice(resp.Recommendations, func(i, j int) bool {
+			a, b := resp.Recommendations[i], resp.Recommendations[j]
+			if a.Namespace != b.Namespace {
+				return a.Namespace < b.Namespace
+			}
+			return a.Quota < b.Quota
+		})
+		jsonOK(w, resp)
+	}
+}
+
 // jsonOK writes v as JSON with 200 OK.
 func jsonOK(w http.ResponseWriter, v interface{}) {
 	w.Header().Set("Content-Type", "application/json")
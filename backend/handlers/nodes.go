@@ -1,185 +1,119 @@
 package handlers
 
 import (
-	"fmt"
+	"cmp"
 	"log"
-	"math"
 	"net/http"
 	"os"
-	"strconv"
-	"time"
+	"slices"
 
 	"github.com/go-chi/chi/v5"
+	"golang.org/x/sync/errgroup"
 
 	"github.com/devops-kubeadjust/backend/k8s"
 	"github.com/devops-kubeadjust/backend/middleware"
 	"github.com/devops-kubeadjust/backend/resources"
 )
 
-// parsePodCount parses the "pods" capacity field from a Node's status.
-// The value is a plain integer (e.g. "110"), not a byte quantity like memory.
-func parsePodCount(s string) int {
-	v, _ := strconv.ParseInt(s, 10, 64)
-	return int(v)
-}
-
-func nodeAge(creationTimestamp string) string {
-	t, err := time.Parse(time.RFC3339, creationTimestamp)
-	if err != nil {
-		return ""
-	}
-	d := time.Since(t)
-	days := int(math.Round(d.Hours() / 24))
-	switch {
-	case days >= 365:
-		return fmt.Sprintf("%dy", days/365)
-	case days >= 1:
-		return fmt.Sprintf("%dd", days)
-	default:
-		return fmt.Sprintf("%dh", int(d.Hours()))
-	}
-}
-
-func nodePressures(conditions []k8s.NodeCondition) (disk, memory, pid bool) {
-	for _, c := range conditions {
-		if c.Status != "True" {
-			continue
-		}
-		switch c.Type {
-		case "DiskPressure":
-			disk = true
-		case "MemoryPressure":
-			memory = true
-		case "PIDPressure":
-			pid = true
-		}
-	}
-	return
-}
-
-// ListNodes returns a cluster-wide node overview with resource aggregation.
-func ListNodes(w http.ResponseWriter, r *http.Request) {
-	token := middleware.TokenFromContext(r.Context())
-	client := k8s.New(token, middleware.ClusterURLFromContext(r.Context()))
-
-	nodes, err := client.ListNodes(r.Context())
-	if err != nil {
-		log.Printf("failed to list nodes: %v", err)
-		jsonError(w, "internal server error", http.StatusInternalServerError)
-		return
-	}
-
-	// All pods across namespaces for request/limit aggregation per node
-	allPods, err := client.ListAllPods(r.Context())
-	if err != nil {
-		log.Printf("failed to list all pods: %v", err)
-		jsonError(w, "internal server error", http.StatusInternalServerError)
-		return
-	}
-
-	// Node metrics (best-effort)
-	nodeMetrics := map[string]k8s.NodeMetrics{}
-	if nm, err := client.ListNodeMetrics(r.Context()); err == nil {
-		for _, m := range nm.Items {
-			nodeMetrics[m.Metadata.Name] = m
+// NewNodesHandler returns a handler giving a cluster-wide node overview with resource
+// aggregation, per node and per node pool, with the kubelet filesystem, PID and system
+// container stats of each node and the breakdown of its reserved resources. Pools are read
+// from poolLabel (overridden by ?poolLabel=) or from the well-known
+// resources.NodePoolLabels. Nodes can be filtered with ?labelSelector= (passed to the API
+// server), ?name= (prefix) and ?status= (see resources.NodeStatuses); pools only sum the
+// nodes kept.
+func NewNodesHandler(poolLabel string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		filter, err := resources.ParseFilter(r.URL.Query(), nil, resources.NodeStatuses)
+		if err != nil {
+			jsonError(w, err.Error(), http.StatusBadRequest)
+			return
 		}
-	}
+		token := middleware.TokenFromContext(r.Context())
+		client := k8s.New(token, middleware.ClusterURLFromContext(r.Context()))
 
-	// Aggregate pod requests/limits per node
-	type aggResources struct {
-		cpuReq, memReq int64
-		cpuLim, memLim int64
-		podCount       int
-	}
-	agg := map[string]*aggResources{}
-	for _, pod := range allPods.Items {
-		node := pod.Spec.NodeName
-		if node == "" || pod.Status.Phase == "Succeeded" || pod.Status.Phase == "Failed" {
-			continue
+		nodes, err := client.ListNodesSelector(r.Context(), filter.LabelSelector)
+		if k8s.IsBadRequest(err) {
+			jsonError(w, "invalid labelSelector", http.StatusBadRequest)
+			return
 		}
-		if agg[node] == nil {
-			agg[node] = &aggResources{}
+		if err != nil {
+			log.Printf("failed to list nodes: %v", err)
+			jsonError(w, "internal server error", http.StatusInternalServerError)
+			return
 		}
-		agg[node].podCount++
-		for _, c := range pod.Spec.Containers {
-			agg[node].cpuReq += resources.ParseCPUMillicores(c.Resources.Requests["cpu"])
-			agg[node].memReq += resources.ParseMemoryBytes(c.Resources.Requests["memory"])
-			agg[node].cpuLim += resources.ParseCPUMillicores(c.Resources.Limits["cpu"])
-			agg[node].memLim += resources.ParseMemoryBytes(c.Resources.Limits["memory"])
-		}
-	}
 
-	result := make([]resources.NodeOverview, 0, len(nodes.Items))
-	for _, node := range nodes.Items {
-		overview := resources.NodeOverview{
-			Name:  node.Metadata.Name,
-			Roles: resources.NodeRoles(node.Metadata.Labels),
-			Capacity: resources.NodeResources{
-				CPU:    resources.ParseResource(node.Status.Capacity["cpu"], true),
-				Memory: resources.ParseResource(node.Status.Capacity["memory"], false),
-			},
-			Allocatable: resources.NodeResources{
-				CPU:    resources.ParseResource(node.Status.Allocatable["cpu"], true),
-				Memory: resources.ParseResource(node.Status.Allocatable["memory"], false),
-			},
-			MaxPods: parsePodCount(node.Status.Capacity["pods"]),
+		// All pods across namespaces for request/limit aggregation per node
+		allPods, err := client.ListAllPods(r.Context())
+		if err != nil {
+			log.Printf("failed to list all pods: %v", err)
+			jsonError(w, "internal server error", http.StatusInternalServerError)
+			return
 		}
 
-		// Node status + pressure conditions
-		overview.Status = resources.NodeStatus(node.Status.Conditions)
-		overview.DiskPressure, overview.MemoryPressure, overview.PIDPressure = nodePressures(node.Status.Conditions)
-
-		// Node info
-		overview.KernelVersion = node.Status.NodeInfo.KernelVersion
-		overview.OSImage = node.Status.NodeInfo.OSImage
-		overview.Age = nodeAge(node.Metadata.CreationTimestamp)
-
-		// Taints
-		for _, t := range node.Spec.Taints {
-			overview.Taints = append(overview.Taints, resources.NodeTaint{
-				Key: t.Key, Value: t.Value, Effect: t.Effect,
-			})
+		// Node metrics (best-effort)
+		var nodeMetrics *k8s.NodeMetricsList
+		if nm, err := client.ListNodeMetrics(r.Context()); err == nil {
+			nodeMetrics = nm
 		}
 
-		// Aggregated pod data
-		if a := agg[node.Metadata.Name]; a != nil {
-			overview.PodCount = a.podCount
-			overview.Requested = resources.NodeResources{
-				CPU:    resources.ResourceValue{Millicores: a.cpuReq, Raw: resources.FmtMillicores(a.cpuReq)},
-				Memory: resources.ResourceValue{Bytes: a.memReq, Raw: resources.FmtBytes(a.memReq)},
-			}
-			overview.Limited = resources.NodeResources{
-				CPU:    resources.ResourceValue{Millicores: a.cpuLim, Raw: resources.FmtMillicores(a.cpuLim)},
-				Memory: resources.ResourceValue{Bytes: a.memLim, Raw: resources.FmtBytes(a.memLim)},
-			}
-		}
+		result := resources.BuildNodeOverviews(nodes, allPods, nodeMetrics)
+		result = slices.DeleteFunc(result, func(n resources.NodeOverview) bool { return !filter.MatchNode(n) })
 
-		// Node metrics usage
-		if nm, ok := nodeMetrics[node.Metadata.Name]; ok {
-			usage := &resources.NodeResources{
-				CPU:    resources.ParseResource(nm.Usage["cpu"], true),
-				Memory: resources.ParseResource(nm.Usage["memory"], false),
-			}
-			overview.Usage = usage
+		// Kubelet stats and configuration of the nodes kept (best-effort, needs nodes/proxy)
+		names := make(map[string]bool, len(result))
+		for _, n := range result {
+			names[n.Name] = true
 		}
-
-		result = append(result, overview)
+		kept := slices.DeleteFunc(slices.Clone(nodes.Items), func(n k8s.Node) bool {
+			return !names[n.Metadata.Name]
+		})
+		var (
+			summaries []*k8s.NodeSummary
+			configs   map[string]*k8s.KubeletConfig
+		)
+		g, gctx := errgroup.WithContext(r.Context())
+		g.Go(func() error { summaries = client.GetNodeSummaries(gctx, kept); return nil })
+		g.Go(func() error { configs = client.GetKubeletConfigs(gctx, kept); return nil })
+		_ = g.Wait()
+		resources.ApplyNodeSummaries(result, summaries)
+		resources.ApplyKubeletConfigs(result, configs)
+
+		pools := resources.BuildNodePools(result, cmp.Or(r.URL.Query().Get("poolLabel"), poolLabel))
+
+		jsonOK(w, map[string]interface{}{
+			"nodes":               result,
+			"pools":               pools,
+			"prometheusAvailable": os.Getenv("PROMETHEUS_URL") != "",
+		})
 	}
-
-	jsonOK(w, map[string]interface{}{
-		"nodes":               result,
-		"prometheusAvailable": os.Getenv("PROMETHEUS_URL") != "",
-	})
 }
 
 // GetNodePods returns the list of non-terminal pods running on a given node,
 // with per-container resource requests, limits, and live usage (best-effort).
+// Pods can be filtered with ?labelSelector= (passed to the API server), ?kind= (of the pod's
+// controller), ?name= (prefix) and ?status= (see resources.WorkloadStatuses).
 func GetNodePods(w http.ResponseWriter, r *http.Request) {
 	nodeName := chi.URLParam(r, "node")
+	filter, err := resources.ParseFilter(r.URL.Query(), resources.PodKinds, resources.WorkloadStatuses)
+	if err != nil {
+		jsonError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	token := middleware.TokenFromContext(r.Context())
 	client := k8s.New(token, middleware.ClusterURLFromContext(r.Context()))
 
-	allPods, err := client.ListAllPods(r.Context())
+	// The cached cluster-wide list serves unfiltered requests; selectors go to the API server.
+	var allPods *k8s.PodList
+	if filter.LabelSelector != "" {
+		allPods, err = client.ListNodePodsSelector(r.Context(), nodeName, filter.LabelSelector)
+		if k8s.IsBadRequest(err) {
+			jsonError(w, "invalid labelSelector", http.StatusBadRequest)
+			return
+		}
+	} else {
+		allPods, err = client.ListAllPods(r.Context())
+	}
 	if err != nil {
 		log.Printf("failed to list pods for node %s: %v", nodeName, err)
 		jsonError(w, "internal server error", http.StatusInternalServerError)
@@ -206,6 +140,9 @@ func GetNodePods(w http.ResponseWriter, r *http.Request) {
 		}
 
 		containerMetrics := metricsMap[pod.Metadata.Name]
+		statuses := resources.ContainerStatusMap(pod)
+		defaulted := resources.LimitRangeDefaulted(pod)
+		var restarts int32
 		containers := make([]resources.ContainerResources, 0, len(pod.Spec.Containers))
 		for _, c := range pod.Spec.Containers {
 			cr := resources.ContainerResources{
@@ -218,6 +155,7 @@ func GetNodePods(w http.ResponseWriter, r *http.Request) {
 					CPU:    resources.ParseResource(c.Resources.Limits["cpu"], true),
 					Memory: resources.ParseResource(c.Resources.Limits["memory"], false),
 				},
+				Defaulted: defaulted[c.Name],
 			}
 			if m, ok := containerMetrics[c.Name]; ok {
 				cr.Usage = &resources.ResourcePair{
@@ -225,15 +163,23 @@ func GetNodePods(w http.ResponseWriter, r *http.Request) {
 					Memory: resources.ParseResource(m.Usage["memory"], false),
 				}
 			}
+			if cs, ok := statuses[c.Name]; ok {
+				resources.ApplyContainerStatus(&cr, cs)
+				restarts += cs.RestartCount
+			}
 			containers = append(containers, cr)
 		}
 
-		result = append(result, resources.PodDetail{
+		detail := resources.PodDetail{
 			Name:       pod.Metadata.Name,
 			Namespace:  pod.Metadata.Namespace,
 			Phase:      pod.Status.Phase,
+			Restarts:   restarts,
 			Containers: containers,
-		})
+		}
+		if filter.MatchPod(pod, detail) {
+			result = append(result, detail)
+		}
 	}
 
 	jsonOK(w, result)
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/devops-kubeadjust/backend/middleware"
+	"github.com/devops-kubeadjust/backend/resources"
+)
+
+func TestNodesHandlerKubeletUnavailable(t *testing.T) {
+	for _, tt := range []struct {
+		name        string
+		status      int
+		wantConfigz int32
+	}{
+		{"configz not exposed is cached", http.StatusNotFound, 1},
+		{"RBAC denial is not cached", http.StatusForbidden, 2},
+	} {
+		t.Run(tt.name, func(t *testing.T) { testNodesKubeletUnavailable(t, tt.status, tt.wantConfigz) })
+	}
+}
+
+func testNodesKubeletUnavailable(t *testing.T, status int, wantConfigz int32) {
+	var configzCalls atomic.Int32
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.Contains(r.URL.Path, "/proxy/"):
+			if strings.HasSuffix(r.URL.Path, "/configz") {
+				configzCalls.Add(1)
+			}
+			http.Error(w, `{}`, status)
+		case r.URL.Path == "/api/v1/nodes":
+			_, _ = w.Write([]byte(`{"items": [{"metadata": {"name": "node-a", "labels": {"karpenter.sh/nodepool": "general"}},
+				"status": {"capacity": {"cpu": "4", "memory": "16Gi", "pods": "110"}, "allocatable": {"cpu": "3900m", "memory": "15Gi", "pods": "110"}}}]}`))
+		default:
+			_, _ = w.Write([]byte(`{"items": []}`))
+		}
+	}))
+	defer api.Close()
+	h := middleware.ClusterURL(map[string]string{"test": api.URL})(middleware.BearerToken(NewNodesHandler("")))
+
+	for range 2 {
+		req := httptest.NewRequest("GET", "/api/nodes", nil)
+		req.Header.Set("Authorization", "Bearer token")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		var resp struct {
+			Nodes []resources.NodeOverview `json:"nodes"`
+			Pools []resources.NodePool     `json:"pools"`
+		}
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil || w.Code != http.StatusOK {
+			t.Fatalf("got %d: %v", w.Code, err)
+		}
+		if len(resp.Nodes) != 1 || resp.Nodes[0].Kubelet != nil || resp.Nodes[0].Reserved.KubeReserved != nil ||
+			resp.Nodes[0].Reserved.Total.CPU.Millicores != 100 || len(resp.Pools) != 1 || resp.Pools[0].Name != "general" {
+			t.Errorf("got %+v", resp)
+		}
+	}
+	if n := configzCalls.Load(); n != wantConfigz {
+		t.Errorf("%d configz calls, want %d", n, wantConfigz)
+	}
+}
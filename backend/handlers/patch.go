@@ -0,0 +1,174 @@
+package handlers
+
+import (
+	"log"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/devops-kubeadjust/backend/k8s"
+	"github.com/devops-kubeadjust/backend/middleware"
+	"github.com/devops-kubeadjust/backend/patch"
+	"github.com/devops-kubeadjust/backend/prometheus"
+	"github.com/devops-kubeadjust/backend/report"
+	"github.com/devops-kubeadjust/backend/resources"
+	"github.com/devops-kubeadjust/backend/suggestions"
+)
+
+// NewPatchHandler returns a handler rendering the suggested requests/limits of one workload as
+// a ready-to-apply patch (?format=strategic|json|kustomize|helm-values, default strategic).
+// Usage comes from Prometheus history over ?range (default 7d) when configured, else from a
+// metrics-server snapshot, and thresholds from the cluster/namespace config. Nothing is
+// written to the cluster.
+func NewPatchHandler(promClient *prometheus.Client, thresholds *suggestions.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ns := chi.URLParam(r, "namespace")
+		kind := normalizeKind(chi.URLParam(r, "kind"))
+		name := chi.URLParam(r, "name")
+		format := r.URL.Query().Get("format")
+		if format == "" {
+			format = patch.FormatStrategic
+		}
+		if !patch.ValidFormat(format) {
+			jsonError(w, "format must be one of strategic, json, kustomize, helm-values", http.StatusBadRequest)
+			return
+		}
+		if kind == "" {
+			jsonError(w, "kind must be Deployment, StatefulSet or CronJob", http.StatusBadRequest)
+			return
+		}
+
+		wl, changes, ok := suggestWorkload(w, r, promClient, thresholds, ns, kind, name)
+		if !ok {
+			return
+		}
+		out, err := patch.Render(format, wl, changes)
+		if err != nil {
+			log.Printf("failed to render %s patch for %s/%s: %v", format, ns, name, err)
+			jsonError(w, "internal server error", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", patch.ContentType(format))
+		_, _ = w.Write(out)
+	}
+}
+
+// suggestWorkload fetches a workload's pod template and usage and returns the suggested
+// changes. On failure the error response is written and ok is false.
+func suggestWorkload(w http.ResponseWriter, r *http.Request, promClient *prometheus.Client, thresholds *suggestions.Config, ns, kind, name string) (wl patch.Workload, changes []patch.Change, ok bool) {
+	rangeParam := r.URL.Query().Get("range")
+	if rangeParam != "" && !slices.Contains(prometheus.TimeRanges, rangeParam) {
+		jsonError(w, "range must be 1h, 6h, 24h or 7d", http.StatusBadRequest)
+		return wl, nil, false
+	}
+	client := k8s.New(middleware.TokenFromContext(r.Context()), middleware.ClusterURLFromContext(r.Context()))
+	spec, sizing, err := getPodTemplate(r, client, ns, kind, name)
+	if err != nil {
+		if k8s.IsNotFound(err) {
+			jsonError(w, "workload not found", http.StatusNotFound)
+			return wl, nil, false
+		}
+		log.Printf("failed to get %s %s/%s: %v", kind, ns, name, err)
+		jsonError(w, "internal server error", http.StatusInternalServerError)
+		return wl, nil, false
+	}
+
+	usage, err := workloadUsage(r, client, promClient, ns, resources.WorkloadKey{Kind: kind, Name: name}, rangeParam)
+	if err != nil {
+		log.Printf("failed to collect usage for %s %s/%s: %v", kind, ns, name, err)
+		jsonError(w, "internal server error", http.StatusInternalServerError)
+		return wl, nil, false
+	}
+
+	recs := suggestions.RecommendContainers(*spec, usage, namespaceThresholds(r, client, thresholds, ns), sizing)
+	wl = patch.Workload{Kind: kind, Name: name, Namespace: ns, Containers: spec.Containers}
+	return wl, patch.Changes(recs), true
+}
+
+// normalizeKind maps a URL kind ("deployment", "Deployments", "cronjob", …) to the workload
+// kind used across the API, or "" if unsupported.
+func normalizeKind(k string) string {
+	switch strings.TrimSuffix(strings.ToLower(k), "s") {
+	case "deployment":
+		return "Deployment"
+	case "statefulset":
+		return "StatefulSet"
+	case "cronjob":
+		return "CronJob"
+	}
+	return ""
+}
+
+// getPodTemplate fetches a workload and returns its pod template spec and the sizing policy
+// from its kubeadjust.io annotations (invalid values are logged and ignored).
+func getPodTemplate(r *http.Request, client *k8s.Client, ns, kind, name string) (*k8s.PodSpec, *resources.SizingPolicy, error) {
+	var meta, template k8s.ObjectMeta
+	var spec *k8s.PodSpec
+	switch kind {
+	case "Deployment":
+		d, err := client.GetDeployment(r.Context(), ns, name)
+		if err != nil {
+			return nil, nil, err
+		}
+		meta, template, spec = d.Metadata, d.Spec.Template.Metadata, &d.Spec.Template.Spec
+	case "StatefulSet":
+		s, err := client.GetStatefulSet(r.Context(), ns, name)
+		if err != nil {
+			return nil, nil, err
+		}
+		meta, template, spec = s.Metadata, s.Spec.Template.Metadata, &s.Spec.Template.Spec
+	default:
+		cj, err := client.GetCronJob(r.Context(), ns, name)
+		if err != nil {
+			return nil, nil, err
+		}
+		meta, template, spec = cj.Metadata, cj.Spec.JobTemplate.Spec.Template.Metadata, &cj.Spec.JobTemplate.Spec.Template.Spec
+	}
+	sizing, err := resources.SizingPolicyFor(meta, template)
+	if err != nil {
+		log.Printf("%s %s/%s: %v", kind, ns, name, err)
+	}
+	return spec, sizing, nil
+}
+
+// workloadUsage returns the observed usage per container name across the workload's pods
+// (busiest replica wins), see report.Usage.
+func workloadUsage(r *http.Request, client *k8s.Client, promClient *prometheus.Client, ns string, wk resources.WorkloadKey, rangeParam string) (map[string]suggestions.ContainerUsage, error) {
+	var (
+		pods   *k8s.PodList
+		rsList *k8s.ReplicaSetList
+		jobs   *k8s.JobList
+	)
+	g, ctx := errgroup.WithContext(r.Context())
+	g.Go(func() error {
+		var err error
+		pods, err = client.ListPods(ctx, ns)
+		return err
+	})
+	if wk.Kind == "Deployment" {
+		g.Go(func() error {
+			var err error
+			rsList, err = client.ListReplicaSets(ctx, ns)
+			return err
+		})
+	}
+	if wk.Kind == "CronJob" {
+		g.Go(func() error {
+			var err error
+			jobs, err = client.ListJobs(ctx, ns)
+			return err
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+	owners := resources.BuildOwnerMaps(pods.Items, rsList, jobs)
+	maps.DeleteFunc(owners, func(_ string, key resources.WorkloadKey) bool { return key != wk })
+
+	usage, _ := report.Usage(r.Context(), client, promClient, ns, owners, rangeParam, nil)
+	return usage[wk], nil
+}
@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/devops-kubeadjust/backend/middleware"
+	"github.com/devops-kubeadjust/backend/suggestions"
+)
+
+func TestPatchHandlerRange(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/apis/apps/v1/namespaces/shop/deployments/web" {
+			_, _ = w.Write([]byte(`{"metadata": {"name": "web", "namespace": "shop"},
+				"spec": {"template": {"spec": {"containers": [{"name": "app", "resources": {"requests": {"cpu": "100m"}}}]}}}}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"items": []}`))
+	}))
+	defer api.Close()
+	router := chi.NewRouter()
+	router.Get("/api/namespaces/{namespace}/workloads/{kind}/{name}/patch", NewPatchHandler(nil, suggestions.DefaultConfig()))
+	h := middleware.ClusterURL(map[string]string{"test": api.URL})(middleware.BearerToken(router))
+
+	for query, want := range map[string]int{"": http.StatusOK, "range=6h": http.StatusOK, "range=30d": http.StatusBadRequest} {
+		req := httptest.NewRequest("GET", "/api/namespaces/shop/workloads/deployment/web/patch?"+query, nil)
+		req.Header.Set("Authorization", "Bearer token")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		if w.Code != want {
+			t.Errorf("%q: got %d, want %d: %s", query, w.Code, want, w.Body)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"slices"
+
+	"golang.org/x/sync/errgroup"
+
+	"github.com/devops-kubeadjust/backend/k8s"
+	"github.com/devops-kubeadjust/backend/middleware"
+	"github.com/devops-kubeadjust/backend/prometheus"
+	"github.com/devops-kubeadjust/backend/resources"
+)
+
+// defaultEstimateRange is the Prometheus window used for P95 estimates when ?range is not set.
+const defaultEstimateRange = "7d"
+
+// NewPendingPodsHandler returns a handler reporting unscheduled pods cluster-wide with their
+// requests, the scheduler's PodScheduled message, and whether they would fit on a node if
+// their requests matched the P95 usage of their running siblings.
+// Estimates use Prometheus when configured, and fall back to a metrics-server snapshot.
+func NewPendingPodsHandler(promClient *prometheus.Client) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rangeParam := r.URL.Query().Get("range")
+		if rangeParam == "" {
+			rangeParam = defaultEstimateRange
+		}
+		if !slices.Contains(prometheus.TimeRanges, rangeParam) {
+			jsonError(w, "range must be 1h, 6h, 24h or 7d", http.StatusBadRequest)
+			return
+		}
+		token := middleware.TokenFromContext(r.Context())
+		client := k8s.New(token, middleware.ClusterURLFromContext(r.Context()))
+
+		var nodes *k8s.NodeList
+		var allPods *k8s.PodList
+		g, ctx := errgroup.WithContext(r.Context())
+		g.Go(func() error {
+			var err error
+			nodes, err = client.ListNodes(ctx)
+			return err
+		})
+		g.Go(func() error {
+			var err error
+			allPods, err = client.ListAllPods(ctx)
+			return err
+		})
+		if err := g.Wait(); err != nil {
+			log.Printf("failed to list nodes/pods for pending report: %v", err)
+			jsonError(w, "internal server error", http.StatusInternalServerError)
+			return
+		}
+
+		// Only namespaces with pending pods need usage data.
+		pendingNS := map[string]struct{}{}
+		for _, pod := range allPods.Items {
+			if resources.IsPending(pod) {
+				pendingNS[pod.Metadata.Namespace] = struct{}{}
+			}
+		}
+
+		var usage usageIndex
+		source := ""
+		if len(pendingNS) > 0 {
+			if promClient != nil {
+				usage = p95Usage(promClient, pendingNS, prometheus.ParseTimeRange(rangeParam))
+				source = "prometheus"
+			} else if pm, err := client.ListAllPodMetrics(r.Context()); err == nil {
+				usage = snapshotUsage(pm, pendingNS)
+				source = "metrics-server"
+			} else {
+				log.Printf("pod metrics unavailable for pending report: %v", err)
+			}
+		}
+
+		resp := resources.PendingResponse{
+			Nodes:               resources.FreeAllocatable(nodes.Items, allPods.Items),
+			PrometheusAvailable: promClient != nil,
+		}
+		resp.Pods = resources.BuildPendingPods(allPods.Items, resp.Nodes, usage, source)
+		if source == "prometheus" {
+			resp.EstimateRange = rangeParam
+		}
+		jsonOK(w, resp)
+	}
+}
@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/devops-kubeadjust/backend/middleware"
+)
+
+func TestPendingPodsHandlerRange(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"items": []}`))
+	}))
+	defer api.Close()
+	h := middleware.ClusterURL(map[string]string{"test": api.URL})(middleware.BearerToken(NewPendingPodsHandler(nil)))
+
+	for query, want := range map[string]int{"": http.StatusOK, "range=24h": http.StatusOK, "range=30d": http.StatusBadRequest, "range=1d": http.StatusBadRequest} {
+		req := httptest.NewRequest("GET", "/api/pods/pending?"+query, nil)
+		req.Header.Set("Authorization", "Bearer token")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		if w.Code != want {
+			t.Errorf("%q: got %d, want %d", query, w.Code, want)
+		}
+	}
+}
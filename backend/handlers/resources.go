@@ -1,87 +1,67 @@
 package handlers
 
 import (
+	"context"
 	"log"
 	"net/http"
 	"os"
+	"slices"
 	"sync"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"golang.org/x/sync/errgroup"
 
 	"github.com/devops-kubeadjust/backend/k8s"
 	"github.com/devops-kubeadjust/backend/middleware"
+	"github.com/devops-kubeadjust/backend/pricing"
 	"github.com/devops-kubeadjust/backend/resources"
 )
 
-// ListDeployments fetches all workloads (Deployments, StatefulSets, CronJobs) in a namespace
-// along with per-container CPU/memory metrics, ephemeral storage, and PVC details.
-func ListDeployments(w http.ResponseWriter, r *http.Request) {
-	ns := chi.URLParam(r, "namespace")
-	token := middleware.TokenFromContext(r.Context())
-	client := k8s.New(token, middleware.ClusterURLFromContext(r.Context()))
+// recentEventWindow bounds how old a Warning event may be to still be attached to a workload.
+const recentEventWindow = 24 * time.Hour
 
-	// 1. Fetch pods once
-	podList, err := client.ListPods(r.Context(), ns)
+// NewDeploymentsHandler returns a handler listing all workloads (Deployments, StatefulSets,
+// CronJobs) in a namespace along with per-container CPU/memory metrics, ephemeral storage,
+// PVC details, recent Warning events explaining scheduling failures, evictions and OOM kills,
+// and the namespace's ResourceQuotas (so suggestions that would exceed them can be flagged).
+// When prices is non-nil, each container and workload also carries its monthly cost.
+//
+// Workloads can be filtered with ?labelSelector= (workload labels, passed to the API server),
+// ?kind=Deployment,StatefulSet, ?name= (prefix) and ?status= (see resources.WorkloadStatuses).
+func NewDeploymentsHandler(prices *pricing.Table) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		listDeployments(w, r, prices)
+	}
+}
+
+func listDeployments(w http.ResponseWriter, r *http.Request, prices *pricing.Table) {
+	ns := chi.URLParam(r, "namespace")
+	filter, err := resources.ParseFilter(r.URL.Query(), resources.WorkloadKinds, resources.WorkloadStatuses)
 	if err != nil {
-		log.Printf("failed to list pods in %s: %v", ns, err)
-		jsonError(w, "internal server error", http.StatusInternalServerError)
+		jsonError(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	token := middleware.TokenFromContext(r.Context())
+	client := k8s.New(token, middleware.ClusterURLFromContext(r.Context()))
 
-	// 2. Fetch workload types + auxiliary data in parallel
+	// 1. Fetch pods, workload types and auxiliary data in parallel
 	var (
-		deployments  *k8s.DeploymentList
-		statefulSets *k8s.StatefulSetList
-		cronJobs     *k8s.CronJobList
-		rsList       *k8s.ReplicaSetList
-		jobs         *k8s.JobList
-		podMetrics   *k8s.PodMetricsList
-		pvcList      *k8s.PVCList
+		podList    *k8s.PodList
+		objs       *namespaceObjects
+		podMetrics *k8s.PodMetricsList
+		nodes      *k8s.NodeList
 	)
-
 	g, ctx := errgroup.WithContext(r.Context())
-
 	g.Go(func() error {
 		var err error
-		deployments, err = client.ListDeployments(ctx, ns)
-		return err // required — fail if deployments can't load
+		podList, err = client.ListPods(ctx, ns)
+		return err // required
 	})
 	g.Go(func() error {
-		ss, err := client.ListStatefulSets(ctx, ns)
-		if err != nil {
-			log.Printf("failed to list statefulsets in %s: %v", ns, err)
-			return nil
-		}
-		statefulSets = ss
-		return nil
-	})
-	g.Go(func() error {
-		cj, err := client.ListCronJobs(ctx, ns)
-		if err != nil {
-			log.Printf("failed to list cronjobs in %s: %v", ns, err)
-			return nil
-		}
-		cronJobs = cj
-		return nil
-	})
-	g.Go(func() error {
-		rs, err := client.ListReplicaSets(ctx, ns)
-		if err != nil {
-			log.Printf("failed to list replicasets in %s: %v", ns, err)
-			return nil
-		}
-		rsList = rs
-		return nil
-	})
-	g.Go(func() error {
-		jl, err := client.ListJobs(ctx, ns)
-		if err != nil {
-			log.Printf("failed to list jobs in %s: %v", ns, err)
-			return nil
-		}
-		jobs = jl
-		return nil
+		var err error
+		objs, err = listNamespaceObjects(ctx, client, ns, filter)
+		return err
 	})
 	g.Go(func() error {
 		pm, err := client.ListPodMetrics(ctx, ns)
@@ -92,59 +72,142 @@ func ListDeployments(w http.ResponseWriter, r *http.Request) {
 		podMetrics = pm
 		return nil
 	})
-	g.Go(func() error {
-		pvcs, err := client.ListPVCs(ctx, ns)
-		if err != nil {
-			log.Printf("failed to list PVCs in %s: %v", ns, err)
+	if prices != nil && prices.ByInstanceType() {
+		g.Go(func() error {
+			nodes = listNodesForPricing(ctx, client)
 			return nil
-		}
-		pvcList = pvcs
-		return nil
-	})
-
+		})
+	}
 	if err := g.Wait(); err != nil {
+		if k8s.IsBadRequest(err) {
+			jsonError(w, "invalid labelSelector", http.StatusBadRequest)
+			return
+		}
 		log.Printf("failed to fetch workloads in %s: %v", ns, err)
 		jsonError(w, "internal server error", http.StatusInternalServerError)
 		return
 	}
 
-	// 3. Build pod → workload ownership map
-	podToWorkload := resources.BuildOwnerMaps(podList.Items, rsList, jobs)
+	// 2. Fetch node summaries for storage stats (best-effort)
+	storage := podStorage(r.Context(), client, podList.Items, map[string]bool{ns: true})
 
-	// 4. Build metrics lookup
-	metricsMap := map[string]map[string]k8s.ContainerUsage{}
-	metricsAvailable := podMetrics != nil
+	var metricsMap map[string]map[string]k8s.ContainerUsage
 	if podMetrics != nil {
-		for _, pm := range podMetrics.Items {
-			m := map[string]k8s.ContainerUsage{}
-			for _, cu := range pm.Containers {
-				m[cu.Name] = cu
+		metricsMap = metricsByPod(podMetrics.Items)
+	}
+	result, quotaStatus := buildWorkloads(ns, objs, podList.Items, metricsMap, storage[ns], prices, nodes, filter)
+	jsonOK(w, resources.WorkloadResponse{
+		Workloads:           result,
+		MetricsAvailable:    podMetrics != nil,
+		PrometheusAvailable: os.Getenv("PROMETHEUS_URL") != "",
+		Quotas:              quotaStatus,
+	})
+}
+
+// namespaceObjects are the namespace-scoped lists a workload response is built from, besides
+// pods and pod metrics. The lists are nil or empty when they could not be fetched, or when
+// the filter excludes their kind.
+type namespaceObjects struct {
+	deployments  *k8s.DeploymentList
+	statefulSets *k8s.StatefulSetList
+	cronJobs     *k8s.CronJobList
+	rsList       *k8s.ReplicaSetList
+	jobs         *k8s.JobList
+	pvcList      *k8s.PVCList
+	events       *k8s.EventList
+	quotas       *k8s.ResourceQuotaList
+}
+
+// listNamespaceObjects fetches the workload lists of ns in parallel, passing the filter's
+// label selector to the API server and skipping the kinds it excludes. Deployments are
+// required; the other lookups are best-effort.
+func listNamespaceObjects(ctx context.Context, client *k8s.Client, ns string, f resources.Filter) (*namespaceObjects, error) {
+	var o namespaceObjects
+	wants := func(kind string) bool { return len(f.Kinds) == 0 || slices.Contains(f.Kinds, kind) }
+	g, ctx := errgroup.WithContext(ctx)
+	if wants("Deployment") {
+		g.Go(func() error {
+			var err error
+			o.deployments, err = client.ListDeploymentsSelector(ctx, ns, f.LabelSelector)
+			return err // required — fail if deployments can't load
+		})
+	}
+	bestEffort := func(what string, fetch func() error) {
+		g.Go(func() error {
+			if err := fetch(); err != nil {
+				log.Printf("failed to list %s in %s: %v", what, ns, err)
 			}
-			metricsMap[pm.Metadata.Name] = m
+			return nil
+		})
+	}
+	if wants("StatefulSet") {
+		bestEffort("statefulsets", func() (err error) {
+			o.statefulSets, err = client.ListStatefulSetsSelector(ctx, ns, f.LabelSelector)
+			return
+		})
+	}
+	if wants("CronJob") {
+		bestEffort("cronjobs", func() (err error) { o.cronJobs, err = client.ListCronJobsSelector(ctx, ns, f.LabelSelector); return })
+	}
+	bestEffort("replicasets", func() (err error) { o.rsList, err = client.ListReplicaSets(ctx, ns); return })
+	bestEffort("jobs", func() (err error) { o.jobs, err = client.ListJobs(ctx, ns); return })
+	bestEffort("PVCs", func() (err error) { o.pvcList, err = client.ListPVCs(ctx, ns); return })
+	bestEffort("events", func() (err error) { o.events, err = client.ListEvents(ctx, ns, "Warning"); return })
+	bestEffort("resource quotas", func() (err error) { o.quotas, err = client.ListResourceQuotas(ctx, ns); return })
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+	return &o, nil
+}
+
+// listNodesForPricing lists nodes to price containers by instance type. Returns nil (the
+// default rate applies) when nodes cannot be listed.
+func listNodesForPricing(ctx context.Context, client *k8s.Client) *k8s.NodeList {
+	nl, err := client.ListNodes(ctx)
+	if err != nil {
+		log.Printf("failed to list nodes for pricing: %v", err)
+		return nil
+	}
+	return nl
+}
+
+// metricsByPod indexes pod metrics by pod name, then container name.
+func metricsByPod(items []k8s.PodMetrics) map[string]map[string]k8s.ContainerUsage {
+	out := make(map[string]map[string]k8s.ContainerUsage, len(items))
+	for _, pm := range items {
+		m := make(map[string]k8s.ContainerUsage, len(pm.Containers))
+		for _, cu := range pm.Containers {
+			m[cu.Name] = cu
 		}
+		out[pm.Metadata.Name] = m
 	}
+	return out
+}
 
-	// 5. Fetch node summaries in parallel for storage stats (best-effort)
+// podStorage fetches the kubelet summaries of the nodes running pods, in parallel, and
+// returns the storage stats of the pods of namespaces, keyed by namespace then pod name.
+// Best-effort: nodes whose kubelet cannot be reached are skipped.
+func podStorage(ctx context.Context, client *k8s.Client, pods []k8s.Pod, namespaces map[string]bool) map[string]map[string]resources.PodStorageStats {
 	nodeNames := map[string]struct{}{}
-	for _, pod := range podList.Items {
+	for _, pod := range pods {
 		if pod.Spec.NodeName != "" {
 			nodeNames[pod.Spec.NodeName] = struct{}{}
 		}
 	}
-	podStorageMap := map[string]resources.PodStorageStats{}
-	var storageMu sync.Mutex
-	storageG, storageCtx := errgroup.WithContext(r.Context())
-	storageG.SetLimit(5) // bound concurrent kubelet calls to avoid kubelet overload
+	out := map[string]map[string]resources.PodStorageStats{}
+	var mu sync.Mutex
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(5) // bound concurrent kubelet calls to avoid kubelet overload
 	for node := range nodeNames {
-		storageG.Go(func() error {
-			summary, err := client.GetNodeSummary(storageCtx, node)
+		g.Go(func() error {
+			summary, err := client.GetNodeSummary(gctx, node)
 			if err != nil {
 				return nil // best-effort
 			}
-			storageMu.Lock()
-			defer storageMu.Unlock()
+			mu.Lock()
+			defer mu.Unlock()
 			for _, ps := range summary.Pods {
-				if ps.PodRef.Namespace != ns {
+				if !namespaces[ps.PodRef.Namespace] {
 					continue
 				}
 				stats := resources.PodStorageStats{
@@ -164,93 +227,135 @@ func ListDeployments(w http.ResponseWriter, r *http.Request) {
 				for _, vs := range ps.Volumes {
 					stats.Volumes[vs.Name] = vs
 				}
-				podStorageMap[ps.PodRef.Name] = stats
+				if out[ps.PodRef.Namespace] == nil {
+					out[ps.PodRef.Namespace] = map[string]resources.PodStorageStats{}
+				}
+				out[ps.PodRef.Namespace][ps.PodRef.Name] = stats
 			}
 			return nil
 		})
 	}
-	if err := storageG.Wait(); err != nil {
-		log.Printf("storage stat error: %v", err)
+	_ = g.Wait()
+	return out
+}
+
+// buildWorkloads assembles the Deployments, StatefulSets and CronJobs of ns that pass f with
+// their pods, metrics, storage, recent Warning events and costs, and the namespace's
+// ResourceQuotas. metricsMap and podStorageMap are keyed by pod name (nil when unavailable);
+// nodes prices pods by instance type when set.
+func buildWorkloads(ns string, objs *namespaceObjects, pods []k8s.Pod, metricsMap map[string]map[string]k8s.ContainerUsage,
+	podStorageMap map[string]resources.PodStorageStats, prices *pricing.Table, nodes *k8s.NodeList, f resources.Filter) ([]resources.DeploymentDetail, []resources.QuotaStatus) {
+	if metricsMap == nil {
+		metricsMap = map[string]map[string]k8s.ContainerUsage{}
 	}
+	if podStorageMap == nil {
+		podStorageMap = map[string]resources.PodStorageStats{}
+	}
+
+	// Build pod → workload ownership map
+	podToWorkload := resources.BuildOwnerMaps(pods, objs.rsList, objs.jobs)
 
-	// 6. Build PVC lookup
+	// Build PVC lookup
 	pvcMap := map[string]k8s.PVC{}
-	if pvcList != nil {
-		for _, pvc := range pvcList.Items {
+	if objs.pvcList != nil {
+		for _, pvc := range objs.pvcList.Items {
 			pvcMap[pvc.Metadata.Name] = pvc
 		}
 	}
 
-	// 7. Group pods by workload
+	// Group pods by workload
 	podsByWorkload := map[resources.WorkloadKey][]k8s.Pod{}
-	for _, pod := range podList.Items {
+	for _, pod := range pods {
 		if wk, ok := podToWorkload[pod.Metadata.Name]; ok {
 			podsByWorkload[wk] = append(podsByWorkload[wk], pod)
 		}
 	}
 
-	// 8. Build response — Deployments, StatefulSets, CronJobs
-	var result []resources.DeploymentDetail
+	// Deployments, StatefulSets, CronJobs
+	result := []resources.DeploymentDetail{}
+	add := func(d resources.DeploymentDetail, meta, template k8s.ObjectMeta, spec k8s.PodSpec) {
+		d.Namespace = ns
+		d.Pods = resources.BuildPodDetails(podsByWorkload[resources.WorkloadKey{Kind: d.Kind, Name: d.Name}], metricsMap, podStorageMap, pvcMap)
+		if !f.MatchWorkload(d, spec) {
+			return
+		}
+		d.Sizing = sizingPolicy(d.Kind, meta, template)
+		result = append(result, d)
+	}
 
-	for _, dep := range deployments.Items {
-		wk := resources.WorkloadKey{Kind: "Deployment", Name: dep.Metadata.Name}
-		pods := resources.BuildPodDetails(podsByWorkload[wk], metricsMap, podStorageMap, pvcMap)
-		result = append(result, resources.DeploymentDetail{
-			Kind:              "Deployment",
-			Name:              dep.Metadata.Name,
-			Namespace:         dep.Metadata.Namespace,
-			Replicas:          dep.Spec.Replicas,
-			ReadyReplicas:     dep.Status.ReadyReplicas,
-			AvailableReplicas: dep.Status.AvailableReplicas,
-			Pods:              pods,
-		})
+	if objs.deployments != nil {
+		for _, dep := range objs.deployments.Items {
+			add(resources.DeploymentDetail{
+				Kind:              "Deployment",
+				Name:              dep.Metadata.Name,
+				Replicas:          dep.Spec.Replicas,
+				ReadyReplicas:     dep.Status.ReadyReplicas,
+				AvailableReplicas: dep.Status.AvailableReplicas,
+			}, dep.Metadata, dep.Spec.Template.Metadata, dep.Spec.Template.Spec)
+		}
 	}
 
-	if statefulSets != nil {
-		for _, ss := range statefulSets.Items {
-			wk := resources.WorkloadKey{Kind: "StatefulSet", Name: ss.Metadata.Name}
-			pods := resources.BuildPodDetails(podsByWorkload[wk], metricsMap, podStorageMap, pvcMap)
+	if objs.statefulSets != nil {
+		for _, ss := range objs.statefulSets.Items {
 			avail := ss.Status.AvailableReplicas
 			if avail == 0 {
 				avail = ss.Status.CurrentReplicas
 			}
-			result = append(result, resources.DeploymentDetail{
+			add(resources.DeploymentDetail{
 				Kind:              "StatefulSet",
 				Name:              ss.Metadata.Name,
-				Namespace:         ns,
 				Replicas:          ss.Spec.Replicas,
 				ReadyReplicas:     ss.Status.ReadyReplicas,
 				AvailableReplicas: avail,
-				Pods:              pods,
-			})
+			}, ss.Metadata, ss.Spec.Template.Metadata, ss.Spec.Template.Spec)
 		}
 	}
 
-	if cronJobs != nil {
-		for _, cj := range cronJobs.Items {
-			wk := resources.WorkloadKey{Kind: "CronJob", Name: cj.Metadata.Name}
-			pods := resources.BuildPodDetails(podsByWorkload[wk], metricsMap, podStorageMap, pvcMap)
+	if objs.cronJobs != nil {
+		for _, cj := range objs.cronJobs.Items {
 			active := int32(len(cj.Status.Active))
-			result = append(result, resources.DeploymentDetail{
+			add(resources.DeploymentDetail{
 				Kind:              "CronJob",
 				Name:              cj.Metadata.Name,
-				Namespace:         ns,
 				Replicas:          active,
 				ReadyReplicas:     active,
 				AvailableReplicas: active,
-				Pods:              pods,
-			})
+			}, cj.Metadata, cj.Spec.JobTemplate.Spec.Template.Metadata, cj.Spec.JobTemplate.Spec.Template.Spec)
 		}
 	}
 
-	if result == nil {
-		result = []resources.DeploymentDetail{}
+	// Attach recent sizing-related Warning events (best-effort)
+	if objs.events != nil {
+		eventIdx := resources.IndexSizingEvents(objs.events.Items, time.Now(), recentEventWindow)
+		for i := range result {
+			resources.AttachEvents(&result[i], eventIdx)
+		}
 	}
-	jsonOK(w, resources.WorkloadResponse{
-		Workloads:           result,
-		MetricsAvailable:    metricsAvailable,
-		PrometheusAvailable: os.Getenv("PROMETHEUS_URL") != "",
-	})
+
+	// Price containers by the instance type of the node they run on
+	if prices != nil {
+		var rates map[string]pricing.Rate
+		if nodes != nil {
+			rates = prices.NodeRates(nodes.Items)
+		}
+		nodeOf := make(map[string]string, len(pods))
+		for _, pod := range pods {
+			nodeOf[pod.Metadata.Name] = pod.Spec.NodeName
+		}
+		for i := range result {
+			prices.ApplyWorkload(&result[i], nodeOf, rates)
+		}
+	}
+
+	var quotaStatus []resources.QuotaStatus
+	if objs.quotas != nil {
+		for _, q := range objs.quotas.Items {
+			if qs, ok := resources.BuildQuotaStatus(q); ok {
+				quotaStatus = append(quotaStatus, qs)
+			}
+		}
+	}
+	return result, quotaStatus
 }
 
 // GetPodMetrics proxies raw pod metrics from metrics-server. Useful for debugging.
@@ -265,3 +370,12 @@ func GetPodMetrics(w http.ResponseWriter, r *http.Request) {
 	}
 	jsonOK(w, metrics)
 }
+
+// sizingPolicy reads the kubeadjust.io annotations of a workload, logging invalid values.
+func sizingPolicy(kind string, meta, template k8s.ObjectMeta) *resources.SizingPolicy {
+	p, err := resources.SizingPolicyFor(meta, template)
+	if err != nil {
+		log.Printf("%s %s/%s: %v", kind, meta.Namespace, meta.Name, err)
+	}
+	return p
+}
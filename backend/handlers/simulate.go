@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"slices"
+
+	"golang.org/x/sync/errgroup"
+
+	"github.com/devops-kubeadjust/backend/k8s"
+	"github.com/devops-kubeadjust/backend/middleware"
+	"github.com/devops-kubeadjust/backend/prometheus"
+	"github.com/devops-kubeadjust/backend/resources"
+	"github.com/devops-kubeadjust/backend/simulator"
+	"github.com/devops-kubeadjust/backend/suggestions"
+)
+
+// maxScenarioBytes caps the size of a simulation request body.
+const maxScenarioBytes = 64 << 10 // 64 KB
+
+// NewPackingSimulationHandler returns a handler that re-packs every pod onto the current nodes
+// with either their current requests or the requests the suggestions would set, and reports
+// how many nodes are needed, which could be freed, and how much capacity stays stranded.
+// Recommended requests use Prometheus P95 when configured, else a metrics-server snapshot,
+// and each namespace's thresholds.
+func NewPackingSimulationHandler(promClient *prometheus.Client, thresholds *suggestions.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var sc simulator.Scenario
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxScenarioBytes)).Decode(&sc); err != nil && !errors.Is(err, io.EOF) {
+			jsonError(w, "invalid scenario body", http.StatusBadRequest)
+			return
+		}
+		if sc.Requests == "" {
+			sc.Requests = "current"
+		}
+		if sc.Requests != "current" && sc.Requests != "recommended" {
+			jsonError(w, `requests must be "current" or "recommended"`, http.StatusBadRequest)
+			return
+		}
+		if sc.Range == "" {
+			sc.Range = defaultEstimateRange
+		}
+		if !slices.Contains(prometheus.TimeRanges, sc.Range) {
+			jsonError(w, "range must be 1h, 6h, 24h or 7d", http.StatusBadRequest)
+			return
+		}
+
+		token := middleware.TokenFromContext(r.Context())
+		client := k8s.New(token, middleware.ClusterURLFromContext(r.Context()))
+
+		var nodes *k8s.NodeList
+		var allPods *k8s.PodList
+		g, ctx := errgroup.WithContext(r.Context())
+		g.Go(func() error {
+			var err error
+			nodes, err = client.ListNodes(ctx)
+			return err
+		})
+		g.Go(func() error {
+			var err error
+			allPods, err = client.ListAllPods(ctx)
+			return err
+		})
+		if err := g.Wait(); err != nil {
+			log.Printf("failed to list nodes/pods for packing simulation: %v", err)
+			jsonError(w, "internal server error", http.StatusInternalServerError)
+			return
+		}
+
+		reqs := simulator.ContainerRequests(simulator.CurrentRequests)
+		if sc.Requests == "recommended" {
+			var usage usageIndex
+			if promClient != nil {
+				usage = p95Usage(promClient, nil, prometheus.ParseTimeRange(sc.Range))
+			} else if pm, err := client.ListAllPodMetrics(r.Context()); err == nil {
+				usage = snapshotUsage(pm, nil)
+			} else {
+				log.Printf("pod metrics unavailable for packing simulation: %v", err)
+				jsonError(w, "no usage data available for recommended requests", http.StatusServiceUnavailable)
+				return
+			}
+			reqs = recommendedRequests(usage, clusterThresholds(r, client, thresholds), allPods.Items)
+		}
+
+		overviews := resources.BuildNodeOverviews(nodes, allPods, nil)
+		result := simulator.Pack(overviews, simulator.FromPods(allPods.Items, reqs), sc.ExcludeNodes)
+		result.Requests = sc.Requests
+		jsonOK(w, result)
+	}
+}
+
+// recommendedRequests returns the requests the suggestions would set for each container,
+// keeping the current request when no usage was observed. Only P95 is known here, so it also
+// stands in for mean usage: reductions are slightly more conservative than in the dashboard.
+// Pods carry their template's kubeadjust.io annotations, which are honored.
+func recommendedRequests(usage usageIndex, thresholds func(namespace string) suggestions.Thresholds, pods []k8s.Pod) simulator.ContainerRequests {
+	sizing := map[string]*resources.SizingPolicy{}
+	for _, pod := range pods {
+		if p, _ := resources.SizingPolicyFor(k8s.ObjectMeta{}, pod.Metadata); p != nil {
+			sizing[pod.Metadata.Namespace+"/"+pod.Metadata.Name] = p
+		}
+	}
+	return func(namespace, pod string, c k8s.Container) (int64, int64) {
+		u, ok := usage[namespace][pod][c.Name]
+		if !ok {
+			return simulator.CurrentRequests(namespace, pod, c)
+		}
+		cu := map[string]suggestions.ContainerUsage{c.Name: {
+			CPU:    suggestions.SnapshotUsage(float64(u.CPUMillicores)),
+			Memory: suggestions.SnapshotUsage(float64(u.MemoryBytes)),
+		}}
+		rec := suggestions.RecommendContainers(k8s.PodSpec{Containers: []k8s.Container{c}}, cu, thresholds(namespace), sizing[namespace+"/"+pod])[0]
+		return rec.CPU.Request, rec.Memory.Request
+	}
+}
+
+// clusterThresholds returns the thresholds of every namespace of the cluster. Namespaces are
+// listed best-effort: on failure (or for unlisted namespaces) the cluster thresholds apply.
+func clusterThresholds(r *http.Request, client *k8s.Client, cfg *suggestions.Config) func(namespace string) suggestions.Thresholds {
+	cluster := clusterName(r)
+	def, _ := cfg.ForCluster(cluster)
+	byNamespace := map[string]suggestions.Thresholds{}
+	list, err := client.ListNamespaces(r.Context())
+	if err != nil {
+		log.Printf("failed to list namespaces for thresholds: %v", err)
+		list = &k8s.NamespaceList{}
+	}
+	for _, ns := range list.Items {
+		th, _, err := cfg.ForNamespace(cluster, ns.Metadata.Annotations)
+		if err != nil {
+			log.Printf("namespace %s: %v", ns.Metadata.Name, err)
+		}
+		byNamespace[ns.Metadata.Name] = th
+	}
+	return func(namespace string) suggestions.Thresholds {
+		if th, ok := byNamespace[namespace]; ok {
+			return th
+		}
+		return def
+	}
+}
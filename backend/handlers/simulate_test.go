@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/devops-kubeadjust/backend/middleware"
+)
+
+func TestPackingSimulationHandlerValidation(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"items": []}`))
+	}))
+	defer api.Close()
+	h := middleware.ClusterURL(map[string]string{"test": api.URL})(middleware.BearerToken(NewPackingSimulationHandler(nil, nil)))
+
+	for body, want := range map[string]int{
+		``:                        http.StatusOK,
+		`{"range": "24h"}`:        http.StatusOK,
+		`{"range": "30d"}`:        http.StatusBadRequest,
+		`{"requests": "optimal"}`: http.StatusBadRequest,
+	} {
+		req := httptest.NewRequest("POST", "/api/simulate/packing", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer token")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		if w.Code != want {
+			t.Errorf("%q: got %d, want %d", body, w.Code, want)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/devops-kubeadjust/backend/k8s"
+	"github.com/devops-kubeadjust/backend/middleware"
+	"github.com/devops-kubeadjust/backend/suggestions"
+)
+
+// ThresholdsResponse is the effective thresholds for the requested cluster (and namespace).
+type ThresholdsResponse struct {
+	suggestions.Thresholds
+	Source    string `json:"source"` // global | cluster | namespace
+	Cluster   string `json:"cluster"`
+	Namespace string `json:"namespace,omitempty"`
+	Error     string `json:"error,omitempty"` // invalid namespace annotation, ignored
+}
+
+// NewThresholdsHandler returns a handler serving the status thresholds the dashboard should
+// use: the cluster's (X-Cluster) thresholds, overridden by the kubeadjust.io/thresholds
+// annotation of ?namespace when given.
+func NewThresholdsHandler(cfg *suggestions.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		resp := ThresholdsResponse{Cluster: clusterName(r), Namespace: r.URL.Query().Get("namespace")}
+		if resp.Namespace == "" {
+			resp.Thresholds, resp.Source = cfg.ForCluster(resp.Cluster)
+			jsonOK(w, resp)
+			return
+		}
+		client := k8s.New(middleware.TokenFromContext(r.Context()), middleware.ClusterURLFromContext(r.Context()))
+		ns, err := client.GetNamespace(r.Context(), resp.Namespace)
+		if err != nil {
+			if k8s.IsNotFound(err) {
+				jsonError(w, "namespace not found", http.StatusNotFound)
+				return
+			}
+			log.Printf("failed to get namespace %s: %v", resp.Namespace, err)
+			jsonError(w, "internal server error", http.StatusInternalServerError)
+			return
+		}
+		resp.Thresholds, resp.Source, err = cfg.ForNamespace(resp.Cluster, ns.Metadata.Annotations)
+		if err != nil {
+			resp.Error = err.Error()
+		}
+		jsonOK(w, resp)
+	}
+}
+
+// namespaceThresholds returns the thresholds of one namespace for server-side suggestions.
+// The namespace is read best-effort: on failure the cluster thresholds apply.
+func namespaceThresholds(r *http.Request, client *k8s.Client, cfg *suggestions.Config, namespace string) suggestions.Thresholds {
+	ns, err := client.GetNamespace(r.Context(), namespace)
+	if err != nil {
+		log.Printf("failed to get namespace %s for thresholds: %v", namespace, err)
+		th, _ := cfg.ForCluster(clusterName(r))
+		return th
+	}
+	th, _, err := cfg.ForNamespace(clusterName(r), ns.Metadata.Annotations)
+	if err != nil {
+		log.Printf("namespace %s: %v", namespace, err)
+	}
+	return th
+}
+
+// clusterName is the cluster selected by the X-Cluster header, "default" when absent.
+func clusterName(r *http.Request) string {
+	if name := r.Header.Get("X-Cluster"); name != "" {
+		return name
+	}
+	return "default"
+}
@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+
+	"golang.org/x/sync/errgroup"
+
+	"github.com/devops-kubeadjust/backend/k8s"
+	"github.com/devops-kubeadjust/backend/middleware"
+	"github.com/devops-kubeadjust/backend/resources"
+)
+
+// GetTopology returns the allocatable, requested and used resources of each zone, flags
+// zones much fuller than the others, and lists the workloads whose replicas all run in one
+// zone (see resources.BuildTopology).
+func GetTopology(w http.ResponseWriter, r *http.Request) {
+	token := middleware.TokenFromContext(r.Context())
+	client := k8s.New(token, middleware.ClusterURLFromContext(r.Context()))
+
+	var nodes *k8s.NodeList
+	var allPods *k8s.PodList
+	g, ctx := errgroup.WithContext(r.Context())
+	g.Go(func() error {
+		var err error
+		nodes, err = client.ListNodes(ctx)
+		return err
+	})
+	g.Go(func() error {
+		var err error
+		allPods, err = client.ListAllPods(ctx)
+		return err
+	})
+	if err := g.Wait(); err != nil {
+		log.Printf("failed to list nodes/pods for topology: %v", err)
+		jsonError(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	// Node metrics (best-effort)
+	var nodeMetrics *k8s.NodeMetricsList
+	if nm, err := client.ListNodeMetrics(r.Context()); err == nil {
+		nodeMetrics = nm
+	}
+
+	jsonOK(w, resources.BuildTopology(resources.BuildNodeOverviews(nodes, allPods, nodeMetrics), allPods.Items))
+}
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"log"
+	"sync"
+
+	"golang.org/x/sync/errgroup"
+
+	"github.com/devops-kubeadjust/backend/k8s"
+	"github.com/devops-kubeadjust/backend/prometheus"
+	"github.com/devops-kubeadjust/backend/resources"
+)
+
+// usageIndex maps namespace → pod → container → observed usage.
+type usageIndex = map[string]map[string]map[string]resources.UsageEstimate
+
+// p95Usage queries P95 usage per container from Prometheus (best-effort).
+// A nil namespaces set queries the whole cluster in one go; otherwise one query pair per
+// namespace is issued with bounded concurrency.
+func p95Usage(promClient *prometheus.Client, namespaces map[string]struct{}, tr prometheus.TimeRange) usageIndex {
+	usage := usageIndex{}
+	var mu sync.Mutex
+	add := func(p95 []prometheus.ContainerP95) {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, c := range p95 {
+			if usage[c.Namespace] == nil {
+				usage[c.Namespace] = map[string]map[string]resources.UsageEstimate{}
+			}
+			if usage[c.Namespace][c.Pod] == nil {
+				usage[c.Namespace][c.Pod] = map[string]resources.UsageEstimate{}
+			}
+			usage[c.Namespace][c.Pod][c.Container] = resources.UsageEstimate{
+				CPUMillicores: int64(c.CPU),
+				MemoryBytes:   int64(c.Memory),
+			}
+		}
+	}
+
+	if namespaces == nil {
+		p95, err := promClient.GetClusterP95(tr)
+		if err != nil {
+			log.Printf("prometheus cluster P95 query failed: %v", err)
+			return usage
+		}
+		add(p95)
+		return usage
+	}
+
+	g := new(errgroup.Group)
+	g.SetLimit(5)
+	for ns := range namespaces {
+		if !resources.IsValidLabelValue(ns) {
+			continue
+		}
+		g.Go(func() error {
+			p95, err := promClient.GetNamespaceP95(ns, tr)
+			if err != nil {
+				log.Printf("prometheus P95 query failed for %s: %v", ns, err)
+				return nil // best-effort
+			}
+			add(p95)
+			return nil
+		})
+	}
+	_ = g.Wait()
+	return usage
+}
+
+// snapshotUsage converts metrics-server pod metrics into a usageIndex.
+// A nil namespaces set keeps every namespace.
+func snapshotUsage(pm *k8s.PodMetricsList, namespaces map[string]struct{}) usageIndex {
+	usage := usageIndex{}
+	for _, m := range pm.Items {
+		ns := m.Metadata.Namespace
+		if namespaces != nil {
+			if _, ok := namespaces[ns]; !ok {
+				continue
+			}
+		}
+		if usage[ns] == nil {
+			usage[ns] = map[string]map[string]resources.UsageEstimate{}
+		}
+		containers := make(map[string]resources.UsageEstimate, len(m.Containers))
+		for _, c := range m.Containers {
+			containers[c.Name] = resources.UsageEstimate{
+				CPUMillicores: resources.ParseCPUMillicores(c.Usage["cpu"]),
+				MemoryBytes:   resources.ParseMemoryBytes(c.Usage["memory"]),
+			}
+		}
+		usage[ns][m.Metadata.Name] = containers
+	}
+	return usage
+}
@@ -0,0 +1,205 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+
+	"golang.org/x/sync/errgroup"
+
+	"github.com/devops-kubeadjust/backend/k8s"
+	"github.com/devops-kubeadjust/backend/middleware"
+	"github.com/devops-kubeadjust/backend/pricing"
+	"github.com/devops-kubeadjust/backend/resources"
+)
+
+// maxWorkloadNamespaces bounds the namespaces of one cross-namespace workload query.
+const maxWorkloadNamespaces = 100
+
+// NewWorkloadsHandler returns a handler listing the workloads of several namespaces in one
+// WorkloadResponse, like NewDeploymentsHandler does for one: ?namespaces=a,b,c and/or
+// ?namespaceSelector=team=x (a namespace label selector; both are merged). The workload
+// filters of NewDeploymentsHandler apply.
+//
+// Pods and pod metrics come from the cluster-wide cached lists when the token may read
+// them (finished Job pods are then left out), or per namespace otherwise. Namespaces are
+// fetched in parallel with bounded concurrency; workloads are ordered by namespace.
+// Namespaces that are forbidden or not found are listed in SkippedNamespaces instead of
+// failing the request.
+func NewWorkloadsHandler(prices *pricing.Table) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		filter, err := resources.ParseFilter(r.URL.Query(), resources.WorkloadKinds, resources.WorkloadStatuses)
+		if err != nil {
+			jsonError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		client := k8s.New(middleware.TokenFromContext(r.Context()), middleware.ClusterURLFromContext(r.Context()))
+
+		var namespaces []string
+		for n := range strings.SplitSeq(r.URL.Query().Get("namespaces"), ",") {
+			if n = strings.TrimSpace(n); n != "" {
+				namespaces = append(namespaces, n)
+			}
+		}
+		selector := r.URL.Query().Get("namespaceSelector")
+		if len(namespaces) == 0 && selector == "" {
+			jsonError(w, "namespaces or namespaceSelector is required", http.StatusBadRequest)
+			return
+		}
+		if selector != "" {
+			list, err := client.ListNamespacesSelector(r.Context(), selector)
+			if err != nil {
+				log.Printf("failed to list namespaces matching %q: %v", selector, err)
+				jsonError(w, "invalid namespaceSelector or namespaces not listable", http.StatusBadRequest)
+				return
+			}
+			for _, ns := range list.Items {
+				namespaces = append(namespaces, ns.Metadata.Name)
+			}
+		}
+		slices.Sort(namespaces)
+		namespaces = slices.Compact(namespaces)
+		if len(namespaces) > maxWorkloadNamespaces {
+			jsonError(w, "too many namespaces", http.StatusBadRequest)
+			return
+		}
+		resp := resources.WorkloadResponse{
+			Workloads:           []resources.DeploymentDetail{},
+			MetricsAvailable:    true,
+			PrometheusAvailable: os.Getenv("PROMETHEUS_URL") != "",
+		}
+		if len(namespaces) == 0 {
+			jsonOK(w, resp)
+			return
+		}
+
+		// 1. Cluster-wide lists (cached), when permitted
+		var (
+			allPods    *k8s.PodList
+			allMetrics *k8s.PodMetricsList
+			nodes      *k8s.NodeList
+		)
+		g, ctx := errgroup.WithContext(r.Context())
+		g.Go(func() error {
+			var err error
+			if allPods, err = client.ListAllPods(ctx); err != nil {
+				log.Printf("listing pods per namespace (cluster-wide list failed: %v)", err)
+				allPods = nil
+			}
+			return nil
+		})
+		g.Go(func() error {
+			var err error
+			if allMetrics, err = client.ListAllPodMetrics(ctx); err != nil {
+				allMetrics = nil // per namespace below
+			}
+			return nil
+		})
+		if prices != nil && prices.ByInstanceType() {
+			g.Go(func() error {
+				nodes = listNodesForPricing(ctx, client)
+				return nil
+			})
+		}
+		_ = g.Wait()
+
+		podsByNS := map[string][]k8s.Pod{}
+		if allPods != nil {
+			for _, pod := range allPods.Items {
+				podsByNS[pod.Metadata.Namespace] = append(podsByNS[pod.Metadata.Namespace], pod)
+			}
+		}
+		metricsByNS := map[string][]k8s.PodMetrics{}
+		if allMetrics != nil {
+			for _, pm := range allMetrics.Items {
+				metricsByNS[pm.Metadata.Namespace] = append(metricsByNS[pm.Metadata.Namespace], pm)
+			}
+		}
+
+		// 2. Namespace-scoped lists, in parallel
+		type namespaceData struct {
+			objs    *namespaceObjects
+			pods    []k8s.Pod
+			metrics map[string]map[string]k8s.ContainerUsage // nil: metrics-server unavailable
+		}
+		data := make([]namespaceData, len(namespaces))
+		var mu sync.Mutex
+		var allNamespacePods []k8s.Pod
+		g, ctx = errgroup.WithContext(r.Context())
+		g.SetLimit(4) // bound concurrent namespaces (each lists ~8 resources)
+		for i, ns := range namespaces {
+			g.Go(func() error {
+				// Namespaces the token may not read, or deleted since selected, are skipped.
+				skip := func(err error) error {
+					if !k8s.IsForbidden(err) && !k8s.IsNotFound(err) {
+						return err
+					}
+					log.Printf("skipping namespace %s: %v", ns, err)
+					mu.Lock()
+					defer mu.Unlock()
+					resp.SkippedNamespaces = append(resp.SkippedNamespaces, ns)
+					return nil
+				}
+				d := namespaceData{pods: podsByNS[ns]}
+				if allPods == nil {
+					pl, err := client.ListPods(ctx, ns)
+					if err != nil {
+						return skip(err)
+					}
+					d.pods = pl.Items
+				}
+				if allMetrics != nil {
+					d.metrics = metricsByPod(metricsByNS[ns])
+				} else if pm, err := client.ListPodMetrics(ctx, ns); err != nil {
+					log.Printf("metrics-server unavailable for %s: %v", ns, err)
+				} else {
+					d.metrics = metricsByPod(pm.Items)
+				}
+				var err error
+				if d.objs, err = listNamespaceObjects(ctx, client, ns, filter); err != nil {
+					return skip(err)
+				}
+				data[i] = d
+				mu.Lock()
+				allNamespacePods = append(allNamespacePods, d.pods...)
+				mu.Unlock()
+				return nil
+			})
+		}
+		if err := g.Wait(); err != nil {
+			if k8s.IsBadRequest(err) {
+				jsonError(w, "invalid labelSelector", http.StatusBadRequest)
+				return
+			}
+			log.Printf("failed to fetch workloads in %s: %v", strings.Join(namespaces, ","), err)
+			jsonError(w, "internal server error", http.StatusInternalServerError)
+			return
+		}
+
+		// 3. Storage stats of every selected pod, one kubelet call per node
+		wanted := make(map[string]bool, len(namespaces))
+		for _, ns := range namespaces {
+			wanted[ns] = true
+		}
+		storage := podStorage(r.Context(), client, allNamespacePods, wanted)
+
+		// 4. Merge
+		slices.Sort(resp.SkippedNamespaces)
+		for i, ns := range namespaces {
+			d := data[i]
+			if d.objs == nil {
+				continue // skipped
+			}
+			workloads, quotas := buildWorkloads(ns, d.objs, d.pods, d.metrics, storage[ns], prices, nodes, filter)
+			resp.Workloads = append(resp.Workloads, workloads...)
+			resp.Quotas = append(resp.Quotas, quotas...)
+			if d.metrics == nil {
+				resp.MetricsAvailable = false
+			}
+		}
+		jsonOK(w, resp)
+	}
+}
@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/devops-kubeadjust/backend/middleware"
+	"github.com/devops-kubeadjust/backend/resources"
+)
+
+// fakeWorkloadsAPI serves two namespaces with one Deployment each; shop is labelled
+// team=web and the workloads of namespace secret are forbidden. Without clusterWide,
+// cluster-wide pod and metrics lists are forbidden.
+func fakeWorkloadsAPI(t *testing.T, clusterWide bool, clusterWideCalls *atomic.Int32) *httptest.Server {
+	deployment := func(ns, name string) string {
+		return `{"items": [{"metadata": {"name": "` + name + `", "namespace": "` + ns + `"}, "spec": {"replicas": 1},
+			"status": {"readyReplicas": 1, "availableReplicas": 1}}]}`
+	}
+	pod := func(ns, name, owner string) string {
+		return `{"metadata": {"name": "` + name + `", "namespace": "` + ns + `", "ownerReferences": [{"kind": "ReplicaSet", "name": "` + owner + `"}]},
+			"spec": {"nodeName": "node-a", "containers": [{"name": "app", "resources": {"requests": {"cpu": "100m"}}}]}, "status": {"phase": "Running"}}`
+	}
+	routes := map[string]string{
+		"/apis/apps/v1/namespaces/payments/deployments": deployment("payments", "api"),
+		"/apis/apps/v1/namespaces/shop/deployments":     deployment("shop", "web"),
+		"/apis/apps/v1/namespaces/payments/replicasets": `{"items": [{"metadata": {"name": "api-1", "ownerReferences": [{"kind": "Deployment", "name": "api"}]}}]}`,
+		"/apis/apps/v1/namespaces/shop/replicasets":     `{"items": [{"metadata": {"name": "web-1", "ownerReferences": [{"kind": "Deployment", "name": "web"}]}}]}`,
+		"/api/v1/namespaces/payments/pods":              `{"items": [` + pod("payments", "api-1-a", "api-1") + `]}`,
+		"/api/v1/namespaces/shop/pods":                  `{"items": [` + pod("shop", "web-1-a", "web-1") + `]}`,
+		"/api/v1/pods":                                  `{"items": [` + pod("payments", "api-1-a", "api-1") + `, ` + pod("shop", "web-1-a", "web-1") + `]}`,
+		"/apis/metrics.k8s.io/v1beta1/pods": `{"items": [{"metadata": {"name": "api-1-a", "namespace": "payments"}, "containers": [{"name": "app", "usage": {"cpu": "20m", "memory": "10Mi"}}]},
+			{"metadata": {"name": "web-1-a", "namespace": "shop"}, "containers": [{"name": "app", "usage": {"cpu": "30m", "memory": "10Mi"}}]}]}`,
+		"/apis/metrics.k8s.io/v1beta1/namespaces/payments/pods": `{"items": [{"metadata": {"name": "api-1-a"}, "containers": [{"name": "app", "usage": {"cpu": "20m", "memory": "10Mi"}}]}]}`,
+		"/apis/metrics.k8s.io/v1beta1/namespaces/shop/pods":     `{"items": [{"metadata": {"name": "web-1-a"}, "containers": [{"name": "app", "usage": {"cpu": "30m", "memory": "10Mi"}}]}]}`,
+		"/api/v1/namespaces/shop/resourcequotas": `{"items": [{"metadata": {"name": "compute", "namespace": "shop"},
+			"status": {"hard": {"requests.cpu": "2"}, "used": {"requests.cpu": "100m"}}}]}`,
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/pods" || r.URL.Path == "/apis/metrics.k8s.io/v1beta1/pods" {
+			clusterWideCalls.Add(1)
+			if !clusterWide {
+				http.Error(w, `{"reason": "Forbidden"}`, http.StatusForbidden)
+				return
+			}
+		}
+		if strings.HasPrefix(r.URL.Path, "/apis/apps/v1/namespaces/secret/") {
+			http.Error(w, `{"reason": "Forbidden"}`, http.StatusForbidden)
+			return
+		}
+		if r.URL.Path == "/api/v1/namespaces" {
+			if r.URL.Query().Get("labelSelector") != "team=web" {
+				http.Error(w, `{"reason": "BadRequest"}`, http.StatusBadRequest)
+				return
+			}
+			_, _ = w.Write([]byte(`{"items": [{"metadata": {"name": "shop", "labels": {"team": "web"}}}]}`))
+			return
+		}
+		body, ok := routes[r.URL.Path]
+		if !ok {
+			body = `{"items": []}`
+		}
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func getWorkloads(t *testing.T, apiURL, query string) (int, resources.WorkloadResponse) {
+	h := middleware.ClusterURL(map[string]string{"test": apiURL})(middleware.BearerToken(NewWorkloadsHandler(nil)))
+	req := httptest.NewRequest("GET", "/api/workloads?"+query, nil)
+	req.Header.Set("Authorization", "Bearer token")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	var resp resources.WorkloadResponse
+	if w.Code == http.StatusOK {
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return w.Code, resp
+}
+
+func TestWorkloadsHandler(t *testing.T) {
+	for _, clusterWide := range []bool{true, false} {
+		var calls atomic.Int32
+		api := fakeWorkloadsAPI(t, clusterWide, &calls)
+
+		code, resp := getWorkloads(t, api.URL, "namespaces=shop,payments,shop")
+		if code != http.StatusOK || len(resp.Workloads) != 2 {
+			t.Fatalf("clusterWide=%v: got %d %+v", clusterWide, code, resp)
+		}
+		var got []string
+		for _, wl := range resp.Workloads {
+			usage := "-"
+			if len(wl.Pods) == 1 && wl.Pods[0].Containers[0].Usage != nil {
+				usage = wl.Pods[0].Containers[0].Usage.CPU.Raw
+			}
+			got = append(got, wl.Namespace+"/"+wl.Name+":"+usage)
+		}
+		if strings.Join(got, ",") != "payments/api:20m,shop/web:30m" || !resp.MetricsAvailable {
+			t.Errorf("clusterWide=%v: workloads %v (metrics %v)", clusterWide, got, resp.MetricsAvailable)
+		}
+		if len(resp.Quotas) != 1 || resp.Quotas[0].Namespace != "shop" {
+			t.Errorf("clusterWide=%v: quotas %+v", clusterWide, resp.Quotas)
+		}
+		if calls.Load() != 2 {
+			t.Errorf("clusterWide=%v: %d cluster-wide calls, want 2", clusterWide, calls.Load())
+		}
+
+		code, resp = getWorkloads(t, api.URL, "namespaces=secret,shop")
+		if code != http.StatusOK || len(resp.Workloads) != 1 || resp.Workloads[0].Namespace != "shop" ||
+			len(resp.SkippedNamespaces) != 1 || resp.SkippedNamespaces[0] != "secret" {
+			t.Errorf("clusterWide=%v: forbidden namespace: got %d %+v", clusterWide, code, resp)
+		}
+
+		code, resp = getWorkloads(t, api.URL, "namespaceSelector=team%3Dweb")
+		if code != http.StatusOK || len(resp.Workloads) != 1 || resp.Workloads[0].Namespace != "shop" {
+			t.Errorf("clusterWide=%v: selector: got %d %+v", clusterWide, code, resp)
+		}
+	}
+
+	var calls atomic.Int32
+	api := fakeWorkloadsAPI(t, true, &calls)
+	if code, resp := getWorkloads(t, api.URL, "namespaces=payments,shop&name=we&kind=deployment"); code != http.StatusOK || len(resp.Workloads) != 1 || resp.Workloads[0].Name != "web" {
+		t.Errorf("filters: got %d %+v", code, resp)
+	}
+	for _, query := range []string{"", "namespaces=,", "namespaceSelector=team%3D%3D%3D", "namespaces=shop&status=sleepy"} {
+		if code, _ := getWorkloads(t, api.URL, query); code != http.StatusBadRequest {
+			t.Errorf("%q: got %d, want 400", query, code)
+		}
+	}
+}
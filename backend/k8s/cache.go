@@ -3,21 +3,25 @@ package k8s
 import (
 	"sync"
 	"time"
+
+	"github.com/devops-kubeadjust/backend/metrics"
 )
 
 const (
-	ttlShort = 30 * time.Second
-	ttlLong  = 60 * time.Second
+	ttlShort  = 30 * time.Second
+	ttlLong   = 60 * time.Second
+	ttlStatic = 10 * time.Minute // kubelet configuration, changed only by a kubelet restart
 )
 
 // Package-level caches keyed by API server URL (cluster-scoped, not per-user).
 // All cached data is read-only — callers must not mutate returned pointers.
 var (
-	allPodsCache       = newClusterCache[*PodList]()
-	nodesCache         = newClusterCache[*NodeList]()
-	nodeMetricsCache   = newClusterCache[*NodeMetricsList]()
-	allPodMetricsCache = newClusterCache[*PodMetricsList]()
-	nodeSummaryCache   = newClusterCache[*NodeSummary]()
+	allPodsCache       = newClusterCache[*PodList]("pods")
+	nodesCache         = newClusterCache[*NodeList]("nodes")
+	nodeMetricsCache   = newClusterCache[*NodeMetricsList]("node_metrics")
+	allPodMetricsCache = newClusterCache[*PodMetricsList]("pod_metrics")
+	nodeSummaryCache   = newClusterCache[*NodeSummary]("node_summary")
+	kubeletConfigCache = newClusterCache[kubeletConfigResult]("kubelet_config")
 )
 
 type cacheEntry[T any] struct {
@@ -30,14 +34,15 @@ func (e *cacheEntry[T]) valid() bool {
 }
 
 // clusterCache is a generic TTL cache keyed by an arbitrary string.
-// Safe for concurrent use.
+// Safe for concurrent use. Lookups are counted in metrics.CacheRequests under name.
 type clusterCache[T any] struct {
+	name    string
 	mu      sync.RWMutex
 	entries map[string]*cacheEntry[T]
 }
 
-func newClusterCache[T any]() *clusterCache[T] {
-	return &clusterCache[T]{entries: make(map[string]*cacheEntry[T])}
+func newClusterCache[T any](name string) *clusterCache[T] {
+	return &clusterCache[T]{name: name, entries: make(map[string]*cacheEntry[T])}
 }
 
 func (c *clusterCache[T]) get(key string) (T, bool) {
@@ -45,8 +50,10 @@ func (c *clusterCache[T]) get(key string) (T, bool) {
 	e, ok := c.entries[key]
 	c.mu.RUnlock()
 	if ok && e.valid() {
+		metrics.CacheRequests.Inc(c.name, "hit")
 		return e.value, true
 	}
+	metrics.CacheRequests.Inc(c.name, "miss")
 	var zero T
 	return zero, false
 }
@@ -1,6 +1,7 @@
 package k8s
 
 import (
+	"bytes"
 	"context"
 	"crypto/tls"
 	"encoding/json"
@@ -11,7 +12,12 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"sync"
 	"time"
+
+	"golang.org/x/sync/errgroup"
+
+	"github.com/devops-kubeadjust/backend/metrics"
 )
 
 const defaultAPIServer = "https://kubernetes.default.svc"
@@ -56,6 +62,23 @@ func New(token, apiServer string) *Client {
 	}
 }
 
+// NewWithTransport returns a Client using rt instead of the shared in-cluster transport,
+// e.g. with the CA and client certificate of a kubeconfig. An empty token sends no
+// Authorization header (client certificate authentication).
+func NewWithTransport(token, apiServer string, rt http.RoundTripper) *Client {
+	c := New(token, apiServer)
+	c.httpClient.Transport = rt
+	return c
+}
+
+// Target is a named cluster reached with a token held by the backend, for background jobs
+// (alerting, metrics). Client is called on every run so rotated tokens (e.g. the in-cluster
+// projected SA token) are picked up.
+type Target struct {
+	Name   string
+	Client func() (*Client, error)
+}
+
 const maxRetries = 3
 
 func (c *Client) get(ctx context.Context, path string, out interface{}) error {
@@ -81,20 +104,46 @@ func (c *Client) get(ctx context.Context, path string, out interface{}) error {
 }
 
 func (c *Client) doGet(ctx context.Context, path string, out interface{}) error {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.apiServer+path, nil)
+	return c.do(ctx, http.MethodGet, path, nil, out)
+}
+
+// post sends in as JSON without retrying: it is only used for review requests, which are
+// cheap to repeat from the caller.
+func (c *Client) post(ctx context.Context, path string, in, out interface{}) error {
+	return c.do(ctx, http.MethodPost, path, in, out)
+}
+
+func (c *Client) do(ctx context.Context, method, path string, in, out interface{}) error {
+	var reqBody io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.apiServer+path, reqBody)
 	if err != nil {
 		return err
 	}
-	req.Header.Set("Authorization", "Bearer "+c.token)
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
 	req.Header.Set("Accept", "application/json")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
 
+	start := time.Now()
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
+		metrics.ObserveK8sRequest(path, 0, time.Since(start))
 		return err
 	}
 	defer func() { _ = resp.Body.Close() }()
 
 	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	metrics.ObserveK8sRequest(path, resp.StatusCode, time.Since(start))
 	if err != nil {
 		return fmt.Errorf("reading response for %s: %w", path, err)
 	}
@@ -115,6 +164,24 @@ type apiError struct {
 
 func (e *apiError) Error() string { return e.message }
 
+// IsNotFound reports whether err is a 404 from the API server.
+func IsNotFound(err error) bool {
+	var ae *apiError
+	return errors.As(err, &ae) && ae.statusCode == http.StatusNotFound
+}
+
+// IsForbidden reports whether err is a 403 from the API server (RBAC denied).
+func IsForbidden(err error) bool {
+	var ae *apiError
+	return errors.As(err, &ae) && ae.statusCode == http.StatusForbidden
+}
+
+// IsBadRequest reports whether err is a 400 from the API server, e.g. an invalid label selector.
+func IsBadRequest(err error) bool {
+	var ae *apiError
+	return errors.As(err, &ae) && ae.statusCode == http.StatusBadRequest
+}
+
 func isClientError(err error) bool {
 	var ae *apiError
 	if errors.As(err, &ae) {
@@ -128,19 +195,71 @@ func (c *Client) VerifyToken(ctx context.Context) error {
 	return c.get(ctx, "/api", &out)
 }
 
+// CanI reports whether the token may perform verb on the named resource, through a
+// SelfSubjectAccessReview (the API behind "kubectl auth can-i").
+func (c *Client) CanI(ctx context.Context, verb, group, resource, namespace, name string) (bool, error) {
+	review := map[string]any{
+		"apiVersion": "authorization.k8s.io/v1",
+		"kind":       "SelfSubjectAccessReview",
+		"spec": map[string]any{
+			"resourceAttributes": map[string]string{
+				"verb":      verb,
+				"group":     group,
+				"resource":  resource,
+				"namespace": namespace,
+				"name":      name,
+			},
+		},
+	}
+	var out struct {
+		Status struct {
+			Allowed bool `json:"allowed"`
+		} `json:"status"`
+	}
+	if err := c.post(ctx, "/apis/authorization.k8s.io/v1/selfsubjectaccessreviews", review, &out); err != nil {
+		return false, err
+	}
+	return out.Status.Allowed, nil
+}
+
 // --- API methods ---
 
 // p escapes a path segment for safe interpolation into K8s API URLs.
 func p(segment string) string { return url.PathEscape(segment) }
 
+// selectorQuery returns the "?labelSelector=" query of a label selector, "" when empty.
+func selectorQuery(labelSelector string) string {
+	if labelSelector == "" {
+		return ""
+	}
+	return "?labelSelector=" + url.QueryEscape(labelSelector)
+}
+
 func (c *Client) ListNamespaces(ctx context.Context) (*NamespaceList, error) {
 	var out NamespaceList
 	return &out, c.get(ctx, "/api/v1/namespaces", &out)
 }
 
+// ListNamespacesSelector lists the namespaces matching a label selector, e.g. "team=payments".
+func (c *Client) ListNamespacesSelector(ctx context.Context, labelSelector string) (*NamespaceList, error) {
+	var out NamespaceList
+	return &out, c.get(ctx, "/api/v1/namespaces"+selectorQuery(labelSelector), &out)
+}
+
+func (c *Client) GetNamespace(ctx context.Context, name string) (*Namespace, error) {
+	var out Namespace
+	return &out, c.get(ctx, "/api/v1/namespaces/"+p(name), &out)
+}
+
 func (c *Client) ListDeployments(ctx context.Context, namespace string) (*DeploymentList, error) {
+	return c.ListDeploymentsSelector(ctx, namespace, "")
+}
+
+// ListDeploymentsSelector lists the Deployments of a namespace matching a label selector
+// ("" for all).
+func (c *Client) ListDeploymentsSelector(ctx context.Context, namespace, labelSelector string) (*DeploymentList, error) {
 	var out DeploymentList
-	return &out, c.get(ctx, fmt.Sprintf("/apis/apps/v1/namespaces/%s/deployments", p(namespace)), &out)
+	return &out, c.get(ctx, fmt.Sprintf("/apis/apps/v1/namespaces/%s/deployments", p(namespace))+selectorQuery(labelSelector), &out)
 }
 
 func (c *Client) ListPods(ctx context.Context, namespace string) (*PodList, error) {
@@ -184,6 +303,16 @@ func (c *Client) ListNodes(ctx context.Context) (*NodeList, error) {
 	return &out, nil
 }
 
+// ListNodesSelector lists the nodes matching a label selector. An empty selector uses the
+// cached ListNodes; filtered lists are not cached.
+func (c *Client) ListNodesSelector(ctx context.Context, labelSelector string) (*NodeList, error) {
+	if labelSelector == "" {
+		return c.ListNodes(ctx)
+	}
+	var out NodeList
+	return &out, c.get(ctx, "/api/v1/nodes"+selectorQuery(labelSelector), &out)
+}
+
 func (c *Client) ListNodeMetrics(ctx context.Context) (*NodeMetricsList, error) {
 	if v, ok := nodeMetricsCache.get(c.apiServer); ok {
 		return v, nil
@@ -211,6 +340,17 @@ func (c *Client) ListAllPods(ctx context.Context) (*PodList, error) {
 	return &out, nil
 }
 
+// ListNodePodsSelector lists the non-terminal pods of a node matching a label selector,
+// filtered by the API server (not cached).
+func (c *Client) ListNodePodsSelector(ctx context.Context, nodeName, labelSelector string) (*PodList, error) {
+	var out PodList
+	q := url.Values{
+		"fieldSelector": {"spec.nodeName=" + nodeName + ",status.phase!=Succeeded,status.phase!=Failed"},
+		"labelSelector": {labelSelector},
+	}
+	return &out, c.get(ctx, "/api/v1/pods?"+q.Encode(), &out)
+}
+
 func (c *Client) ListPVCs(ctx context.Context, namespace string) (*PVCList, error) {
 	var out PVCList
 	return &out, c.get(ctx, fmt.Sprintf("/api/v1/namespaces/%s/persistentvolumeclaims", p(namespace)), &out)
@@ -222,8 +362,14 @@ func (c *Client) ListReplicaSets(ctx context.Context, namespace string) (*Replic
 }
 
 func (c *Client) ListStatefulSets(ctx context.Context, namespace string) (*StatefulSetList, error) {
+	return c.ListStatefulSetsSelector(ctx, namespace, "")
+}
+
+// ListStatefulSetsSelector lists the StatefulSets of a namespace matching a label selector
+// ("" for all).
+func (c *Client) ListStatefulSetsSelector(ctx context.Context, namespace, labelSelector string) (*StatefulSetList, error) {
 	var out StatefulSetList
-	return &out, c.get(ctx, fmt.Sprintf("/apis/apps/v1/namespaces/%s/statefulsets", p(namespace)), &out)
+	return &out, c.get(ctx, fmt.Sprintf("/apis/apps/v1/namespaces/%s/statefulsets", p(namespace))+selectorQuery(labelSelector), &out)
 }
 
 func (c *Client) ListJobs(ctx context.Context, namespace string) (*JobList, error) {
@@ -232,8 +378,56 @@ func (c *Client) ListJobs(ctx context.Context, namespace string) (*JobList, erro
 }
 
 func (c *Client) ListCronJobs(ctx context.Context, namespace string) (*CronJobList, error) {
+	return c.ListCronJobsSelector(ctx, namespace, "")
+}
+
+// ListCronJobsSelector lists the CronJobs of a namespace matching a label selector ("" for all).
+func (c *Client) ListCronJobsSelector(ctx context.Context, namespace, labelSelector string) (*CronJobList, error) {
 	var out CronJobList
-	return &out, c.get(ctx, fmt.Sprintf("/apis/batch/v1/namespaces/%s/cronjobs", p(namespace)), &out)
+	return &out, c.get(ctx, fmt.Sprintf("/apis/batch/v1/namespaces/%s/cronjobs", p(namespace))+selectorQuery(labelSelector), &out)
+}
+
+func (c *Client) GetDeployment(ctx context.Context, namespace, name string) (*Deployment, error) {
+	var out Deployment
+	return &out, c.get(ctx, fmt.Sprintf("/apis/apps/v1/namespaces/%s/deployments/%s", p(namespace), p(name)), &out)
+}
+
+func (c *Client) GetStatefulSet(ctx context.Context, namespace, name string) (*StatefulSet, error) {
+	var out StatefulSet
+	return &out, c.get(ctx, fmt.Sprintf("/apis/apps/v1/namespaces/%s/statefulsets/%s", p(namespace), p(name)), &out)
+}
+
+func (c *Client) GetCronJob(ctx context.Context, namespace, name string) (*CronJob, error) {
+	var out CronJob
+	return &out, c.get(ctx, fmt.Sprintf("/apis/batch/v1/namespaces/%s/cronjobs/%s", p(namespace), p(name)), &out)
+}
+
+func (c *Client) ListResourceQuotas(ctx context.Context, namespace string) (*ResourceQuotaList, error) {
+	var out ResourceQuotaList
+	return &out, c.get(ctx, fmt.Sprintf("/api/v1/namespaces/%s/resourcequotas", p(namespace)), &out)
+}
+
+// ListAllResourceQuotas lists ResourceQuotas across all namespaces.
+func (c *Client) ListAllResourceQuotas(ctx context.Context) (*ResourceQuotaList, error) {
+	var out ResourceQuotaList
+	return &out, c.get(ctx, "/api/v1/resourcequotas", &out)
+}
+
+// ListAllLimitRanges lists LimitRanges across all namespaces.
+func (c *Client) ListAllLimitRanges(ctx context.Context) (*LimitRangeList, error) {
+	var out LimitRangeList
+	return &out, c.get(ctx, "/api/v1/limitranges", &out)
+}
+
+// ListEvents lists events in a namespace. A non-empty eventType ("Warning", "Normal")
+// is applied as a server-side field selector to keep the response small.
+func (c *Client) ListEvents(ctx context.Context, namespace, eventType string) (*EventList, error) {
+	path := fmt.Sprintf("/api/v1/namespaces/%s/events", p(namespace))
+	if eventType != "" {
+		path += "?fieldSelector=" + url.QueryEscape("type="+eventType)
+	}
+	var out EventList
+	return &out, c.get(ctx, path, &out)
 }
 
 // GetNodeSummary calls the kubelet stats/summary via the API server proxy.
@@ -252,6 +446,79 @@ func (c *Client) GetNodeSummary(ctx context.Context, nodeName string) (*NodeSumm
 	return &out, nil
 }
 
+// GetNodeSummaries fetches the stats summary of every node, skipping the nodes whose
+// kubelet cannot be reached. Order is unspecified.
+func (c *Client) GetNodeSummaries(ctx context.Context, nodes []Node) []*NodeSummary {
+	var mu sync.Mutex
+	var out []*NodeSummary
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(5) // bound concurrent kubelet calls to avoid kubelet overload
+	for _, n := range nodes {
+		g.Go(func() error {
+			s, err := c.GetNodeSummary(gctx, n.Metadata.Name)
+			if err != nil {
+				return nil // best-effort
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			out = append(out, s)
+			return nil
+		})
+	}
+	_ = g.Wait()
+	return out
+}
+
+// kubeletConfigResult is a cached configz response or its 404.
+type kubeletConfigResult struct {
+	cfg *KubeletConfig
+	err error
+}
+
+// GetKubeletConfig reads the running kubelet configuration of a node from its configz
+// endpoint via the API server proxy. Requires nodes/proxy get permission; some managed
+// clusters do not expose it. Results are cached per (cluster, node) for ttlStatic. The
+// cache is shared by all users, so of the failures only a 404 (endpoint not exposed, the
+// same for everyone) is cached, for ttlLong; RBAC denials and transient errors are not.
+func (c *Client) GetKubeletConfig(ctx context.Context, nodeName string) (*KubeletConfig, error) {
+	key := c.apiServer + ":" + nodeName
+	if v, ok := kubeletConfigCache.get(key); ok {
+		return v.cfg, v.err
+	}
+	var out KubeletConfigz
+	if err := c.get(ctx, fmt.Sprintf("/api/v1/nodes/%s/proxy/configz", p(nodeName)), &out); err != nil {
+		if IsNotFound(err) {
+			kubeletConfigCache.set(key, kubeletConfigResult{err: err}, ttlLong)
+		}
+		return nil, err
+	}
+	kubeletConfigCache.set(key, kubeletConfigResult{cfg: &out.KubeletConfig}, ttlStatic)
+	return &out.KubeletConfig, nil
+}
+
+// GetKubeletConfigs fetches the kubelet configuration of every node, keyed by node name,
+// skipping the nodes whose configz cannot be read.
+func (c *Client) GetKubeletConfigs(ctx context.Context, nodes []Node) map[string]*KubeletConfig {
+	var mu sync.Mutex
+	out := map[string]*KubeletConfig{}
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(5) // bound concurrent kubelet calls to avoid kubelet overload
+	for _, n := range nodes {
+		g.Go(func() error {
+			cfg, err := c.GetKubeletConfig(gctx, n.Metadata.Name)
+			if err != nil {
+				return nil // best-effort
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			out[n.Metadata.Name] = cfg
+			return nil
+		})
+	}
+	_ = g.Wait()
+	return out
+}
+
 func envOr(key, def string) string {
 	if v := os.Getenv(key); v != "" {
 		return v
@@ -9,6 +9,7 @@ type ObjectMeta struct {
 	Name              string            `json:"name"`
 	Namespace         string            `json:"namespace"`
 	Labels            map[string]string `json:"labels"`
+	Annotations       map[string]string `json:"annotations,omitempty"`
 	UID               string            `json:"uid"`
 	OwnerReferences   []OwnerReference  `json:"ownerReferences,omitempty"`
 	CreationTimestamp string            `json:"creationTimestamp,omitempty"`
@@ -30,6 +31,36 @@ type Namespace struct {
 	Metadata ObjectMeta `json:"metadata"`
 }
 
+// --- ResourceQuotas / LimitRanges ---
+
+type ResourceQuotaList struct {
+	Items []ResourceQuota `json:"items"`
+}
+type ResourceQuota struct {
+	Metadata ObjectMeta `json:"metadata"`
+	Status   struct {
+		Hard map[string]string `json:"hard"`
+		Used map[string]string `json:"used"`
+	} `json:"status"`
+}
+
+type LimitRangeList struct {
+	Items []LimitRange `json:"items"`
+}
+type LimitRange struct {
+	Metadata ObjectMeta `json:"metadata"`
+	Spec     struct {
+		Limits []LimitRangeItem `json:"limits"`
+	} `json:"spec"`
+}
+type LimitRangeItem struct {
+	Type           string            `json:"type"` // Container | Pod | PersistentVolumeClaim
+	Default        map[string]string `json:"default,omitempty"`
+	DefaultRequest map[string]string `json:"defaultRequest,omitempty"`
+	Max            map[string]string `json:"max,omitempty"`
+	Min            map[string]string `json:"min,omitempty"`
+}
+
 // --- Deployments ---
 
 type DeploymentList struct {
@@ -40,7 +71,8 @@ type Deployment struct {
 	Spec     struct {
 		Replicas int32 `json:"replicas"`
 		Template struct {
-			Spec PodSpec `json:"spec"`
+			Metadata ObjectMeta `json:"metadata"`
+			Spec     PodSpec    `json:"spec"`
 		} `json:"template"`
 	} `json:"spec"`
 	Status struct {
@@ -67,6 +99,10 @@ type StatefulSet struct {
 	Metadata ObjectMeta `json:"metadata"`
 	Spec     struct {
 		Replicas int32 `json:"replicas"`
+		Template struct {
+			Metadata ObjectMeta `json:"metadata"`
+			Spec     PodSpec    `json:"spec"`
+		} `json:"template"`
 	} `json:"spec"`
 	Status struct {
 		ReadyReplicas     int32 `json:"readyReplicas"`
@@ -94,7 +130,17 @@ type CronJobList struct {
 }
 type CronJob struct {
 	Metadata ObjectMeta `json:"metadata"`
-	Status   struct {
+	Spec     struct {
+		JobTemplate struct {
+			Spec struct {
+				Template struct {
+					Metadata ObjectMeta `json:"metadata"`
+					Spec     PodSpec    `json:"spec"`
+				} `json:"template"`
+			} `json:"spec"`
+		} `json:"jobTemplate"`
+	} `json:"spec"`
+	Status struct {
 		Active []ObjectReference `json:"active,omitempty"`
 	} `json:"status"`
 }
@@ -111,17 +157,54 @@ type PodList struct {
 type Pod struct {
 	Metadata ObjectMeta `json:"metadata"`
 	Spec     PodSpec    `json:"spec"`
-	Status   struct {
-		Phase             string            `json:"phase"`
-		ContainerStatuses []ContainerStatus `json:"containerStatuses"`
-	} `json:"status"`
+	Status   PodStatus  `json:"status"`
+}
+
+type PodStatus struct {
+	Phase             string            `json:"phase"`
+	Conditions        []PodCondition    `json:"conditions,omitempty"`
+	ContainerStatuses []ContainerStatus `json:"containerStatuses"`
+}
+type PodCondition struct {
+	Type    string `json:"type"`   // e.g. "PodScheduled", "Ready"
+	Status  string `json:"status"` // "True" | "False" | "Unknown"
+	Reason  string `json:"reason,omitempty"`
+	Message string `json:"message,omitempty"`
 }
 
 type PodSpec struct {
-	NodeName       string      `json:"nodeName"`
-	Containers     []Container `json:"containers"`
-	InitContainers []Container `json:"initContainers"`
-	Volumes        []Volume    `json:"volumes"`
+	NodeName       string            `json:"nodeName"`
+	Containers     []Container       `json:"containers"`
+	InitContainers []Container       `json:"initContainers"`
+	Volumes        []Volume          `json:"volumes"`
+	Tolerations    []Toleration      `json:"tolerations,omitempty"`
+	NodeSelector   map[string]string `json:"nodeSelector,omitempty"`
+	Affinity       *Affinity         `json:"affinity,omitempty"`
+}
+
+// Affinity only carries node affinity; pod (anti-)affinity is not evaluated by KubeAdjust.
+type Affinity struct {
+	NodeAffinity *NodeAffinity `json:"nodeAffinity,omitempty"`
+}
+type NodeAffinity struct {
+	RequiredDuringSchedulingIgnoredDuringExecution *NodeSelector `json:"requiredDuringSchedulingIgnoredDuringExecution,omitempty"`
+}
+type NodeSelector struct {
+	NodeSelectorTerms []NodeSelectorTerm `json:"nodeSelectorTerms"`
+}
+type NodeSelectorTerm struct {
+	MatchExpressions []NodeSelectorRequirement `json:"matchExpressions,omitempty"`
+}
+type NodeSelectorRequirement struct {
+	Key      string   `json:"key"`
+	Operator string   `json:"operator"` // In | NotIn | Exists | DoesNotExist | Gt | Lt
+	Values   []string `json:"values,omitempty"`
+}
+type Toleration struct {
+	Key      string `json:"key,omitempty"`
+	Operator string `json:"operator,omitempty"` // "Exists" | "Equal" (default)
+	Value    string `json:"value,omitempty"`
+	Effect   string `json:"effect,omitempty"` // empty matches all effects
 }
 
 type Container struct {
@@ -133,8 +216,51 @@ type ResourceRequire struct {
 	Limits   map[string]string `json:"limits"`
 }
 type ContainerStatus struct {
-	Name  string `json:"name"`
-	Ready bool   `json:"ready"`
+	Name         string         `json:"name"`
+	Ready        bool           `json:"ready"`
+	RestartCount int32          `json:"restartCount"`
+	State        ContainerState `json:"state"`
+	LastState    ContainerState `json:"lastState"`
+}
+
+// ContainerState has exactly one of its members set (or none if the kubelet has not reported yet).
+type ContainerState struct {
+	Running    *ContainerStateRunning    `json:"running,omitempty"`
+	Waiting    *ContainerStateWaiting    `json:"waiting,omitempty"`
+	Terminated *ContainerStateTerminated `json:"terminated,omitempty"`
+}
+type ContainerStateRunning struct {
+	StartedAt string `json:"startedAt"`
+}
+type ContainerStateWaiting struct {
+	Reason string `json:"reason"` // e.g. "CrashLoopBackOff", "ContainerCreating"
+}
+type ContainerStateTerminated struct {
+	Reason     string `json:"reason"` // e.g. "OOMKilled", "Error", "Completed"
+	ExitCode   int32  `json:"exitCode"`
+	FinishedAt string `json:"finishedAt"`
+}
+
+// --- Events ---
+
+type EventList struct {
+	Items []Event `json:"items"`
+}
+type Event struct {
+	Metadata       ObjectMeta     `json:"metadata"`
+	InvolvedObject EventObjectRef `json:"involvedObject"`
+	Type           string         `json:"type"` // "Normal" | "Warning"
+	Reason         string         `json:"reason"`
+	Message        string         `json:"message"`
+	Count          int32          `json:"count"`
+	FirstTimestamp string         `json:"firstTimestamp"`
+	LastTimestamp  string         `json:"lastTimestamp"`
+	EventTime      string         `json:"eventTime"` // set instead of lastTimestamp by events.k8s.io/v1 producers
+}
+type EventObjectRef struct {
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
 }
 
 // --- Volumes ---
@@ -173,8 +299,46 @@ type PVC struct {
 // --- Kubelet summary API (stats/summary endpoint) ---
 
 type NodeSummary struct {
+	Node NodeStatsSummary  `json:"node"`
 	Pods []PodStatsSummary `json:"pods"`
 }
+type NodeStatsSummary struct {
+	NodeName         string                   `json:"nodeName"`
+	SystemContainers []SystemContainerSummary `json:"systemContainers"` // kubelet, runtime, pods, misc
+	Fs               *FsStats                 `json:"fs"`
+	Runtime          *struct {
+		ImageFs *FsStats `json:"imageFs"`
+	} `json:"runtime"`
+	Rlimit  *RlimitStats  `json:"rlimit"`
+	Network *NetworkStats `json:"network"`
+}
+type SystemContainerSummary struct {
+	Name string `json:"name"`
+	CPU  *struct {
+		UsageNanoCores int64 `json:"usageNanoCores"`
+	} `json:"cpu"`
+	Memory *struct {
+		WorkingSetBytes int64 `json:"workingSetBytes"`
+	} `json:"memory"`
+}
+type FsStats struct {
+	CapacityBytes  int64 `json:"capacityBytes"`
+	UsedBytes      int64 `json:"usedBytes"`
+	AvailableBytes int64 `json:"availableBytes"`
+	Inodes         int64 `json:"inodes"`
+	InodesUsed     int64 `json:"inodesUsed"`
+}
+type RlimitStats struct {
+	MaxPID  int64 `json:"maxpid"`
+	CurProc int64 `json:"curproc"`
+}
+type NetworkStats struct {
+	Name     string `json:"name"` // default interface
+	RxBytes  int64  `json:"rxBytes"`
+	TxBytes  int64  `json:"txBytes"`
+	RxErrors int64  `json:"rxErrors"`
+	TxErrors int64  `json:"txErrors"`
+}
 type PodStatsSummary struct {
 	PodRef     PodRef                  `json:"podRef"`
 	Containers []ContainerStatsSummary `json:"containers"`
@@ -204,6 +368,17 @@ type PVCRef struct {
 	Namespace string `json:"namespace"`
 }
 
+// --- Kubelet configuration (configz endpoint) ---
+
+type KubeletConfigz struct {
+	KubeletConfig KubeletConfig `json:"kubeletconfig"`
+}
+type KubeletConfig struct {
+	KubeReserved   map[string]string `json:"kubeReserved"`
+	SystemReserved map[string]string `json:"systemReserved"`
+	EvictionHard   map[string]string `json:"evictionHard"` // e.g. "memory.available": "100Mi" or "5%"
+}
+
 // --- Nodes ---
 
 type NodeList struct {
@@ -217,7 +392,8 @@ type NodeInfo struct {
 type Node struct {
 	Metadata ObjectMeta `json:"metadata"`
 	Spec     struct {
-		Taints []Taint `json:"taints,omitempty"`
+		Taints        []Taint `json:"taints,omitempty"`
+		Unschedulable bool    `json:"unschedulable,omitempty"` // cordoned
 	} `json:"spec"`
 	Status struct {
 		Capacity    map[string]string `json:"capacity"`
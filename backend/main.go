@@ -1,20 +1,35 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	chiMiddleware "github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/cors"
 
+	"github.com/devops-kubeadjust/backend/alerting"
+	"github.com/devops-kubeadjust/backend/digest"
+	"github.com/devops-kubeadjust/backend/gitops"
 	"github.com/devops-kubeadjust/backend/handlers"
+	"github.com/devops-kubeadjust/backend/k8s"
+	"github.com/devops-kubeadjust/backend/metrics"
+	"github.com/devops-kubeadjust/backend/metrics/findings"
 	"github.com/devops-kubeadjust/backend/middleware"
+	"github.com/devops-kubeadjust/backend/pricing"
 	"github.com/devops-kubeadjust/backend/prometheus"
+	"github.com/devops-kubeadjust/backend/suggestions"
 )
 
+// inClusterTokenFile is the Kubernetes-projected SA token, rotated by the kubelet.
+const inClusterTokenFile = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+
 func main() {
 	port := os.Getenv("PORT")
 	if port == "" {
@@ -51,13 +66,43 @@ func main() {
 	if promClient != nil {
 		log.Println("Prometheus client configured")
 	}
+	// Cluster scraped by PROMETHEUS_URL, shared by the background jobs (alerts, digests,
+	// findings); each defaults to its only cluster and uses metrics-server for the others.
+	prometheusCluster := os.Getenv("PROMETHEUS_CLUSTER")
+
+	// Pricing (nil if neither PRICING_CONFIG nor PRICE_* env vars are set)
+	prices, err := pricing.Load()
+	if err != nil {
+		log.Fatalf("pricing config: %v", err)
+	}
+	if prices != nil {
+		log.Printf("Pricing configured (%s, %d instance type(s))", prices.Currency, len(prices.InstanceTypes))
+	}
+
+	// GitOps pull requests (nil if GITOPS_CONFIG is not set)
+	gitopsCfg, err := gitops.Load()
+	if err != nil {
+		log.Fatalf("gitops config: %v", err)
+	}
+	if gitopsCfg != nil {
+		log.Printf("GitOps integration configured (%s, %s, %d mapping(s))", gitopsCfg.Provider, gitopsCfg.Repository, len(gitopsCfg.Workloads))
+	}
+
+	// Status thresholds (defaults if THRESHOLDS_CONFIG is not set)
+	thresholds, err := suggestions.LoadConfig()
+	if err != nil {
+		log.Fatalf("thresholds config: %v", err)
+	}
+	if os.Getenv("THRESHOLDS_CONFIG") != "" {
+		log.Printf("Thresholds configured (%d cluster override(s))", len(thresholds.Clusters))
+	}
 
 	// SA tokens: used in OIDC mode and in managed-SA mode (no OIDC, backend holds the token).
 	saTokens := parseSATokens()
 	// Detect in-cluster SA token (not stored — ManagedAuth re-reads per-request to avoid staleness).
 	hasInClusterDefault := false
 	if _, ok := saTokens["default"]; !ok {
-		if b, err := os.ReadFile("/var/run/secrets/kubernetes.io/serviceaccount/token"); err == nil {
+		if b, err := os.ReadFile(inClusterTokenFile); err == nil {
 			if strings.TrimSpace(string(b)) != "" {
 				hasInClusterDefault = true
 				log.Printf("in-cluster SA token detected for default cluster (read fresh per-request)")
@@ -65,6 +110,68 @@ func main() {
 		}
 	}
 
+	// Alerting webhooks (disabled if ALERTS_CONFIG is not set): evaluated with the SA tokens.
+	alertsCfg, err := alerting.Load()
+	if err != nil {
+		log.Fatalf("alerts config: %v", err)
+	}
+	if alertsCfg != nil {
+		targets, err := clusterTargets(alertsCfg.Clusters, clusters, saTokens, hasInClusterDefault)
+		if err != nil {
+			log.Fatalf("alerts config: %v", err)
+		}
+		go alerting.NewEvaluator(alertsCfg, targets, promClient, prometheusCluster, thresholds).Run(context.Background())
+		log.Printf("Alerting enabled (%d cluster(s), %d webhook(s), every %s)", len(targets), len(alertsCfg.Webhooks), alertsCfg.Interval)
+	}
+
+	// Weekly digests per namespace owner (disabled if DIGEST_CONFIG is not set), collected
+	// with the SA tokens of the configured clusters.
+	digestCfg, err := digest.Load()
+	if err != nil {
+		log.Fatalf("digest config: %v", err)
+	}
+	if digestCfg != nil {
+		names := make([]string, 0, len(digestCfg.Clusters))
+		for name := range digestCfg.Clusters {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		targets, err := clusterTargets(names, clusters, saTokens, hasInClusterDefault)
+		if err != nil {
+			log.Fatalf("digest config: %v", err)
+		}
+		scheduler, err := digest.NewScheduler(digestCfg, targets, promClient, prometheusCluster, prices, thresholds)
+		if err != nil {
+			log.Fatalf("digest config: %v", err)
+		}
+		go scheduler.Run(context.Background())
+		log.Printf("Digests enabled (%d cluster(s), via %s)", len(targets), digestCfg.SMTP.Host)
+	}
+
+	// Findings gauges on /metrics (disabled unless METRICS_INTERVAL is set), refreshed with
+	// the SA tokens. They name every namespace and workload, so METRICS_TOKEN is required;
+	// backend self-metrics are always exposed.
+	var metricsCollectors []metrics.Collector
+	if v := os.Getenv("METRICS_INTERVAL"); v != "" && v != "0" {
+		metricsInterval, err := time.ParseDuration(v)
+		if err != nil || metricsInterval < 0 {
+			log.Fatalf("METRICS_INTERVAL must be a duration like \"5m\", got %q", v)
+		}
+		if os.Getenv("METRICS_TOKEN") == "" {
+			log.Fatalf("METRICS_INTERVAL requires METRICS_TOKEN: findings expose namespace and workload names")
+		}
+		if metricsInterval > 0 {
+			if targets, err := clusterTargets(nil, clusters, saTokens, hasInClusterDefault); err != nil {
+				log.Printf("Findings metrics disabled: %v", err)
+			} else {
+				collector := findings.New(targets, promClient, prometheusCluster, thresholds)
+				go collector.Run(context.Background(), metricsInterval)
+				metricsCollectors = append(metricsCollectors, collector)
+				log.Printf("Findings metrics enabled (%d cluster(s), every %s)", len(targets), metricsInterval)
+			}
+		}
+	}
+
 	// OIDC mode: OIDC_ENABLED=true + SA tokens per cluster
 	oidcEnabled := os.Getenv("OIDC_ENABLED") == "true"
 	var oidcHandler *handlers.OIDCHandler
@@ -150,6 +257,9 @@ func main() {
 		_, _ = w.Write([]byte("ok"))
 	})
 
+	// Prometheus metrics (bearer METRICS_TOKEN required when set)
+	r.Get("/metrics", metrics.Handler(os.Getenv("METRICS_TOKEN"), metricsCollectors...))
+
 	// managedDefault: single-cluster mode where the backend holds the SA token (no OIDC, no CLUSTERS).
 	// Tells the frontend to skip token entry and go straight to dashboard.
 	managedDefault := !oidcEnabled && len(clusters) == 0 && (saTokens["default"] != "" || hasInClusterDefault)
@@ -198,16 +308,37 @@ func main() {
 			// Auth
 			r.Get("/auth/verify", handlers.VerifyToken)
 
+			// Effective status thresholds for the cluster (?namespace= applies its annotation)
+			r.Get("/config/thresholds", handlers.NewThresholdsHandler(thresholds))
+
 			// Cluster-wide node overview
-			r.Get("/nodes", handlers.ListNodes)
+			r.Get("/nodes", handlers.NewNodesHandler(os.Getenv("NODE_POOL_LABEL")))
 			r.Get("/nodes/{node}/pods", handlers.GetNodePods)
+			// Capacity and requests per zone, single-zone workloads
+			r.Get("/topology", handlers.GetTopology)
+
+			// Unscheduled pods with usage-based fit estimates
+			r.Get("/pods/pending", handlers.NewPendingPodsHandler(promClient))
+
+			// What-if: re-pack pods onto nodes with current or recommended requests
+			r.Post("/simulate/packing", handlers.NewPackingSimulationHandler(promClient, thresholds))
 
 			// Namespaces
 			r.Get("/namespaces", handlers.ListNamespaces)
-			r.Get("/namespaces/stats", handlers.GetNamespaceStats)
+			r.Get("/namespaces/stats", handlers.NewNamespaceStatsHandler(prices))
+			r.Get("/namespaces/quota-recommendations", handlers.NewQuotaRecommendationsHandler(promClient))
+
+			// Showback: requests/usage/cost grouped by namespace, node, pod/namespace/node labels
+			r.Get("/allocation", handlers.NewAllocationHandler(prices))
 
 			// Deployments + pod resource details
-			r.Get("/namespaces/{namespace}/deployments", handlers.ListDeployments)
+			r.Get("/namespaces/{namespace}/deployments", handlers.NewDeploymentsHandler(prices))
+			// Same across namespaces: ?namespaces=a,b and/or ?namespaceSelector=team=x
+			r.Get("/workloads", handlers.NewWorkloadsHandler(prices))
+
+			// Suggested requests/limits rendered as a patch for GitOps (read-only)
+			r.Get("/namespaces/{namespace}/workloads/{kind}/{name}/patch", handlers.NewPatchHandler(promClient, thresholds))
+			r.Post("/namespaces/{namespace}/workloads/{kind}/{name}/pull-request", handlers.NewPullRequestHandler(promClient, gitopsCfg, thresholds))
 
 			// Raw pod metrics (optional, useful for debugging)
 			r.Get("/namespaces/{namespace}/metrics", handlers.GetPodMetrics)
@@ -290,3 +421,46 @@ func parseSATokens() map[string]string {
 	// read here — it is re-read per-request by ManagedAuth to stay current as kubelet rotates it.
 	return tokens
 }
+
+// clusterTargets resolves the clusters of background jobs (alerting, digests, findings metrics).
+// names defaults to every cluster with an SA token; each must have one since there is no
+// user token in the background. The in-cluster token is re-read at every evaluation, like ManagedAuth does.
+func clusterTargets(names []string, clusters, saTokens map[string]string, hasInClusterDefault bool) ([]k8s.Target, error) {
+	if len(names) == 0 {
+		for name := range saTokens {
+			names = append(names, name)
+		}
+		if hasInClusterDefault {
+			names = append(names, "default")
+		}
+		sort.Strings(names)
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no SA token configured (set SA_TOKEN or SA_TOKENS)")
+	}
+	targets := make([]k8s.Target, 0, len(names))
+	for _, name := range names {
+		apiServer := clusters[name] // "" → KUBE_API_SERVER
+		if apiServer == "" && name != "default" {
+			return nil, fmt.Errorf("cluster %q is not in CLUSTERS", name)
+		}
+		token, ok := saTokens[name]
+		switch {
+		case ok:
+			targets = append(targets, k8s.Target{Name: name, Client: func() (*k8s.Client, error) {
+				return k8s.New(token, apiServer), nil
+			}})
+		case name == "default" && hasInClusterDefault:
+			targets = append(targets, k8s.Target{Name: name, Client: func() (*k8s.Client, error) {
+				b, err := os.ReadFile(inClusterTokenFile)
+				if err != nil {
+					return nil, fmt.Errorf("reading in-cluster token: %w", err)
+				}
+				return k8s.New(strings.TrimSpace(string(b)), apiServer), nil
+			}})
+		default:
+			return nil, fmt.Errorf("cluster %q has no SA token — set SA_TOKEN_%s or SA_TOKENS", name, strings.ToUpper(strings.ReplaceAll(name, "-", "_")))
+		}
+	}
+	return targets, nil
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -127,3 +128,38 @@ func TestParseSATokens(t *testing.T) {
 		}
 	})
 }
+
+func TestClusterTargets(t *testing.T) {
+	clusters := map[string]string{"prod": "https://prod", "staging": "https://staging"}
+	tokens := map[string]string{"prod": "p", "staging": "s"}
+
+	targets, err := clusterTargets(nil, clusters, tokens, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, tg := range targets {
+		names = append(names, tg.Name)
+	}
+	if strings.Join(names, ",") != "default,prod,staging" {
+		t.Errorf("default targets = %v", names)
+	}
+	if c, err := targets[1].Client(); err != nil || c == nil {
+		t.Errorf("prod client: %v", err)
+	}
+
+	for _, tt := range []struct {
+		name  string
+		names []string
+	}{
+		{"unknown cluster", []string{"dev"}},
+		{"no token", []string{"default"}},
+	} {
+		if _, err := clusterTargets(tt.names, clusters, tokens, false); err == nil {
+			t.Errorf("%s: expected error", tt.name)
+		}
+	}
+	if _, err := clusterTargets(nil, nil, nil, false); err == nil {
+		t.Error("no SA token: expected error")
+	}
+}
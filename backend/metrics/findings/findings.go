@@ -0,0 +1,315 @@
+// Package findings computes the sizing findings exported on /metrics — request-to-usage
+// ratio per container, wasted requests per namespace, suggestion counts — refreshed in the
+// background for the clusters the backend holds an SA token for.
+package findings
+
+import (
+	"context"
+	"io"
+	"log"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+
+	"github.com/devops-kubeadjust/backend/k8s"
+	"github.com/devops-kubeadjust/backend/metrics"
+	"github.com/devops-kubeadjust/backend/prometheus"
+	"github.com/devops-kubeadjust/backend/report"
+	"github.com/devops-kubeadjust/backend/resources"
+	"github.com/devops-kubeadjust/backend/suggestions"
+)
+
+// Metric families, in exposition order.
+var families = []metrics.Gauge{
+	{Name: "kubeadjust_container_request_to_usage_ratio", Help: "Request of a workload container divided by its P95 usage (metrics-server snapshot without Prometheus).",
+		Labels: []string{"cluster", "namespace", "kind", "workload", "container", "resource"}},
+	{Name: "kubeadjust_namespace_wasted_cpu_millicores", Help: "CPU requested but not used by the running pods of a namespace (sum of request − usage, per container).",
+		Labels: []string{"cluster", "namespace"}},
+	{Name: "kubeadjust_namespace_wasted_memory_bytes", Help: "Memory requested but not used by the running pods of a namespace (sum of request − usage, per container).",
+		Labels: []string{"cluster", "namespace"}},
+	{Name: "kubeadjust_suggestions_total", Help: "Current number of sizing suggestions by kind (danger, warning, overkill), as shown by the dashboard.",
+		Labels: []string{"cluster", "kind"}},
+	{Name: "kubeadjust_findings_last_refresh_timestamp_seconds", Help: "Unix time of the last successful findings refresh of a cluster.",
+		Labels: []string{"cluster"}},
+}
+
+const (
+	familyRatio = iota
+	familyWastedCPU
+	familyWastedMemory
+	familySuggestions
+	familyRefresh
+)
+
+// snapshot holds the samples of one cluster, indexed like families.
+type snapshot [][]metrics.Sample
+
+// Collector refreshes the findings of its targets and exposes the last snapshot of each.
+type Collector struct {
+	targets           []k8s.Target
+	prom              *prometheus.Client // used for prometheusCluster only; nil: metrics-server snapshots
+	prometheusCluster string
+	thresholds        *suggestions.Config
+
+	mu        sync.RWMutex
+	snapshots map[string]snapshot // by cluster; kept when a refresh fails
+}
+
+// New returns a Collector for targets. Prometheus usage (P95 and mean over
+// report.DefaultRange) is used for prometheusCluster, defaulting to the only target.
+func New(targets []k8s.Target, prom *prometheus.Client, prometheusCluster string, thresholds *suggestions.Config) *Collector {
+	if prometheusCluster == "" && len(targets) == 1 {
+		prometheusCluster = targets[0].Name
+	}
+	return &Collector{
+		targets:           targets,
+		prom:              prom,
+		prometheusCluster: prometheusCluster,
+		thresholds:        thresholds,
+		snapshots:         make(map[string]snapshot),
+	}
+}
+
+// Run refreshes immediately, then every interval until ctx is cancelled.
+func (c *Collector) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		c.Refresh(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Refresh recomputes the findings of every target. A cluster that cannot be read keeps
+// its previous snapshot.
+func (c *Collector) Refresh(ctx context.Context) {
+	for _, t := range c.targets {
+		s, err := c.collect(ctx, t)
+		if err != nil {
+			log.Printf("findings: cluster %s: %v", t.Name, err)
+			continue
+		}
+		c.mu.Lock()
+		c.snapshots[t.Name] = s
+		c.mu.Unlock()
+	}
+}
+
+func (c *Collector) collect(ctx context.Context, t k8s.Target) (snapshot, error) {
+	client, err := t.Client()
+	if err != nil {
+		return nil, err
+	}
+	namespaces, err := client.ListNamespaces(ctx)
+	if err != nil {
+		return nil, err
+	}
+	pods, err := client.ListAllPods(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var podMetrics []k8s.PodMetrics
+	if list, err := client.ListAllPodMetrics(ctx); err != nil {
+		log.Printf("findings: cluster %s: metrics-server unavailable: %v", t.Name, err)
+	} else {
+		podMetrics = list.Items
+	}
+	// Prometheus usage comes from one set of cluster-wide instant queries per refresh rather
+	// than range queries per namespace; the reports below then fall back to metrics-server.
+	var history map[string]map[string]suggestions.ContainerUsage
+	if t.Name == c.prometheusCluster && c.prom != nil {
+		tr := prometheus.ParseTimeRange(report.DefaultRange)
+		if stats, err := c.prom.GetClusterUsageStats(tr); err != nil {
+			log.Printf("findings: cluster %s: prometheus usage unavailable, using metrics-server: %v", t.Name, err)
+		} else {
+			history = historyUsage(stats, tr)
+		}
+	}
+
+	reports := make([]*report.Namespace, len(namespaces.Items))
+	thresholds := make(map[string]suggestions.Thresholds, len(namespaces.Items))
+	for _, ns := range namespaces.Items {
+		th, _, err := c.thresholds.ForNamespace(t.Name, ns.Metadata.Annotations)
+		if err != nil {
+			log.Printf("findings: cluster %s: namespace %s: %v", t.Name, ns.Metadata.Name, err)
+		}
+		thresholds[ns.Metadata.Name] = th
+	}
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(4) // bound concurrent namespace reports (each lists ~7 resources)
+	for i, ns := range namespaces.Items {
+		g.Go(func() error {
+			r, err := report.Collect(gctx, client, nil, ns.Metadata.Name, report.DefaultRange, thresholds[ns.Metadata.Name])
+			if err != nil {
+				log.Printf("findings: cluster %s: namespace %s: %v", t.Name, ns.Metadata.Name, err)
+				return nil // best-effort: the namespace is omitted
+			}
+			if history != nil {
+				applyHistory(r, history, thresholds[ns.Metadata.Name])
+			}
+			reports[i] = r
+			return nil
+		})
+	}
+	_ = g.Wait()
+
+	s := clusterSnapshot(t.Name, reports, pods.Items, podMetrics, thresholds)
+	s[familyRefresh] = []metrics.Sample{{LabelValues: []string{t.Name}, Value: float64(time.Now().Unix())}}
+	return s, nil
+}
+
+// historyUsage indexes stats by "namespace/pod" and container.
+func historyUsage(stats []prometheus.ContainerUsageStats, tr prometheus.TimeRange) map[string]map[string]suggestions.ContainerUsage {
+	step, _ := strconv.Atoi(tr.Step)
+	samples := int(tr.Duration.Seconds()) / max(step, 1)
+	out := make(map[string]map[string]suggestions.ContainerUsage)
+	for _, s := range stats {
+		key := s.Namespace + "/" + s.Pod
+		if out[key] == nil {
+			out[key] = make(map[string]suggestions.ContainerUsage)
+		}
+		out[key][s.Container] = suggestions.ContainerUsage{
+			CPU:    suggestions.Usage{P95: s.CPUP95, Mean: s.CPUMean, Samples: samples},
+			Memory: suggestions.Usage{P95: s.MemoryP95, Mean: s.MemoryMean, Samples: samples},
+		}
+	}
+	return out
+}
+
+// applyHistory replaces the usage and recommendations of r's workloads with the Prometheus
+// usage of their current pods (busiest replica wins), as report.Usage does with history.
+func applyHistory(r *report.Namespace, history map[string]map[string]suggestions.ContainerUsage, th suggestions.Thresholds) {
+	for i := range r.Workloads {
+		w := &r.Workloads[i]
+		usage := map[string]suggestions.ContainerUsage{}
+		for _, pod := range w.Pods {
+			for name, u := range history[r.Namespace+"/"+pod.Name] {
+				merged := usage[name]
+				merged.CPU = merged.CPU.Merge(u.CPU)
+				merged.Memory = merged.Memory.Merge(u.Memory)
+				usage[name] = merged
+			}
+		}
+		w.Usage = usage
+		w.Recommendations = suggestions.RecommendContainers(w.Spec, usage, th, w.Sizing)
+	}
+	r.UsageSource = report.SourcePrometheus
+}
+
+// clusterSnapshot computes the samples of one cluster. reports may contain nil entries
+// (namespaces that could not be read); thresholds is keyed by namespace.
+func clusterSnapshot(cluster string, reports []*report.Namespace, pods []k8s.Pod, podMetrics []k8s.PodMetrics, thresholds map[string]suggestions.Thresholds) snapshot {
+	s := make(snapshot, len(families))
+	counts := map[string]int{suggestions.KindDanger: 0, suggestions.KindWarning: 0, suggestions.KindOverkill: 0}
+	for _, r := range reports {
+		if r == nil {
+			continue
+		}
+		th, ok := thresholds[r.Namespace]
+		if !ok {
+			th = suggestions.DefaultThresholds()
+		}
+		for _, w := range r.Workloads {
+			if w.Sizing != nil && w.Sizing.Ignore {
+				continue
+			}
+			var floor int64
+			if w.Sizing != nil && w.Sizing.MinMemory != nil {
+				floor = w.Sizing.MinMemory.Bytes
+			}
+			for _, c := range w.Spec.Containers {
+				u, ok := w.Usage[c.Name]
+				if !ok {
+					continue
+				}
+				cpuReq, cpuLim := resources.ParseCPUMillicores(c.Resources.Requests["cpu"]), resources.ParseCPUMillicores(c.Resources.Limits["cpu"])
+				memReq, memLim := resources.ParseMemoryBytes(c.Resources.Requests["memory"]), resources.ParseMemoryBytes(c.Resources.Limits["memory"])
+				for _, res := range []struct {
+					name     string
+					req, lim int64
+					usage    suggestions.Usage
+					floor    int64
+				}{
+					{"cpu", cpuReq, cpuLim, u.CPU, 0},
+					{"memory", memReq, memLim, u.Memory, floor},
+				} {
+					if res.req > 0 && res.usage.P95 > 0 {
+						s[familyRatio] = append(s[familyRatio], metrics.Sample{
+							LabelValues: []string{cluster, r.Namespace, w.Kind, w.Name, c.Name, res.name},
+							Value:       float64(res.req) / res.usage.P95,
+						})
+					}
+					for _, k := range suggestions.Kinds(res.req, res.lim, res.usage, th, res.floor) {
+						counts[k]++
+					}
+				}
+			}
+		}
+	}
+	for _, k := range []string{suggestions.KindDanger, suggestions.KindWarning, suggestions.KindOverkill} {
+		s[familySuggestions] = append(s[familySuggestions], metrics.Sample{LabelValues: []string{cluster, k}, Value: float64(counts[k])})
+	}
+
+	// Wasted requests over every running pod with live usage, not only the workloads above.
+	requests := make(map[string]map[string]k8s.ResourceRequire, len(pods)) // "ns/pod" → container
+	for _, p := range pods {
+		m := make(map[string]k8s.ResourceRequire, len(p.Spec.Containers))
+		for _, c := range p.Spec.Containers {
+			m[c.Name] = c.Resources
+		}
+		requests[p.Metadata.Namespace+"/"+p.Metadata.Name] = m
+	}
+	wastedCPU, wastedMem := map[string]int64{}, map[string]int64{}
+	for _, pm := range podMetrics {
+		ns := pm.Metadata.Namespace
+		for _, cu := range pm.Containers {
+			req, ok := requests[ns+"/"+pm.Metadata.Name][cu.Name]
+			if !ok {
+				continue
+			}
+			wastedCPU[ns] += max(resources.ParseCPUMillicores(req.Requests["cpu"])-resources.ParseCPUMillicores(cu.Usage["cpu"]), 0)
+			wastedMem[ns] += max(resources.ParseMemoryBytes(req.Requests["memory"])-resources.ParseMemoryBytes(cu.Usage["memory"]), 0)
+		}
+	}
+	for _, ns := range sortedKeys(wastedCPU) {
+		s[familyWastedCPU] = append(s[familyWastedCPU], metrics.Sample{LabelValues: []string{cluster, ns}, Value: float64(wastedCPU[ns])})
+		s[familyWastedMemory] = append(s[familyWastedMemory], metrics.Sample{LabelValues: []string{cluster, ns}, Value: float64(wastedMem[ns])})
+	}
+	return s
+}
+
+func sortedKeys(m map[string]int64) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Expose writes the last snapshot of every cluster, one family at a time.
+func (c *Collector) Expose(w io.Writer) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	clusters := make([]string, 0, len(c.snapshots))
+	for name := range c.snapshots {
+		clusters = append(clusters, name)
+	}
+	sort.Strings(clusters)
+	for i, f := range families {
+		for _, name := range clusters {
+			f.Samples = append(f.Samples, c.snapshots[name][i]...)
+		}
+		if err := f.Expose(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package findings
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/devops-kubeadjust/backend/k8s"
+	"github.com/devops-kubeadjust/backend/prometheus"
+	"github.com/devops-kubeadjust/backend/report"
+	"github.com/devops-kubeadjust/backend/resources"
+	"github.com/devops-kubeadjust/backend/suggestions"
+)
+
+func container(name, cpuReq, memReq, memLim string) k8s.Container {
+	c := k8s.Container{Name: name}
+	c.Resources.Requests = map[string]string{"cpu": cpuReq, "memory": memReq}
+	c.Resources.Limits = map[string]string{"memory": memLim}
+	return c
+}
+
+func TestClusterSnapshot(t *testing.T) {
+	api := report.Workload{
+		DeploymentDetail: resources.DeploymentDetail{Kind: "Deployment", Name: "api", Namespace: "shop"},
+		Spec:             k8s.PodSpec{Containers: []k8s.Container{container("api", "1", "1Gi", "1Gi")}},
+		Usage: map[string]suggestions.ContainerUsage{"api": {
+			CPU:    suggestions.Usage{P95: 250, Mean: 200, Samples: 100},
+			Memory: suggestions.Usage{P95: 1 << 29, Mean: 1 << 29, Samples: 100},
+		}},
+	}
+	ignored := api
+	ignored.Name = "legacy"
+	ignored.Sizing = &resources.SizingPolicy{Ignore: true}
+	reports := []*report.Namespace{{Namespace: "shop", Workloads: []report.Workload{api, ignored}}, nil}
+
+	var pod k8s.Pod
+	pod.Metadata.Namespace, pod.Metadata.Name = "shop", "api-1"
+	pod.Spec.Containers = []k8s.Container{container("api", "1", "1Gi", "1Gi")}
+	var pm k8s.PodMetrics
+	pm.Metadata.Namespace, pm.Metadata.Name = "shop", "api-1"
+	pm.Containers = []k8s.ContainerUsage{{Name: "api", Usage: map[string]string{"cpu": "300m", "memory": "1200Mi"}}}
+
+	c := New(nil, nil, "", suggestions.DefaultConfig())
+	c.snapshots["prod"] = clusterSnapshot("prod", reports, []k8s.Pod{pod}, []k8s.PodMetrics{pm}, nil)
+	var b strings.Builder
+	if err := c.Expose(&b); err != nil {
+		t.Fatal(err)
+	}
+	out := b.String()
+	for _, want := range []string{
+		`kubeadjust_container_request_to_usage_ratio{cluster="prod",namespace="shop",kind="Deployment",workload="api",container="api",resource="cpu"} 4` + "\n",
+		`kubeadjust_container_request_to_usage_ratio{cluster="prod",namespace="shop",kind="Deployment",workload="api",container="api",resource="memory"} 2` + "\n",
+		`kubeadjust_namespace_wasted_cpu_millicores{cluster="prod",namespace="shop"} 700` + "\n",
+		`kubeadjust_namespace_wasted_memory_bytes{cluster="prod",namespace="shop"} 0` + "\n", // usage above request is not negative waste
+		// CPU: no limit (warning) and request 4× mean (overkill); memory: none.
+		`kubeadjust_suggestions_total{cluster="prod",kind="danger"} 0` + "\n",
+		`kubeadjust_suggestions_total{cluster="prod",kind="warning"} 1` + "\n",
+		`kubeadjust_suggestions_total{cluster="prod",kind="overkill"} 1` + "\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "legacy") {
+		t.Errorf("ignored workload exported:\n%s", out)
+	}
+}
+
+func TestApplyHistory(t *testing.T) {
+	tr := prometheus.ParseTimeRange(report.DefaultRange)
+	history := historyUsage([]prometheus.ContainerUsageStats{
+		{Namespace: "shop", Pod: "api-1", Container: "api", CPUP95: 250, CPUMean: 100, MemoryP95: 1 << 28, MemoryMean: 1 << 27},
+		{Namespace: "shop", Pod: "api-2", Container: "api", CPUP95: 150, CPUMean: 120, MemoryP95: 1 << 29, MemoryMean: 1 << 27},
+		{Namespace: "other", Pod: "api-3", Container: "api", CPUP95: 9000},
+	}, tr)
+	r := &report.Namespace{Namespace: "shop", UsageSource: report.SourceMetricsServer, Workloads: []report.Workload{{
+		DeploymentDetail: resources.DeploymentDetail{Kind: "Deployment", Name: "api", Namespace: "shop",
+			Pods: []resources.PodDetail{{Name: "api-1"}, {Name: "api-2"}}},
+		Spec: k8s.PodSpec{Containers: []k8s.Container{container("api", "1", "1Gi", "1Gi")}},
+	}}}
+
+	applyHistory(r, history, suggestions.DefaultThresholds())
+	w := r.Workloads[0]
+	u := w.Usage["api"]
+	if u.CPU.P95 != 250 || u.CPU.Mean != 120 || u.Memory.P95 != 1<<29 || u.CPU.Samples <= 1 {
+		t.Errorf("usage: %+v", u)
+	}
+	if r.UsageSource != report.SourcePrometheus || len(w.Recommendations) != 1 || !w.Recommendations[0].CPU.Changed {
+		t.Errorf("source %s, recommendations %+v", r.UsageSource, w.Recommendations)
+	}
+}
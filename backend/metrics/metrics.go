@@ -0,0 +1,236 @@
+// Package metrics exposes counters, histograms and gauges in the Prometheus text exposition
+// format (version 0.0.4), without pulling in the Prometheus client library. It holds the
+// backend self-metrics; findings gauges are provided by metrics/findings.
+package metrics
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Collector writes one or more metric families in the text format.
+type Collector interface {
+	Expose(w io.Writer) error
+}
+
+// Sample is one value of a metric family, with its label values in family label order.
+type Sample struct {
+	LabelValues []string
+	Value       float64
+}
+
+// Gauge is a snapshot metric family, rebuilt by its owner on every refresh.
+type Gauge struct {
+	Name    string
+	Help    string
+	Labels  []string
+	Samples []Sample
+}
+
+// Expose writes the gauge family; families without samples are omitted.
+func (g *Gauge) Expose(w io.Writer) error {
+	if len(g.Samples) == 0 {
+		return nil
+	}
+	var b strings.Builder
+	writeHeader(&b, g.Name, g.Help, "gauge")
+	for _, s := range g.Samples {
+		writeSample(&b, g.Name, g.Labels, s.LabelValues, "", "", s.Value)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// CounterVec is a monotonic counter partitioned by labels. Safe for concurrent use.
+type CounterVec struct {
+	name, help string
+	labels     []string
+	mu         sync.Mutex
+	values     map[string]*Sample // keyed by joined label values
+}
+
+// NewCounterVec returns a counter family with the given label names.
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	return &CounterVec{name: name, help: help, labels: labels, values: make(map[string]*Sample)}
+}
+
+// Inc adds 1 to the counter with the given label values.
+func (c *CounterVec) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add adds v (≥ 0) to the counter with the given label values.
+func (c *CounterVec) Add(v float64, labelValues ...string) {
+	key := strings.Join(labelValues, "\xff")
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s, ok := c.values[key]
+	if !ok {
+		s = &Sample{LabelValues: slices.Clone(labelValues)}
+		c.values[key] = s
+	}
+	s.Value += v
+}
+
+// Value returns the current value of the counter with the given label values.
+func (c *CounterVec) Value(labelValues ...string) float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if s, ok := c.values[strings.Join(labelValues, "\xff")]; ok {
+		return s.Value
+	}
+	return 0
+}
+
+// Expose writes the counter family, series sorted by label values.
+func (c *CounterVec) Expose(w io.Writer) error {
+	c.mu.Lock()
+	samples := make([]Sample, 0, len(c.values))
+	for _, s := range c.values {
+		samples = append(samples, *s)
+	}
+	c.mu.Unlock()
+	slices.SortFunc(samples, func(a, b Sample) int { return slices.Compare(a.LabelValues, b.LabelValues) })
+
+	var b strings.Builder
+	writeHeader(&b, c.name, c.help, "counter")
+	for _, s := range samples {
+		writeSample(&b, c.name, c.labels, s.LabelValues, "", "", s.Value)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// HistogramVec is a histogram partitioned by labels. Safe for concurrent use.
+type HistogramVec struct {
+	name, help string
+	labels     []string
+	buckets    []float64 // upper bounds, ascending, without +Inf
+	mu         sync.Mutex
+	series     map[string]*histogram
+}
+
+type histogram struct {
+	labelValues []string
+	counts      []uint64 // per bucket, non-cumulative; last is +Inf
+	sum         float64
+	count       uint64
+}
+
+// NewHistogramVec returns a histogram family with the given bucket upper bounds.
+func NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	return &HistogramVec{name: name, help: help, labels: labels, buckets: buckets, series: make(map[string]*histogram)}
+}
+
+// Observe records one value in the histogram with the given label values.
+func (h *HistogramVec) Observe(v float64, labelValues ...string) {
+	key := strings.Join(labelValues, "\xff")
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s, ok := h.series[key]
+	if !ok {
+		s = &histogram{labelValues: slices.Clone(labelValues), counts: make([]uint64, len(h.buckets)+1)}
+		h.series[key] = s
+	}
+	i, _ := slices.BinarySearch(h.buckets, v) // first bucket with upper bound ≥ v
+	s.counts[i]++
+	s.sum += v
+	s.count++
+}
+
+// Expose writes the histogram family with cumulative buckets, _sum and _count.
+func (h *HistogramVec) Expose(w io.Writer) error {
+	h.mu.Lock()
+	series := make([]histogram, 0, len(h.series))
+	for _, s := range h.series {
+		series = append(series, histogram{labelValues: s.labelValues, counts: slices.Clone(s.counts), sum: s.sum, count: s.count})
+	}
+	h.mu.Unlock()
+	slices.SortFunc(series, func(a, b histogram) int { return slices.Compare(a.labelValues, b.labelValues) })
+
+	var b strings.Builder
+	writeHeader(&b, h.name, h.help, "histogram")
+	for _, s := range series {
+		var cumulative uint64
+		for i, c := range s.counts {
+			cumulative += c
+			le := math.Inf(1)
+			if i < len(h.buckets) {
+				le = h.buckets[i]
+			}
+			writeSample(&b, h.name+"_bucket", h.labels, s.labelValues, "le", formatValue(le), float64(cumulative))
+		}
+		writeSample(&b, h.name+"_sum", h.labels, s.labelValues, "", "", s.sum)
+		writeSample(&b, h.name+"_count", h.labels, s.labelValues, "", "", float64(s.count))
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeHeader(w *strings.Builder, name, help, typ string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(help), name, typ)
+}
+
+// writeSample writes one sample line; extraName/extraValue is an additional label (le).
+func writeSample(w *strings.Builder, name string, labels, values []string, extraName, extraValue string, v float64) {
+	w.WriteString(name)
+	if len(labels) > 0 || extraName != "" {
+		w.WriteByte('{')
+		sep := ""
+		for i, l := range labels {
+			var val string
+			if i < len(values) {
+				val = values[i]
+			}
+			fmt.Fprintf(w, "%s%s=\"%s\"", sep, l, escapeLabel(val))
+			sep = ","
+		}
+		if extraName != "" {
+			fmt.Fprintf(w, "%s%s=\"%s\"", sep, extraName, extraValue)
+		}
+		w.WriteByte('}')
+	}
+	w.WriteByte(' ')
+	w.WriteString(formatValue(v))
+	w.WriteByte('\n')
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(v string) string { return labelEscaper.Replace(v) }
+
+func formatValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// Handler serves the self-metrics followed by the given collectors. When token is
+// non-empty, requests must carry it as a bearer token.
+func Handler(token string, collectors ...Collector) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if token != "" && subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte("Bearer "+token)) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		for _, c := range append(selfMetrics(), collectors...) {
+			if err := c.Expose(w); err != nil {
+				return // client went away
+			}
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestExposition(t *testing.T) {
+	c := NewCounterVec("test_requests_total", "Requests.", "cache", "result")
+	c.Inc("pods", "miss")
+	c.Inc("pods", "hit")
+	c.Add(2, "pods", "hit")
+	h := NewHistogramVec("test_duration_seconds", "Latency.", []float64{0.1, 1}, "endpoint")
+	h.Observe(0.05, "/api")
+	h.Observe(0.1, "/api")
+	h.Observe(3, "/api")
+	g := &Gauge{Name: "test_ratio", Help: "Ratio.", Labels: []string{"name"}, Samples: []Sample{{LabelValues: []string{`a"b\c`}, Value: 1.5}}}
+
+	var b strings.Builder
+	for _, col := range []Collector{c, h, g, &Gauge{Name: "test_empty"}} {
+		if err := col.Expose(&b); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := `# HELP test_requests_total Requests.
+# TYPE test_requests_total counter
+test_requests_total{cache="pods",result="hit"} 3
+test_requests_total{cache="pods",result="miss"} 1
+# HELP test_duration_seconds Latency.
+# TYPE test_duration_seconds histogram
+test_duration_seconds_bucket{endpoint="/api",le="0.1"} 2
+test_duration_seconds_bucket{endpoint="/api",le="1"} 2
+test_duration_seconds_bucket{endpoint="/api",le="+Inf"} 3
+test_duration_seconds_sum{endpoint="/api"} 3.15
+test_duration_seconds_count{endpoint="/api"} 3
+# HELP test_ratio Ratio.
+# TYPE test_ratio gauge
+test_ratio{name="a\"b\\c"} 1.5
+`
+	if got := b.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestEndpoint(t *testing.T) {
+	tests := map[string]string{
+		"/api/v1/pods":                                   "/api/v1/pods",
+		"/api/v1/namespaces":                             "/api/v1/namespaces",
+		"/api/v1/namespaces/shop":                        "/api/v1/namespaces/{namespace}",
+		"/api/v1/namespaces/shop/pods?limit=5":           "/api/v1/namespaces/{namespace}/pods",
+		"/apis/apps/v1/namespaces/shop/deployments/api":  "/apis/apps/v1/namespaces/{namespace}/deployments/{name}",
+		"/api/v1/nodes/node-a/proxy/stats/summary":       "/api/v1/nodes/{node}/proxy/stats/summary",
+		"/apis/metrics.k8s.io/v1beta1/namespaces/x/pods": "/apis/metrics.k8s.io/v1beta1/namespaces/{namespace}/pods",
+	}
+	for path, want := range tests {
+		if got := Endpoint(path); got != want {
+			t.Errorf("Endpoint(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestHandlerToken(t *testing.T) {
+	h := Handler("s3cret", &Gauge{Name: "test_up", Samples: []Sample{{Value: 1}}})
+	for _, tt := range []struct {
+		auth string
+		code int
+	}{
+		{"", http.StatusUnauthorized},
+		{"Bearer nope", http.StatusUnauthorized},
+		{"Bearer s3cret", http.StatusOK},
+	} {
+		req := httptest.NewRequest("GET", "/metrics", nil)
+		if tt.auth != "" {
+			req.Header.Set("Authorization", tt.auth)
+		}
+		rec := httptest.NewRecorder()
+		h(rec, req)
+		if rec.Code != tt.code {
+			t.Errorf("auth %q: got %d, want %d", tt.auth, rec.Code, tt.code)
+		}
+		if tt.code == http.StatusOK && !strings.Contains(rec.Body.String(), "\ntest_up 1\n") {
+			t.Errorf("body: %s", rec.Body.String())
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package metrics
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Backend self-metrics, updated by the k8s and prometheus clients.
+var (
+	K8sRequestDuration = NewHistogramVec("kubeadjust_k8s_request_duration_seconds",
+		"Latency of Kubernetes API requests by endpoint (names replaced by placeholders) and status code class.",
+		[]float64{0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}, "endpoint", "code")
+	CacheRequests = NewCounterVec("kubeadjust_cache_requests_total",
+		"Lookups of the cluster-wide caches (pods, nodes, metrics, kubelet summaries) by result (hit or miss).",
+		"cache", "result")
+	PrometheusQueries = NewCounterVec("kubeadjust_prometheus_queries_total",
+		"Prometheus HTTP API queries by result (success or error).",
+		"result")
+)
+
+func selfMetrics() []Collector {
+	return []Collector{K8sRequestDuration, CacheRequests, PrometheusQueries}
+}
+
+// ObserveK8sRequest records one Kubernetes API request. code is the HTTP status, 0 when the
+// request failed before a response.
+func ObserveK8sRequest(path string, code int, d time.Duration) {
+	class := "error"
+	if code > 0 {
+		class = strconv.Itoa(code/100) + "xx"
+	}
+	K8sRequestDuration.Observe(d.Seconds(), Endpoint(path), class)
+}
+
+// Endpoint turns a Kubernetes API path into a low-cardinality label by replacing namespace,
+// node and object names with placeholders, e.g.
+// "/apis/apps/v1/namespaces/shop/deployments/api" → "/apis/apps/v1/namespaces/{namespace}/deployments/{name}".
+func Endpoint(path string) string {
+	path, _, _ = strings.Cut(path, "?")
+	segs := strings.Split(path, "/")
+	for i := 1; i < len(segs); i++ {
+		switch {
+		case segs[i-1] == "namespaces":
+			segs[i] = "{namespace}"
+		case segs[i-1] == "nodes":
+			segs[i] = "{node}"
+		case i >= 3 && segs[i-2] == "{namespace}":
+			segs[i] = "{name}"
+		}
+	}
+	return strings.Join(segs, "/")
+}
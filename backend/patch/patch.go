@@ -0,0 +1,255 @@
+// Package patch renders resource suggestions as ready-to-apply manifests: strategic merge
+// patches, RFC 6902 JSON patches, Kustomize patch entries and Helm values snippets.
+// Nothing is ever sent to the cluster; the output is meant to be committed through GitOps.
+package patch
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/devops-kubeadjust/backend/k8s"
+	"github.com/devops-kubeadjust/backend/resources"
+	"github.com/devops-kubeadjust/backend/suggestions"
+)
+
+// Supported output formats.
+const (
+	FormatStrategic  = "strategic"
+	FormatJSON       = "json"
+	FormatKustomize  = "kustomize"
+	FormatHelmValues = "helm-values"
+)
+
+// ValidFormat reports whether f is a supported format.
+func ValidFormat(f string) bool {
+	switch f {
+	case FormatStrategic, FormatJSON, FormatKustomize, FormatHelmValues:
+		return true
+	}
+	return false
+}
+
+// ContentType returns the MIME type of a rendered format.
+func ContentType(format string) string {
+	if format == FormatJSON {
+		return "application/json-patch+json"
+	}
+	return "application/yaml"
+}
+
+// Workload identifies the patched object and its pod template containers (in spec order).
+type Workload struct {
+	Kind       string // Deployment | StatefulSet | DaemonSet | CronJob
+	Name       string
+	Namespace  string
+	Containers []k8s.Container
+}
+
+// Change is the target resources of one container: the full recommended requests and limits
+// ("cpu", "memory" → quantity), so the rendered patch is idempotent.
+type Change struct {
+	Container string
+	Requests  map[string]string
+	Limits    map[string]string
+}
+
+// Changes converts recommendations into patch changes, skipping unchanged containers.
+func Changes(recs []suggestions.ContainerRecommendation) []Change {
+	var out []Change
+	for _, r := range recs {
+		if !r.Changed() {
+			continue
+		}
+		c := Change{Container: r.Container, Requests: map[string]string{}, Limits: map[string]string{}}
+		set := func(m map[string]string, name string, v int64, isCPU bool) {
+			if v > 0 {
+				m[name] = resources.FmtQuantity(v, isCPU)
+			}
+		}
+		set(c.Requests, "cpu", r.CPU.Request, true)
+		set(c.Requests, "memory", r.Memory.Request, false)
+		set(c.Limits, "cpu", r.CPU.Limit, true)
+		set(c.Limits, "memory", r.Memory.Limit, false)
+		out = append(out, c)
+	}
+	return out
+}
+
+// Render produces the patch for w in the given format. With no changes the output is an
+// empty but valid document of that format.
+func Render(format string, w Workload, changes []Change) ([]byte, error) {
+	switch format {
+	case FormatStrategic:
+		return []byte(strategic(w, changes, true)), nil
+	case FormatJSON:
+		return jsonPatch(w, changes)
+	case FormatKustomize:
+		return []byte(kustomize(w, changes)), nil
+	case FormatHelmValues:
+		return []byte(helmValues(w, changes)), nil
+	}
+	return nil, fmt.Errorf("unknown patch format %q", format)
+}
+
+// apiVersion and podSpecPath describe where the pod template lives for each kind.
+func apiVersion(kind string) string {
+	if kind == "CronJob" {
+		return "batch/v1"
+	}
+	return "apps/v1"
+}
+
+func podSpecPath(kind string) []string {
+	if kind == "CronJob" {
+		return []string{"spec", "jobTemplate", "spec", "template", "spec"}
+	}
+	return []string{"spec", "template", "spec"}
+}
+
+func noChanges(w Workload) string {
+	return fmt.Sprintf("# no resource changes suggested for %s/%s\n", w.Kind, w.Name)
+}
+
+// strategic renders a strategic merge patch (containers are merged by name).
+func strategic(w Workload, changes []Change, withNamespace bool) string {
+	if len(changes) == 0 {
+		return noChanges(w)
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "apiVersion: %s\nkind: %s\nmetadata:\n  name: %s\n", apiVersion(w.Kind), w.Kind, w.Name)
+	if withNamespace {
+		fmt.Fprintf(&b, "  namespace: %s\n", w.Namespace)
+	}
+	indent := ""
+	for _, key := range podSpecPath(w.Kind) {
+		fmt.Fprintf(&b, "%s%s:\n", indent, key)
+		indent += "  "
+	}
+	fmt.Fprintf(&b, "%scontainers:\n", indent)
+	for _, c := range changes {
+		fmt.Fprintf(&b, "%s  - name: %s\n", indent, c.Container)
+		writeResources(&b, indent+"    ", c)
+	}
+	return b.String()
+}
+
+// OverlayPatch renders the strategic merge patch without a namespace, as stored in a
+// Kustomize overlay (the overlay sets the namespace).
+func OverlayPatch(w Workload, changes []Change) string {
+	return strategic(w, changes, false)
+}
+
+// ResourcesYAML renders the "resources:" block of c at the given indentation.
+func ResourcesYAML(indent string, c Change) string {
+	var b strings.Builder
+	writeResources(&b, indent, c)
+	return b.String()
+}
+
+// writeResources writes a "resources:" block at the given indentation.
+func writeResources(b *strings.Builder, indent string, c Change) {
+	fmt.Fprintf(b, "%sresources:\n", indent)
+	for _, section := range []struct {
+		name   string
+		values map[string]string
+	}{{"requests", c.Requests}, {"limits", c.Limits}} {
+		if len(section.values) == 0 {
+			continue
+		}
+		fmt.Fprintf(b, "%s  %s:\n", indent, section.name)
+		for _, k := range sortedKeys(section.values) {
+			fmt.Fprintf(b, "%s    %s: %q\n", indent, k, section.values[k])
+		}
+	}
+}
+
+type jsonOp struct {
+	Op    string `json:"op"`
+	Path  string `json:"path"`
+	Value any    `json:"value"`
+}
+
+// jsonPatch renders an RFC 6902 patch addressing containers by index. "add" replaces existing
+// members, and whole requests/limits objects are added when the container has none.
+func jsonPatch(w Workload, changes []Change) ([]byte, error) {
+	ops := []jsonOp{}
+	base := "/" + strings.Join(podSpecPath(w.Kind), "/") + "/containers"
+	for _, c := range changes {
+		idx := -1
+		for i, sc := range w.Containers {
+			if sc.Name == c.Container {
+				idx = i
+			}
+		}
+		if idx < 0 {
+			return nil, fmt.Errorf("container %q not found in %s/%s", c.Container, w.Kind, w.Name)
+		}
+		existing := w.Containers[idx].Resources
+		for _, section := range []struct {
+			name    string
+			values  map[string]string
+			current map[string]string
+		}{{"requests", c.Requests, existing.Requests}, {"limits", c.Limits, existing.Limits}} {
+			if len(section.values) == 0 {
+				continue
+			}
+			path := fmt.Sprintf("%s/%d/resources/%s", base, idx, section.name)
+			if section.current == nil {
+				ops = append(ops, jsonOp{Op: "add", Path: path, Value: section.values})
+				continue
+			}
+			for _, k := range sortedKeys(section.values) {
+				ops = append(ops, jsonOp{Op: "add", Path: path + "/" + k, Value: section.values[k]})
+			}
+		}
+	}
+	out, err := json.MarshalIndent(ops, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(out, '\n'), nil
+}
+
+// kustomize renders a kustomization.yaml "patches" entry with the strategic patch inlined.
+func kustomize(w Workload, changes []Change) string {
+	if len(changes) == 0 {
+		return noChanges(w)
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "# kustomization.yaml\npatches:\n  - target:\n      kind: %s\n      name: %s\n    patch: |-\n", w.Kind, w.Name)
+	for line := range strings.Lines(strategic(w, changes, false)) {
+		fmt.Fprintf(&b, "      %s", line)
+	}
+	return b.String()
+}
+
+// helmValues renders a values snippet: a top-level "resources" block for single-container
+// workloads (the common chart convention), one block per container name otherwise.
+func helmValues(w Workload, changes []Change) string {
+	if len(changes) == 0 {
+		return noChanges(w)
+	}
+	var b strings.Builder
+	if len(w.Containers) <= 1 && len(changes) == 1 {
+		fmt.Fprintf(&b, "# %s/%s\n", w.Kind, w.Name)
+		writeResources(&b, "", changes[0])
+		return b.String()
+	}
+	fmt.Fprintf(&b, "# %s/%s — one block per container; adapt keys to the chart's values layout\n", w.Kind, w.Name)
+	for _, c := range changes {
+		fmt.Fprintf(&b, "%s:\n", c.Container)
+		writeResources(&b, "  ", c)
+	}
+	return b.String()
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
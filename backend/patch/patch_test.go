@@ -0,0 +1,130 @@
+package patch
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/devops-kubeadjust/backend/k8s"
+	"github.com/devops-kubeadjust/backend/suggestions"
+)
+
+func workload(kind string) Workload {
+	return Workload{
+		Kind:      kind,
+		Name:      "web",
+		Namespace: "payments",
+		Containers: []k8s.Container{
+			{Name: "app", Resources: k8s.ResourceRequire{Requests: map[string]string{"cpu": "1"}}},
+			{Name: "sidecar"},
+		},
+	}
+}
+
+var changes = []Change{{
+	Container: "sidecar",
+	Requests:  map[string]string{"cpu": "50m", "memory": "64Mi"},
+	Limits:    map[string]string{"memory": "128Mi"},
+}}
+
+func TestChanges(t *testing.T) {
+	recs := []suggestions.ContainerRecommendation{
+		{Container: "app", CPU: suggestions.Recommendation{Request: 500, Limit: 1000}},
+		{Container: "sidecar", CPU: suggestions.Recommendation{Request: 50, Changed: true}, Memory: suggestions.Recommendation{Request: 64 << 20, Limit: 128 << 20}},
+	}
+	got := Changes(recs)
+	if len(got) != 1 || got[0].Container != "sidecar" {
+		t.Fatalf("got %+v, want only the changed sidecar", got)
+	}
+	if got[0].Requests["cpu"] != "50m" || got[0].Limits["memory"] != "128Mi" {
+		t.Errorf("values: got %+v", got[0])
+	}
+	if _, ok := got[0].Limits["cpu"]; ok {
+		t.Error("unset CPU limit should not be rendered as 0")
+	}
+}
+
+func TestRenderStrategic(t *testing.T) {
+	out, err := Render(FormatStrategic, workload("CronJob"), changes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `apiVersion: batch/v1
+kind: CronJob
+metadata:
+  name: web
+  namespace: payments
+spec:
+  jobTemplate:
+    spec:
+      template:
+        spec:
+          containers:
+            - name: sidecar
+              resources:
+                requests:
+                  cpu: "50m"
+                  memory: "64Mi"
+                limits:
+                  memory: "128Mi"
+`
+	if string(out) != want {
+		t.Errorf("got:\n%s\nwant:\n%s", out, want)
+	}
+}
+
+func TestRenderJSON(t *testing.T) {
+	more := append([]Change{{Container: "app", Requests: map[string]string{"cpu": "500m"}, Limits: map[string]string{"cpu": "1"}}}, changes...)
+	out, err := Render(FormatJSON, workload("Deployment"), more)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ops []jsonOp
+	if err := json.Unmarshal(out, &ops); err != nil {
+		t.Fatalf("invalid JSON patch: %v", err)
+	}
+	paths := make([]string, len(ops))
+	for i, op := range ops {
+		paths[i] = op.Path
+	}
+	want := []string{
+		"/spec/template/spec/containers/0/resources/requests/cpu", // existing requests: per key
+		"/spec/template/spec/containers/0/resources/limits",       // no limits yet: whole object
+		"/spec/template/spec/containers/1/resources/requests",
+		"/spec/template/spec/containers/1/resources/limits",
+	}
+	if strings.Join(paths, " ") != strings.Join(want, " ") {
+		t.Errorf("paths: got %v, want %v", paths, want)
+	}
+
+	if _, err := Render(FormatJSON, workload("Deployment"), []Change{{Container: "missing"}}); err == nil {
+		t.Error("expected error for unknown container")
+	}
+}
+
+func TestRenderKustomizeAndHelm(t *testing.T) {
+	out, _ := Render(FormatKustomize, workload("Deployment"), changes)
+	if !strings.Contains(string(out), "    patch: |-\n      apiVersion: apps/v1\n") {
+		t.Errorf("kustomize patch not inlined:\n%s", out)
+	}
+	if strings.Contains(string(out), "namespace:") {
+		t.Error("kustomize patch should leave the namespace to the overlay")
+	}
+
+	out, _ = Render(FormatHelmValues, workload("Deployment"), changes)
+	if !strings.Contains(string(out), "sidecar:\n  resources:\n    requests:\n") {
+		t.Errorf("multi-container helm values should be keyed by container:\n%s", out)
+	}
+
+	single := workload("Deployment")
+	single.Containers = single.Containers[1:]
+	out, _ = Render(FormatHelmValues, single, changes)
+	if !strings.HasPrefix(string(out), "# Deployment/web\nresources:\n") {
+		t.Errorf("single-container helm values should use a top-level resources block:\n%s", out)
+	}
+
+	out, _ = Render(FormatHelmValues, single, nil)
+	if !strings.HasPrefix(string(out), "# no resource changes") {
+		t.Errorf("no changes: got %q", out)
+	}
+}
@@ -0,0 +1,136 @@
+package policy
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"strings"
+
+	"github.com/devops-kubeadjust/backend/report"
+)
+
+type junitSuites struct {
+	XMLName  xml.Name     `xml:"testsuites"`
+	Name     string       `xml:"name,attr"`
+	Tests    int          `xml:"tests,attr"`
+	Failures int          `xml:"failures,attr"`
+	Suites   []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name     string      `xml:"name,attr"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Cases    []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// JUnit renders one test suite per namespace and one test case per container, failed when
+// the container has findings (all of them listed in the failure body).
+func JUnit(namespaces []*report.Namespace, findings []Finding) ([]byte, error) {
+	byContainer := map[string][]Finding{}
+	for _, f := range findings {
+		byContainer[f.Location()] = append(byContainer[f.Location()], f)
+	}
+	out := junitSuites{Name: "kubeadjust"}
+	for _, n := range namespaces {
+		suite := junitSuite{Name: n.Namespace}
+		for _, w := range n.Workloads {
+			for _, c := range w.Spec.Containers {
+				tc := junitCase{Name: w.Kind + "/" + w.Name + "/" + c.Name, ClassName: n.Namespace}
+				if fs := byContainer[Finding{Namespace: n.Namespace, Kind: w.Kind, Workload: w.Name, Container: c.Name}.Location()]; len(fs) > 0 {
+					var text strings.Builder
+					for _, f := range fs {
+						fmt.Fprintf(&text, "[%s] %s\n", f.Rule, f.Message)
+					}
+					tc.Failure = &junitFailure{
+						Message: fmt.Sprintf("%d policy violation(s)", len(fs)),
+						Type:    fs[0].Rule,
+						Text:    text.String(),
+					}
+					suite.Failures++
+				}
+				suite.Cases = append(suite.Cases, tc)
+				suite.Tests++
+			}
+		}
+		out.Tests += suite.Tests
+		out.Failures += suite.Failures
+		out.Suites = append(out.Suites, suite)
+	}
+	data, err := xml.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), append(data, '\n')...), nil
+}
+
+// SARIF renders the findings as a SARIF 2.1.0 log. Workloads have no source file, so each
+// result carries a logical location "namespace/Kind/name/container".
+func SARIF(findings []Finding) ([]byte, error) {
+	type message struct {
+		Text string `json:"text"`
+	}
+	type rule struct {
+		ID               string  `json:"id"`
+		ShortDescription message `json:"shortDescription"`
+	}
+	type logicalLocation struct {
+		FullyQualifiedName string `json:"fullyQualifiedName"`
+		Kind               string `json:"kind"`
+	}
+	type location struct {
+		LogicalLocations []logicalLocation `json:"logicalLocations"`
+	}
+	type result struct {
+		RuleID    string     `json:"ruleId"`
+		Level     string     `json:"level"`
+		Message   message    `json:"message"`
+		Locations []location `json:"locations"`
+	}
+
+	rules := make([]rule, len(Rules))
+	for i, r := range Rules {
+		rules[i] = rule{ID: r.ID, ShortDescription: message{r.Description}}
+	}
+	results := make([]result, 0, len(findings))
+	for _, f := range findings {
+		results = append(results, result{
+			RuleID:  f.Rule,
+			Level:   "error",
+			Message: message{fmt.Sprintf("%s %s/%s container %s: %s", f.Kind, f.Namespace, f.Workload, f.Container, f.Message)},
+			Locations: []location{{LogicalLocations: []logicalLocation{{
+				FullyQualifiedName: f.Location(),
+				Kind:               "resource",
+			}}}},
+		})
+	}
+	log := map[string]any{
+		"$schema": "https://json.schemastore.org/sarif-2.1.0.json",
+		"version": "2.1.0",
+		"runs": []any{map[string]any{
+			"tool": map[string]any{"driver": map[string]any{
+				"name":           "kubeadjust",
+				"informationUri": "https://github.com/Thomas6013/kubeadjust",
+				"rules":          rules,
+			}},
+			"results": results,
+		}},
+	}
+	data, err := json.MarshalIndent(log, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
@@ -0,0 +1,133 @@
+// Package policy checks workloads against sizing rules — requests set, memory limit set,
+// requests not far above observed usage, limits not far above requests — for CI gates,
+// and renders the findings as JUnit XML or SARIF.
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/devops-kubeadjust/backend/report"
+	"github.com/devops-kubeadjust/backend/resources"
+	"github.com/devops-kubeadjust/backend/suggestions"
+)
+
+// Rule identifiers, stable for SARIF and JUnit consumers.
+const (
+	RuleNoRequest         = "no-request"
+	RuleNoMemoryLimit     = "no-memory-limit"
+	RuleRequestOverP95    = "request-over-p95"
+	RuleLimitRequestRatio = "limit-request-ratio"
+)
+
+// Rules describes every rule, in evaluation order.
+var Rules = []struct {
+	ID          string
+	Description string
+}{
+	{RuleNoRequest, "Containers must set CPU and memory requests."},
+	{RuleNoMemoryLimit, "Containers must set a memory limit."},
+	{RuleRequestOverP95, "Requests must not exceed the observed P95 usage by more than the configured factor."},
+	{RuleLimitRequestRatio, "Limits must not exceed requests by more than the configured ratio."},
+}
+
+// Policy selects the rules to enforce. A zero factor disables the matching rule.
+type Policy struct {
+	RequireRequests    bool    `json:"requireRequests"`
+	RequireMemoryLimit bool    `json:"requireMemoryLimit"`
+	MaxRequestToP95    float64 `json:"maxRequestToP95"`   // request > N × P95 usage → violation
+	MaxLimitToRequest  float64 `json:"maxLimitToRequest"` // limit / request > N → violation
+}
+
+// DefaultPolicy enables every rule: requests above 3× P95 usage and limits above 4× the
+// request fail.
+func DefaultPolicy() Policy {
+	return Policy{RequireRequests: true, RequireMemoryLimit: true, MaxRequestToP95: 3, MaxLimitToRequest: 4}
+}
+
+// Parse decodes a JSON policy on top of DefaultPolicy, so a file only lists what it changes.
+func Parse(data []byte) (Policy, error) {
+	p := DefaultPolicy()
+	if err := json.Unmarshal(data, &p); err != nil {
+		return p, fmt.Errorf("parsing policy: %w", err)
+	}
+	if p.MaxRequestToP95 < 0 || (p.MaxLimitToRequest != 0 && p.MaxLimitToRequest < 1) {
+		return p, fmt.Errorf("policy: maxRequestToP95 must be ≥ 0 and maxLimitToRequest 0 or ≥ 1")
+	}
+	return p, nil
+}
+
+// Finding is one rule violation by one container.
+type Finding struct {
+	Rule      string `json:"rule"`
+	Namespace string `json:"namespace"`
+	Kind      string `json:"kind"`
+	Workload  string `json:"workload"`
+	Container string `json:"container"`
+	Resource  string `json:"resource"` // cpu | memory
+	Message   string `json:"message"`
+}
+
+// Location is the "namespace/Kind/name/container" path of the finding.
+func (f Finding) Location() string {
+	return strings.Join([]string{f.Namespace, f.Kind, f.Workload, f.Container}, "/")
+}
+
+// Evaluate checks the pod template of every workload of n. Usage-based rules are skipped
+// for containers without observed usage, workloads annotated kubeadjust.io/ignore are
+// skipped, and memory requests at or below kubeadjust.io/min-memory are not oversized.
+func Evaluate(p Policy, n *report.Namespace) []Finding {
+	var out []Finding
+	for _, w := range n.Workloads {
+		if w.Sizing != nil && w.Sizing.Ignore {
+			continue
+		}
+		var minMemory int64
+		if w.Sizing != nil && w.Sizing.MinMemory != nil {
+			minMemory = w.Sizing.MinMemory.Bytes
+		}
+		for _, c := range w.Spec.Containers {
+			add := func(rule, resource, format string, args ...any) {
+				out = append(out, Finding{
+					Rule: rule, Namespace: n.Namespace, Kind: w.Kind, Workload: w.Name, Container: c.Name,
+					Resource: resource, Message: fmt.Sprintf(format, args...),
+				})
+			}
+			usage := w.Usage[c.Name]
+			for _, r := range []struct {
+				name     string
+				isCPU    bool
+				req, lim int64
+				usage    suggestions.Usage
+				floor    int64
+			}{
+				{"cpu", true, resources.ParseCPUMillicores(c.Resources.Requests["cpu"]), resources.ParseCPUMillicores(c.Resources.Limits["cpu"]), usage.CPU, 0},
+				{"memory", false, resources.ParseMemoryBytes(c.Resources.Requests["memory"]), resources.ParseMemoryBytes(c.Resources.Limits["memory"]), usage.Memory, minMemory},
+			} {
+				if p.RequireRequests && r.req == 0 {
+					add(RuleNoRequest, r.name, "no %s request set", r.name)
+				}
+				if p.RequireMemoryLimit && !r.isCPU && r.lim == 0 {
+					add(RuleNoMemoryLimit, r.name, "no memory limit set")
+				}
+				if p.MaxRequestToP95 > 0 && r.req > r.floor && r.usage.P95 > 0 && float64(r.req) > p.MaxRequestToP95*r.usage.P95 {
+					add(RuleRequestOverP95, r.name, "%s request %s is %.1f× its P95 usage (%s), above %g×",
+						r.name, resources.FmtQuantity(r.req, r.isCPU), float64(r.req)/r.usage.P95, fmtUsage(r.usage.P95, r.isCPU), p.MaxRequestToP95)
+				}
+				if p.MaxLimitToRequest > 0 && r.req > 0 && r.lim > 0 && float64(r.lim) > p.MaxLimitToRequest*float64(r.req) {
+					add(RuleLimitRequestRatio, r.name, "%s limit %s is %.1f× the request %s, above %g×",
+						r.name, resources.FmtQuantity(r.lim, r.isCPU), float64(r.lim)/float64(r.req), resources.FmtQuantity(r.req, r.isCPU), p.MaxLimitToRequest)
+				}
+			}
+		}
+	}
+	return out
+}
+
+func fmtUsage(v float64, isCPU bool) string {
+	if isCPU {
+		return resources.FmtMillicores(int64(v))
+	}
+	return resources.FmtBytes(int64(v))
+}
@@ -0,0 +1,141 @@
+package policy
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/devops-kubeadjust/backend/k8s"
+	"github.com/devops-kubeadjust/backend/report"
+	"github.com/devops-kubeadjust/backend/resources"
+	"github.com/devops-kubeadjust/backend/suggestions"
+)
+
+const mib = 1 << 20
+
+func testNamespace() *report.Namespace {
+	w := func(name string, c k8s.Container, usage suggestions.ContainerUsage) report.Workload {
+		return report.Workload{
+			DeploymentDetail: resources.DeploymentDetail{Kind: "Deployment", Name: name, Namespace: "payments"},
+			Spec:             k8s.PodSpec{Containers: []k8s.Container{c}},
+			Usage:            map[string]suggestions.ContainerUsage{c.Name: usage},
+		}
+	}
+	return &report.Namespace{Namespace: "payments", Workloads: []report.Workload{
+		w("good", k8s.Container{Name: "app", Resources: k8s.ResourceRequire{
+			Requests: map[string]string{"cpu": "200m", "memory": "256Mi"},
+			Limits:   map[string]string{"cpu": "400m", "memory": "512Mi"},
+		}}, suggestions.ContainerUsage{CPU: suggestions.Usage{P95: 150}, Memory: suggestions.Usage{P95: 200 * mib}}),
+		w("bare", k8s.Container{Name: "app"}, suggestions.ContainerUsage{}),
+		w("fat", k8s.Container{Name: "app", Resources: k8s.ResourceRequire{
+			Requests: map[string]string{"cpu": "2", "memory": "128Mi"},
+			Limits:   map[string]string{"memory": "1Gi"},
+		}}, suggestions.ContainerUsage{CPU: suggestions.Usage{P95: 100}, Memory: suggestions.Usage{P95: 100 * mib}}),
+	}}
+}
+
+func TestEvaluate(t *testing.T) {
+	n := testNamespace()
+	var got []string
+	for _, f := range Evaluate(DefaultPolicy(), n) {
+		got = append(got, f.Workload+":"+f.Rule+":"+f.Resource)
+	}
+	want := []string{
+		"bare:no-request:cpu", "bare:no-request:memory", "bare:no-memory-limit:memory",
+		"fat:request-over-p95:cpu", "fat:limit-request-ratio:memory",
+	}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	// disabled rules and a looser factor leave only the missing requests
+	p := Policy{RequireRequests: true, MaxRequestToP95: 30}
+	if fs := Evaluate(p, n); len(fs) != 2 || fs[0].Rule != RuleNoRequest {
+		t.Errorf("relaxed policy: got %+v", fs)
+	}
+}
+
+func TestParse(t *testing.T) {
+	p, err := Parse([]byte(`{"requireMemoryLimit": false, "maxLimitToRequest": 8}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (Policy{RequireRequests: true, MaxRequestToP95: 3, MaxLimitToRequest: 8}); p != want {
+		t.Errorf("got %+v, want %+v", p, want)
+	}
+	for _, bad := range []string{`{"maxLimitToRequest": 0.5}`, `{"maxRequestToP95": -1}`, `not json`} {
+		if _, err := Parse([]byte(bad)); err == nil {
+			t.Errorf("expected error for %s", bad)
+		}
+	}
+}
+
+func TestJUnitAndSARIF(t *testing.T) {
+	n := testNamespace()
+	findings := Evaluate(DefaultPolicy(), n)
+
+	data, err := JUnit([]*report.Namespace{n}, findings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	xml := string(data)
+	for _, want := range []string{
+		`<testsuites name="kubeadjust" tests="3" failures="2">`,
+		`<testcase name="Deployment/good/app" classname="payments"></testcase>`,
+		`<failure message="3 policy violation(s)" type="no-request">`,
+	} {
+		if !strings.Contains(xml, want) {
+			t.Errorf("junit missing %q:\n%s", want, xml)
+		}
+	}
+
+	data, err = SARIF(findings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var log struct {
+		Version string `json:"version"`
+		Runs    []struct {
+			Tool struct {
+				Driver struct {
+					Rules []struct {
+						ID string `json:"id"`
+					} `json:"rules"`
+				} `json:"driver"`
+			} `json:"tool"`
+			Results []struct {
+				RuleID    string `json:"ruleId"`
+				Locations []struct {
+					LogicalLocations []struct {
+						FullyQualifiedName string `json:"fullyQualifiedName"`
+					} `json:"logicalLocations"`
+				} `json:"locations"`
+			} `json:"results"`
+		} `json:"runs"`
+	}
+	if err := json.Unmarshal(data, &log); err != nil {
+		t.Fatal(err)
+	}
+	run := log.Runs[0]
+	if log.Version != "2.1.0" || len(run.Tool.Driver.Rules) != len(Rules) || len(run.Results) != len(findings) {
+		t.Fatalf("sarif: %s", data)
+	}
+	if loc := run.Results[3].Locations[0].LogicalLocations[0].FullyQualifiedName; loc != "payments/Deployment/fat/app" {
+		t.Errorf("location: got %q", loc)
+	}
+}
+
+func TestEvaluateSizingAnnotations(t *testing.T) {
+	n := testNamespace()
+	n.Workloads[1].Sizing = &resources.SizingPolicy{Ignore: true}
+	// fat: a 1Gi memory request at its 1Gi floor is not oversized; CPU is still 20× its P95
+	n.Workloads[2].Spec.Containers[0].Resources.Requests["memory"] = "1Gi"
+	n.Workloads[2].Sizing = &resources.SizingPolicy{MinMemory: &resources.ResourceValue{Raw: "1Gi", Bytes: 1 << 30}}
+	var got []string
+	for _, f := range Evaluate(DefaultPolicy(), n) {
+		got = append(got, f.Workload+":"+f.Rule+":"+f.Resource)
+	}
+	if want := "fat:request-over-p95:cpu"; strings.Join(got, " ") != want {
+		t.Errorf("got %v, want [%s]", got, want)
+	}
+}
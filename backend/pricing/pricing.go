@@ -0,0 +1,161 @@
+// Package pricing turns CPU and memory quantities into money. Prices are per core-hour and
+// per GiB-hour, looked up by the node's instance type label with a flat default fallback.
+package pricing
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/devops-kubeadjust/backend/k8s"
+	"github.com/devops-kubeadjust/backend/resources"
+)
+
+// InstanceTypeLabel is the well-known node label used to pick a rate.
+const InstanceTypeLabel = "node.kubernetes.io/instance-type"
+
+// hoursPerMonth is the average number of hours in a month (8760 / 12), as used by cloud providers.
+const hoursPerMonth = 730
+
+const gib = 1024 * 1024 * 1024
+
+// Rate is the price of one CPU core and one GiB of memory for one hour.
+type Rate struct {
+	CPUCoreHour   float64 `json:"cpuCoreHour"`
+	MemoryGiBHour float64 `json:"memoryGiBHour"`
+}
+
+// Table holds the configured rates.
+type Table struct {
+	Currency      string          `json:"currency"`
+	Default       Rate            `json:"default"`
+	InstanceTypes map[string]Rate `json:"instanceTypes,omitempty"` // keyed by InstanceTypeLabel value
+}
+
+// Load builds the pricing table from the environment:
+//   - PRICING_CONFIG → path to a JSON file {"currency", "default", "instanceTypes"}
+//   - PRICE_CPU_CORE_HOUR / PRICE_MEMORY_GIB_HOUR → flat default rate (override the file's default)
+//   - PRICING_CURRENCY → currency label, "USD" if unset
+//
+// Returns nil (and no error) when none of these is set: costs are then omitted.
+func Load() (*Table, error) {
+	var t *Table
+	if path := os.Getenv("PRICING_CONFIG"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("reading pricing config: %w", err)
+		}
+		if t, err = Parse(data); err != nil {
+			return nil, err
+		}
+	}
+	for _, env := range []struct {
+		key string
+		set func(*Rate, float64)
+	}{
+		{"PRICE_CPU_CORE_HOUR", func(r *Rate, v float64) { r.CPUCoreHour = v }},
+		{"PRICE_MEMORY_GIB_HOUR", func(r *Rate, v float64) { r.MemoryGiBHour = v }},
+	} {
+		raw := os.Getenv(env.key)
+		if raw == "" {
+			continue
+		}
+		v, err := strconv.ParseFloat(raw, 64)
+		if err != nil || v < 0 {
+			return nil, fmt.Errorf("%s must be a non-negative number, got %q", env.key, raw)
+		}
+		if t == nil {
+			t = &Table{}
+		}
+		env.set(&t.Default, v)
+	}
+	if t == nil {
+		return nil, nil
+	}
+	if c := os.Getenv("PRICING_CURRENCY"); c != "" {
+		t.Currency = c
+	}
+	if t.Currency == "" {
+		t.Currency = "USD"
+	}
+	return t, nil
+}
+
+// Parse decodes and validates a JSON pricing config.
+func Parse(data []byte) (*Table, error) {
+	var t Table
+	if err := json.Unmarshal(data, &t); err != nil {
+		return nil, fmt.Errorf("parsing pricing config: %w", err)
+	}
+	if t.Default.CPUCoreHour < 0 || t.Default.MemoryGiBHour < 0 {
+		return nil, fmt.Errorf("pricing config: default rate must not be negative")
+	}
+	for name, r := range t.InstanceTypes {
+		if r.CPUCoreHour < 0 || r.MemoryGiBHour < 0 {
+			return nil, fmt.Errorf("pricing config: rate for instance type %q must not be negative", name)
+		}
+	}
+	return &t, nil
+}
+
+// RateFor returns the rate for an instance type, or the default rate if it is not listed.
+func (t *Table) RateFor(instanceType string) Rate {
+	if r, ok := t.InstanceTypes[instanceType]; ok {
+		return r
+	}
+	return t.Default
+}
+
+// ByInstanceType reports whether any per-instance-type rate is configured,
+// i.e. whether callers need the node list to price pods.
+func (t *Table) ByInstanceType() bool {
+	return len(t.InstanceTypes) > 0
+}
+
+// NodeRates returns the rate of each node, keyed by node name.
+func (t *Table) NodeRates(nodes []k8s.Node) map[string]Rate {
+	rates := make(map[string]Rate, len(nodes))
+	for _, n := range nodes {
+		rates[n.Metadata.Name] = t.RateFor(n.Metadata.Labels[InstanceTypeLabel])
+	}
+	return rates
+}
+
+// Cost prices requests and usage (millicores / bytes) at rate r. Without usage only the
+// requested cost is set.
+func (t *Table) Cost(r Rate, cpuReqM, memReqB, cpuUseM, memUseB int64, hasUsage bool) resources.Cost {
+	cpu := func(m int64) float64 { return float64(m) / 1000 * r.CPUCoreHour * hoursPerMonth }
+	mem := func(b int64) float64 { return float64(b) / gib * r.MemoryGiBHour * hoursPerMonth }
+
+	c := resources.Cost{Currency: t.Currency, Requested: cpu(cpuReqM) + mem(memReqB)}
+	if hasUsage {
+		c.Used = cpu(cpuUseM) + mem(memUseB)
+		c.Wasted = cpu(max(cpuReqM-cpuUseM, 0)) + mem(max(memReqB-memUseB, 0))
+	}
+	return c
+}
+
+// ApplyWorkload sets the cost of every container of d and their sum on d itself.
+// nodeOf maps pod names to node names; pods on unknown nodes (or pending) use the default rate.
+func (t *Table) ApplyWorkload(d *resources.DeploymentDetail, nodeOf map[string]string, rates map[string]Rate) {
+	total := resources.Cost{Currency: t.Currency}
+	for i := range d.Pods {
+		pod := &d.Pods[i]
+		r, ok := rates[nodeOf[pod.Name]]
+		if !ok {
+			r = t.Default
+		}
+		for j := range pod.Containers {
+			cr := &pod.Containers[j]
+			var cpuUse, memUse int64
+			if cr.Usage != nil {
+				cpuUse, memUse = cr.Usage.CPU.Millicores, cr.Usage.Memory.Bytes
+			}
+			c := t.Cost(r, cr.Requests.CPU.Millicores, cr.Requests.Memory.Bytes, cpuUse, memUse, cr.Usage != nil)
+			cr.Cost = &c
+			total.Add(c)
+		}
+	}
+	d.Cost = &total
+}
@@ -0,0 +1,104 @@
+package pricing
+
+import (
+	"math"
+	"testing"
+
+	"github.com/devops-kubeadjust/backend/k8s"
+	"github.com/devops-kubeadjust/backend/resources"
+)
+
+func approx(a, b float64) bool { return math.Abs(a-b) < 1e-9 }
+
+func TestParse(t *testing.T) {
+	tbl, err := Parse([]byte(`{"currency":"EUR","default":{"cpuCoreHour":0.04,"memoryGiBHour":0.005},
+		"instanceTypes":{"m5.large":{"cpuCoreHour":0.048,"memoryGiBHour":0.006}}}`))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if got := tbl.RateFor("m5.large"); got.CPUCoreHour != 0.048 {
+		t.Errorf("m5.large cpu rate: got %v, want 0.048", got.CPUCoreHour)
+	}
+	if got := tbl.RateFor("unknown"); got != tbl.Default {
+		t.Errorf("unknown instance type: got %+v, want default", got)
+	}
+
+	if _, err := Parse([]byte(`{"default":{"cpuCoreHour":-1}}`)); err == nil {
+		t.Error("expected error for negative rate")
+	}
+	if _, err := Parse([]byte(`not json`)); err == nil {
+		t.Error("expected error for invalid JSON")
+	}
+}
+
+func TestLoadFromEnv(t *testing.T) {
+	t.Setenv("PRICING_CONFIG", "")
+	t.Setenv("PRICE_CPU_CORE_HOUR", "")
+	t.Setenv("PRICE_MEMORY_GIB_HOUR", "")
+	if tbl, err := Load(); tbl != nil || err != nil {
+		t.Errorf("unconfigured: got %+v, %v, want nil, nil", tbl, err)
+	}
+
+	t.Setenv("PRICE_CPU_CORE_HOUR", "0.03")
+	tbl, err := Load()
+	if err != nil || tbl == nil {
+		t.Fatalf("flat rate: got %+v, %v", tbl, err)
+	}
+	if tbl.Default.CPUCoreHour != 0.03 || tbl.Currency != "USD" {
+		t.Errorf("flat rate: got %+v, want 0.03 USD", tbl)
+	}
+
+	t.Setenv("PRICE_MEMORY_GIB_HOUR", "cheap")
+	if _, err := Load(); err == nil {
+		t.Error("expected error for non-numeric price")
+	}
+}
+
+func TestCost(t *testing.T) {
+	tbl := &Table{Currency: "USD"}
+	r := Rate{CPUCoreHour: 0.04, MemoryGiBHour: 0.005}
+
+	// 1 core + 2 GiB requested, 250m + 3 GiB used (memory over request: no negative waste)
+	c := tbl.Cost(r, 1000, 2*gib, 250, 3*gib, true)
+	if !approx(c.Requested, (0.04+2*0.005)*730) {
+		t.Errorf("requested: got %v", c.Requested)
+	}
+	if !approx(c.Used, (0.01+3*0.005)*730) {
+		t.Errorf("used: got %v", c.Used)
+	}
+	if !approx(c.Wasted, 0.03*730) {
+		t.Errorf("wasted: got %v, want only the unused CPU", c.Wasted)
+	}
+
+	if c := tbl.Cost(r, 1000, 0, 0, 0, false); c.Used != 0 || c.Wasted != 0 {
+		t.Errorf("without usage: got %+v, want used and wasted at 0", c)
+	}
+}
+
+func TestApplyWorkload(t *testing.T) {
+	tbl := &Table{
+		Currency:      "USD",
+		Default:       Rate{CPUCoreHour: 0.01},
+		InstanceTypes: map[string]Rate{"big": {CPUCoreHour: 0.1}},
+	}
+	var big k8s.Node
+	big.Metadata.Name = "node-big"
+	big.Metadata.Labels = map[string]string{InstanceTypeLabel: "big"}
+
+	container := resources.ContainerResources{Name: "app", Requests: resources.ResourcePair{CPU: resources.ResourceValue{Millicores: 1000}}}
+	d := resources.DeploymentDetail{Pods: []resources.PodDetail{
+		{Name: "web-1", Containers: []resources.ContainerResources{container}},
+		{Name: "web-2", Containers: []resources.ContainerResources{container}},
+	}}
+	tbl.ApplyWorkload(&d, map[string]string{"web-1": "node-big", "web-2": ""}, tbl.NodeRates([]k8s.Node{big}))
+
+	if got := d.Pods[0].Containers[0].Cost.Requested; !approx(got, 73) {
+		t.Errorf("web-1 on big node: got %v, want 73", got)
+	}
+	if got := d.Pods[1].Containers[0].Cost.Requested; !approx(got, 7.3) {
+		t.Errorf("pending web-2 at default rate: got %v, want 7.3", got)
+	}
+	if d.Cost == nil || !approx(d.Cost.Requested, 80.3) {
+		t.Errorf("workload total: got %+v, want 80.3", d.Cost)
+	}
+}
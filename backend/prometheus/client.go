@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"log"
 	"net"
 	"net/http"
 	"net/url"
@@ -14,6 +15,8 @@ import (
 	"time"
 
 	"golang.org/x/sync/errgroup"
+
+	"github.com/devops-kubeadjust/backend/metrics"
 )
 
 // DataPoint is a single (timestamp, value) sample.
@@ -23,19 +26,29 @@ type DataPoint struct {
 }
 
 // HistoryResult holds CPU and memory time series for one container.
+// CPUThrottling and OOMKilled are best-effort: they stay empty when the cAdvisor CFS
+// metrics or kube-state-metrics are not scraped by Prometheus.
 type HistoryResult struct {
-	CPU    []DataPoint `json:"cpu"`
-	Memory []DataPoint `json:"memory"`
+	CPU           []DataPoint `json:"cpu"`
+	Memory        []DataPoint `json:"memory"`
+	CPUThrottling []DataPoint `json:"cpuThrottling,omitempty"` // throttled / total CFS periods (0–1)
+	OOMKilled     bool        `json:"oomKilled,omitempty"`     // OOMKilled at least once in the last oomWindow
 }
 
 // ContainerHistory is keyed by "pod/container".
 type ContainerHistory struct {
-	Pod       string      `json:"pod"`
-	Container string      `json:"container"`
-	CPU       []DataPoint `json:"cpu"`
-	Memory    []DataPoint `json:"memory"`
+	Pod           string      `json:"pod"`
+	Container     string      `json:"container"`
+	CPU           []DataPoint `json:"cpu"`
+	Memory        []DataPoint `json:"memory"`
+	CPUThrottling []DataPoint `json:"cpuThrottling,omitempty"`
+	OOMKilled     bool        `json:"oomKilled,omitempty"`
 }
 
+// oomWindow is how far back OOM kills are looked up, independently of the selected range:
+// a container OOMKilled a few days ago is still undersized even if the last hour looks fine.
+const oomWindow = "7d"
+
 // NamespaceHistoryResult holds history for all containers in a namespace.
 type NamespaceHistoryResult struct {
 	Containers []ContainerHistory `json:"containers"`
@@ -48,6 +61,9 @@ type TimeRange struct {
 	RateWindow string // for rate() queries
 }
 
+// TimeRanges are the range strings ParseTimeRange knows; it falls back to 1h for others.
+var TimeRanges = []string{"1h", "6h", "24h", "7d"}
+
 // ParseTimeRange converts a range string (1h/6h/24h/7d) to a TimeRange.
 func ParseTimeRange(r string) TimeRange {
 	switch r {
@@ -74,7 +90,11 @@ type Client struct {
 // New returns a Client using PROMETHEUS_URL env var.
 // Returns nil if the env var is not set.
 func New() *Client {
-	u := os.Getenv("PROMETHEUS_URL")
+	return NewWithURL(os.Getenv("PROMETHEUS_URL"))
+}
+
+// NewWithURL returns a Client for the given Prometheus URL, or nil if u is empty.
+func NewWithURL(u string) *Client {
 	if u == "" {
 		return nil
 	}
@@ -102,6 +122,18 @@ func New() *Client {
 
 // QueryRange fetches a PromQL range query with the given TimeRange.
 func (c *Client) QueryRange(query string, tr TimeRange) ([]DataPoint, error) {
+	series, err := c.QueryRangeMulti(query, tr)
+	if err != nil {
+		return nil, err
+	}
+	if len(series) == 0 {
+		return []DataPoint{}, nil
+	}
+	return parseValues(series[0].Values), nil
+}
+
+// QueryRangeMulti fetches a PromQL range query and returns results grouped by label values.
+func (c *Client) QueryRangeMulti(query string, tr TimeRange) ([]promSeriesResult, error) {
 	now := time.Now()
 	start := now.Add(-tr.Duration)
 
@@ -111,70 +143,61 @@ func (c *Client) QueryRange(query string, tr TimeRange) ([]DataPoint, error) {
 	params.Set("end", strconv.FormatInt(now.Unix(), 10))
 	params.Set("step", tr.Step)
 
-	resp, err := c.httpClient.Get(c.baseURL + "/api/v1/query_range?" + params.Encode())
-	if err != nil {
+	var result promRangeResponse
+	if err := c.getJSON("/api/v1/query_range", params, &result); err != nil {
 		return nil, err
 	}
-	defer func() { _ = resp.Body.Close() }()
-
-	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
-	if err != nil {
-		return nil, fmt.Errorf("reading prometheus response: %w", err)
-	}
-	if int64(len(body)) == maxResponseBytes {
-		return nil, fmt.Errorf("prometheus response exceeded %d MB limit", maxResponseBytes>>20)
-	}
-	if resp.StatusCode >= 400 {
-		return nil, fmt.Errorf("prometheus: %d %s", resp.StatusCode, string(body))
+	if result.Status != "success" {
+		return nil, nil
 	}
+	return result.Data.Result, nil
+}
 
-	var result promRangeResponse
-	if err := json.Unmarshal(body, &result); err != nil {
+// Query runs an instant PromQL query evaluated at the current time.
+func (c *Client) Query(query string) ([]promSample, error) {
+	params := url.Values{}
+	params.Set("query", query)
+
+	var result promInstantResponse
+	if err := c.getJSON("/api/v1/query", params, &result); err != nil {
 		return nil, err
 	}
-	if result.Status != "success" || len(result.Data.Result) == 0 {
-		return []DataPoint{}, nil
+	if result.Status != "success" {
+		return nil, nil
 	}
-
-	return parseValues(result.Data.Result[0].Values), nil
+	return result.Data.Result, nil
 }
 
-// QueryRangeMulti fetches a PromQL range query and returns results grouped by label values.
-func (c *Client) QueryRangeMulti(query string, tr TimeRange) ([]promSeriesResult, error) {
-	now := time.Now()
-	start := now.Add(-tr.Duration)
-
-	params := url.Values{}
-	params.Set("query", query)
-	params.Set("start", strconv.FormatInt(start.Unix(), 10))
-	params.Set("end", strconv.FormatInt(now.Unix(), 10))
-	params.Set("step", tr.Step)
+// getJSON performs a GET against the Prometheus HTTP API and decodes the response into out.
+// Every call is counted in metrics.PrometheusQueries.
+func (c *Client) getJSON(path string, params url.Values, out interface{}) error {
+	err := c.doGetJSON(path, params, out)
+	result := "success"
+	if err != nil {
+		result = "error"
+	}
+	metrics.PrometheusQueries.Inc(result)
+	return err
+}
 
-	resp, err := c.httpClient.Get(c.baseURL + "/api/v1/query_range?" + params.Encode())
+func (c *Client) doGetJSON(path string, params url.Values, out interface{}) error {
+	resp, err := c.httpClient.Get(c.baseURL + path + "?" + params.Encode())
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer func() { _ = resp.Body.Close() }()
 
 	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
 	if err != nil {
-		return nil, fmt.Errorf("reading prometheus response: %w", err)
+		return fmt.Errorf("reading prometheus response: %w", err)
 	}
 	if int64(len(body)) == maxResponseBytes {
-		return nil, fmt.Errorf("prometheus response exceeded %d MB limit", maxResponseBytes>>20)
+		return fmt.Errorf("prometheus response exceeded %d MB limit", maxResponseBytes>>20)
 	}
 	if resp.StatusCode >= 400 {
-		return nil, fmt.Errorf("prometheus: %d %s", resp.StatusCode, string(body))
+		return fmt.Errorf("prometheus: %d %s", resp.StatusCode, string(body))
 	}
-
-	var result promRangeResponse
-	if err := json.Unmarshal(body, &result); err != nil {
-		return nil, err
-	}
-	if result.Status != "success" {
-		return nil, nil
-	}
-	return result.Data.Result, nil
+	return json.Unmarshal(body, out)
 }
 
 type promRangeResponse struct {
@@ -189,6 +212,35 @@ type promSeriesResult struct {
 	Values [][]interface{}   `json:"values"`
 }
 
+type promInstantResponse struct {
+	Status string `json:"status"`
+	Data   struct {
+		Result []promSample `json:"result"`
+	} `json:"data"`
+}
+
+// promSample is one series of an instant query: labels plus a single [timestamp, "value"] pair.
+type promSample struct {
+	Metric map[string]string `json:"metric"`
+	Value  []interface{}     `json:"value"`
+}
+
+// value returns the sample value as float64, or 0 if it cannot be parsed.
+func (s promSample) value() float64 {
+	if len(s.Value) != 2 {
+		return 0
+	}
+	vs, ok := s.Value[1].(string)
+	if !ok {
+		return 0
+	}
+	v, err := strconv.ParseFloat(vs, 64)
+	if err != nil {
+		return 0
+	}
+	return v
+}
+
 func parseValues(raw [][]interface{}) []DataPoint {
 	points := make([]DataPoint, 0, len(raw))
 	for _, pair := range raw {
@@ -212,7 +264,8 @@ func parseValues(raw [][]interface{}) []DataPoint {
 	return points
 }
 
-// GetContainerHistory returns CPU (millicores) and memory (bytes) history for a container.
+// GetContainerHistory returns CPU (millicores) and memory (bytes) history for a container,
+// plus CPU throttling ratio and recent OOM kills when the underlying metrics exist.
 func (c *Client) GetContainerHistory(namespace, pod, container string, tr TimeRange) (*HistoryResult, error) {
 	labels := fmt.Sprintf(`namespace="%s",pod="%s",container="%s"`, namespace, pod, container)
 
@@ -227,8 +280,40 @@ func (c *Client) GetContainerHistory(namespace, pod, container string, tr TimeRa
 	if err != nil {
 		return nil, fmt.Errorf("memory query: %w", err)
 	}
+	result := &HistoryResult{CPU: cpu, Memory: mem}
 
-	return &HistoryResult{CPU: cpu, Memory: mem}, nil
+	// Throttling and OOM signals are best-effort.
+	if throttled, err := c.QueryRange(throttlingQuery(labels, tr.RateWindow, false), tr); err != nil {
+		log.Printf("prometheus throttling query failed for %s/%s/%s: %v", namespace, pod, container, err)
+	} else if len(throttled) > 0 {
+		result.CPUThrottling = throttled
+	}
+	if oom, err := c.Query(oomKilledQuery(labels)); err != nil {
+		log.Printf("prometheus OOM query failed for %s/%s/%s: %v", namespace, pod, container, err)
+	} else {
+		result.OOMKilled = len(oom) > 0
+	}
+
+	return result, nil
+}
+
+// throttlingQuery builds the ratio of throttled CFS periods to total periods.
+// When byContainer is set, the ratio is kept per pod/container for namespace-wide queries.
+func throttlingQuery(labels, rateWindow string, byContainer bool) string {
+	agg := "sum"
+	if byContainer {
+		agg = "sum by (pod, container)"
+	}
+	return fmt.Sprintf(`%[1]s (rate(container_cpu_cfs_throttled_periods_total{%[2]s}[%[3]s])) / %[1]s (rate(container_cpu_cfs_periods_total{%[2]s}[%[3]s]))`,
+		agg, labels, rateWindow)
+}
+
+// oomKilledQuery matches containers whose last termination reason was OOMKilled at some point
+// during oomWindow and that restarted during that window (so stale reasons are ignored).
+func oomKilledQuery(labels string) string {
+	return fmt.Sprintf(`max by (pod, container) (max_over_time(kube_pod_container_status_last_terminated_reason{%[1]s,reason="OOMKilled"}[%[2]s])) > 0 `+
+		`and on (pod, container) max by (pod, container) (increase(kube_pod_container_status_restarts_total{%[1]s}[%[2]s])) > 0`,
+		labels, oomWindow)
 }
 
 // GetNamespaceHistory returns CPU and memory history for all containers in a namespace.
@@ -237,7 +322,8 @@ func (c *Client) GetNamespaceHistory(namespace string, tr TimeRange) (*Namespace
 	cpuQuery := fmt.Sprintf(`rate(container_cpu_usage_seconds_total{%s}[%s]) * 1000`, nsLabel, tr.RateWindow)
 	memQuery := fmt.Sprintf(`container_memory_working_set_bytes{%s}`, nsLabel)
 
-	var cpuSeries, memSeries []promSeriesResult
+	var cpuSeries, memSeries, throttleSeries []promSeriesResult
+	var oomSamples []promSample
 	g := new(errgroup.Group)
 
 	g.Go(func() error {
@@ -250,6 +336,22 @@ func (c *Client) GetNamespaceHistory(namespace string, tr TimeRange) (*Namespace
 		memSeries, err = c.QueryRangeMulti(memQuery, tr)
 		return err
 	})
+	g.Go(func() error {
+		var err error
+		throttleSeries, err = c.QueryRangeMulti(throttlingQuery(nsLabel, tr.RateWindow, true), tr)
+		if err != nil {
+			log.Printf("prometheus throttling query failed for %s: %v", namespace, err)
+		}
+		return nil // best-effort
+	})
+	g.Go(func() error {
+		var err error
+		oomSamples, err = c.Query(oomKilledQuery(nsLabel))
+		if err != nil {
+			log.Printf("prometheus OOM query failed for %s: %v", namespace, err)
+		}
+		return nil // best-effort
+	})
 
 	if err := g.Wait(); err != nil {
 		return nil, err
@@ -281,6 +383,17 @@ func (c *Client) GetNamespaceHistory(namespace string, tr TimeRange) (*Namespace
 		ch := getOrCreate(k)
 		ch.Memory = parseValues(s.Values)
 	}
+	// Throttling and OOM only annotate containers that already have usage series.
+	for _, s := range throttleSeries {
+		if ch, ok := idx[key{pod: s.Metric["pod"], container: s.Metric["container"]}]; ok {
+			ch.CPUThrottling = parseValues(s.Values)
+		}
+	}
+	for _, s := range oomSamples {
+		if ch, ok := idx[key{pod: s.Metric["pod"], container: s.Metric["container"]}]; ok {
+			ch.OOMKilled = true
+		}
+	}
 
 	result := &NamespaceHistoryResult{Containers: make([]ContainerHistory, 0, len(idx))}
 	for _, ch := range idx {
@@ -288,3 +401,126 @@ func (c *Client) GetNamespaceHistory(namespace string, tr TimeRange) (*Namespace
 	}
 	return result, nil
 }
+
+// ContainerP95 holds the 95th percentile CPU (millicores) and memory (bytes) usage of one
+// container over a time range.
+type ContainerP95 struct {
+	Namespace string  `json:"namespace"`
+	Pod       string  `json:"pod"`
+	Container string  `json:"container"`
+	CPU       float64 `json:"cpu"`
+	Memory    float64 `json:"memory"`
+}
+
+// GetNamespaceP95 returns P95 CPU and memory usage over tr for every container in a namespace.
+func (c *Client) GetNamespaceP95(namespace string, tr TimeRange) ([]ContainerP95, error) {
+	return c.usageP95(fmt.Sprintf(`namespace="%s",container!=""`, namespace), tr)
+}
+
+// GetClusterP95 returns P95 CPU and memory usage over tr for every container in the cluster.
+func (c *Client) GetClusterP95(tr TimeRange) ([]ContainerP95, error) {
+	return c.usageP95(`container!=""`, tr)
+}
+
+// usageP95 runs the P95 queries for the containers matching selector.
+// CPU uses a subquery over the rate so the percentile is computed on per-window averages.
+func (c *Client) usageP95(selector string, tr TimeRange) ([]ContainerP95, error) {
+	window := promDuration(tr.Duration)
+	cpuQuery := fmt.Sprintf(`max by (namespace, pod, container) (quantile_over_time(0.95, (rate(container_cpu_usage_seconds_total{%s}[%s]) * 1000)[%s:%ss]))`,
+		selector, tr.RateWindow, window, tr.Step)
+	memQuery := fmt.Sprintf(`max by (namespace, pod, container) (quantile_over_time(0.95, container_memory_working_set_bytes{%s}[%s]))`,
+		selector, window)
+
+	var cpuSamples, memSamples []promSample
+	g := new(errgroup.Group)
+	g.Go(func() error {
+		var err error
+		cpuSamples, err = c.Query(cpuQuery)
+		return err
+	})
+	g.Go(func() error {
+		var err error
+		memSamples, err = c.Query(memQuery)
+		return err
+	})
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	type key struct{ namespace, pod, container string }
+	idx := map[key]*ContainerP95{}
+	getOrCreate := func(m map[string]string) *ContainerP95 {
+		k := key{namespace: m["namespace"], pod: m["pod"], container: m["container"]}
+		if p, ok := idx[k]; ok {
+			return p
+		}
+		p := &ContainerP95{Namespace: k.namespace, Pod: k.pod, Container: k.container}
+		idx[k] = p
+		return p
+	}
+	for _, s := range cpuSamples {
+		getOrCreate(s.Metric).CPU = s.value()
+	}
+	for _, s := range memSamples {
+		getOrCreate(s.Metric).Memory = s.value()
+	}
+
+	result := make([]ContainerP95, 0, len(idx))
+	for _, p := range idx {
+		result = append(result, *p)
+	}
+	return result, nil
+}
+
+// NamespaceP95 is the P95 of a namespace's total usage: the percentile of the summed series,
+// not the sum of per-container percentiles (which would overstate peaks that never coincide).
+type NamespaceP95 struct {
+	CPU    float64 // millicores
+	Memory float64 // bytes
+}
+
+// GetNamespaceTotalsP95 returns the P95 of the total CPU and memory usage of every namespace
+// over tr, keyed by namespace.
+func (c *Client) GetNamespaceTotalsP95(tr TimeRange) (map[string]NamespaceP95, error) {
+	window := promDuration(tr.Duration)
+	cpuQuery := fmt.Sprintf(`quantile_over_time(0.95, (sum by (namespace) (rate(container_cpu_usage_seconds_total{container!=""}[%s])) * 1000)[%s:%ss])`,
+		tr.RateWindow, window, tr.Step)
+	memQuery := fmt.Sprintf(`quantile_over_time(0.95, sum by (namespace) (container_memory_working_set_bytes{container!=""})[%s:%ss])`,
+		window, tr.Step)
+
+	var cpuSamples, memSamples []promSample
+	g := new(errgroup.Group)
+	g.Go(func() error {
+		var err error
+		cpuSamples, err = c.Query(cpuQuery)
+		return err
+	})
+	g.Go(func() error {
+		var err error
+		memSamples, err = c.Query(memQuery)
+		return err
+	})
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	result := map[string]NamespaceP95{}
+	for _, s := range cpuSamples {
+		ns := s.Metric["namespace"]
+		p := result[ns]
+		p.CPU = s.value()
+		result[ns] = p
+	}
+	for _, s := range memSamples {
+		ns := s.Metric["namespace"]
+		p := result[ns]
+		p.Memory = s.value()
+		result[ns] = p
+	}
+	return result, nil
+}
+
+// promDuration formats a duration as a PromQL range selector (e.g. "604800s").
+func promDuration(d time.Duration) string {
+	return strconv.FormatInt(int64(d.Seconds()), 10) + "s"
+}
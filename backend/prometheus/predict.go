@@ -0,0 +1,35 @@
+package prometheus
+
+import (
+	"fmt"
+	"time"
+)
+
+// MemoryPrediction is the working-set memory predict_linear expects for one container.
+type MemoryPrediction struct {
+	Namespace string  `json:"namespace"`
+	Pod       string  `json:"pod"`
+	Container string  `json:"container"`
+	Bytes     float64 `json:"bytes"`
+}
+
+// PredictMemory extrapolates the working-set memory of every container of the cluster
+// ahead in time, from the linear trend over window (mirrors the dashboard's trend check).
+func (c *Client) PredictMemory(window, ahead time.Duration) ([]MemoryPrediction, error) {
+	query := fmt.Sprintf(`max by (namespace, pod, container) (predict_linear(container_memory_working_set_bytes{container!="",container!="POD"}[%s], %d))`,
+		promDuration(window), int64(ahead.Seconds()))
+	samples, err := c.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]MemoryPrediction, 0, len(samples))
+	for _, s := range samples {
+		out = append(out, MemoryPrediction{
+			Namespace: s.Metric["namespace"],
+			Pod:       s.Metric["pod"],
+			Container: s.Metric["container"],
+			Bytes:     s.value(),
+		})
+	}
+	return out, nil
+}
@@ -0,0 +1,73 @@
+package prometheus
+
+import (
+	"fmt"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// ContainerUsageStats is the P95 and mean usage of one container over a time range:
+// CPU in millicores, memory in bytes.
+type ContainerUsageStats struct {
+	Namespace  string
+	Pod        string
+	Container  string
+	CPUP95     float64
+	CPUMean    float64
+	MemoryP95  float64
+	MemoryMean float64
+}
+
+// GetClusterUsageStats returns P95 and mean usage over tr for every container in the
+// cluster, with four instant queries. The statistics mirror those computed from
+// GetNamespaceHistory, at a fraction of the cost of its per-namespace range queries.
+func (c *Client) GetClusterUsageStats(tr TimeRange) ([]ContainerUsageStats, error) {
+	window := promDuration(tr.Duration)
+	cpuMeanQuery := fmt.Sprintf(`max by (namespace, pod, container) (avg_over_time((rate(container_cpu_usage_seconds_total{container!=""}[%s]) * 1000)[%s:%ss]))`,
+		tr.RateWindow, window, tr.Step)
+	memMeanQuery := fmt.Sprintf(`max by (namespace, pod, container) (avg_over_time(container_memory_working_set_bytes{container!=""}[%s:%ss]))`,
+		window, tr.Step)
+
+	var (
+		p95                   []ContainerP95
+		cpuMeans, memoryMeans []promSample
+	)
+	g := new(errgroup.Group)
+	g.Go(func() error {
+		var err error
+		p95, err = c.GetClusterP95(tr)
+		return err
+	})
+	g.Go(func() error {
+		var err error
+		cpuMeans, err = c.Query(cpuMeanQuery)
+		return err
+	})
+	g.Go(func() error {
+		var err error
+		memoryMeans, err = c.Query(memMeanQuery)
+		return err
+	})
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	type key struct{ namespace, pod, container string }
+	idx := make(map[key]*ContainerUsageStats, len(p95))
+	out := make([]ContainerUsageStats, len(p95))
+	for i, p := range p95 {
+		out[i] = ContainerUsageStats{Namespace: p.Namespace, Pod: p.Pod, Container: p.Container, CPUP95: p.CPU, MemoryP95: p.Memory}
+		idx[key{p.Namespace, p.Pod, p.Container}] = &out[i]
+	}
+	for _, s := range cpuMeans {
+		if st, ok := idx[key{s.Metric["namespace"], s.Metric["pod"], s.Metric["container"]}]; ok {
+			st.CPUMean = s.value()
+		}
+	}
+	for _, s := range memoryMeans {
+		if st, ok := idx[key{s.Metric["namespace"], s.Metric["pod"], s.Metric["container"]}]; ok {
+			st.MemoryMean = s.value()
+		}
+	}
+	return out, nil
+}
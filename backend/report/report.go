@@ -0,0 +1,216 @@
+// Package report collects the workloads of a namespace together with their observed usage
+// and right-sizing recommendations, without going through the HTTP API. It backs the
+// command-line client and the per-workload patch and pull request endpoints.
+package report
+
+import (
+	"context"
+	"log"
+	"slices"
+
+	"golang.org/x/sync/errgroup"
+
+	"github.com/devops-kubeadjust/backend/k8s"
+	"github.com/devops-kubeadjust/backend/prometheus"
+	"github.com/devops-kubeadjust/backend/resources"
+	"github.com/devops-kubeadjust/backend/suggestions"
+)
+
+// DefaultRange is the Prometheus window used for P95/mean usage when none is given.
+const DefaultRange = "7d"
+
+// Usage sources reported in Namespace.UsageSource.
+const (
+	SourcePrometheus    = "prometheus"
+	SourceMetricsServer = "metrics-server"
+	SourceNone          = "none"
+)
+
+// Workload is one workload with its pods and the recommended requests/limits of its
+// pod template containers.
+type Workload struct {
+	resources.DeploymentDetail
+	Spec            k8s.PodSpec                           `json:"-"`
+	Usage           map[string]suggestions.ContainerUsage `json:"-"` // by container name
+	Recommendations []suggestions.ContainerRecommendation `json:"recommendations"`
+}
+
+// Namespace is the report of one namespace.
+type Namespace struct {
+	Namespace        string     `json:"namespace"`
+	Workloads        []Workload `json:"workloads"`
+	MetricsAvailable bool       `json:"metricsAvailable"`
+	UsageSource      string     `json:"usageSource"` // prometheus | metrics-server | none
+}
+
+// Collect lists the Deployments, StatefulSets and CronJobs of ns with their pods and live
+// usage, and recommends requests/limits from usage over rangeParam (see Usage).
+// Only pods and Deployments are required; other lookups are best-effort.
+func Collect(ctx context.Context, client *k8s.Client, prom *prometheus.Client, ns, rangeParam string, th suggestions.Thresholds) (*Namespace, error) {
+	var (
+		pods         *k8s.PodList
+		deployments  *k8s.DeploymentList
+		statefulSets *k8s.StatefulSetList
+		cronJobs     *k8s.CronJobList
+		rsList       *k8s.ReplicaSetList
+		jobs         *k8s.JobList
+		podMetrics   *k8s.PodMetricsList
+	)
+	g, gctx := errgroup.WithContext(ctx)
+	g.Go(func() error {
+		var err error
+		pods, err = client.ListPods(gctx, ns)
+		return err
+	})
+	g.Go(func() error {
+		var err error
+		deployments, err = client.ListDeployments(gctx, ns)
+		return err
+	})
+	bestEffort := func(what string, fetch func() error) {
+		g.Go(func() error {
+			if err := fetch(); err != nil {
+				log.Printf("failed to list %s in %s: %v", what, ns, err)
+			}
+			return nil
+		})
+	}
+	bestEffort("statefulsets", func() (err error) { statefulSets, err = client.ListStatefulSets(gctx, ns); return })
+	bestEffort("cronjobs", func() (err error) { cronJobs, err = client.ListCronJobs(gctx, ns); return })
+	bestEffort("replicasets", func() (err error) { rsList, err = client.ListReplicaSets(gctx, ns); return })
+	bestEffort("jobs", func() (err error) { jobs, err = client.ListJobs(gctx, ns); return })
+	bestEffort("pod metrics", func() (err error) { podMetrics, err = client.ListPodMetrics(gctx, ns); return })
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	owners := resources.BuildOwnerMaps(pods.Items, rsList, jobs)
+	podsByWorkload := map[resources.WorkloadKey][]k8s.Pod{}
+	for _, pod := range pods.Items {
+		if wk, ok := owners[pod.Metadata.Name]; ok {
+			podsByWorkload[wk] = append(podsByWorkload[wk], pod)
+		}
+	}
+	metricsMap := map[string]map[string]k8s.ContainerUsage{}
+	if podMetrics != nil {
+		for _, pm := range podMetrics.Items {
+			m := make(map[string]k8s.ContainerUsage, len(pm.Containers))
+			for _, cu := range pm.Containers {
+				m[cu.Name] = cu
+			}
+			metricsMap[pm.Metadata.Name] = m
+		}
+	}
+
+	usage, source := Usage(ctx, client, prom, ns, owners, rangeParam, podMetrics)
+	out := &Namespace{Namespace: ns, Workloads: []Workload{}, MetricsAvailable: podMetrics != nil, UsageSource: source}
+	add := func(d resources.DeploymentDetail, meta, template k8s.ObjectMeta, spec k8s.PodSpec) {
+		wk := resources.WorkloadKey{Kind: d.Kind, Name: d.Name}
+		d.Pods = resources.BuildPodDetails(podsByWorkload[wk], metricsMap, nil, nil)
+		var err error
+		if d.Sizing, err = resources.SizingPolicyFor(meta, template); err != nil {
+			log.Printf("%s %s/%s: %v", d.Kind, ns, d.Name, err)
+		}
+		out.Workloads = append(out.Workloads, Workload{
+			DeploymentDetail: d,
+			Spec:             spec,
+			Usage:            usage[wk],
+			Recommendations:  suggestions.RecommendContainers(spec, usage[wk], th, d.Sizing),
+		})
+	}
+
+	for _, dep := range deployments.Items {
+		add(resources.DeploymentDetail{
+			Kind: "Deployment", Name: dep.Metadata.Name, Namespace: ns,
+			Replicas: dep.Spec.Replicas, ReadyReplicas: dep.Status.ReadyReplicas, AvailableReplicas: dep.Status.AvailableReplicas,
+		}, dep.Metadata, dep.Spec.Template.Metadata, dep.Spec.Template.Spec)
+	}
+	if statefulSets != nil {
+		for _, ss := range statefulSets.Items {
+			avail := ss.Status.AvailableReplicas
+			if avail == 0 {
+				avail = ss.Status.CurrentReplicas
+			}
+			add(resources.DeploymentDetail{
+				Kind: "StatefulSet", Name: ss.Metadata.Name, Namespace: ns,
+				Replicas: ss.Spec.Replicas, ReadyReplicas: ss.Status.ReadyReplicas, AvailableReplicas: avail,
+			}, ss.Metadata, ss.Spec.Template.Metadata, ss.Spec.Template.Spec)
+		}
+	}
+	if cronJobs != nil {
+		for _, cj := range cronJobs.Items {
+			active := int32(len(cj.Status.Active))
+			add(resources.DeploymentDetail{
+				Kind: "CronJob", Name: cj.Metadata.Name, Namespace: ns,
+				Replicas: active, ReadyReplicas: active, AvailableReplicas: active,
+			}, cj.Metadata, cj.Spec.JobTemplate.Spec.Template.Metadata, cj.Spec.JobTemplate.Spec.Template.Spec)
+		}
+	}
+	return out, nil
+}
+
+// Usage returns the observed usage per workload and container name (busiest replica wins)
+// and its source: Prometheus P95/mean over rangeParam (default DefaultRange) when configured,
+// else the metrics-server snapshot in metrics (fetched when nil). Usage is best-effort: an
+// empty map with SourceNone means no recommendation can be made.
+func Usage(ctx context.Context, client *k8s.Client, prom *prometheus.Client, ns string, owners map[string]resources.WorkloadKey, rangeParam string, metrics *k8s.PodMetricsList) (map[resources.WorkloadKey]map[string]suggestions.ContainerUsage, string) {
+	usage := map[resources.WorkloadKey]map[string]suggestions.ContainerUsage{}
+	add := func(pod, container string, cpu, mem suggestions.Usage) {
+		wk, ok := owners[pod]
+		if !ok {
+			return
+		}
+		if usage[wk] == nil {
+			usage[wk] = map[string]suggestions.ContainerUsage{}
+		}
+		u := usage[wk][container]
+		u.CPU = u.CPU.Merge(cpu)
+		u.Memory = u.Memory.Merge(mem)
+		usage[wk][container] = u
+	}
+
+	if prom != nil && resources.IsValidLabelValue(ns) {
+		if rangeParam == "" {
+			rangeParam = DefaultRange
+		}
+		hist, err := prom.GetNamespaceHistory(ns, prometheus.ParseTimeRange(rangeParam))
+		if err == nil {
+			for _, ch := range hist.Containers {
+				add(ch.Pod, ch.Container, suggestions.UsageFromSeries(seriesValues(ch.CPU)), suggestions.UsageFromSeries(seriesValues(ch.Memory)))
+			}
+			return usage, SourcePrometheus
+		}
+		log.Printf("prometheus history unavailable for %s, using metrics-server: %v", ns, err)
+	}
+
+	if metrics == nil {
+		var err error
+		if metrics, err = client.ListPodMetrics(ctx, ns); err != nil {
+			log.Printf("metrics-server unavailable for %s: %v", ns, err)
+			return usage, SourceNone
+		}
+	}
+	for _, m := range metrics.Items {
+		for _, c := range m.Containers {
+			add(m.Metadata.Name, c.Name,
+				suggestions.SnapshotUsage(float64(resources.ParseCPUMillicores(c.Usage["cpu"]))),
+				suggestions.SnapshotUsage(float64(resources.ParseMemoryBytes(c.Usage["memory"]))))
+		}
+	}
+	return usage, SourceMetricsServer
+}
+
+// Changed returns the workloads with at least one recommended change.
+func (n *Namespace) Changed() []Workload {
+	return slices.DeleteFunc(slices.Clone(n.Workloads), func(w Workload) bool {
+		return !slices.ContainsFunc(w.Recommendations, suggestions.ContainerRecommendation.Changed)
+	})
+}
+
+func seriesValues(points []prometheus.DataPoint) []float64 {
+	values := make([]float64, len(points))
+	for i, p := range points {
+		values[i] = p.V
+	}
+	return values
+}
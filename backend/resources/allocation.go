@@ -0,0 +1,170 @@
+package resources
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/devops-kubeadjust/backend/k8s"
+)
+
+// GroupKey is one dimension of an allocation report.
+type GroupKey struct {
+	Source string // "namespace" | "node" | "label" | "namespaceLabel" | "nodeLabel"
+	Label  string // label key for the *label sources
+}
+
+// String returns the groupBy token the key was parsed from, e.g. "label:team".
+func (k GroupKey) String() string {
+	if k.Label == "" {
+		return k.Source
+	}
+	return k.Source + ":" + k.Label
+}
+
+// maxGroupKeys bounds the number of dimensions in one report.
+const maxGroupKeys = 5
+
+// ParseGroupBy parses a comma-separated groupBy parameter such as
+// "label:team,namespaceLabel:cost-center,namespace". Supported tokens: namespace, node,
+// label:<pod label>, namespaceLabel:<namespace label>, nodeLabel:<node label>.
+func ParseGroupBy(s string) ([]GroupKey, error) {
+	var keys []GroupKey
+	for tok := range strings.SplitSeq(s, ",") {
+		tok = strings.TrimSpace(tok)
+		if tok == "" {
+			continue
+		}
+		source, label, hasLabel := strings.Cut(tok, ":")
+		switch source {
+		case "namespace", "node":
+			if hasLabel {
+				return nil, fmt.Errorf("groupBy %q takes no label key", source)
+			}
+		case "label", "namespaceLabel", "nodeLabel":
+			if !IsValidLabelKey(label) {
+				return nil, fmt.Errorf("invalid label key in groupBy %q", tok)
+			}
+		default:
+			return nil, fmt.Errorf("unknown groupBy %q", tok)
+		}
+		keys = append(keys, GroupKey{Source: source, Label: label})
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("groupBy is required")
+	}
+	if len(keys) > maxGroupKeys {
+		return nil, fmt.Errorf("at most %d groupBy dimensions are allowed", maxGroupKeys)
+	}
+	return keys, nil
+}
+
+// AllocationGroup aggregates requests, limits, usage and cost of the pods sharing the same
+// values for every groupBy dimension. A pod without a label gets "" for that dimension.
+type AllocationGroup struct {
+	Keys          map[string]string `json:"keys"` // groupBy token → value
+	Pods          int               `json:"pods"`
+	CPURequestedM int64             `json:"cpuRequestedM"`
+	CPULimitedM   int64             `json:"cpuLimitedM"`
+	MemRequestedB int64             `json:"memRequestedB"`
+	MemLimitedB   int64             `json:"memLimitedB"`
+	CPUUsageM     int64             `json:"cpuUsageM"` // 0 if metrics-server unavailable
+	MemUsageB     int64             `json:"memUsageB"` // 0 if metrics-server unavailable
+	Cost          *Cost             `json:"cost,omitempty"`
+}
+
+// AllocationResponse is returned by GET /api/allocation.
+type AllocationResponse struct {
+	GroupBy          []string          `json:"groupBy"`
+	Groups           []AllocationGroup `json:"groups"`
+	MetricsAvailable bool              `json:"metricsAvailable"`
+}
+
+// AllocationInput holds the cluster state an allocation report is computed from.
+type AllocationInput struct {
+	Pods            []k8s.Pod
+	NamespaceLabels map[string]map[string]string                   // namespace → labels
+	NodeLabels      map[string]map[string]string                   // node → labels
+	Usage           map[string]map[string]map[string]UsageEstimate // namespace → pod → container; nil if unavailable
+	// Cost prices one container; nil when pricing is not configured.
+	Cost func(pod *k8s.Pod, cpuReqM, memReqB, cpuUseM, memUseB int64, hasUsage bool) Cost
+}
+
+// Allocate groups the non-terminal pods of in by keys. Groups are sorted by CPU requests,
+// largest first.
+func Allocate(in AllocationInput, keys []GroupKey) []AllocationGroup {
+	byValues := map[string]*AllocationGroup{}
+	for i := range in.Pods {
+		pod := &in.Pods[i]
+		if pod.Status.Phase == "Succeeded" || pod.Status.Phase == "Failed" {
+			continue
+		}
+		values := make([]string, len(keys))
+		for j, k := range keys {
+			values[j] = groupValue(k, pod, in)
+		}
+		id := strings.Join(values, "\x00")
+		g := byValues[id]
+		if g == nil {
+			g = &AllocationGroup{Keys: make(map[string]string, len(keys))}
+			for j, k := range keys {
+				g.Keys[k.String()] = values[j]
+			}
+			byValues[id] = g
+		}
+		g.Pods++
+
+		ns, name := pod.Metadata.Namespace, pod.Metadata.Name
+		for _, c := range pod.Spec.Containers {
+			cpuReq := ParseCPUMillicores(c.Resources.Requests["cpu"])
+			memReq := ParseMemoryBytes(c.Resources.Requests["memory"])
+			g.CPURequestedM += cpuReq
+			g.MemRequestedB += memReq
+			g.CPULimitedM += ParseCPUMillicores(c.Resources.Limits["cpu"])
+			g.MemLimitedB += ParseMemoryBytes(c.Resources.Limits["memory"])
+			u, hasUsage := in.Usage[ns][name][c.Name]
+			g.CPUUsageM += u.CPUMillicores
+			g.MemUsageB += u.MemoryBytes
+			if in.Cost != nil {
+				if g.Cost == nil {
+					g.Cost = &Cost{}
+				}
+				g.Cost.Add(in.Cost(pod, cpuReq, memReq, u.CPUMillicores, u.MemoryBytes, hasUsage))
+			}
+		}
+	}
+
+	groups := make([]AllocationGroup, 0, len(byValues))
+	for _, g := range byValues {
+		groups = append(groups, *g)
+	}
+	slices.SortFunc(groups, func(a, b AllocationGroup) int {
+		if c := cmp.Compare(b.CPURequestedM, a.CPURequestedM); c != 0 {
+			return c
+		}
+		for _, k := range keys {
+			if c := cmp.Compare(a.Keys[k.String()], b.Keys[k.String()]); c != 0 {
+				return c
+			}
+		}
+		return 0
+	})
+	return groups
+}
+
+func groupValue(k GroupKey, pod *k8s.Pod, in AllocationInput) string {
+	switch k.Source {
+	case "namespace":
+		return pod.Metadata.Namespace
+	case "node":
+		return pod.Spec.NodeName
+	case "label":
+		return pod.Metadata.Labels[k.Label]
+	case "namespaceLabel":
+		return in.NamespaceLabels[pod.Metadata.Namespace][k.Label]
+	case "nodeLabel":
+		return in.NodeLabels[pod.Spec.NodeName][k.Label]
+	}
+	return ""
+}
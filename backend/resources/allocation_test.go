@@ -0,0 +1,92 @@
+package resources
+
+import (
+	"testing"
+
+	"github.com/devops-kubeadjust/backend/k8s"
+)
+
+func TestParseGroupBy(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    []string
+		wantErr bool
+	}{
+		{"namespace", []string{"namespace"}, false},
+		{"label:team, namespaceLabel:cost-center ,node", []string{"label:team", "namespaceLabel:cost-center", "node"}, false},
+		{"nodeLabel:topology.kubernetes.io/zone", []string{"nodeLabel:topology.kubernetes.io/zone"}, false},
+		{"", nil, true},
+		{"namespace:foo", nil, true},
+		{"label:", nil, true},
+		{"label:bad key", nil, true},
+		{"owner", nil, true},
+		{"node,node,node,node,node,node", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			keys, err := ParseGroupBy(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseGroupBy(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if len(keys) != len(tt.want) {
+				t.Fatalf("ParseGroupBy(%q) = %v, want %v", tt.input, keys, tt.want)
+			}
+			for i, k := range keys {
+				if k.String() != tt.want[i] {
+					t.Errorf("key %d: got %q, want %q", i, k.String(), tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestAllocate(t *testing.T) {
+	labelled := func(name, ns, team string, c k8s.Container) k8s.Pod {
+		p := pod(name, "Running", c)
+		p.Metadata.Namespace = ns
+		if team != "" {
+			p.Metadata.Labels = map[string]string{"team": team}
+		}
+		return p
+	}
+	in := AllocationInput{
+		Pods: []k8s.Pod{
+			labelled("api-1", "payments", "core", container("app", "500m", "1Gi", "1", "2Gi")),
+			labelled("web-1", "frontend", "core", container("app", "250m", "512Mi", "", "")),
+			labelled("batch-1", "data", "", container("app", "2", "4Gi", "", "")),
+			pod("done", "Succeeded", container("app", "8", "8Gi", "", "")),
+		},
+		NamespaceLabels: map[string]map[string]string{"payments": {"cost-center": "cc-1"}},
+		Usage: map[string]map[string]map[string]UsageEstimate{
+			"payments": {"api-1": {"app": {CPUMillicores: 100, MemoryBytes: 256 << 20}}},
+		},
+		Cost: func(_ *k8s.Pod, cpuReqM, _, _, _ int64, _ bool) Cost {
+			return Cost{Currency: "USD", Requested: float64(cpuReqM) / 1000}
+		},
+	}
+	keys, _ := ParseGroupBy("label:team,namespaceLabel:cost-center")
+	groups := Allocate(in, keys)
+
+	if len(groups) != 3 {
+		t.Fatalf("got %d groups, want 3: %+v", len(groups), groups)
+	}
+	// Sorted by CPU requests: the unlabelled batch pod comes first.
+	if groups[0].Keys["label:team"] != "" || groups[0].CPURequestedM != 2000 {
+		t.Errorf("first group: got %+v, want unlabelled batch with 2000m", groups[0])
+	}
+	var core *AllocationGroup
+	for i := range groups {
+		if groups[i].Keys["label:team"] == "core" && groups[i].Keys["namespaceLabel:cost-center"] == "cc-1" {
+			core = &groups[i]
+		}
+	}
+	if core == nil {
+		t.Fatalf("missing core/cc-1 group in %+v", groups)
+	}
+	if core.Pods != 1 || core.CPUUsageM != 100 || core.MemLimitedB != 2<<30 {
+		t.Errorf("core/cc-1: got %+v", core)
+	}
+	if core.Cost == nil || core.Cost.Requested != 0.5 {
+		t.Errorf("core/cc-1 cost: got %+v, want 0.5", core.Cost)
+	}
+}
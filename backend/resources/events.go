@@ -0,0 +1,89 @@
+package resources
+
+import (
+	"sort"
+	"time"
+
+	"github.com/devops-kubeadjust/backend/k8s"
+)
+
+// sizingEventReasons are the Warning event reasons that point at a resource sizing problem:
+// pods that cannot be scheduled, are evicted under node pressure, or crash-loop. Container
+// OOM kills come from the container statuses instead: node-problem-detector's OOMKilling
+// events involve the Node, not the pod.
+var sizingEventReasons = map[string]bool{
+	"FailedScheduling": true,
+	"Evicted":          true,
+	"BackOff":          true,
+}
+
+// maxEventsPerObject caps the number of events attached to a single pod or workload.
+const maxEventsPerObject = 10
+
+// IndexSizingEvents keeps Warning events relevant to resource sizing that were last seen
+// within maxAge of now, and indexes them by involved object kind and name.
+// Each list is sorted newest first and capped at maxEventsPerObject.
+func IndexSizingEvents(events []k8s.Event, now time.Time, maxAge time.Duration) map[WorkloadKey][]WorkloadEvent {
+	idx := map[WorkloadKey][]WorkloadEvent{}
+	for _, ev := range events {
+		if ev.Type != "Warning" || !sizingEventReasons[ev.Reason] {
+			continue
+		}
+		lastSeen := eventLastSeen(ev)
+		if t, err := time.Parse(time.RFC3339, lastSeen); err != nil || now.Sub(t) > maxAge {
+			continue
+		}
+		count := ev.Count
+		if count == 0 {
+			count = 1
+		}
+		key := WorkloadKey{Kind: ev.InvolvedObject.Kind, Name: ev.InvolvedObject.Name}
+		idx[key] = append(idx[key], WorkloadEvent{
+			Reason:   ev.Reason,
+			Message:  ev.Message,
+			Count:    count,
+			LastSeen: lastSeen,
+			Object:   key.Kind + "/" + key.Name,
+		})
+	}
+	for key, list := range idx {
+		idx[key] = capEvents(list)
+	}
+	return idx
+}
+
+// AttachEvents sets pod-level events on each pod of d and collects the workload's own events
+// plus those of its pods on d.Events.
+func AttachEvents(d *DeploymentDetail, idx map[WorkloadKey][]WorkloadEvent) {
+	all := append([]WorkloadEvent(nil), idx[WorkloadKey{Kind: d.Kind, Name: d.Name}]...)
+	for i := range d.Pods {
+		evs := idx[WorkloadKey{Kind: "Pod", Name: d.Pods[i].Name}]
+		d.Pods[i].Events = evs
+		all = append(all, evs...)
+	}
+	if len(all) > 0 {
+		d.Events = capEvents(all)
+	}
+}
+
+// eventLastSeen returns the most precise "last seen" timestamp available on an event.
+func eventLastSeen(ev k8s.Event) string {
+	switch {
+	case ev.LastTimestamp != "":
+		return ev.LastTimestamp
+	case ev.EventTime != "":
+		return ev.EventTime
+	default:
+		return ev.FirstTimestamp
+	}
+}
+
+// capEvents sorts events newest first and keeps at most maxEventsPerObject.
+// RFC3339 timestamps in UTC sort lexically, which is how the API server emits them.
+func capEvents(list []WorkloadEvent) []WorkloadEvent {
+	sort.SliceStable(list, func(i, j int) bool { return list[i].LastSeen > list[j].LastSeen })
+	if len(list) > maxEventsPerObject {
+		list = list[:maxEventsPerObject]
+	}
+	return list
+}
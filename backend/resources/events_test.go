@@ -0,0 +1,87 @@
+package resources
+
+import (
+	"testing"
+	"time"
+
+	"github.com/devops-kubeadjust/backend/k8s"
+)
+
+func warning(kind, name, reason, lastSeen string) k8s.Event {
+	return k8s.Event{
+		InvolvedObject: k8s.EventObjectRef{Kind: kind, Name: name},
+		Type:           "Warning",
+		Reason:         reason,
+		Message:        reason + " message",
+		LastTimestamp:  lastSeen,
+	}
+}
+
+func TestIndexSizingEvents(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("keeps only sizing reasons within window", func(t *testing.T) {
+		events := []k8s.Event{
+			warning("Pod", "web-1", "FailedScheduling", "2026-03-01T11:00:00Z"),
+			warning("Pod", "web-1", "Unhealthy", "2026-03-01T11:00:00Z"), // not sizing-related
+			warning("Pod", "web-1", "BackOff", "2026-02-20T11:00:00Z"),   // too old
+			{InvolvedObject: k8s.EventObjectRef{Kind: "Pod", Name: "web-1"}, Type: "Normal", Reason: "Evicted", LastTimestamp: "2026-03-01T11:00:00Z"},
+			warning("Node", "node-a", "OOMKilling", "2026-03-01T11:00:00Z"), // node-problem-detector, no pod to attach to
+		}
+		idx := IndexSizingEvents(events, now, 24*time.Hour)
+		got := idx[WorkloadKey{Kind: "Pod", Name: "web-1"}]
+		if len(got) != 1 || got[0].Reason != "FailedScheduling" {
+			t.Fatalf("expected only FailedScheduling, got %+v", got)
+		}
+		if got[0].Count != 1 {
+			t.Errorf("count: got %d, want 1 (zero count defaults to 1)", got[0].Count)
+		}
+		if got[0].Object != "Pod/web-1" {
+			t.Errorf("object: got %q, want Pod/web-1", got[0].Object)
+		}
+		if len(idx) != 1 {
+			t.Errorf("expected only web-1 to be indexed, got %v", idx)
+		}
+	})
+
+	t.Run("falls back to eventTime when lastTimestamp is empty", func(t *testing.T) {
+		ev := warning("Pod", "web-1", "Evicted", "")
+		ev.EventTime = "2026-03-01T11:59:00.123456Z"
+		idx := IndexSizingEvents([]k8s.Event{ev}, now, time.Hour)
+		if len(idx[WorkloadKey{Kind: "Pod", Name: "web-1"}]) != 1 {
+			t.Error("expected event with only eventTime to be kept")
+		}
+	})
+
+	t.Run("sorted newest first and capped", func(t *testing.T) {
+		var events []k8s.Event
+		for i := range maxEventsPerObject + 5 {
+			events = append(events, warning("Pod", "web-1", "BackOff", now.Add(-time.Duration(i)*time.Minute).Format(time.RFC3339)))
+		}
+		events[0], events[len(events)-1] = events[len(events)-1], events[0]
+		got := IndexSizingEvents(events, now, 24*time.Hour)[WorkloadKey{Kind: "Pod", Name: "web-1"}]
+		if len(got) != maxEventsPerObject {
+			t.Fatalf("expected %d events, got %d", maxEventsPerObject, len(got))
+		}
+		if got[0].LastSeen != now.Format(time.RFC3339) {
+			t.Errorf("expected newest first, got %s", got[0].LastSeen)
+		}
+	})
+}
+
+func TestAttachEvents(t *testing.T) {
+	idx := map[WorkloadKey][]WorkloadEvent{
+		{Kind: "Pod", Name: "web-1"}:         {{Reason: "BackOff", LastSeen: "2026-03-01T10:00:00Z"}},
+		{Kind: "StatefulSet", Name: "web"}:   {{Reason: "FailedScheduling", LastSeen: "2026-03-01T11:00:00Z"}},
+		{Kind: "Pod", Name: "unrelated-pod"}: {{Reason: "Evicted", LastSeen: "2026-03-01T11:30:00Z"}},
+	}
+	d := DeploymentDetail{Kind: "StatefulSet", Name: "web", Pods: []PodDetail{{Name: "web-1"}, {Name: "web-2"}}}
+	AttachEvents(&d, idx)
+
+	if len(d.Pods[0].Events) != 1 || d.Pods[1].Events != nil {
+		t.Errorf("pod events: got %+v / %+v", d.Pods[0].Events, d.Pods[1].Events)
+	}
+	if len(d.Events) != 2 || d.Events[0].Reason != "FailedScheduling" {
+		t.Errorf("workload events: got %+v, want FailedScheduling then BackOff", d.Events)
+	}
+}
@@ -0,0 +1,166 @@
+package resources
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/devops-kubeadjust/backend/k8s"
+)
+
+// Status filters of workloads and node pods.
+const (
+	StatusNoRequests = "no-requests" // a container without CPU or memory request
+	StatusNoLimits   = "no-limits"   // a container without memory limit
+	StatusOOMKilled  = "oom-killed"  // a container whose last termination was an OOM kill
+	StatusDegraded   = "degraded"    // fewer ready than desired replicas; pods: not Running
+)
+
+// Status filters of nodes.
+const (
+	StatusReady      = "ready"
+	StatusNotReady   = "not-ready" // NotReady or Unknown
+	StatusPressure   = "pressure"  // disk, memory or PID pressure
+	StatusCordoned   = "cordoned"
+	StatusBottleneck = "bottleneck" // see NodeOverview.Bottleneck
+)
+
+// Filter kinds and statuses accepted by each list endpoint.
+var (
+	WorkloadKinds    = []string{"Deployment", "StatefulSet", "CronJob"}
+	PodKinds         = []string{"Deployment", "ReplicaSet", "StatefulSet", "DaemonSet", "Job", "Node", "Pod"}
+	WorkloadStatuses = []string{StatusNoRequests, StatusNoLimits, StatusOOMKilled, StatusDegraded}
+	NodeStatuses     = []string{StatusReady, StatusNotReady, StatusPressure, StatusCordoned, StatusBottleneck}
+)
+
+// Filter narrows the items of a list endpoint. The zero value matches everything.
+type Filter struct {
+	// LabelSelector is passed down to the Kubernetes API (labels of the workloads, nodes
+	// or pods themselves).
+	LabelSelector string
+	Kinds         []string // any of, canonical case
+	NamePrefix    string
+	Statuses      []string // any of
+}
+
+// ParseFilter reads ?labelSelector=, ?kind=, ?name= (prefix) and ?status= from q. kind and
+// status take comma-separated values among kinds and statuses (case-insensitive); a nil
+// kinds rejects the kind parameter.
+func ParseFilter(q url.Values, kinds, statuses []string) (Filter, error) {
+	f := Filter{LabelSelector: strings.TrimSpace(q.Get("labelSelector")), NamePrefix: q.Get("name")}
+	var err error
+	if v := q.Get("kind"); v != "" {
+		if kinds == nil {
+			return Filter{}, fmt.Errorf("kind filter is not supported here")
+		}
+		if f.Kinds, err = parseChoices("kind", v, kinds); err != nil {
+			return Filter{}, err
+		}
+	}
+	if v := q.Get("status"); v != "" {
+		if f.Statuses, err = parseChoices("status", v, statuses); err != nil {
+			return Filter{}, err
+		}
+	}
+	return f, nil
+}
+
+func parseChoices(param, v string, allowed []string) ([]string, error) {
+	var out []string
+	for s := range strings.SplitSeq(v, ",") {
+		s = strings.TrimSpace(s)
+		i := slices.IndexFunc(allowed, func(a string) bool { return strings.EqualFold(a, s) })
+		if i < 0 {
+			return nil, fmt.Errorf("invalid %s %q (want one of %s)", param, s, strings.Join(allowed, ", "))
+		}
+		out = append(out, allowed[i])
+	}
+	return out, nil
+}
+
+func (f Filter) matchName(name string) bool { return strings.HasPrefix(name, f.NamePrefix) }
+
+func (f Filter) matchKind(kind string) bool {
+	return len(f.Kinds) == 0 || slices.Contains(f.Kinds, kind)
+}
+
+func (f Filter) matchStatus(has func(status string) bool) bool {
+	return len(f.Statuses) == 0 || slices.ContainsFunc(f.Statuses, has)
+}
+
+// MatchWorkload reports whether d, whose pod template is spec, passes the kind, name and
+// status filters. Requests and limits are read from the template, so workloads without
+// pods (scaled down, CronJobs between runs) are matched too.
+func (f Filter) MatchWorkload(d DeploymentDetail, spec k8s.PodSpec) bool {
+	return f.matchKind(d.Kind) && f.matchName(d.Name) && f.matchStatus(func(s string) bool {
+		switch s {
+		case StatusDegraded:
+			return d.ReadyReplicas < d.Replicas
+		case StatusOOMKilled:
+			return slices.ContainsFunc(d.Pods, oomKilled)
+		default:
+			return lacks(s, spec.Containers)
+		}
+	})
+}
+
+// MatchPod reports whether pod, detailed as d, passes the kind, name and status filters.
+// Its kind is that of its controller, a ReplicaSet also matching Deployment; bare pods
+// are of kind Pod and static pods of kind Node.
+func (f Filter) MatchPod(pod k8s.Pod, d PodDetail) bool {
+	kind := "Pod"
+	if owner := controllerOf(pod); owner != nil {
+		kind = owner.Kind
+	}
+	return (f.matchKind(kind) || kind == "ReplicaSet" && f.matchKind("Deployment")) && f.matchName(pod.Metadata.Name) &&
+		f.matchStatus(func(s string) bool {
+			switch s {
+			case StatusDegraded:
+				return pod.Status.Phase != "Running"
+			case StatusOOMKilled:
+				return oomKilled(d)
+			default:
+				return lacks(s, pod.Spec.Containers)
+			}
+		})
+}
+
+// MatchNode reports whether n passes the name and status filters.
+func (f Filter) MatchNode(n NodeOverview) bool {
+	return f.matchName(n.Name) && f.matchStatus(func(s string) bool {
+		switch s {
+		case StatusReady:
+			return n.Status == "Ready"
+		case StatusNotReady:
+			return n.Status != "Ready"
+		case StatusPressure:
+			return n.DiskPressure || n.MemoryPressure || n.PIDPressure
+		case StatusCordoned:
+			return n.Unschedulable
+		case StatusBottleneck:
+			return n.Bottleneck != ""
+		}
+		return false
+	})
+}
+
+// lacks reports whether a container misses what status StatusNoRequests or StatusNoLimits
+// is about.
+func lacks(status string, containers []k8s.Container) bool {
+	return slices.ContainsFunc(containers, func(c k8s.Container) bool {
+		switch status {
+		case StatusNoRequests:
+			return c.Resources.Requests["cpu"] == "" || c.Resources.Requests["memory"] == ""
+		case StatusNoLimits:
+			return c.Resources.Limits["memory"] == ""
+		}
+		return false
+	})
+}
+
+func oomKilled(p PodDetail) bool {
+	return slices.ContainsFunc(p.Containers, func(c ContainerResources) bool {
+		return c.StateReason == "OOMKilled" || c.LastTermination != nil && c.LastTermination.Reason == "OOMKilled"
+	})
+}
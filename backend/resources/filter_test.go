@@ -0,0 +1,97 @@
+package resources
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/devops-kubeadjust/backend/k8s"
+)
+
+func TestParseFilter(t *testing.T) {
+	q := url.Values{"labelSelector": {" app.kubernetes.io/part-of=checkout "}, "kind": {"deployment, CronJob"}, "name": {"api-"}, "status": {"No-Requests"}}
+	f, err := ParseFilter(q, WorkloadKinds, WorkloadStatuses)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.LabelSelector != "app.kubernetes.io/part-of=checkout" || f.NamePrefix != "api-" ||
+		len(f.Kinds) != 2 || f.Kinds[0] != "Deployment" || f.Kinds[1] != "CronJob" || len(f.Statuses) != 1 || f.Statuses[0] != StatusNoRequests {
+		t.Errorf("got %+v", f)
+	}
+
+	for _, bad := range []url.Values{{"kind": {"DaemonSet"}}, {"status": {"ready"}}, {"status": {"no-requests,"}}} {
+		if _, err := ParseFilter(bad, WorkloadKinds, WorkloadStatuses); err == nil {
+			t.Errorf("%v: expected an error", bad)
+		}
+	}
+	if _, err := ParseFilter(url.Values{"kind": {"Deployment"}}, nil, NodeStatuses); err == nil {
+		t.Error("kind should be rejected where unsupported")
+	}
+}
+
+func TestFilterMatch(t *testing.T) {
+	sized := k8s.Container{Name: "app", Resources: k8s.ResourceRequire{
+		Requests: map[string]string{"cpu": "100m", "memory": "128Mi"}, Limits: map[string]string{"memory": "128Mi"},
+	}}
+	unsized := k8s.Container{Name: "sidecar"}
+	oom := PodDetail{Containers: []ContainerResources{{Name: "app", LastTermination: &ContainerTermination{Reason: "OOMKilled"}}}}
+
+	web := DeploymentDetail{Kind: "Deployment", Name: "web", Replicas: 2, ReadyReplicas: 2}
+	degraded := DeploymentDetail{Kind: "StatefulSet", Name: "db", Replicas: 3, ReadyReplicas: 1, Pods: []PodDetail{oom}}
+	workloads := []struct {
+		filter        Filter
+		web, degraded bool
+	}{
+		{Filter{}, true, true},
+		{Filter{Kinds: []string{"StatefulSet"}}, false, true},
+		{Filter{NamePrefix: "we"}, true, false},
+		{Filter{Statuses: []string{StatusNoRequests}}, true, false},
+		{Filter{Statuses: []string{StatusDegraded}}, false, true},
+		{Filter{Statuses: []string{StatusOOMKilled, StatusNoLimits}}, true, true},
+	}
+	for _, c := range workloads {
+		if got := c.filter.MatchWorkload(web, k8s.PodSpec{Containers: []k8s.Container{sized, unsized}}); got != c.web {
+			t.Errorf("%+v: web matched %v", c.filter, got)
+		}
+		if got := c.filter.MatchWorkload(degraded, k8s.PodSpec{Containers: []k8s.Container{sized}}); got != c.degraded {
+			t.Errorf("%+v: degraded matched %v", c.filter, got)
+		}
+	}
+
+	var pod k8s.Pod
+	pod.Metadata.Name = "web-5d8f-x1"
+	pod.Metadata.OwnerReferences = []k8s.OwnerReference{{Kind: "ReplicaSet", Name: "web-5d8f"}}
+	pod.Spec.Containers = []k8s.Container{sized}
+	pod.Status.Phase = "Running"
+	for _, c := range []struct {
+		filter Filter
+		want   bool
+	}{
+		{Filter{Kinds: []string{"Deployment"}}, true},
+		{Filter{Kinds: []string{"ReplicaSet"}}, true},
+		{Filter{Kinds: []string{"DaemonSet"}}, false},
+		{Filter{Statuses: []string{StatusNoRequests}}, false},
+		{Filter{Statuses: []string{StatusOOMKilled}}, true},
+		{Filter{NamePrefix: "api"}, false},
+	} {
+		if got := c.filter.MatchPod(pod, oom); got != c.want {
+			t.Errorf("%+v: pod matched %v", c.filter, got)
+		}
+	}
+
+	node := NodeOverview{Name: "node-a", Status: "Ready", MemoryPressure: true}
+	for _, c := range []struct {
+		statuses []string
+		want     bool
+	}{
+		{nil, true},
+		{[]string{StatusReady}, true},
+		{[]string{StatusNotReady}, false},
+		{[]string{StatusPressure}, true},
+		{[]string{StatusCordoned}, false},
+		{[]string{StatusBottleneck}, false},
+	} {
+		if got := (Filter{Statuses: c.statuses}).MatchNode(node); got != c.want {
+			t.Errorf("%v: node matched %v", c.statuses, got)
+		}
+	}
+}
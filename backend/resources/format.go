@@ -33,3 +33,22 @@ func FmtMillicores(m int64) string {
 	}
 	return fmt.Sprintf("%dm", m)
 }
+
+// FmtQuantity formats a value as a Kubernetes quantity for manifests:
+// CPU as whole cores ("2") or millicores ("250m"), memory as Gi, Mi or plain bytes.
+func FmtQuantity(v int64, isCPU bool) string {
+	const gib = 1024 * 1024 * 1024
+	const mib = 1024 * 1024
+	switch {
+	case isCPU && v%1000 == 0:
+		return fmt.Sprintf("%d", v/1000)
+	case isCPU:
+		return fmt.Sprintf("%dm", v)
+	case v != 0 && v%gib == 0:
+		return fmt.Sprintf("%dGi", v/gib)
+	case v != 0 && v%mib == 0:
+		return fmt.Sprintf("%dMi", v/mib)
+	default:
+		return fmt.Sprintf("%d", v)
+	}
+}
@@ -48,3 +48,26 @@ func TestFmtMillicores(t *testing.T) {
 		})
 	}
 }
+
+func TestFmtQuantity(t *testing.T) {
+	tests := []struct {
+		input int64
+		isCPU bool
+		want  string
+	}{
+		{250, true, "250m"},
+		{2000, true, "2"},
+		{1500, true, "1500m"},
+		{512 * 1024 * 1024, false, "512Mi"},
+		{3 * 1024 * 1024 * 1024, false, "3Gi"},
+		{1536 * 1024 * 1024, false, "1536Mi"},
+		{1000, false, "1000"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := FmtQuantity(tt.input, tt.isCPU); got != tt.want {
+				t.Errorf("FmtQuantity(%d, %v) = %q, want %q", tt.input, tt.isCPU, got, tt.want)
+			}
+		})
+	}
+}
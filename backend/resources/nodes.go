@@ -1,7 +1,11 @@
 package resources
 
 import (
+	"fmt"
+	"math"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/devops-kubeadjust/backend/k8s"
 )
@@ -40,3 +44,292 @@ func NodeStatus(conditions []k8s.NodeCondition) string {
 	}
 	return "Unknown"
 }
+
+// parsePodCount parses the "pods" capacity field from a Node's status.
+// The value is a plain integer (e.g. "110"), not a byte quantity like memory.
+func parsePodCount(s string) int {
+	v, _ := strconv.ParseInt(s, 10, 64)
+	return int(v)
+}
+
+func nodeAge(creationTimestamp string) string {
+	t, err := time.Parse(time.RFC3339, creationTimestamp)
+	if err != nil {
+		return ""
+	}
+	d := time.Since(t)
+	days := int(math.Round(d.Hours() / 24))
+	switch {
+	case days >= 365:
+		return fmt.Sprintf("%dy", days/365)
+	case days >= 1:
+		return fmt.Sprintf("%dd", days)
+	default:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	}
+}
+
+func nodePressures(conditions []k8s.NodeCondition) (disk, memory, pid bool) {
+	for _, c := range conditions {
+		if c.Status != "True" {
+			continue
+		}
+		switch c.Type {
+		case "DiskPressure":
+			disk = true
+		case "MemoryPressure":
+			memory = true
+		case "PIDPressure":
+			pid = true
+		}
+	}
+	return
+}
+
+// Node bottlenecks, see NodeOverview.Bottleneck.
+const (
+	BottleneckPods             = "pods"
+	BottleneckEphemeralStorage = "ephemeral-storage"
+)
+
+// bottleneckRatio is the share of pod slots or allocatable ephemeral storage from which a
+// node counts as bottlenecked on it, when that share also exceeds its CPU and memory ones.
+const bottleneckRatio = 0.8
+
+// BuildNodeOverviews aggregates pod requests/limits per node and combines them with node
+// capacity, conditions and (optional) metrics-server usage.
+func BuildNodeOverviews(nodes *k8s.NodeList, allPods *k8s.PodList, metrics *k8s.NodeMetricsList) []NodeOverview {
+	nodeMetrics := map[string]k8s.NodeMetrics{}
+	if metrics != nil {
+		for _, m := range metrics.Items {
+			nodeMetrics[m.Metadata.Name] = m
+		}
+	}
+
+	// Aggregate pod requests/limits per node
+	type aggResources struct {
+		cpuReq, memReq int64
+		cpuLim, memLim int64
+		ephemeralReq   int64
+		podCount       int
+	}
+	agg := map[string]*aggResources{}
+	for _, pod := range allPods.Items {
+		node := pod.Spec.NodeName
+		if node == "" || pod.Status.Phase == "Succeeded" || pod.Status.Phase == "Failed" {
+			continue
+		}
+		if agg[node] == nil {
+			agg[node] = &aggResources{}
+		}
+		agg[node].podCount++
+		for _, c := range pod.Spec.Containers {
+			agg[node].cpuReq += ParseCPUMillicores(c.Resources.Requests["cpu"])
+			agg[node].memReq += ParseMemoryBytes(c.Resources.Requests["memory"])
+			agg[node].cpuLim += ParseCPUMillicores(c.Resources.Limits["cpu"])
+			agg[node].memLim += ParseMemoryBytes(c.Resources.Limits["memory"])
+			agg[node].ephemeralReq += ParseMemoryBytes(c.Resources.Requests["ephemeral-storage"])
+		}
+	}
+
+	result := make([]NodeOverview, 0, len(nodes.Items))
+	for _, node := range nodes.Items {
+		overview := NodeOverview{
+			Name:  node.Metadata.Name,
+			Roles: NodeRoles(node.Metadata.Labels),
+			Capacity: NodeResources{
+				CPU:    ParseResource(node.Status.Capacity["cpu"], true),
+				Memory: ParseResource(node.Status.Capacity["memory"], false),
+			},
+			Allocatable: NodeResources{
+				CPU:    ParseResource(node.Status.Allocatable["cpu"], true),
+				Memory: ParseResource(node.Status.Allocatable["memory"], false),
+			},
+			MaxPods:       parsePodCount(node.Status.Capacity["pods"]),
+			Labels:        node.Metadata.Labels,
+			Unschedulable: node.Spec.Unschedulable,
+			EphemeralStorage: NodeStorage{
+				Capacity:    ParseResource(node.Status.Capacity["ephemeral-storage"], false),
+				Allocatable: ParseResource(node.Status.Allocatable["ephemeral-storage"], false),
+			},
+		}
+
+		overview.Reserved = nodeReserved(overview.Capacity, overview.Allocatable)
+		overview.Zone, overview.Region, overview.InstanceType = NodeTopology(node.Metadata.Labels)
+
+		// Node status + pressure conditions
+		overview.Status = NodeStatus(node.Status.Conditions)
+		overview.DiskPressure, overview.MemoryPressure, overview.PIDPressure = nodePressures(node.Status.Conditions)
+
+		// Node info
+		overview.KernelVersion = node.Status.NodeInfo.KernelVersion
+		overview.OSImage = node.Status.NodeInfo.OSImage
+		overview.Age = nodeAge(node.Metadata.CreationTimestamp)
+
+		// Taints
+		for _, t := range node.Spec.Taints {
+			overview.Taints = append(overview.Taints, NodeTaint{
+				Key: t.Key, Value: t.Value, Effect: t.Effect,
+			})
+		}
+
+		// Aggregated pod data
+		if a := agg[node.Metadata.Name]; a != nil {
+			overview.PodCount = a.podCount
+			overview.Requested = NodeResources{
+				CPU:    ResourceValue{Millicores: a.cpuReq, Raw: FmtMillicores(a.cpuReq)},
+				Memory: ResourceValue{Bytes: a.memReq, Raw: FmtBytes(a.memReq)},
+			}
+			overview.Limited = NodeResources{
+				CPU:    ResourceValue{Millicores: a.cpuLim, Raw: FmtMillicores(a.cpuLim)},
+				Memory: ResourceValue{Bytes: a.memLim, Raw: FmtBytes(a.memLim)},
+			}
+			overview.EphemeralStorage.Requested = ResourceValue{Bytes: a.ephemeralReq, Raw: FmtBytes(a.ephemeralReq)}
+		}
+		if overview.MaxPods > 0 {
+			overview.PodSlotRatio = float64(overview.PodCount) / float64(overview.MaxPods)
+		}
+		overview.Bottleneck = nodeBottleneck(overview)
+
+		// Node metrics usage
+		if nm, ok := nodeMetrics[node.Metadata.Name]; ok {
+			usage := &NodeResources{
+				CPU:    ParseResource(nm.Usage["cpu"], true),
+				Memory: ParseResource(nm.Usage["memory"], false),
+			}
+			overview.Usage = usage
+		}
+
+		result = append(result, overview)
+	}
+	return result
+}
+
+// ApplyNodeSummaries sets the Kubelet stats and ephemeral storage usage of the nodes whose
+// summary is in summaries.
+func ApplyNodeSummaries(nodes []NodeOverview, summaries []*k8s.NodeSummary) {
+	byName := make(map[string]*k8s.NodeSummary, len(summaries))
+	for _, s := range summaries {
+		byName[s.Node.NodeName] = s
+	}
+	for i := range nodes {
+		if s, ok := byName[nodes[i].Name]; ok {
+			nodes[i].Kubelet = NodeKubeletStatsFrom(s.Node)
+			if fs := nodes[i].Kubelet.Fs; fs != nil {
+				nodes[i].EphemeralStorage.Used = &fs.Used
+			}
+		}
+	}
+}
+
+// NodeKubeletStatsFrom converts the node section of a kubelet stats summary.
+func NodeKubeletStatsFrom(n k8s.NodeStatsSummary) *NodeKubeletStats {
+	stats := &NodeKubeletStats{Fs: fsUsage(n.Fs)}
+	if n.Runtime != nil {
+		stats.ImageFs = fsUsage(n.Runtime.ImageFs)
+	}
+	if r := n.Rlimit; r != nil && r.MaxPID > 0 {
+		stats.PIDs = &PIDUsage{Running: r.CurProc, Max: r.MaxPID, Ratio: float64(r.CurProc) / float64(r.MaxPID)}
+	}
+	if nw := n.Network; nw != nil {
+		stats.Network = &NetworkUsage{Interface: nw.Name, RxBytes: nw.RxBytes, TxBytes: nw.TxBytes, RxErrors: nw.RxErrors, TxErrors: nw.TxErrors}
+	}
+	for _, sc := range n.SystemContainers {
+		u := SystemContainerUsage{Name: sc.Name}
+		if sc.CPU != nil {
+			m := sc.CPU.UsageNanoCores / 1_000_000
+			u.CPU = &ResourceValue{Millicores: m, Raw: FmtMillicores(m)}
+		}
+		if sc.Memory != nil {
+			u.Memory = &ResourceValue{Bytes: sc.Memory.WorkingSetBytes, Raw: FmtBytes(sc.Memory.WorkingSetBytes)}
+		}
+		stats.SystemContainers = append(stats.SystemContainers, u)
+	}
+	return stats
+}
+
+func fsUsage(fs *k8s.FsStats) *FsUsage {
+	if fs == nil || fs.CapacityBytes <= 0 {
+		return nil
+	}
+	u := &FsUsage{
+		Capacity:  ResourceValue{Bytes: fs.CapacityBytes, Raw: FmtBytes(fs.CapacityBytes)},
+		Used:      ResourceValue{Bytes: fs.UsedBytes, Raw: FmtBytes(fs.UsedBytes)},
+		Available: ResourceValue{Bytes: fs.AvailableBytes, Raw: FmtBytes(fs.AvailableBytes)},
+		UsedRatio: 1 - float64(fs.AvailableBytes)/float64(fs.CapacityBytes),
+	}
+	if fs.Inodes > 0 {
+		u.InodesUsedRatio = float64(fs.InodesUsed) / float64(fs.Inodes)
+	}
+	return u
+}
+
+// nodeBottleneck returns the resource other than CPU and memory that limits scheduling on
+// n, if any: the one of pod slots and ephemeral storage most requested, at or above
+// bottleneckRatio of allocatable and above the CPU and memory request ratios.
+func nodeBottleneck(n NodeOverview) string {
+	ratio := func(used, total int64) float64 {
+		if total <= 0 {
+			return 0
+		}
+		return float64(used) / float64(total)
+	}
+	compute := max(ratio(n.Requested.CPU.Millicores, n.Allocatable.CPU.Millicores), ratio(n.Requested.Memory.Bytes, n.Allocatable.Memory.Bytes))
+	disk := ratio(n.EphemeralStorage.Requested.Bytes, n.EphemeralStorage.Allocatable.Bytes)
+	switch {
+	case n.PodSlotRatio >= bottleneckRatio && n.PodSlotRatio > compute && n.PodSlotRatio >= disk:
+		return BottleneckPods
+	case disk >= bottleneckRatio && disk > compute:
+		return BottleneckEphemeralStorage
+	}
+	return ""
+}
+
+func nodeReserved(capacity, allocatable NodeResources) NodeReserved {
+	cpu := capacity.CPU.Millicores - allocatable.CPU.Millicores
+	mem := capacity.Memory.Bytes - allocatable.Memory.Bytes
+	r := NodeReserved{Total: NodeResources{
+		CPU:    ResourceValue{Millicores: cpu, Raw: FmtMillicores(cpu)},
+		Memory: ResourceValue{Bytes: mem, Raw: FmtBytes(mem)},
+	}}
+	if capacity.CPU.Millicores > 0 {
+		r.CPURatio = float64(cpu) / float64(capacity.CPU.Millicores)
+	}
+	if capacity.Memory.Bytes > 0 {
+		r.MemoryRatio = float64(mem) / float64(capacity.Memory.Bytes)
+	}
+	return r
+}
+
+// ApplyKubeletConfigs breaks down the Reserved resources of the nodes whose kubelet
+// configuration is in configs (keyed by node name): allocatable is capacity minus
+// kube-reserved, system-reserved and, for memory, the hard eviction threshold.
+func ApplyKubeletConfigs(nodes []NodeOverview, configs map[string]*k8s.KubeletConfig) {
+	for i := range nodes {
+		cfg, ok := configs[nodes[i].Name]
+		if !ok {
+			continue
+		}
+		r := &nodes[i].Reserved
+		r.KubeReserved = reservedResources(cfg.KubeReserved)
+		r.SystemReserved = reservedResources(cfg.SystemReserved)
+		eviction := evictionBytes(cfg.EvictionHard["memory.available"], nodes[i].Capacity.Memory.Bytes)
+		r.EvictionMemory = &ResourceValue{Bytes: eviction, Raw: FmtBytes(eviction)}
+	}
+}
+
+func reservedResources(m map[string]string) *NodeResources {
+	return &NodeResources{CPU: ParseResource(m["cpu"], true), Memory: ParseResource(m["memory"], false)}
+}
+
+// evictionBytes converts an eviction threshold, a quantity or a percentage of capacity.
+func evictionBytes(threshold string, capacity int64) int64 {
+	if pct, ok := strings.CutSuffix(threshold, "%"); ok {
+		v, err := strconv.ParseFloat(pct, 64)
+		if err != nil {
+			return 0
+		}
+		return int64(v / 100 * float64(capacity))
+	}
+	return ParseMemoryBytes(threshold)
+}
@@ -1,6 +1,7 @@
 package resources
 
 import (
+	"encoding/json"
 	"sort"
 	"testing"
 
@@ -132,3 +133,113 @@ func TestNodeStatus(t *testing.T) {
 		})
 	}
 }
+
+func TestApplyNodeSummaries(t *testing.T) {
+	var summary k8s.NodeSummary
+	err := json.Unmarshal([]byte(`{"node": {
+		"nodeName": "node-a",
+		"systemContainers": [{"name": "kubelet", "cpu": {"usageNanoCores": 35000000}, "memory": {"workingSetBytes": 104857600}}, {"name": "runtime"}],
+		"fs": {"capacityBytes": 100, "usedBytes": 70, "availableBytes": 20, "inodes": 1000, "inodesUsed": 250},
+		"runtime": {"imageFs": {"capacityBytes": 200, "usedBytes": 50, "availableBytes": 150}},
+		"rlimit": {"maxpid": 4000, "curproc": 1000},
+		"network": {"name": "eth0", "rxBytes": 10, "txBytes": 20}
+	}, "pods": []}`), &summary)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nodes := []NodeOverview{{Name: "node-a"}, {Name: "node-b"}}
+	ApplyNodeSummaries(nodes, []*k8s.NodeSummary{&summary})
+	if nodes[1].Kubelet != nil {
+		t.Errorf("node-b: unexpected stats %+v", nodes[1].Kubelet)
+	}
+	s := nodes[0].Kubelet
+	if s == nil || s.Fs == nil || s.ImageFs == nil || s.PIDs == nil || s.Network == nil {
+		t.Fatalf("node-a: incomplete stats %+v", s)
+	}
+	// Reserved blocks make used + available < capacity; eviction is on available.
+	if s.Fs.UsedRatio != 0.8 || s.Fs.InodesUsedRatio != 0.25 || s.ImageFs.UsedRatio != 0.25 || s.ImageFs.InodesUsedRatio != 0 {
+		t.Errorf("fs: %+v, imageFs: %+v", s.Fs, s.ImageFs)
+	}
+	if s.PIDs.Running != 1000 || s.PIDs.Max != 4000 || s.PIDs.Ratio != 0.25 {
+		t.Errorf("pids: %+v", s.PIDs)
+	}
+	if s.Network.Interface != "eth0" || s.Network.TxBytes != 20 {
+		t.Errorf("network: %+v", s.Network)
+	}
+	if len(s.SystemContainers) != 2 || s.SystemContainers[0].CPU.Raw != "35m" || s.SystemContainers[0].Memory.Bytes != 104857600 || s.SystemContainers[1].CPU != nil {
+		t.Errorf("system containers: %+v", s.SystemContainers)
+	}
+}
+
+func TestApplyKubeletConfigs(t *testing.T) {
+	capacity := NodeResources{CPU: ResourceValue{Millicores: 4000}, Memory: ResourceValue{Bytes: 16 << 30}}
+	allocatable := NodeResources{CPU: ResourceValue{Millicores: 3900}, Memory: ResourceValue{Bytes: 15 << 30}}
+	nodes := []NodeOverview{
+		{Name: "a", Capacity: capacity, Allocatable: allocatable, Reserved: nodeReserved(capacity, allocatable)},
+		{Name: "b", Capacity: capacity, Allocatable: allocatable, Reserved: nodeReserved(capacity, allocatable)},
+	}
+	if r := nodes[0].Reserved; r.Total.CPU.Millicores != 100 || r.Total.Memory.Bytes != 1<<30 || r.CPURatio != 0.025 || r.MemoryRatio != 0.0625 {
+		t.Errorf("reserved: %+v", r)
+	}
+
+	ApplyKubeletConfigs(nodes, map[string]*k8s.KubeletConfig{"a": {
+		KubeReserved:   map[string]string{"cpu": "80m", "memory": "512Mi"},
+		SystemReserved: map[string]string{"cpu": "20m"},
+		EvictionHard:   map[string]string{"memory.available": "2%", "nodefs.available": "10%"},
+	}})
+	r := nodes[0].Reserved
+	if r.KubeReserved == nil || r.KubeReserved.CPU.Millicores != 80 || r.KubeReserved.Memory.Bytes != 512<<20 {
+		t.Errorf("kube-reserved: %+v", r.KubeReserved)
+	}
+	if r.SystemReserved == nil || r.SystemReserved.CPU.Millicores != 20 || r.SystemReserved.Memory.Bytes != 0 {
+		t.Errorf("system-reserved: %+v", r.SystemReserved)
+	}
+	if r.EvictionMemory == nil || r.EvictionMemory.Bytes != 343597383 {
+		t.Errorf("eviction: %+v", r.EvictionMemory)
+	}
+	if b := nodes[1].Reserved; b.KubeReserved != nil || b.EvictionMemory != nil {
+		t.Errorf("node without configz got a breakdown: %+v", b)
+	}
+	if got := evictionBytes("100Mi", 16<<30); got != 100<<20 {
+		t.Errorf("evictionBytes(100Mi) = %d", got)
+	}
+}
+
+func TestBuildNodeOverviewsBottleneck(t *testing.T) {
+	var nodes k8s.NodeList
+	var pods k8s.PodList
+	err := json.Unmarshal([]byte(`{"items": [
+		{"metadata": {"name": "slots"}, "status": {"capacity": {"cpu": "4", "memory": "16Gi", "pods": "4", "ephemeral-storage": "100Gi"}, "allocatable": {"cpu": "4", "memory": "16Gi", "pods": "4", "ephemeral-storage": "90Gi"}}},
+		{"metadata": {"name": "disk"}, "status": {"capacity": {"cpu": "4", "memory": "16Gi", "pods": "110", "ephemeral-storage": "20Gi"}, "allocatable": {"cpu": "4", "memory": "16Gi", "pods": "110", "ephemeral-storage": "10Gi"}}},
+		{"metadata": {"name": "compute"}, "status": {"capacity": {"cpu": "1", "memory": "16Gi", "pods": "4"}, "allocatable": {"cpu": "1", "memory": "16Gi", "pods": "4"}}}
+	]}`), &nodes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pod := func(node, cpu, storage string) k8s.Pod {
+		var p k8s.Pod
+		p.Spec.NodeName = node
+		p.Status.Phase = "Running"
+		p.Spec.Containers = []k8s.Container{{Resources: k8s.ResourceRequire{Requests: map[string]string{"cpu": cpu, "ephemeral-storage": storage}}}}
+		return p
+	}
+	for range 4 {
+		pods.Items = append(pods.Items, pod("slots", "100m", ""), pod("compute", "250m", ""))
+	}
+	pods.Items = append(pods.Items, pod("disk", "100m", "6Gi"), pod("disk", "100m", "3Gi"))
+
+	got := map[string]NodeOverview{}
+	for _, n := range BuildNodeOverviews(&nodes, &pods, nil) {
+		got[n.Name] = n
+	}
+	if n := got["slots"]; n.Bottleneck != BottleneckPods || n.PodSlotRatio != 1 || n.EphemeralStorage.Allocatable.Bytes != 90<<30 {
+		t.Errorf("slots: bottleneck %q, pod slots %v, storage %+v", n.Bottleneck, n.PodSlotRatio, n.EphemeralStorage)
+	}
+	if n := got["disk"]; n.Bottleneck != BottleneckEphemeralStorage || n.EphemeralStorage.Requested.Bytes != 9<<30 {
+		t.Errorf("disk: bottleneck %q, storage %+v", n.Bottleneck, n.EphemeralStorage)
+	}
+	// All pod slots taken, but so is all of the CPU.
+	if n := got["compute"]; n.Bottleneck != "" || n.PodSlotRatio != 1 {
+		t.Errorf("compute: bottleneck %q, pod slots %v", n.Bottleneck, n.PodSlotRatio)
+	}
+}
@@ -0,0 +1,132 @@
+package resources
+
+import (
+	"sort"
+
+	"github.com/devops-kubeadjust/backend/k8s"
+)
+
+// maxCandidateNodes caps the node names listed per pending pod.
+const maxCandidateNodes = 5
+
+// UsageEstimate is the observed usage of one container: P95 over a window, or a live snapshot.
+type UsageEstimate struct {
+	CPUMillicores int64
+	MemoryBytes   int64
+}
+
+// IsPending reports whether a pod has not been bound to a node yet.
+func IsPending(pod k8s.Pod) bool {
+	return pod.Spec.NodeName == "" && pod.Status.Phase == "Pending"
+}
+
+// ownerKey identifies the controller of a pod (ReplicaSet, StatefulSet, Job…) within a namespace.
+type ownerKey struct {
+	namespace string
+	uid       string
+}
+
+// controllerOf returns the first owner reference of a pod, or nil for bare pods.
+func controllerOf(pod k8s.Pod) *k8s.OwnerReference {
+	if len(pod.Metadata.OwnerReferences) == 0 {
+		return nil
+	}
+	return &pod.Metadata.OwnerReferences[0]
+}
+
+// EstimatePodUsage estimates the requests a pod would need if they matched the usage observed
+// on its running siblings. For each container it takes the highest usage seen across siblings;
+// containers without any observation keep their current request. Init containers are accounted
+// the same way as in PodRequests. ok is false when no container had an observation.
+func EstimatePodUsage(spec k8s.PodSpec, siblings []map[string]UsageEstimate) (cpu, mem int64, ok bool) {
+	for _, c := range spec.Containers {
+		var est UsageEstimate
+		found := false
+		for _, sib := range siblings {
+			if u, exists := sib[c.Name]; exists {
+				est.CPUMillicores = max(est.CPUMillicores, u.CPUMillicores)
+				est.MemoryBytes = max(est.MemoryBytes, u.MemoryBytes)
+				found = true
+			}
+		}
+		if found {
+			cpu += est.CPUMillicores
+			mem += est.MemoryBytes
+			ok = true
+		} else {
+			cpu += ParseCPUMillicores(c.Resources.Requests["cpu"])
+			mem += ParseMemoryBytes(c.Resources.Requests["memory"])
+		}
+	}
+	for _, c := range spec.InitContainers {
+		cpu = max(cpu, ParseCPUMillicores(c.Resources.Requests["cpu"]))
+		mem = max(mem, ParseMemoryBytes(c.Resources.Requests["memory"]))
+	}
+	return cpu, mem, ok
+}
+
+// BuildPendingPods reports every unscheduled pod with its effective requests, the scheduler's
+// PodScheduled condition, and whether it would fit on a node as requested and at the usage
+// observed on its running siblings. usage maps namespace → pod → container → observation and
+// may be nil; source labels where the observations came from ("prometheus", "metrics-server").
+func BuildPendingPods(pods []k8s.Pod, free []NodeFree, usage map[string]map[string]map[string]UsageEstimate, source string) []PendingPod {
+	siblings := map[ownerKey][]map[string]UsageEstimate{}
+	for _, pod := range pods {
+		owner := controllerOf(pod)
+		if owner == nil || IsPending(pod) {
+			continue
+		}
+		if u, ok := usage[pod.Metadata.Namespace][pod.Metadata.Name]; ok {
+			key := ownerKey{namespace: pod.Metadata.Namespace, uid: owner.UID}
+			siblings[key] = append(siblings[key], u)
+		}
+	}
+
+	result := []PendingPod{}
+	for _, pod := range pods {
+		if !IsPending(pod) {
+			continue
+		}
+		cpu, mem := PodRequests(pod.Spec)
+		pp := PendingPod{
+			Name:      pod.Metadata.Name,
+			Namespace: pod.Metadata.Namespace,
+			CreatedAt: pod.Metadata.CreationTimestamp,
+			Requested: NodeResources{
+				CPU:    ResourceValue{Millicores: cpu, Raw: FmtMillicores(cpu)},
+				Memory: ResourceValue{Bytes: mem, Raw: FmtBytes(mem)},
+			},
+			FitsAsRequested: len(FittingNodes(free, pod.Spec.Tolerations, cpu, mem)) > 0,
+		}
+		for _, c := range pod.Status.Conditions {
+			if c.Type == "PodScheduled" && c.Status != "True" {
+				pp.Reason, pp.Message = c.Reason, c.Message
+			}
+		}
+		if owner := controllerOf(pod); owner != nil {
+			pp.Owner = owner.Kind + "/" + owner.Name
+			sibs := siblings[ownerKey{namespace: pod.Metadata.Namespace, uid: owner.UID}]
+			if estCPU, estMem, ok := EstimatePodUsage(pod.Spec, sibs); ok {
+				pp.Estimate = &NodeResources{
+					CPU:    ResourceValue{Millicores: estCPU, Raw: FmtMillicores(estCPU)},
+					Memory: ResourceValue{Bytes: estMem, Raw: FmtBytes(estMem)},
+				}
+				pp.EstimateSource = source
+				nodes := FittingNodes(free, pod.Spec.Tolerations, estCPU, estMem)
+				pp.FitsAtEstimate = len(nodes) > 0
+				if len(nodes) > maxCandidateNodes {
+					nodes = nodes[:maxCandidateNodes]
+				}
+				pp.CandidateNodes = nodes
+			}
+		}
+		result = append(result, pp)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Namespace != result[j].Namespace {
+			return result[i].Namespace < result[j].Namespace
+		}
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
@@ -0,0 +1,86 @@
+package resources
+
+import (
+	"testing"
+
+	"github.com/devops-kubeadjust/backend/k8s"
+)
+
+func ownedPod(name, owner, nodeName string, c k8s.Container) k8s.Pod {
+	p := pod(name, "Running", c)
+	p.Metadata.Namespace = "payments"
+	p.Metadata.OwnerReferences = []k8s.OwnerReference{{Kind: "ReplicaSet", Name: owner, UID: owner + "-uid"}}
+	p.Spec.NodeName = nodeName
+	if nodeName == "" {
+		p.Status.Phase = "Pending"
+	}
+	return p
+}
+
+func TestEstimatePodUsage(t *testing.T) {
+	spec := k8s.PodSpec{Containers: []k8s.Container{
+		container("app", "2", "4Gi", "", ""),
+		container("sidecar", "100m", "64Mi", "", ""),
+	}}
+
+	t.Run("max across siblings, request fallback for unobserved containers", func(t *testing.T) {
+		siblings := []map[string]UsageEstimate{
+			{"app": {CPUMillicores: 300, MemoryBytes: 512 << 20}},
+			{"app": {CPUMillicores: 450, MemoryBytes: 256 << 20}},
+		}
+		cpu, mem, ok := EstimatePodUsage(spec, siblings)
+		if !ok {
+			t.Fatal("expected ok")
+		}
+		if cpu != 550 {
+			t.Errorf("cpu: got %d, want 550 (450 observed + 100 sidecar request)", cpu)
+		}
+		if mem != (512+64)<<20 {
+			t.Errorf("memory: got %d, want %d", mem, (512+64)<<20)
+		}
+	})
+
+	t.Run("no observations", func(t *testing.T) {
+		if _, _, ok := EstimatePodUsage(spec, nil); ok {
+			t.Error("expected ok=false without sibling data")
+		}
+	})
+}
+
+func TestBuildPendingPods(t *testing.T) {
+	running := ownedPod("web-a", "web-rs", "node-a", container("app", "2", "4Gi", "", ""))
+	pending := ownedPod("web-b", "web-rs", "", container("app", "2", "4Gi", "", ""))
+	pending.Status.Conditions = []k8s.PodCondition{{
+		Type: "PodScheduled", Status: "False", Reason: "Unschedulable",
+		Message: "0/1 nodes are available: 1 Insufficient cpu.",
+	}}
+	bare := pod("debug", "Pending", container("sh", "100m", "64Mi", "", ""))
+	bare.Metadata.Namespace = "payments"
+
+	free := []NodeFree{{Name: "node-a", FreeCPUM: 1000, FreeMemB: 2 << 30}}
+	usage := map[string]map[string]map[string]UsageEstimate{
+		"payments": {"web-a": {"app": {CPUMillicores: 400, MemoryBytes: 1 << 30}}},
+	}
+
+	result := BuildPendingPods([]k8s.Pod{running, pending, bare}, free, usage, "prometheus")
+	if len(result) != 2 {
+		t.Fatalf("expected 2 pending pods, got %d", len(result))
+	}
+	if result[0].Name != "debug" || result[0].Estimate != nil || !result[0].FitsAsRequested {
+		t.Errorf("bare pod: got %+v", result[0])
+	}
+
+	web := result[1]
+	if web.Owner != "ReplicaSet/web-rs" || web.Reason != "Unschedulable" || web.Message == "" {
+		t.Errorf("metadata: got %+v", web)
+	}
+	if web.FitsAsRequested {
+		t.Error("2 CPU request should not fit in 1 CPU free")
+	}
+	if web.Estimate == nil || web.Estimate.CPU.Millicores != 400 || web.EstimateSource != "prometheus" {
+		t.Fatalf("estimate: got %+v", web.Estimate)
+	}
+	if !web.FitsAtEstimate || len(web.CandidateNodes) != 1 || web.CandidateNodes[0] != "node-a" {
+		t.Errorf("fit at estimate: got %v %v", web.FitsAtEstimate, web.CandidateNodes)
+	}
+}
@@ -0,0 +1,90 @@
+package resources
+
+import (
+	"slices"
+	"strings"
+)
+
+// NodePoolLabels are the node labels naming a node's pool on managed clusters and with
+// Karpenter, in the order they are looked up.
+var NodePoolLabels = []string{
+	"eks.amazonaws.com/nodegroup",
+	"cloud.google.com/gke-nodepool",
+	"karpenter.sh/nodepool",
+	"kubernetes.azure.com/agentpool",
+	"agentpool",
+}
+
+// NodePoolName returns the pool of a node with the given labels and the label it was read
+// from: label when set and present, else the first of NodePoolLabels. Both are empty when
+// the node carries none of them.
+func NodePoolName(labels map[string]string, label string) (name, from string) {
+	if label != "" {
+		if v, ok := labels[label]; ok {
+			return v, label
+		}
+	}
+	for _, l := range NodePoolLabels {
+		if v, ok := labels[l]; ok {
+			return v, l
+		}
+	}
+	return "", ""
+}
+
+// BuildNodePools sets the Pool of each node (see NodePoolName) and sums the nodes of each
+// pool. Pools are ordered by name, nodes without a pool coming first.
+func BuildNodePools(nodes []NodeOverview, label string) []NodePool {
+	byName := map[string]*NodePool{}
+	var names []string
+	for i := range nodes {
+		n := &nodes[i]
+		name, from := NodePoolName(n.Labels, label)
+		n.Pool = name
+		p := byName[name]
+		if p == nil {
+			p = &NodePool{Name: name, Label: from, Usage: &NodeResources{}}
+			byName[name] = p
+			names = append(names, name)
+		}
+		p.NodeCount++
+		if n.Status == "Ready" {
+			p.ReadyNodes++
+		}
+		addNodeResources(&p.Capacity, n.Capacity)
+		addNodeResources(&p.Allocatable, n.Allocatable)
+		addNodeResources(&p.Requested, n.Requested)
+		addNodeResources(&p.Limited, n.Limited)
+		if n.Usage == nil {
+			p.Usage = nil
+		} else if p.Usage != nil {
+			addNodeResources(p.Usage, *n.Usage)
+		}
+		p.PodCount += n.PodCount
+		p.MaxPods += n.MaxPods
+	}
+
+	slices.SortFunc(names, strings.Compare)
+	pools := make([]NodePool, 0, len(names))
+	for _, name := range names {
+		p := byName[name]
+		formatNodeResources(&p.Capacity, &p.Allocatable, &p.Requested, &p.Limited, p.Usage)
+		pools = append(pools, *p)
+	}
+	return pools
+}
+
+func addNodeResources(sum *NodeResources, r NodeResources) {
+	sum.CPU.Millicores += r.CPU.Millicores
+	sum.Memory.Bytes += r.Memory.Bytes
+}
+
+// formatNodeResources sets the Raw strings of summed resources, skipping nil ones.
+func formatNodeResources(rs ...*NodeResources) {
+	for _, r := range rs {
+		if r != nil {
+			r.CPU.Raw = FmtMillicores(r.CPU.Millicores)
+			r.Memory.Raw = FmtBytes(r.Memory.Bytes)
+		}
+	}
+}
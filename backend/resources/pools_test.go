@@ -0,0 +1,74 @@
+package resources
+
+import "testing"
+
+func TestNodePoolName(t *testing.T) {
+	tests := []struct {
+		name              string
+		labels            map[string]string
+		label             string
+		wantPool, wantKey string
+	}{
+		{"eks", map[string]string{"eks.amazonaws.com/nodegroup": "general"}, "", "general", "eks.amazonaws.com/nodegroup"},
+		{"karpenter", map[string]string{"karpenter.sh/nodepool": "spot"}, "", "spot", "karpenter.sh/nodepool"},
+		{"configured label wins", map[string]string{"agentpool": "sys", "pool": "batch"}, "pool", "batch", "pool"},
+		{"configured label missing", map[string]string{"cloud.google.com/gke-nodepool": "default-pool"}, "pool", "default-pool", "cloud.google.com/gke-nodepool"},
+		{"none", map[string]string{"kubernetes.io/os": "linux"}, "", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pool, key := NodePoolName(tt.labels, tt.label)
+			if pool != tt.wantPool || key != tt.wantKey {
+				t.Errorf("got (%q, %q), want (%q, %q)", pool, key, tt.wantPool, tt.wantKey)
+			}
+		})
+	}
+}
+
+func TestBuildNodePools(t *testing.T) {
+	node := func(name, pool string, cpu, mem int64, usage bool) NodeOverview {
+		n := NodeOverview{
+			Name:        name,
+			Status:      "Ready",
+			Allocatable: NodeResources{CPU: ResourceValue{Millicores: cpu}, Memory: ResourceValue{Bytes: mem}},
+			Requested:   NodeResources{CPU: ResourceValue{Millicores: cpu / 2}},
+			PodCount:    3,
+			MaxPods:     110,
+		}
+		if pool != "" {
+			n.Labels = map[string]string{"eks.amazonaws.com/nodegroup": pool}
+		}
+		if usage {
+			n.Usage = &NodeResources{CPU: ResourceValue{Millicores: cpu / 4}}
+		}
+		return n
+	}
+	nodes := []NodeOverview{
+		node("a", "general", 4000, 16<<30, true),
+		node("b", "", 2000, 8<<30, true),
+		node("c", "general", 4000, 16<<30, true),
+		node("d", "batch", 8000, 32<<30, false),
+	}
+	nodes[2].Status = "NotReady"
+
+	pools := BuildNodePools(nodes, "")
+	if len(pools) != 3 || pools[0].Name != "" || pools[1].Name != "batch" || pools[2].Name != "general" {
+		t.Fatalf("got pools %+v", pools)
+	}
+	general := pools[2]
+	if general.NodeCount != 2 || general.ReadyNodes != 1 || general.PodCount != 6 || general.MaxPods != 220 {
+		t.Errorf("general counts: %+v", general)
+	}
+	if general.Allocatable.CPU.Raw != "8.00" || general.Allocatable.Memory.Raw != FmtBytes(32<<30) || general.Requested.CPU.Millicores != 4000 {
+		t.Errorf("general resources: %+v", general)
+	}
+	if general.Usage == nil || general.Usage.CPU.Millicores != 2000 || general.Label != "eks.amazonaws.com/nodegroup" {
+		t.Errorf("general usage: %+v", general.Usage)
+	}
+	if pools[1].Usage != nil {
+		t.Errorf("batch usage should be unknown, got %+v", pools[1].Usage)
+	}
+	if nodes[0].Pool != "general" || nodes[1].Pool != "" || nodes[3].Pool != "batch" {
+		t.Errorf("node pools not set: %q %q %q", nodes[0].Pool, nodes[1].Pool, nodes[3].Pool)
+	}
+}
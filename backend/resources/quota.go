@@ -0,0 +1,130 @@
+package resources
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/devops-kubeadjust/backend/k8s"
+)
+
+// QuotaUsage is the hard limit and current usage of one quota resource.
+type QuotaUsage struct {
+	Hard ResourceValue `json:"hard"`
+	Used ResourceValue `json:"used"`
+}
+
+// QuotaStatus reports a ResourceQuota's compute resources, keyed by
+// "requests.cpu", "requests.memory", "limits.cpu" and "limits.memory".
+// The "cpu" and "memory" shorthands are reported as requests.*.
+type QuotaStatus struct {
+	Name      string                `json:"name"`
+	Namespace string                `json:"namespace,omitempty"`
+	Resources map[string]QuotaUsage `json:"resources"`
+}
+
+// LimitRangeDefaults holds the container defaults of a LimitRange: values the admission
+// controller injects into containers that do not set them.
+type LimitRangeDefaults struct {
+	Name           string        `json:"name"`
+	DefaultRequest *ResourcePair `json:"defaultRequest,omitempty"`
+	Default        *ResourcePair `json:"default,omitempty"` // default limits
+}
+
+// quotaAliases maps quota resource names to the normalised key they are reported under.
+var quotaAliases = map[string]string{
+	"cpu":             "requests.cpu",
+	"memory":          "requests.memory",
+	"requests.cpu":    "requests.cpu",
+	"requests.memory": "requests.memory",
+	"limits.cpu":      "limits.cpu",
+	"limits.memory":   "limits.memory",
+}
+
+// BuildQuotaStatus extracts the compute resources of a ResourceQuota.
+// Returns ok=false for quotas that do not constrain CPU or memory (e.g. object counts only).
+// When a quota sets both a shorthand and its requests.* key, both are enforced, so the
+// smaller hard value is kept (the requests.* one on a tie).
+func BuildQuotaStatus(q k8s.ResourceQuota) (QuotaStatus, bool) {
+	qs := QuotaStatus{Name: q.Metadata.Name, Namespace: q.Metadata.Namespace, Resources: map[string]QuotaUsage{}}
+	for name, hard := range q.Status.Hard {
+		key, ok := quotaAliases[name]
+		if !ok {
+			continue
+		}
+		isCPU := strings.HasSuffix(key, ".cpu")
+		u := QuotaUsage{
+			Hard: ParseResource(hard, isCPU),
+			Used: ParseResource(q.Status.Used[name], isCPU),
+		}
+		if prev, dup := qs.Resources[key]; dup {
+			size := func(v ResourceValue) int64 { return v.Millicores + v.Bytes }
+			if size(prev.Hard) < size(u.Hard) || size(prev.Hard) == size(u.Hard) && name != key {
+				continue
+			}
+		}
+		qs.Resources[key] = u
+	}
+	return qs, len(qs.Resources) > 0
+}
+
+// BuildLimitRangeDefaults extracts the Container-type defaults of a LimitRange.
+// Returns ok=false when the LimitRange sets no container default.
+func BuildLimitRangeDefaults(lr k8s.LimitRange) (LimitRangeDefaults, bool) {
+	d := LimitRangeDefaults{Name: lr.Metadata.Name}
+	pair := func(m map[string]string) *ResourcePair {
+		if m["cpu"] == "" && m["memory"] == "" {
+			return nil
+		}
+		return &ResourcePair{CPU: ParseResource(m["cpu"], true), Memory: ParseResource(m["memory"], false)}
+	}
+	for _, item := range lr.Spec.Limits {
+		if item.Type != "Container" {
+			continue
+		}
+		if p := pair(item.DefaultRequest); p != nil {
+			d.DefaultRequest = p
+		}
+		if p := pair(item.Default); p != nil {
+			d.Default = p
+		}
+	}
+	return d, d.DefaultRequest != nil || d.Default != nil
+}
+
+// limitRangerAnnotation is set by the LimitRanger admission plugin on pods it mutated, e.g.
+// "LimitRanger plugin set: cpu, memory request for container app; cpu limit for container app".
+const limitRangerAnnotation = "kubernetes.io/limit-ranger"
+
+// LimitRangeDefaulted returns, per app container, the resources the LimitRanger admission
+// plugin injected, as "requests.cpu", "limits.memory", … (init containers are skipped).
+func LimitRangeDefaulted(pod k8s.Pod) map[string][]string {
+	ann, ok := strings.CutPrefix(pod.Metadata.Annotations[limitRangerAnnotation], "LimitRanger plugin set: ")
+	if !ok {
+		return nil
+	}
+	out := map[string][]string{}
+	for entry := range strings.SplitSeq(ann, ";") {
+		entry = strings.TrimSpace(entry)
+		var kind, names, target string
+		if before, after, found := strings.Cut(entry, " request for "); found {
+			kind, names, target = "requests", before, after
+		} else if before, after, found := strings.Cut(entry, " limit for "); found {
+			kind, names, target = "limits", before, after
+		} else {
+			continue
+		}
+		container, ok := strings.CutPrefix(target, "container ")
+		if !ok {
+			continue // "init container <name>"
+		}
+		for res := range strings.SplitSeq(names, ",") {
+			if res = strings.TrimSpace(res); res == "cpu" || res == "memory" {
+				key := kind + "." + res
+				if !slices.Contains(out[container], key) {
+					out[container] = append(out[container], key)
+				}
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,96 @@
+package resources
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/devops-kubeadjust/backend/k8s"
+)
+
+func TestBuildQuotaStatus(t *testing.T) {
+	var q k8s.ResourceQuota
+	q.Metadata.Name = "team-quota"
+	q.Status.Hard = map[string]string{"cpu": "10", "limits.memory": "20Gi", "pods": "50"}
+	q.Status.Used = map[string]string{"cpu": "2500m", "limits.memory": "4Gi", "pods": "12"}
+
+	qs, ok := BuildQuotaStatus(q)
+	if !ok {
+		t.Fatal("expected compute quota to be reported")
+	}
+	if len(qs.Resources) != 2 {
+		t.Errorf("got %d resources, want 2 (pods ignored): %v", len(qs.Resources), qs.Resources)
+	}
+	if got := qs.Resources["requests.cpu"]; got.Hard.Millicores != 10000 || got.Used.Millicores != 2500 {
+		t.Errorf("requests.cpu (from cpu shorthand): got %+v", got)
+	}
+	if got := qs.Resources["limits.memory"]; got.Hard.Bytes != 20<<30 {
+		t.Errorf("limits.memory hard: got %d", got.Hard.Bytes)
+	}
+
+	// Shorthand and requests.* both set: the smaller hard wins, requests.* on a tie,
+	// whatever the map iteration order.
+	var both k8s.ResourceQuota
+	both.Status.Hard = map[string]string{"cpu": "4", "requests.cpu": "6", "memory": "8Gi", "requests.memory": "8192Mi"}
+	both.Status.Used = map[string]string{"cpu": "1", "requests.cpu": "1", "memory": "1Gi", "requests.memory": "1Gi"}
+	for range 20 {
+		qs, _ := BuildQuotaStatus(both)
+		if got := qs.Resources["requests.cpu"].Hard.Raw; got != "4" {
+			t.Fatalf("requests.cpu hard: got %q, want the smaller 4", got)
+		}
+		if got := qs.Resources["requests.memory"].Hard.Raw; got != "8192Mi" {
+			t.Fatalf("requests.memory hard: got %q, want requests.memory's 8192Mi", got)
+		}
+	}
+
+	var objectsOnly k8s.ResourceQuota
+	objectsOnly.Status.Hard = map[string]string{"pods": "10"}
+	if _, ok := BuildQuotaStatus(objectsOnly); ok {
+		t.Error("quota without CPU/memory should not be reported")
+	}
+}
+
+func TestBuildLimitRangeDefaults(t *testing.T) {
+	var lr k8s.LimitRange
+	lr.Metadata.Name = "defaults"
+	lr.Spec.Limits = []k8s.LimitRangeItem{
+		{Type: "Pod", Max: map[string]string{"cpu": "4"}},
+		{Type: "Container", DefaultRequest: map[string]string{"cpu": "100m", "memory": "128Mi"}, Default: map[string]string{"memory": "256Mi"}},
+	}
+	d, ok := BuildLimitRangeDefaults(lr)
+	if !ok {
+		t.Fatal("expected container defaults")
+	}
+	if d.DefaultRequest == nil || d.DefaultRequest.CPU.Millicores != 100 {
+		t.Errorf("defaultRequest: got %+v", d.DefaultRequest)
+	}
+	if d.Default == nil || d.Default.Memory.Bytes != 256<<20 {
+		t.Errorf("default: got %+v", d.Default)
+	}
+
+	var maxOnly k8s.LimitRange
+	maxOnly.Spec.Limits = []k8s.LimitRangeItem{{Type: "Container", Max: map[string]string{"cpu": "2"}}}
+	if _, ok := BuildLimitRangeDefaults(maxOnly); ok {
+		t.Error("LimitRange without defaults should not be reported")
+	}
+}
+
+func TestLimitRangeDefaulted(t *testing.T) {
+	p := pod("web-1", "Running")
+	p.Metadata.Annotations = map[string]string{
+		limitRangerAnnotation: "LimitRanger plugin set: cpu, memory request for container app; " +
+			"memory limit for container app; cpu request for container sidecar; cpu request for init container migrate",
+	}
+	got := LimitRangeDefaulted(p)
+	if want := []string{"requests.cpu", "requests.memory", "limits.memory"}; !slices.Equal(got["app"], want) {
+		t.Errorf("app: got %v, want %v", got["app"], want)
+	}
+	if want := []string{"requests.cpu"}; !slices.Equal(got["sidecar"], want) {
+		t.Errorf("sidecar: got %v, want %v", got["sidecar"], want)
+	}
+	if _, ok := got["migrate"]; ok {
+		t.Error("init containers should be skipped")
+	}
+	if LimitRangeDefaulted(pod("plain", "Running")) != nil {
+		t.Error("pod without annotation should have no defaulted values")
+	}
+}
@@ -0,0 +1,177 @@
+package resources
+
+import (
+	"slices"
+	"strconv"
+
+	"github.com/devops-kubeadjust/backend/k8s"
+)
+
+// PodRequests returns the effective CPU (millicores) and memory (bytes) requests the scheduler
+// accounts for a pod: the sum over app containers, or the largest init container when that
+// is bigger (init containers run one at a time, before the app containers).
+func PodRequests(spec k8s.PodSpec) (cpu, mem int64) {
+	for _, c := range spec.Containers {
+		cpu += ParseCPUMillicores(c.Resources.Requests["cpu"])
+		mem += ParseMemoryBytes(c.Resources.Requests["memory"])
+	}
+	for _, c := range spec.InitContainers {
+		cpu = max(cpu, ParseCPUMillicores(c.Resources.Requests["cpu"]))
+		mem = max(mem, ParseMemoryBytes(c.Resources.Requests["memory"]))
+	}
+	return cpu, mem
+}
+
+// ToleratesTaints reports whether tolerations allow scheduling onto a node with the given taints.
+// Only NoSchedule and NoExecute taints block scheduling; PreferNoSchedule is a soft preference.
+func ToleratesTaints(tolerations []k8s.Toleration, taints []k8s.Taint) bool {
+	for _, taint := range taints {
+		if taint.Effect != "NoSchedule" && taint.Effect != "NoExecute" {
+			continue
+		}
+		tolerated := false
+		for _, t := range tolerations {
+			if tolerates(t, taint) {
+				tolerated = true
+				break
+			}
+		}
+		if !tolerated {
+			return false
+		}
+	}
+	return true
+}
+
+// tolerates mirrors the Kubernetes toleration matching rules for a single taint.
+func tolerates(t k8s.Toleration, taint k8s.Taint) bool {
+	if t.Effect != "" && t.Effect != taint.Effect {
+		return false
+	}
+	if t.Key == "" {
+		// An empty key with operator Exists matches every taint.
+		return t.Operator == "Exists"
+	}
+	if t.Key != taint.Key {
+		return false
+	}
+	if t.Operator == "Exists" {
+		return true
+	}
+	return t.Value == taint.Value
+}
+
+// MatchesNode reports whether a pod's nodeSelector and required node affinity accept a node
+// with the given labels. Node selector terms are ORed; expressions within a term are ANDed.
+// Terms with an unknown operator never match, so a pod is never placed where it could not run.
+func MatchesNode(spec k8s.PodSpec, labels map[string]string) bool {
+	for k, v := range spec.NodeSelector {
+		if labels[k] != v {
+			return false
+		}
+	}
+	if spec.Affinity == nil || spec.Affinity.NodeAffinity == nil {
+		return true
+	}
+	required := spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution
+	if required == nil || len(required.NodeSelectorTerms) == 0 {
+		return true
+	}
+	for _, term := range required.NodeSelectorTerms {
+		if matchesTerm(term, labels) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesTerm(term k8s.NodeSelectorTerm, labels map[string]string) bool {
+	if len(term.MatchExpressions) == 0 {
+		return false // an empty term matches no objects
+	}
+	for _, req := range term.MatchExpressions {
+		if !matchesRequirement(req, labels) {
+			return false
+		}
+	}
+	return true
+}
+
+func matchesRequirement(req k8s.NodeSelectorRequirement, labels map[string]string) bool {
+	v, exists := labels[req.Key]
+	switch req.Operator {
+	case "In":
+		return exists && slices.Contains(req.Values, v)
+	case "NotIn":
+		return !exists || !slices.Contains(req.Values, v)
+	case "Exists":
+		return exists
+	case "DoesNotExist":
+		return !exists
+	case "Gt", "Lt":
+		if !exists || len(req.Values) != 1 {
+			return false
+		}
+		have, err1 := strconv.ParseInt(v, 10, 64)
+		want, err2 := strconv.ParseInt(req.Values[0], 10, 64)
+		if err1 != nil || err2 != nil {
+			return false
+		}
+		if req.Operator == "Gt" {
+			return have > want
+		}
+		return have < want
+	default:
+		return false
+	}
+}
+
+// NodeFree is a schedulable node's allocatable capacity minus what its pods already request.
+// Values can be negative on overcommitted nodes (static pods, pods bound before a resize).
+type NodeFree struct {
+	Name     string      `json:"name"`
+	FreeCPUM int64       `json:"freeCpuM"`
+	FreeMemB int64       `json:"freeMemB"`
+	Taints   []k8s.Taint `json:"-"`
+}
+
+// FreeAllocatable computes NodeFree for every Ready, uncordoned node, subtracting the
+// requests of all non-terminal pods bound to it.
+func FreeAllocatable(nodes []k8s.Node, pods []k8s.Pod) []NodeFree {
+	requested := map[string][2]int64{}
+	for _, pod := range pods {
+		if pod.Spec.NodeName == "" || pod.Status.Phase == "Succeeded" || pod.Status.Phase == "Failed" {
+			continue
+		}
+		cpu, mem := PodRequests(pod.Spec)
+		r := requested[pod.Spec.NodeName]
+		requested[pod.Spec.NodeName] = [2]int64{r[0] + cpu, r[1] + mem}
+	}
+
+	result := make([]NodeFree, 0, len(nodes))
+	for _, node := range nodes {
+		if node.Spec.Unschedulable || NodeStatus(node.Status.Conditions) != "Ready" {
+			continue
+		}
+		r := requested[node.Metadata.Name]
+		result = append(result, NodeFree{
+			Name:     node.Metadata.Name,
+			FreeCPUM: ParseCPUMillicores(node.Status.Allocatable["cpu"]) - r[0],
+			FreeMemB: ParseMemoryBytes(node.Status.Allocatable["memory"]) - r[1],
+			Taints:   node.Spec.Taints,
+		})
+	}
+	return result
+}
+
+// FittingNodes returns the names of nodes whose taints are tolerated and that have enough
+// free CPU and memory for the given requests.
+func FittingNodes(free []NodeFree, tolerations []k8s.Toleration, cpu, mem int64) []string {
+	var names []string
+	for _, n := range free {
+		if n.FreeCPUM >= cpu && n.FreeMemB >= mem && ToleratesTaints(tolerations, n.Taints) {
+			names = append(names, n.Name)
+		}
+	}
+	return names
+}
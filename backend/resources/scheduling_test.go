@@ -0,0 +1,111 @@
+package resources
+
+import (
+	"testing"
+
+	"github.com/devops-kubeadjust/backend/k8s"
+)
+
+func TestPodRequests(t *testing.T) {
+	spec := k8s.PodSpec{
+		Containers: []k8s.Container{
+			container("app", "200m", "128Mi", "", ""),
+			container("sidecar", "50m", "64Mi", "", ""),
+		},
+		InitContainers: []k8s.Container{container("migrate", "500m", "32Mi", "", "")},
+	}
+	cpu, mem := PodRequests(spec)
+	if cpu != 500 {
+		t.Errorf("cpu: got %d, want 500 (init container dominates)", cpu)
+	}
+	if mem != 192*1024*1024 {
+		t.Errorf("memory: got %d, want %d (app containers dominate)", mem, 192*1024*1024)
+	}
+}
+
+func TestToleratesTaints(t *testing.T) {
+	noSchedule := k8s.Taint{Key: "dedicated", Value: "gpu", Effect: "NoSchedule"}
+	tests := []struct {
+		name        string
+		tolerations []k8s.Toleration
+		taints      []k8s.Taint
+		want        bool
+	}{
+		{"no taints", nil, nil, true},
+		{"untolerated NoSchedule", nil, []k8s.Taint{noSchedule}, false},
+		{"PreferNoSchedule is soft", nil, []k8s.Taint{{Key: "x", Effect: "PreferNoSchedule"}}, true},
+		{"Equal match", []k8s.Toleration{{Key: "dedicated", Value: "gpu", Effect: "NoSchedule"}}, []k8s.Taint{noSchedule}, true},
+		{"Equal value mismatch", []k8s.Toleration{{Key: "dedicated", Value: "cpu"}}, []k8s.Taint{noSchedule}, false},
+		{"Exists on key", []k8s.Toleration{{Key: "dedicated", Operator: "Exists"}}, []k8s.Taint{noSchedule}, true},
+		{"effect mismatch", []k8s.Toleration{{Key: "dedicated", Operator: "Exists", Effect: "NoExecute"}}, []k8s.Taint{noSchedule}, false},
+		{"empty key Exists tolerates all", []k8s.Toleration{{Operator: "Exists"}}, []k8s.Taint{noSchedule, {Key: "other", Effect: "NoExecute"}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ToleratesTaints(tt.tolerations, tt.taints); got != tt.want {
+				t.Errorf("ToleratesTaints() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func readyNode(name, cpu, mem string, taints ...k8s.Taint) k8s.Node {
+	var n k8s.Node
+	n.Metadata.Name = name
+	n.Spec.Taints = taints
+	n.Status.Allocatable = map[string]string{"cpu": cpu, "memory": mem}
+	n.Status.Conditions = []k8s.NodeCondition{{Type: "Ready", Status: "True"}}
+	return n
+}
+
+func TestFreeAllocatable(t *testing.T) {
+	cordoned := readyNode("cordoned", "4", "8Gi")
+	cordoned.Spec.Unschedulable = true
+	notReady := readyNode("not-ready", "4", "8Gi")
+	notReady.Status.Conditions[0].Status = "False"
+
+	scheduled := pod("web-1", "Running", container("app", "1", "1Gi", "", ""))
+	scheduled.Spec.NodeName = "node-a"
+	done := pod("job-1", "Succeeded", container("app", "1", "1Gi", "", ""))
+	done.Spec.NodeName = "node-a"
+
+	free := FreeAllocatable([]k8s.Node{readyNode("node-a", "4", "8Gi"), cordoned, notReady}, []k8s.Pod{scheduled, done})
+	if len(free) != 1 {
+		t.Fatalf("expected only node-a to be schedulable, got %+v", free)
+	}
+	if free[0].FreeCPUM != 3000 || free[0].FreeMemB != 7*1024*1024*1024 {
+		t.Errorf("free: got %dm / %d B, want 3000m / 7Gi", free[0].FreeCPUM, free[0].FreeMemB)
+	}
+}
+
+func TestMatchesNode(t *testing.T) {
+	labels := map[string]string{"kubernetes.io/arch": "amd64", "pool": "general", "gpus": "2"}
+	affinity := func(reqs ...k8s.NodeSelectorRequirement) *k8s.Affinity {
+		return &k8s.Affinity{NodeAffinity: &k8s.NodeAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: &k8s.NodeSelector{
+				NodeSelectorTerms: []k8s.NodeSelectorTerm{{MatchExpressions: reqs}},
+			},
+		}}
+	}
+	tests := []struct {
+		name string
+		spec k8s.PodSpec
+		want bool
+	}{
+		{"no constraints", k8s.PodSpec{}, true},
+		{"nodeSelector match", k8s.PodSpec{NodeSelector: map[string]string{"pool": "general"}}, true},
+		{"nodeSelector mismatch", k8s.PodSpec{NodeSelector: map[string]string{"pool": "gpu"}}, false},
+		{"In", k8s.PodSpec{Affinity: affinity(k8s.NodeSelectorRequirement{Key: "pool", Operator: "In", Values: []string{"gpu", "general"}})}, true},
+		{"NotIn", k8s.PodSpec{Affinity: affinity(k8s.NodeSelectorRequirement{Key: "pool", Operator: "NotIn", Values: []string{"general"}})}, false},
+		{"DoesNotExist", k8s.PodSpec{Affinity: affinity(k8s.NodeSelectorRequirement{Key: "spot", Operator: "DoesNotExist"})}, true},
+		{"Gt", k8s.PodSpec{Affinity: affinity(k8s.NodeSelectorRequirement{Key: "gpus", Operator: "Gt", Values: []string{"1"}})}, true},
+		{"Lt on missing label", k8s.PodSpec{Affinity: affinity(k8s.NodeSelectorRequirement{Key: "cores", Operator: "Lt", Values: []string{"8"}})}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MatchesNode(tt.spec, labels); got != tt.want {
+				t.Errorf("MatchesNode() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package resources
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/devops-kubeadjust/backend/k8s"
+)
+
+// Annotations read on workloads and their pod templates to tune or silence suggestions.
+const (
+	AnnotationIgnore    = "kubeadjust.io/ignore"     // "true": never suggest changes (intentionally over-provisioned)
+	AnnotationMinMemory = "kubeadjust.io/min-memory" // quantity: memory request/limit floor, e.g. fixed JVM heaps
+	AnnotationHeadroom  = "kubeadjust.io/headroom"   // request headroom over usage: "1.5" or "50%" (default ×1.3)
+)
+
+// SizingPolicy is the per-workload tuning from the kubeadjust.io annotations.
+type SizingPolicy struct {
+	Ignore    bool           `json:"ignore,omitempty"`
+	MinMemory *ResourceValue `json:"minMemory,omitempty"`
+	Headroom  float64        `json:"headroom,omitempty"` // request = usage × headroom
+}
+
+// SizingPolicyFor reads the annotations of a workload and of its pod template; the workload's
+// win. Returns nil when none is set. Invalid values are ignored and reported in err.
+func SizingPolicyFor(workload, template k8s.ObjectMeta) (*SizingPolicy, error) {
+	get := func(key string) (string, bool) {
+		if v, ok := workload.Annotations[key]; ok {
+			return v, true
+		}
+		v, ok := template.Annotations[key]
+		return v, ok
+	}
+	var p SizingPolicy
+	var errs []error
+	set := false
+	if v, ok := get(AnnotationIgnore); ok {
+		b, err := strconv.ParseBool(strings.TrimSpace(v))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %q is not a boolean", AnnotationIgnore, v))
+		}
+		p.Ignore, set = b, set || b
+	}
+	if v, ok := get(AnnotationMinMemory); ok {
+		v = strings.TrimSpace(v)
+		if b := ParseMemoryBytes(v); b > 0 {
+			p.MinMemory, set = &ResourceValue{Raw: v, Bytes: b}, true
+		} else {
+			errs = append(errs, fmt.Errorf("%s: %q is not a memory quantity", AnnotationMinMemory, v))
+		}
+	}
+	if v, ok := get(AnnotationHeadroom); ok {
+		if h, err := parseHeadroom(v); err == nil {
+			p.Headroom, set = h, true
+		} else {
+			errs = append(errs, fmt.Errorf("%s: %w", AnnotationHeadroom, err))
+		}
+	}
+	if !set {
+		return nil, errors.Join(errs...)
+	}
+	return &p, errors.Join(errs...)
+}
+
+// parseHeadroom accepts a multiplier ("1.5") or a percentage on top of usage ("50%").
+func parseHeadroom(v string) (float64, error) {
+	v = strings.TrimSpace(v)
+	pct := strings.HasSuffix(v, "%")
+	f, err := strconv.ParseFloat(strings.TrimSuffix(v, "%"), 64)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a number", v)
+	}
+	if pct {
+		f = 1 + f/100
+	}
+	if f < 1 || f > 10 {
+		return 0, fmt.Errorf("%q must be between 1 (0%%) and 10", v)
+	}
+	return f, nil
+}
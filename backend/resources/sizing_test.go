@@ -0,0 +1,45 @@
+package resources
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/devops-kubeadjust/backend/k8s"
+)
+
+func TestSizingPolicyFor(t *testing.T) {
+	meta := func(kv ...string) k8s.ObjectMeta {
+		m := k8s.ObjectMeta{Annotations: map[string]string{}}
+		for i := 0; i < len(kv); i += 2 {
+			m.Annotations[kv[i]] = kv[i+1]
+		}
+		return m
+	}
+	tests := []struct {
+		name               string
+		workload, template k8s.ObjectMeta
+		want               *SizingPolicy
+		wantErr            bool
+	}{
+		{"no annotations", meta(), meta(), nil, false},
+		{"ignore on the template", meta(), meta(AnnotationIgnore, "true"), &SizingPolicy{Ignore: true}, false},
+		{"workload wins over template", meta(AnnotationIgnore, "false"), meta(AnnotationIgnore, "true"), nil, false},
+		{"min-memory and percent headroom", meta(AnnotationMinMemory, "2Gi", AnnotationHeadroom, "50%"), meta(),
+			&SizingPolicy{MinMemory: &ResourceValue{Raw: "2Gi", Bytes: 2 << 30}, Headroom: 1.5}, false},
+		{"multiplier headroom", meta(AnnotationHeadroom, "1.2"), meta(), &SizingPolicy{Headroom: 1.2}, false},
+		{"invalid values are dropped", meta(AnnotationHeadroom, "0.5", AnnotationMinMemory, "lots", AnnotationIgnore, "yes"), meta(), nil, true},
+		{"valid values survive invalid ones", meta(AnnotationHeadroom, "x", AnnotationMinMemory, "512Mi"), meta(),
+			&SizingPolicy{MinMemory: &ResourceValue{Raw: "512Mi", Bytes: 512 << 20}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := SizingPolicyFor(tt.workload, tt.template)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
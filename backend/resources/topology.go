@@ -0,0 +1,134 @@
+package resources
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+
+	"github.com/devops-kubeadjust/backend/k8s"
+)
+
+// Well-known node topology labels, each with the deprecated beta label older nodes carry.
+var (
+	zoneLabels         = []string{"topology.kubernetes.io/zone", "failure-domain.beta.kubernetes.io/zone"}
+	regionLabels       = []string{"topology.kubernetes.io/region", "failure-domain.beta.kubernetes.io/region"}
+	instanceTypeLabels = []string{"node.kubernetes.io/instance-type", "beta.kubernetes.io/instance-type"}
+)
+
+// zoneImbalance is how far above the mean RequestRatio of the zones a zone counts as fuller:
+// after its loss, the other zones could not take its pods back.
+const zoneImbalance = 0.2
+
+// NodeTopology returns the zone, region and instance type of a node from its labels.
+func NodeTopology(labels map[string]string) (zone, region, instanceType string) {
+	first := func(keys []string) string {
+		for _, k := range keys {
+			if v := labels[k]; v != "" {
+				return v
+			}
+		}
+		return ""
+	}
+	return first(zoneLabels), first(regionLabels), first(instanceTypeLabels)
+}
+
+// BuildTopology sums nodes per zone (ordered by name, nodes without a zone first) and
+// reports the Deployments and StatefulSets whose running pods, two or more, all run in one
+// zone while the cluster spans several. A zone is Fuller when its RequestRatio is at least
+// zoneImbalance above the mean of the zones.
+func BuildTopology(nodes []NodeOverview, pods []k8s.Pod) TopologyResponse {
+	byZone := map[string]*ZoneOverview{}
+	nodeZone := make(map[string]string, len(nodes))
+	var names []string
+	for _, n := range nodes {
+		nodeZone[n.Name] = n.Zone
+		z := byZone[n.Zone]
+		if z == nil {
+			z = &ZoneOverview{Zone: n.Zone, Region: n.Region, Usage: &NodeResources{}}
+			byZone[n.Zone] = z
+			names = append(names, n.Zone)
+		}
+		z.NodeCount++
+		if n.InstanceType != "" {
+			if z.InstanceTypes == nil {
+				z.InstanceTypes = map[string]int{}
+			}
+			z.InstanceTypes[n.InstanceType]++
+		}
+		addNodeResources(&z.Allocatable, n.Allocatable)
+		addNodeResources(&z.Requested, n.Requested)
+		if n.Usage == nil {
+			z.Usage = nil
+		} else if z.Usage != nil {
+			addNodeResources(z.Usage, *n.Usage)
+		}
+		z.PodCount += n.PodCount
+	}
+
+	slices.SortFunc(names, strings.Compare)
+	resp := TopologyResponse{Zones: make([]ZoneOverview, 0, len(names)), SingleZoneWorkloads: []ZoneWorkload{}}
+	var mean float64
+	for _, name := range names {
+		z := byZone[name]
+		formatNodeResources(&z.Allocatable, &z.Requested, z.Usage)
+		if z.Allocatable.CPU.Millicores > 0 {
+			z.RequestRatio = float64(z.Requested.CPU.Millicores) / float64(z.Allocatable.CPU.Millicores)
+		}
+		if z.Allocatable.Memory.Bytes > 0 {
+			z.RequestRatio = max(z.RequestRatio, float64(z.Requested.Memory.Bytes)/float64(z.Allocatable.Memory.Bytes))
+		}
+		mean += z.RequestRatio / float64(len(names))
+		resp.Zones = append(resp.Zones, *z)
+	}
+	if len(resp.Zones) < 2 {
+		return resp
+	}
+	for i := range resp.Zones {
+		resp.Zones[i].Fuller = resp.Zones[i].RequestRatio-mean >= zoneImbalance
+	}
+	if _, ok := byZone[""]; ok && len(resp.Zones) == 2 {
+		return resp // a single zone, and unlabelled nodes
+	}
+
+	// Running replicas per workload and zone
+	type workload struct{ namespace, kind, name string }
+	zones := map[workload]map[string]int{}
+	for _, pod := range pods {
+		owner := controllerOf(pod)
+		if owner == nil || pod.Status.Phase != "Running" || pod.Spec.NodeName == "" {
+			continue
+		}
+		wl := workload{namespace: pod.Metadata.Namespace, kind: owner.Kind, name: owner.Name}
+		switch owner.Kind {
+		case "ReplicaSet":
+			// A Deployment's ReplicaSet is named <deployment>-<pod-template-hash>; the pods of
+			// all its revisions count for the Deployment.
+			if hash := pod.Metadata.Labels["pod-template-hash"]; hash != "" {
+				wl.kind, wl.name = "Deployment", strings.TrimSuffix(owner.Name, "-"+hash)
+			}
+		case "StatefulSet":
+		default:
+			continue // DaemonSets run in every zone; Jobs are not services
+		}
+		if zones[wl] == nil {
+			zones[wl] = map[string]int{}
+		}
+		zones[wl][nodeZone[pod.Spec.NodeName]]++
+	}
+	for wl, counts := range zones {
+		if len(counts) != 1 {
+			continue
+		}
+		for zone, n := range counts {
+			if zone != "" && n >= 2 {
+				resp.SingleZoneWorkloads = append(resp.SingleZoneWorkloads, ZoneWorkload{
+					Namespace: wl.namespace, Kind: wl.kind, Name: wl.name, Zone: zone, Replicas: n,
+				})
+			}
+		}
+	}
+	slices.SortFunc(resp.SingleZoneWorkloads, func(a, b ZoneWorkload) int {
+		return cmp.Or(strings.Compare(a.Namespace, b.Namespace), strings.Compare(a.Kind, b.Kind), strings.Compare(a.Name, b.Name))
+	})
+	return resp
+}
@@ -0,0 +1,83 @@
+package resources
+
+import (
+	"testing"
+
+	"github.com/devops-kubeadjust/backend/k8s"
+)
+
+func TestNodeTopology(t *testing.T) {
+	zone, region, instanceType := NodeTopology(map[string]string{
+		"topology.kubernetes.io/zone":              "eu-west-1a",
+		"failure-domain.beta.kubernetes.io/zone":   "old",
+		"failure-domain.beta.kubernetes.io/region": "eu-west-1",
+		"beta.kubernetes.io/instance-type":         "m5.large",
+	})
+	if zone != "eu-west-1a" || region != "eu-west-1" || instanceType != "m5.large" {
+		t.Errorf("got %q %q %q", zone, region, instanceType)
+	}
+}
+
+func TestBuildTopology(t *testing.T) {
+	node := func(name, zone string, cpuReq int64) NodeOverview {
+		return NodeOverview{
+			Name: name, Zone: zone, Region: "eu-west-1", InstanceType: "m5.large", PodCount: 2,
+			Allocatable: NodeResources{CPU: ResourceValue{Millicores: 1000}, Memory: ResourceValue{Bytes: 4 << 30}},
+			Requested:   NodeResources{CPU: ResourceValue{Millicores: cpuReq}, Memory: ResourceValue{Bytes: 1 << 30}},
+		}
+	}
+	nodes := []NodeOverview{node("a1", "a", 900), node("a2", "a", 900), node("b1", "b", 300), node("c1", "c", 300)}
+	pod := func(name, nodeName, kind, owner, hash string) k8s.Pod {
+		var p k8s.Pod
+		p.Metadata.Name, p.Metadata.Namespace = name, "shop"
+		p.Metadata.Labels = map[string]string{"pod-template-hash": hash}
+		p.Metadata.OwnerReferences = []k8s.OwnerReference{{Kind: kind, Name: owner}}
+		p.Spec.NodeName = nodeName
+		p.Status.Phase = "Running"
+		return p
+	}
+	pods := []k8s.Pod{
+		// web: two revisions, all in zone a
+		pod("web-1-x", "a1", "ReplicaSet", "web-1", "1"),
+		pod("web-2-y", "a2", "ReplicaSet", "web-2", "2"),
+		// api: spread
+		pod("api-1-x", "a1", "ReplicaSet", "api-1", "1"),
+		pod("api-1-y", "b1", "ReplicaSet", "api-1", "1"),
+		// db: StatefulSet in zone c
+		pod("db-0", "c1", "StatefulSet", "db", ""),
+		pod("db-1", "c1", "StatefulSet", "db", ""),
+		// single replica and DaemonSet pods are not reported
+		pod("cache-1-x", "b1", "ReplicaSet", "cache-1", "1"),
+		pod("agent-a", "a1", "DaemonSet", "agent", ""),
+		pod("agent-b", "a2", "DaemonSet", "agent", ""),
+	}
+
+	resp := BuildTopology(nodes, pods)
+	if len(resp.Zones) != 3 || resp.Zones[0].Zone != "a" || resp.Zones[2].Zone != "c" {
+		t.Fatalf("zones: %+v", resp.Zones)
+	}
+	a := resp.Zones[0]
+	if a.NodeCount != 2 || a.InstanceTypes["m5.large"] != 2 || a.Requested.CPU.Raw != "1.80" || a.RequestRatio != 0.9 || !a.Fuller || a.Usage != nil {
+		t.Errorf("zone a: %+v", a)
+	}
+	if b := resp.Zones[1]; b.RequestRatio != 0.3 || b.Fuller {
+		t.Errorf("zone b: %+v", b)
+	}
+	want := []ZoneWorkload{
+		{Namespace: "shop", Kind: "Deployment", Name: "web", Zone: "a", Replicas: 2},
+		{Namespace: "shop", Kind: "StatefulSet", Name: "db", Zone: "c", Replicas: 2},
+	}
+	if len(resp.SingleZoneWorkloads) != len(want) {
+		t.Fatalf("single-zone workloads: %+v", resp.SingleZoneWorkloads)
+	}
+	for i := range want {
+		if resp.SingleZoneWorkloads[i] != want[i] {
+			t.Errorf("single-zone workload %d: got %+v, want %+v", i, resp.SingleZoneWorkloads[i], want[i])
+		}
+	}
+
+	// Single-zone clusters have nothing to spread over.
+	if resp := BuildTopology(nodes[:2], pods); len(resp.SingleZoneWorkloads) != 0 || resp.Zones[0].Fuller {
+		t.Errorf("single zone: %+v", resp)
+	}
+}
@@ -33,36 +33,86 @@ type VolumeDetail struct {
 	Available    *ResourceValue `json:"available,omitempty"`
 }
 
+// ContainerTermination describes the last time a container terminated (from lastState.terminated).
+type ContainerTermination struct {
+	Reason     string `json:"reason"`
+	ExitCode   int32  `json:"exitCode"`
+	FinishedAt string `json:"finishedAt,omitempty"` // RFC3339
+}
+
+// Cost is a monthly cost (730 hours) of CPU and memory in the configured currency.
+// Used and Wasted are 0 when live usage is unavailable.
+type Cost struct {
+	Currency  string  `json:"currency"`
+	Requested float64 `json:"requested"` // requests × price
+	Used      float64 `json:"used"`      // usage × price
+	Wasted    float64 `json:"wasted"`    // (requests − usage) × price, per resource, never negative
+}
+
+// Add accumulates o into c.
+func (c *Cost) Add(o Cost) {
+	c.Currency = o.Currency
+	c.Requested += o.Requested
+	c.Used += o.Used
+	c.Wasted += o.Wasted
+}
+
 type ContainerResources struct {
 	Name             string                `json:"name"`
 	Requests         ResourcePair          `json:"requests"`
 	Limits           ResourcePair          `json:"limits"`
 	Usage            *ResourcePair         `json:"usage,omitempty"`
 	EphemeralStorage *EphemeralStorageInfo `json:"ephemeralStorage,omitempty"`
+	State            string                `json:"state,omitempty"`       // running | waiting | terminated
+	StateReason      string                `json:"stateReason,omitempty"` // e.g. CrashLoopBackOff, OOMKilled
+	RestartCount     int32                 `json:"restartCount,omitempty"`
+	LastTermination  *ContainerTermination `json:"lastTermination,omitempty"`
+	Cost             *Cost                 `json:"cost,omitempty"` // set when pricing is configured
+	// Defaulted lists the values injected by a LimitRange rather than set in the pod spec,
+	// e.g. ["requests.cpu", "limits.memory"].
+	Defaulted []string `json:"defaulted,omitempty"`
+}
+
+// WorkloadEvent is a recent Warning event relevant to resource sizing
+// (FailedScheduling, Evicted, BackOff).
+type WorkloadEvent struct {
+	Reason   string `json:"reason"`
+	Message  string `json:"message"`
+	Count    int32  `json:"count"`
+	LastSeen string `json:"lastSeen"` // RFC3339
+	Object   string `json:"object"`   // involved object, e.g. "Pod/web-7b5f8c6d4-x9j2k"
 }
 
 type PodDetail struct {
 	Name       string               `json:"name"`
 	Namespace  string               `json:"namespace,omitempty"`
 	Phase      string               `json:"phase"`
+	Restarts   int32                `json:"restarts,omitempty"` // sum of container restart counts
 	Containers []ContainerResources `json:"containers"`
 	Volumes    []VolumeDetail       `json:"volumes,omitempty"`
+	Events     []WorkloadEvent      `json:"events,omitempty"`
 }
 
 type DeploymentDetail struct {
-	Kind              string      `json:"kind"`
-	Name              string      `json:"name"`
-	Namespace         string      `json:"namespace"`
-	Replicas          int32       `json:"replicas"`
-	ReadyReplicas     int32       `json:"readyReplicas"`
-	AvailableReplicas int32       `json:"availableReplicas"`
-	Pods              []PodDetail `json:"pods"`
+	Kind              string          `json:"kind"`
+	Name              string          `json:"name"`
+	Namespace         string          `json:"namespace"`
+	Replicas          int32           `json:"replicas"`
+	ReadyReplicas     int32           `json:"readyReplicas"`
+	AvailableReplicas int32           `json:"availableReplicas"`
+	Pods              []PodDetail     `json:"pods"`
+	Events            []WorkloadEvent `json:"events,omitempty"` // workload + pod events, newest first
+	Cost              *Cost           `json:"cost,omitempty"`   // sum of container costs
+	Sizing            *SizingPolicy   `json:"sizing,omitempty"` // kubeadjust.io annotations
 }
 
 type WorkloadResponse struct {
 	Workloads           []DeploymentDetail `json:"workloads"`
 	MetricsAvailable    bool               `json:"metricsAvailable"`
 	PrometheusAvailable bool               `json:"prometheusAvailable"`
+	Quotas              []QuotaStatus      `json:"quotas,omitempty"` // namespace ResourceQuotas on CPU/memory
+	// SkippedNamespaces of a cross-namespace query could not be read (forbidden or deleted).
+	SkippedNamespaces []string `json:"skippedNamespaces,omitempty"`
 }
 
 type NodeResources struct {
@@ -77,25 +127,171 @@ type NodeTaint struct {
 }
 
 type NodeOverview struct {
-	Name           string         `json:"name"`
-	Status         string         `json:"status"`
-	Roles          []string       `json:"roles"`
-	Taints         []NodeTaint    `json:"taints,omitempty"`
-	Capacity       NodeResources  `json:"capacity"`
-	Allocatable    NodeResources  `json:"allocatable"`
-	Requested      NodeResources  `json:"requested"`
-	Limited        NodeResources  `json:"limited"`
-	Usage          *NodeResources `json:"usage"`
-	PodCount       int            `json:"podCount"`
-	MaxPods        int            `json:"maxPods"`
+	Name          string            `json:"name"`
+	Status        string            `json:"status"`
+	Roles         []string          `json:"roles"`
+	Taints        []NodeTaint       `json:"taints,omitempty"`
+	Capacity      NodeResources     `json:"capacity"`
+	Allocatable   NodeResources     `json:"allocatable"`
+	Requested     NodeResources     `json:"requested"`
+	Limited       NodeResources     `json:"limited"`
+	Usage         *NodeResources    `json:"usage"`
+	PodCount      int               `json:"podCount"`
+	MaxPods       int               `json:"maxPods"`
+	Labels        map[string]string `json:"labels,omitempty"`
+	Unschedulable bool              `json:"unschedulable,omitempty"` // cordoned
+	Pool          string            `json:"pool,omitempty"`          // see NodePoolName
+	Zone          string            `json:"zone,omitempty"`          // see NodeTopology
+	Region        string            `json:"region,omitempty"`
+	InstanceType  string            `json:"instanceType,omitempty"`
 	// Node info
-	KernelVersion  string `json:"kernelVersion,omitempty"`
-	OSImage        string `json:"osImage,omitempty"`
-	Age            string `json:"age,omitempty"`
+	KernelVersion string `json:"kernelVersion,omitempty"`
+	OSImage       string `json:"osImage,omitempty"`
+	Age           string `json:"age,omitempty"`
 	// Pressure conditions
 	DiskPressure   bool `json:"diskPressure"`
 	MemoryPressure bool `json:"memoryPressure"`
 	PIDPressure    bool `json:"pidPressure"`
+	// Kubelet stats summary (nil when the node's kubelet cannot be reached)
+	Kubelet  *NodeKubeletStats `json:"kubelet,omitempty"`
+	Reserved NodeReserved      `json:"reserved"`
+	// Pod slots and ephemeral storage, which can limit scheduling before CPU or memory
+	PodSlotRatio     float64     `json:"podSlotRatio"` // podCount / maxPods
+	EphemeralStorage NodeStorage `json:"ephemeralStorage"`
+	Bottleneck       string      `json:"bottleneck,omitempty"` // BottleneckPods or BottleneckEphemeralStorage
+}
+
+// NodeReserved is what a node keeps from pods: capacity minus allocatable, broken down
+// into its parts when the kubelet configuration can be read (nil parts otherwise).
+type NodeReserved struct {
+	Total          NodeResources  `json:"total"`
+	CPURatio       float64        `json:"cpuRatio"` // of capacity
+	MemoryRatio    float64        `json:"memoryRatio"`
+	KubeReserved   *NodeResources `json:"kubeReserved,omitempty"`
+	SystemReserved *NodeResources `json:"systemReserved,omitempty"`
+	EvictionMemory *ResourceValue `json:"evictionMemory,omitempty"` // evictionHard memory.available
+}
+
+// NodeStorage is the ephemeral storage of a node. Used is the kubelet's root filesystem
+// usage (nil when the kubelet cannot be reached).
+type NodeStorage struct {
+	Capacity    ResourceValue  `json:"capacity"`
+	Allocatable ResourceValue  `json:"allocatable"`
+	Requested   ResourceValue  `json:"requested"`
+	Used        *ResourceValue `json:"used,omitempty"`
+}
+
+// NodeKubeletStats is the node section of the kubelet stats summary: filesystem and PID
+// usage before they turn into DiskPressure or PIDPressure.
+type NodeKubeletStats struct {
+	Fs               *FsUsage               `json:"fs,omitempty"`      // root filesystem: ephemeral storage, logs, emptyDirs
+	ImageFs          *FsUsage               `json:"imageFs,omitempty"` // images and writable layers (same as fs on a single disk)
+	PIDs             *PIDUsage              `json:"pids,omitempty"`
+	Network          *NetworkUsage          `json:"network,omitempty"`
+	SystemContainers []SystemContainerUsage `json:"systemContainers,omitempty"`
+}
+
+type FsUsage struct {
+	Capacity        ResourceValue `json:"capacity"`
+	Used            ResourceValue `json:"used"`
+	Available       ResourceValue `json:"available"`
+	UsedRatio       float64       `json:"usedRatio"`                 // 1 - available/capacity, as the kubelet evicts on
+	InodesUsedRatio float64       `json:"inodesUsedRatio,omitempty"` // 0 when not reported
+}
+
+type PIDUsage struct {
+	Running int64   `json:"running"`
+	Max     int64   `json:"max"`
+	Ratio   float64 `json:"ratio"`
+}
+
+// NetworkUsage holds the cumulative counters of the node's default interface.
+type NetworkUsage struct {
+	Interface string `json:"interface"`
+	RxBytes   int64  `json:"rxBytes"`
+	TxBytes   int64  `json:"txBytes"`
+	RxErrors  int64  `json:"rxErrors"`
+	TxErrors  int64  `json:"txErrors"`
+}
+
+// SystemContainerUsage is the usage of a node-level cgroup such as kubelet or runtime.
+type SystemContainerUsage struct {
+	Name   string         `json:"name"`
+	CPU    *ResourceValue `json:"cpu,omitempty"`
+	Memory *ResourceValue `json:"memory,omitempty"` // working set
+}
+
+// NodePool aggregates the nodes of one node pool (node group), the unit capacity is
+// planned in. Usage is only set when metrics-server reports every node of the pool.
+type NodePool struct {
+	Name        string         `json:"name"`  // "" for nodes without a pool label
+	Label       string         `json:"label"` // node label the pool was read from
+	NodeCount   int            `json:"nodeCount"`
+	ReadyNodes  int            `json:"readyNodes"`
+	Capacity    NodeResources  `json:"capacity"`
+	Allocatable NodeResources  `json:"allocatable"`
+	Requested   NodeResources  `json:"requested"`
+	Limited     NodeResources  `json:"limited"`
+	Usage       *NodeResources `json:"usage"`
+	PodCount    int            `json:"podCount"`
+	MaxPods     int            `json:"maxPods"`
+}
+
+// TopologyResponse is the cluster's capacity per zone and its workloads exposed to a zonal
+// outage.
+type TopologyResponse struct {
+	Zones []ZoneOverview `json:"zones"`
+	// SingleZoneWorkloads have several running replicas, all in one zone of a multi-zone cluster.
+	SingleZoneWorkloads []ZoneWorkload `json:"singleZoneWorkloads"`
+}
+
+// ZoneOverview aggregates the nodes of one zone. Usage is only set when metrics-server
+// reports every node of the zone.
+type ZoneOverview struct {
+	Zone          string         `json:"zone"` // "" for nodes without a zone label
+	Region        string         `json:"region,omitempty"`
+	NodeCount     int            `json:"nodeCount"`
+	InstanceTypes map[string]int `json:"instanceTypes,omitempty"` // instance type → node count
+	Allocatable   NodeResources  `json:"allocatable"`
+	Requested     NodeResources  `json:"requested"`
+	Usage         *NodeResources `json:"usage"`
+	PodCount      int            `json:"podCount"`
+	RequestRatio  float64        `json:"requestRatio"` // the higher of the CPU and memory requested ÷ allocatable
+	Fuller        bool           `json:"fuller"`       // RequestRatio well above the other zones', see BuildTopology
+}
+
+// ZoneWorkload is a workload whose running replicas all landed in Zone.
+type ZoneWorkload struct {
+	Namespace string `json:"namespace"`
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Zone      string `json:"zone"`
+	Replicas  int    `json:"replicas"`
+}
+
+// PendingPod is an unscheduled pod with its requests and a usage-based fit estimate.
+type PendingPod struct {
+	Name            string        `json:"name"`
+	Namespace       string        `json:"namespace"`
+	Owner           string        `json:"owner,omitempty"` // e.g. "ReplicaSet/web-7b5f8c6d4"
+	CreatedAt       string        `json:"createdAt,omitempty"`
+	Reason          string        `json:"reason,omitempty"`  // PodScheduled condition reason, e.g. "Unschedulable"
+	Message         string        `json:"message,omitempty"` // e.g. "0/5 nodes are available: 3 Insufficient cpu."
+	Requested       NodeResources `json:"requested"`
+	FitsAsRequested bool          `json:"fitsAsRequested"` // some node has enough free allocatable today
+	// Estimate is the pod's requests if they matched the usage of its running siblings.
+	Estimate       *NodeResources `json:"estimate,omitempty"`
+	EstimateSource string         `json:"estimateSource,omitempty"` // "prometheus" | "metrics-server"
+	FitsAtEstimate bool           `json:"fitsAtEstimate"`
+	CandidateNodes []string       `json:"candidateNodes,omitempty"` // nodes where the estimate fits (first 5)
+}
+
+// PendingResponse is the cluster-wide unscheduled pod report.
+type PendingResponse struct {
+	Pods                []PendingPod `json:"pods"`
+	Nodes               []NodeFree   `json:"nodes"` // free allocatable per schedulable node
+	EstimateRange       string       `json:"estimateRange,omitempty"`
+	PrometheusAvailable bool         `json:"prometheusAvailable"`
 }
 
 // PodStorageStats holds kubelet summary stats for a pod.
@@ -1,5 +1,7 @@
 package resources
 
+import "strings"
+
 // IsValidLabelValue allows only safe characters for PromQL label values (whitelist approach).
 func IsValidLabelValue(s string) bool {
 	if s == "" {
@@ -12,3 +14,35 @@ func IsValidLabelValue(s string) bool {
 	}
 	return true
 }
+
+// IsValidLabelKey reports whether s is a syntactically valid Kubernetes label key:
+// an optional DNS subdomain prefix and "/", then a name of at most 63 characters
+// (alphanumerics, '-', '_', '.'), starting and ending with an alphanumeric.
+func IsValidLabelKey(s string) bool {
+	name := s
+	if i := strings.LastIndexByte(s, '/'); i >= 0 {
+		prefix := s[:i]
+		name = s[i+1:]
+		if prefix == "" || len(prefix) > 253 {
+			return false
+		}
+		for _, r := range prefix {
+			if !isAlphanumeric(r) && r != '.' && r != '-' {
+				return false
+			}
+		}
+	}
+	if name == "" || len(name) > 63 || !isAlphanumeric(rune(name[0])) || !isAlphanumeric(rune(name[len(name)-1])) {
+		return false
+	}
+	for _, r := range name {
+		if !isAlphanumeric(r) && r != '.' && r != '_' && r != '-' {
+			return false
+		}
+	}
+	return true
+}
+
+func isAlphanumeric(r rune) bool {
+	return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9')
+}
@@ -39,3 +39,31 @@ func TestIsValidLabelValue(t *testing.T) {
 		})
 	}
 }
+
+func TestIsValidLabelKey(t *testing.T) {
+	tests := []struct {
+		input string
+		want  bool
+	}{
+		{"team", true},
+		{"app.kubernetes.io/part-of", true},
+		{"node.kubernetes.io/instance-type", true},
+		{"cost_center", true},
+		{"", false},
+		{"/team", false},
+		{"example.com/", false},
+		{"-team", false},
+		{"team-", false},
+		{"a/b/c", false},
+		{"team name", false},
+		{"team,app", false},
+		{`team"`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if got := IsValidLabelKey(tt.input); got != tt.want {
+				t.Errorf("IsValidLabelKey(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
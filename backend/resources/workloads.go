@@ -45,6 +45,38 @@ func BuildOwnerMaps(pods []k8s.Pod, rsList *k8s.ReplicaSetList, jobs *k8s.JobLis
 	return podToWorkload
 }
 
+// ContainerStatusMap indexes a pod's container statuses by container name.
+func ContainerStatusMap(pod k8s.Pod) map[string]k8s.ContainerStatus {
+	m := make(map[string]k8s.ContainerStatus, len(pod.Status.ContainerStatuses))
+	for _, cs := range pod.Status.ContainerStatuses {
+		m[cs.Name] = cs
+	}
+	return m
+}
+
+// ApplyContainerStatus copies current state, restart count and last termination from a
+// container status onto cr.
+func ApplyContainerStatus(cr *ContainerResources, cs k8s.ContainerStatus) {
+	switch {
+	case cs.State.Running != nil:
+		cr.State = "running"
+	case cs.State.Waiting != nil:
+		cr.State = "waiting"
+		cr.StateReason = cs.State.Waiting.Reason
+	case cs.State.Terminated != nil:
+		cr.State = "terminated"
+		cr.StateReason = cs.State.Terminated.Reason
+	}
+	cr.RestartCount = cs.RestartCount
+	if t := cs.LastState.Terminated; t != nil {
+		cr.LastTermination = &ContainerTermination{
+			Reason:     t.Reason,
+			ExitCode:   t.ExitCode,
+			FinishedAt: t.FinishedAt,
+		}
+	}
+}
+
 // BuildPodDetails builds PodDetail list for a set of pods.
 func BuildPodDetails(
 	pods []k8s.Pod,
@@ -55,6 +87,9 @@ func BuildPodDetails(
 	var result []PodDetail
 	for _, pod := range pods {
 		stoStats := podStorageMap[pod.Metadata.Name]
+		statuses := ContainerStatusMap(pod)
+		defaulted := LimitRangeDefaulted(pod)
+		var restarts int32
 		var containers []ContainerResources
 		for _, c := range pod.Spec.Containers {
 			cr := ContainerResources{
@@ -67,6 +102,7 @@ func BuildPodDetails(
 					CPU:    ParseResource(c.Resources.Limits["cpu"], true),
 					Memory: ParseResource(c.Resources.Limits["memory"], false),
 				},
+				Defaulted: defaulted[c.Name],
 			}
 			if podMetrics, ok := metricsMap[pod.Metadata.Name]; ok {
 				if cu, ok := podMetrics[c.Name]; ok {
@@ -92,6 +128,10 @@ func BuildPodDetails(
 				}
 			}
 			cr.EphemeralStorage = ephInfo
+			if cs, ok := statuses[c.Name]; ok {
+				ApplyContainerStatus(&cr, cs)
+				restarts += cs.RestartCount
+			}
 			containers = append(containers, cr)
 		}
 
@@ -161,6 +201,7 @@ func BuildPodDetails(
 		result = append(result, PodDetail{
 			Name:       pod.Metadata.Name,
 			Phase:      pod.Status.Phase,
+			Restarts:   restarts,
 			Containers: containers,
 			Volumes:    volumes,
 		})
@@ -90,10 +90,7 @@ func pod(name, phase string, containers ...k8s.Container) k8s.Pod {
 	return k8s.Pod{
 		Metadata: k8s.ObjectMeta{Name: name},
 		Spec:     k8s.PodSpec{Containers: containers},
-		Status:   struct {
-			Phase             string            `json:"phase"`
-			ContainerStatuses []k8s.ContainerStatus `json:"containerStatuses"`
-		}{Phase: phase},
+		Status:   k8s.PodStatus{Phase: phase},
 	}
 }
 
@@ -175,4 +172,41 @@ func TestBuildPodDetails(t *testing.T) {
 			t.Errorf("phase: got %q, want %q", result[0].Phase, "Pending")
 		}
 	})
+
+	t.Run("restart count and last termination from container status", func(t *testing.T) {
+		p := pod("app-1", "Running", container("app", "", "", "", ""), container("sidecar", "", "", "", ""))
+		p.Status.ContainerStatuses = []k8s.ContainerStatus{
+			{
+				Name:         "app",
+				RestartCount: 3,
+				State:        k8s.ContainerState{Waiting: &k8s.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+				LastState: k8s.ContainerState{Terminated: &k8s.ContainerStateTerminated{
+					Reason: "OOMKilled", ExitCode: 137, FinishedAt: "2026-01-02T03:04:05Z",
+				}},
+			},
+			{Name: "sidecar", RestartCount: 1, State: k8s.ContainerState{Running: &k8s.ContainerStateRunning{}}},
+		}
+		result := BuildPodDetails([]k8s.Pod{p}, nil, nil, nil)
+		if result[0].Restarts != 4 {
+			t.Errorf("pod restarts: got %d, want 4", result[0].Restarts)
+		}
+		app := result[0].Containers[0]
+		if app.RestartCount != 3 {
+			t.Errorf("restart count: got %d, want 3", app.RestartCount)
+		}
+		if app.State != "waiting" || app.StateReason != "CrashLoopBackOff" {
+			t.Errorf("state: got %q/%q, want waiting/CrashLoopBackOff", app.State, app.StateReason)
+		}
+		want := ContainerTermination{Reason: "OOMKilled", ExitCode: 137, FinishedAt: "2026-01-02T03:04:05Z"}
+		if app.LastTermination == nil || *app.LastTermination != want {
+			t.Errorf("last termination: got %+v, want %+v", app.LastTermination, want)
+		}
+		sidecar := result[0].Containers[1]
+		if sidecar.State != "running" || sidecar.StateReason != "" {
+			t.Errorf("sidecar state: got %q/%q, want running", sidecar.State, sidecar.StateReason)
+		}
+		if sidecar.LastTermination != nil {
+			t.Error("expected nil last termination for container that never terminated")
+		}
+	})
 }
@@ -0,0 +1,209 @@
+// Package simulator answers "how many nodes would we need?" by re-packing pods onto the
+// current nodes with a first-fit-decreasing heuristic. It never talks to the cluster: callers
+// pass the node overview and the per-pod requests to evaluate (current or recommended).
+package simulator
+
+import (
+	"cmp"
+	"slices"
+
+	"github.com/devops-kubeadjust/backend/k8s"
+	"github.com/devops-kubeadjust/backend/resources"
+)
+
+// Pod is one pod to place, with the requests to evaluate and its scheduling constraints.
+type Pod struct {
+	Namespace string
+	Name      string
+	CPUM      int64
+	MemB      int64
+	NodeName  string // current node, "" for pending pods
+	// Pinned pods (DaemonSet, static pods) stay on NodeName and only count as per-node overhead:
+	// they disappear with the node and do not keep it in use.
+	Pinned bool
+	Spec   k8s.PodSpec // tolerations, nodeSelector and node affinity
+}
+
+// Scenario is the request body of POST /api/simulate/packing.
+type Scenario struct {
+	Requests     string   `json:"requests"`               // "current" (default) | "recommended"
+	Range        string   `json:"range,omitempty"`        // Prometheus window for recommended requests
+	ExcludeNodes []string `json:"excludeNodes,omitempty"` // nodes to treat as already removed
+}
+
+// PodRef identifies a pod that could not be placed.
+type PodRef struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	CPUM      int64  `json:"cpuM"`
+	MemB      int64  `json:"memB"`
+}
+
+// NodeResult is the simulated state of one node after packing.
+type NodeResult struct {
+	Name            string `json:"name"`
+	Pods            int    `json:"pods"`
+	RequestedCPUM   int64  `json:"requestedCpuM"`
+	RequestedMemB   int64  `json:"requestedMemB"`
+	AllocatableCPUM int64  `json:"allocatableCpuM"`
+	AllocatableMemB int64  `json:"allocatableMemB"`
+	Freed           bool   `json:"freed"` // no movable pod landed here: the node could be removed
+}
+
+// Result summarises a packing simulation.
+type Result struct {
+	Requests      string   `json:"requests"`
+	NodesTotal    int      `json:"nodesTotal"`  // schedulable nodes considered
+	NodesNeeded   int      `json:"nodesNeeded"` // nodes that received at least one movable pod
+	FreedNodes    []string `json:"freedNodes"`
+	RequestedCPUM int64    `json:"requestedCpuM"` // total requests packed, pinned pods included
+	RequestedMemB int64    `json:"requestedMemB"`
+	// Stranded capacity is allocatable left unrequested on the nodes that stay in use.
+	StrandedCPUM int64        `json:"strandedCpuM"`
+	StrandedMemB int64        `json:"strandedMemB"`
+	Unplaced     []PodRef     `json:"unplaced"`
+	Nodes        []NodeResult `json:"nodes"`
+}
+
+type bin struct {
+	overview   resources.NodeOverview
+	taints     []k8s.Taint
+	freeCPU    int64
+	freeMem    int64
+	freePods   int
+	result     NodeResult
+	hasMovable bool
+}
+
+// Pack places pinned pods on their nodes, then packs every other pod first-fit-decreasing
+// onto the schedulable nodes (largest nodes first), honouring taints, nodeSelector, required
+// node affinity and max pods. excluded nodes are left out entirely.
+func Pack(nodes []resources.NodeOverview, pods []Pod, excluded []string) Result {
+	var bins []*bin
+	byName := map[string]*bin{}
+	for _, n := range nodes {
+		if n.Status != "Ready" || n.Unschedulable || slices.Contains(excluded, n.Name) {
+			continue
+		}
+		b := &bin{
+			overview: n,
+			freeCPU:  n.Allocatable.CPU.Millicores,
+			freeMem:  n.Allocatable.Memory.Bytes,
+			freePods: n.MaxPods,
+			result: NodeResult{
+				Name:            n.Name,
+				AllocatableCPUM: n.Allocatable.CPU.Millicores,
+				AllocatableMemB: n.Allocatable.Memory.Bytes,
+			},
+		}
+		if b.freePods == 0 {
+			b.freePods = 110 // kubelet default when capacity.pods is not reported
+		}
+		for _, t := range n.Taints {
+			b.taints = append(b.taints, k8s.Taint{Key: t.Key, Value: t.Value, Effect: t.Effect})
+		}
+		bins = append(bins, b)
+		byName[n.Name] = b
+	}
+	// Largest nodes first so small nodes are the ones freed.
+	slices.SortStableFunc(bins, func(a, b *bin) int {
+		if c := cmp.Compare(b.result.AllocatableCPUM, a.result.AllocatableCPUM); c != 0 {
+			return c
+		}
+		if c := cmp.Compare(b.result.AllocatableMemB, a.result.AllocatableMemB); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.result.Name, b.result.Name)
+	})
+
+	res := Result{NodesTotal: len(bins), Unplaced: []PodRef{}, FreedNodes: []string{}}
+
+	var movable []Pod
+	var maxCPU, maxMem int64
+	for _, b := range bins {
+		maxCPU = max(maxCPU, b.result.AllocatableCPUM)
+		maxMem = max(maxMem, b.result.AllocatableMemB)
+	}
+	for _, p := range pods {
+		if !p.Pinned {
+			movable = append(movable, p)
+			continue
+		}
+		// Pinned pods on excluded or unknown nodes go away with their node.
+		if b, ok := byName[p.NodeName]; ok {
+			b.place(p)
+			res.RequestedCPUM += p.CPUM
+			res.RequestedMemB += p.MemB
+		}
+	}
+
+	// Decreasing order by dominant share of the largest node.
+	share := func(p Pod) float64 {
+		var s float64
+		if maxCPU > 0 {
+			s = float64(p.CPUM) / float64(maxCPU)
+		}
+		if maxMem > 0 {
+			s = max(s, float64(p.MemB)/float64(maxMem))
+		}
+		return s
+	}
+	slices.SortStableFunc(movable, func(a, b Pod) int {
+		if c := cmp.Compare(share(b), share(a)); c != 0 {
+			return c
+		}
+		if c := cmp.Compare(a.Namespace, b.Namespace); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Name, b.Name)
+	})
+
+	for _, p := range movable {
+		placed := false
+		for _, b := range bins {
+			if b.fits(p) {
+				b.place(p)
+				b.hasMovable = true
+				placed = true
+				break
+			}
+		}
+		if !placed {
+			res.Unplaced = append(res.Unplaced, PodRef{Namespace: p.Namespace, Name: p.Name, CPUM: p.CPUM, MemB: p.MemB})
+			continue
+		}
+		res.RequestedCPUM += p.CPUM
+		res.RequestedMemB += p.MemB
+	}
+
+	res.Nodes = make([]NodeResult, 0, len(bins))
+	for _, b := range bins {
+		if b.hasMovable {
+			res.NodesNeeded++
+			res.StrandedCPUM += max(b.freeCPU, 0)
+			res.StrandedMemB += max(b.freeMem, 0)
+		} else {
+			b.result.Freed = true
+			res.FreedNodes = append(res.FreedNodes, b.result.Name)
+		}
+		res.Nodes = append(res.Nodes, b.result)
+	}
+	return res
+}
+
+func (b *bin) fits(p Pod) bool {
+	return b.freePods > 0 &&
+		b.freeCPU >= p.CPUM &&
+		b.freeMem >= p.MemB &&
+		resources.ToleratesTaints(p.Spec.Tolerations, b.taints) &&
+		resources.MatchesNode(p.Spec, b.overview.Labels)
+}
+
+func (b *bin) place(p Pod) {
+	b.freeCPU -= p.CPUM
+	b.freeMem -= p.MemB
+	b.freePods--
+	b.result.Pods++
+	b.result.RequestedCPUM += p.CPUM
+	b.result.RequestedMemB += p.MemB
+}
@@ -0,0 +1,124 @@
+package simulator
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/devops-kubeadjust/backend/k8s"
+	"github.com/devops-kubeadjust/backend/resources"
+)
+
+const gib = 1024 * 1024 * 1024
+
+func node(name string, cpuM, memB int64, taints ...resources.NodeTaint) resources.NodeOverview {
+	return resources.NodeOverview{
+		Name:   name,
+		Status: "Ready",
+		Taints: taints,
+		Allocatable: resources.NodeResources{
+			CPU:    resources.ResourceValue{Millicores: cpuM},
+			Memory: resources.ResourceValue{Bytes: memB},
+		},
+		MaxPods: 110,
+	}
+}
+
+func TestPackFreesNodes(t *testing.T) {
+	nodes := []resources.NodeOverview{node("a", 4000, 8*gib), node("b", 4000, 8*gib), node("c", 2000, 4*gib)}
+	pods := []Pod{
+		{Name: "web-1", CPUM: 1000, MemB: 2 * gib, NodeName: "a"},
+		{Name: "web-2", CPUM: 1000, MemB: 2 * gib, NodeName: "b"},
+		{Name: "web-3", CPUM: 500, MemB: 1 * gib, NodeName: "c"},
+	}
+	res := Pack(nodes, pods, nil)
+	if res.NodesTotal != 3 || res.NodesNeeded != 1 {
+		t.Fatalf("nodes: got %d needed of %d, want 1 of 3", res.NodesNeeded, res.NodesTotal)
+	}
+	if !slices.Equal(res.FreedNodes, []string{"b", "c"}) {
+		t.Errorf("freed: got %v, want [b c]", res.FreedNodes)
+	}
+	if res.StrandedCPUM != 1500 || res.StrandedMemB != 3*gib {
+		t.Errorf("stranded: got %dm / %d B, want 1500m / 3Gi", res.StrandedCPUM, res.StrandedMemB)
+	}
+	if len(res.Unplaced) != 0 {
+		t.Errorf("unexpected unplaced pods: %v", res.Unplaced)
+	}
+}
+
+func TestPackHonoursTaints(t *testing.T) {
+	gpu := resources.NodeTaint{Key: "dedicated", Value: "gpu", Effect: "NoSchedule"}
+	nodes := []resources.NodeOverview{node("gpu", 8000, 16*gib, gpu), node("general", 2000, 4*gib)}
+	pods := []Pod{
+		{Name: "api", CPUM: 500, MemB: gib},
+		{Name: "train", CPUM: 4000, MemB: 8 * gib, Spec: k8s.PodSpec{
+			Tolerations: []k8s.Toleration{{Key: "dedicated", Operator: "Exists"}},
+		}},
+	}
+	res := Pack(nodes, pods, nil)
+	for _, n := range res.Nodes {
+		if n.Name == "gpu" && n.Pods != 1 {
+			t.Errorf("gpu node: got %d pods, want only the tolerating pod", n.Pods)
+		}
+	}
+	if res.NodesNeeded != 2 {
+		t.Errorf("needed: got %d, want 2", res.NodesNeeded)
+	}
+}
+
+func TestPackPinnedAndUnplaced(t *testing.T) {
+	cordoned := node("cordoned", 4000, 8*gib)
+	cordoned.Unschedulable = true
+	nodes := []resources.NodeOverview{node("a", 2000, 4*gib), node("b", 2000, 4*gib), cordoned}
+	pods := []Pod{
+		{Name: "ds-a", CPUM: 500, MemB: gib, NodeName: "a", Pinned: true},
+		{Name: "ds-b", CPUM: 500, MemB: gib, NodeName: "b", Pinned: true},
+		{Name: "ds-gone", CPUM: 500, MemB: gib, NodeName: "cordoned", Pinned: true},
+		{Name: "big", CPUM: 3000, MemB: gib},
+		{Name: "small", CPUM: 1000, MemB: gib},
+	}
+	res := Pack(nodes, pods, []string{"b"})
+	if res.NodesTotal != 1 {
+		t.Fatalf("total: got %d, want 1 (b excluded, cordoned skipped)", res.NodesTotal)
+	}
+	if len(res.Unplaced) != 1 || res.Unplaced[0].Name != "big" {
+		t.Errorf("unplaced: got %v, want [big]", res.Unplaced)
+	}
+	if got := res.Nodes[0]; got.Pods != 2 || got.RequestedCPUM != 1500 {
+		t.Errorf("node a: got %d pods / %dm, want 2 pods / 1500m (pinned overhead + small)", got.Pods, got.RequestedCPUM)
+	}
+}
+
+func TestFromPods(t *testing.T) {
+	ds := k8s.Pod{}
+	ds.Metadata.Name = "agent"
+	ds.Metadata.OwnerReferences = []k8s.OwnerReference{{Kind: "DaemonSet", Name: "agent"}}
+	ds.Status.Phase = "Running"
+	ds.Spec.Containers = []k8s.Container{{Name: "agent", Resources: k8s.ResourceRequire{Requests: map[string]string{"cpu": "100m", "memory": "64Mi"}}}}
+
+	done := k8s.Pod{}
+	done.Metadata.Name = "job"
+	done.Status.Phase = "Succeeded"
+
+	web := k8s.Pod{}
+	web.Metadata.Name = "web"
+	web.Status.Phase = "Running"
+	web.Spec.Containers = []k8s.Container{{Name: "app"}, {Name: "sidecar"}}
+	web.Spec.InitContainers = []k8s.Container{{Name: "init", Resources: k8s.ResourceRequire{Requests: map[string]string{"cpu": "1"}}}}
+
+	fixed := func(_, _ string, c k8s.Container) (int64, int64) {
+		if c.Name == "agent" {
+			return CurrentRequests("", "", c)
+		}
+		return 200, gib
+	}
+	got := FromPods([]k8s.Pod{ds, done, web}, fixed)
+	if len(got) != 2 {
+		t.Fatalf("got %d pods, want 2 (Succeeded skipped)", len(got))
+	}
+	if !got[0].Pinned || got[0].CPUM != 100 {
+		t.Errorf("daemonset pod: got %+v, want pinned with 100m", got[0])
+	}
+	if got[1].Pinned || got[1].CPUM != 1000 || got[1].MemB != 2*gib {
+		t.Errorf("web pod: got %dm / %d B, want 1000m (init) / 2Gi", got[1].CPUM, got[1].MemB)
+	}
+}
@@ -0,0 +1,49 @@
+package simulator
+
+import (
+	"github.com/devops-kubeadjust/backend/k8s"
+	"github.com/devops-kubeadjust/backend/resources"
+)
+
+// ContainerRequests returns the CPU (millicores) and memory (bytes) requests to evaluate for
+// one container of a pod.
+type ContainerRequests func(namespace, pod string, c k8s.Container) (cpu, mem int64)
+
+// CurrentRequests evaluates containers with the requests from their spec.
+func CurrentRequests(_, _ string, c k8s.Container) (cpu, mem int64) {
+	return resources.ParseCPUMillicores(c.Resources.Requests["cpu"]), resources.ParseMemoryBytes(c.Resources.Requests["memory"])
+}
+
+// FromPods converts non-terminal cluster pods into simulation pods. App container requests
+// come from reqs; init containers keep their spec requests and are accounted as the scheduler
+// does (max of init vs sum of app containers). DaemonSet and static (mirror) pods are pinned.
+func FromPods(pods []k8s.Pod, reqs ContainerRequests) []Pod {
+	result := make([]Pod, 0, len(pods))
+	for _, pod := range pods {
+		if pod.Status.Phase == "Succeeded" || pod.Status.Phase == "Failed" {
+			continue
+		}
+		p := Pod{
+			Namespace: pod.Metadata.Namespace,
+			Name:      pod.Metadata.Name,
+			NodeName:  pod.Spec.NodeName,
+			Spec:      pod.Spec,
+		}
+		for _, ref := range pod.Metadata.OwnerReferences {
+			if ref.Kind == "DaemonSet" || ref.Kind == "Node" {
+				p.Pinned = true
+			}
+		}
+		for _, c := range pod.Spec.Containers {
+			cpu, mem := reqs(p.Namespace, p.Name, c)
+			p.CPUM += cpu
+			p.MemB += mem
+		}
+		for _, c := range pod.Spec.InitContainers {
+			p.CPUM = max(p.CPUM, resources.ParseCPUMillicores(c.Resources.Requests["cpu"]))
+			p.MemB = max(p.MemB, resources.ParseMemoryBytes(c.Resources.Requests["memory"]))
+		}
+		result = append(result, p)
+	}
+	return result
+}
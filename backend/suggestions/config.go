@@ -0,0 +1,117 @@
+package suggestions
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// ThresholdsAnnotation overrides the thresholds of one namespace with a JSON object listing
+// only the values it changes, e.g. kubeadjust.io/thresholds: '{"danger": 0.97, "warning": 0.9}'.
+const ThresholdsAnnotation = "kubeadjust.io/thresholds"
+
+// Where the effective thresholds come from.
+const (
+	SourceGlobal    = "global"
+	SourceCluster   = "cluster"
+	SourceNamespace = "namespace"
+)
+
+// Config holds the global thresholds and the per-cluster overrides, both fully resolved.
+type Config struct {
+	Global   Thresholds            `json:"global"`
+	Clusters map[string]Thresholds `json:"clusters,omitempty"` // keyed by cluster name (X-Cluster)
+}
+
+// DefaultConfig uses DefaultThresholds everywhere.
+func DefaultConfig() *Config {
+	return &Config{Global: DefaultThresholds()}
+}
+
+// LoadConfig reads THRESHOLDS_CONFIG, a JSON file {"global": {...}, "clusters": {"name": {...}}}.
+// Returns DefaultConfig when unset.
+func LoadConfig() (*Config, error) {
+	path := os.Getenv("THRESHOLDS_CONFIG")
+	if path == "" {
+		return DefaultConfig(), nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading thresholds config: %w", err)
+	}
+	return ParseConfig(data)
+}
+
+// ParseConfig decodes a thresholds config. The global object overrides DefaultThresholds and
+// each cluster object overrides the global one, so both only list what they change.
+func ParseConfig(data []byte) (*Config, error) {
+	var raw struct {
+		Global   json.RawMessage            `json:"global"`
+		Clusters map[string]json.RawMessage `json:"clusters"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("parsing thresholds config: %w", err)
+	}
+	global, err := overlay(DefaultThresholds(), raw.Global)
+	if err != nil {
+		return nil, fmt.Errorf("thresholds config global: %w", err)
+	}
+	c := &Config{Global: global}
+	for name, msg := range raw.Clusters {
+		th, err := overlay(global, msg)
+		if err != nil {
+			return nil, fmt.Errorf("thresholds config cluster %q: %w", name, err)
+		}
+		if c.Clusters == nil {
+			c.Clusters = map[string]Thresholds{}
+		}
+		c.Clusters[name] = th
+	}
+	return c, nil
+}
+
+// ForCluster returns the thresholds of a cluster and whether they come from a cluster override.
+func (c *Config) ForCluster(cluster string) (Thresholds, string) {
+	if th, ok := c.Clusters[cluster]; ok {
+		return th, SourceCluster
+	}
+	return c.Global, SourceGlobal
+}
+
+// ForNamespace applies the ThresholdsAnnotation of a namespace on top of its cluster's
+// thresholds. An invalid annotation is reported and ignored.
+func (c *Config) ForNamespace(cluster string, annotations map[string]string) (Thresholds, string, error) {
+	th, source := c.ForCluster(cluster)
+	v, ok := annotations[ThresholdsAnnotation]
+	if !ok {
+		return th, source, nil
+	}
+	ns, err := overlay(th, []byte(v))
+	if err != nil {
+		return th, source, fmt.Errorf("annotation %s: %w", ThresholdsAnnotation, err)
+	}
+	return ns, SourceNamespace, nil
+}
+
+// overlay decodes a partial thresholds object on top of base and validates the result.
+func overlay(base Thresholds, data []byte) (Thresholds, error) {
+	th := base
+	if len(data) == 0 {
+		return th, nil
+	}
+	if err := json.Unmarshal(data, &th); err != nil {
+		return base, err
+	}
+	return th, th.Validate()
+}
+
+// Validate checks that the ratios are ordered: 0 < overkill < warning < danger, limitOverkill > 1.
+func (th Thresholds) Validate() error {
+	if th.Overkill <= 0 || th.Overkill >= th.Warning || th.Warning >= th.Danger {
+		return fmt.Errorf("thresholds must satisfy 0 < overkill (%g) < warning (%g) < danger (%g)", th.Overkill, th.Warning, th.Danger)
+	}
+	if th.LimitOverkill <= 1 {
+		return fmt.Errorf("limitOverkill must be above 1, got %g", th.LimitOverkill)
+	}
+	return nil
+}
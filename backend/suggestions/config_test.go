@@ -0,0 +1,44 @@
+package suggestions
+
+import "testing"
+
+func TestParseConfig(t *testing.T) {
+	c, err := ParseConfig([]byte(`{"global": {"overkill": 0.25}, "clusters": {"batch": {"danger": 0.98, "warning": 0.95}}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (Thresholds{Danger: 0.90, Warning: 0.70, Overkill: 0.25, LimitOverkill: 3}); c.Global != want {
+		t.Errorf("global: got %+v, want %+v", c.Global, want)
+	}
+	if th, src := c.ForCluster("batch"); th != (Thresholds{Danger: 0.98, Warning: 0.95, Overkill: 0.25, LimitOverkill: 3}) || src != SourceCluster {
+		t.Errorf("batch: got %+v from %s", th, src)
+	}
+	if th, src := c.ForCluster("prod"); th != c.Global || src != SourceGlobal {
+		t.Errorf("prod: got %+v from %s, want global", th, src)
+	}
+
+	for _, bad := range []string{
+		`{"global": {"warning": 0.95}}`,              // above danger
+		`{"clusters": {"x": {"limitOverkill": 1}}}`,  // limit at 1× usage is not over-provisioned
+		`{"clusters": {"x": {"overkill": "a lot"}}}`, // wrong type
+	} {
+		if _, err := ParseConfig([]byte(bad)); err == nil {
+			t.Errorf("expected error for %s", bad)
+		}
+	}
+}
+
+func TestConfigForNamespace(t *testing.T) {
+	c := DefaultConfig()
+	th, src, err := c.ForNamespace("default", map[string]string{ThresholdsAnnotation: `{"danger": 0.97, "warning": 0.9}`})
+	if err != nil || src != SourceNamespace || th.Danger != 0.97 || th.Warning != 0.9 || th.Overkill != 0.35 {
+		t.Errorf("annotated: got %+v from %s (%v)", th, src, err)
+	}
+	th, src, err = c.ForNamespace("default", map[string]string{ThresholdsAnnotation: `{"danger": 0.5}`})
+	if err == nil || src != SourceGlobal || th != DefaultThresholds() {
+		t.Errorf("invalid annotation should fall back: got %+v from %s (%v)", th, src, err)
+	}
+	if th, src, err := c.ForNamespace("default", nil); err != nil || src != SourceGlobal || th != DefaultThresholds() {
+		t.Errorf("no annotation: got %+v from %s (%v)", th, src, err)
+	}
+}
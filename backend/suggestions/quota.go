@@ -0,0 +1,102 @@
+package suggestions
+
+import (
+	"slices"
+	"strings"
+)
+
+// Headroom applied when sizing a namespace ResourceQuota.
+const (
+	// quotaRequestHeadroom leaves room for a rolling update with the default 25% maxSurge.
+	quotaRequestHeadroom = 1.25
+	// quotaShrinkTrigger: only propose a smaller quota when it saves at least 20%.
+	quotaShrinkTrigger = 0.8
+)
+
+// QuotaInput is the current state of one quota resource ("requests.cpu", "limits.memory", …).
+// Values are millicores for CPU and bytes for memory.
+type QuotaInput struct {
+	Resource string
+	Hard     int64
+	Used     int64   // sum of requests (or limits) of the namespace's pods, as tracked by the quota
+	P95      float64 // P95 of the namespace's total usage; 0 if unknown
+}
+
+// QuotaResourceRecommendation is the proposed hard value for one quota resource.
+type QuotaResourceRecommendation struct {
+	Resource  string  `json:"resource"`
+	Hard      int64   `json:"hard"`
+	Used      int64   `json:"used"`
+	P95Usage  float64 `json:"p95Usage,omitempty"`
+	Suggested int64   `json:"suggested"`
+	Action    string  `json:"action"` // "reduce" | "increase" | "keep"
+	Reason    string  `json:"reason"`
+}
+
+// RecommendQuota proposes a new hard value. The quota must keep admitting what is already
+// running plus a rolling update (Used × 1.25) and, when usage is known, cover observed P95 usage
+// with the same headroom as container suggestions (×1.3 for requests, ×1.5 for limits).
+// Shrinks below 80% of the current hard value are proposed; smaller savings are not worth the churn.
+func RecommendQuota(in QuotaInput) QuotaResourceRecommendation {
+	if in.Used == 0 && in.P95 <= 0 {
+		// Nothing running and no usage seen: a quota of 0 would block the next deployment.
+		return QuotaResourceRecommendation{Resource: in.Resource, Hard: in.Hard, Suggested: in.Hard,
+			Action: "keep", Reason: "no requests or usage observed"}
+	}
+	isCPU := strings.HasSuffix(in.Resource, ".cpu")
+	usageHeadroom := requestHeadroom
+	if strings.HasPrefix(in.Resource, "limits.") {
+		usageHeadroom = limitHeadroom
+	}
+
+	need := float64(in.Used) * quotaRequestHeadroom
+	reason := "current requests + rolling-update headroom"
+	if strings.HasPrefix(in.Resource, "limits.") {
+		reason = "current limits + rolling-update headroom"
+	}
+	if byUsage := in.P95 * usageHeadroom; byUsage > need {
+		need = byUsage
+		reason = "P95 usage + headroom"
+	}
+
+	rec := QuotaResourceRecommendation{
+		Resource:  in.Resource,
+		Hard:      in.Hard,
+		Used:      in.Used,
+		P95Usage:  in.P95,
+		Suggested: RoundResource(need, isCPU),
+		Action:    "keep",
+		Reason:    reason,
+	}
+	switch {
+	case rec.Suggested > in.Hard:
+		rec.Action = "increase"
+	case float64(rec.Suggested) <= float64(in.Hard)*quotaShrinkTrigger:
+		rec.Action = "reduce"
+	default:
+		rec.Suggested = in.Hard
+	}
+	return rec
+}
+
+// QuotaRecommendation groups the proposals for one ResourceQuota.
+type QuotaRecommendation struct {
+	Namespace string                        `json:"namespace"`
+	Quota     string                        `json:"quota"`
+	Resources []QuotaResourceRecommendation `json:"resources"`
+}
+
+// QuotaRecommendationResponse is returned by GET /api/namespaces/quota-recommendations.
+type QuotaRecommendationResponse struct {
+	Recommendations []QuotaRecommendation `json:"recommendations"`
+	UsageSource     string                `json:"usageSource"` // "prometheus" | "metrics-server" | "none"
+	Range           string                `json:"range,omitempty"`
+}
+
+// SortQuotaResources orders resources as requests.cpu, requests.memory, limits.cpu, limits.memory.
+func SortQuotaResources(recs []QuotaResourceRecommendation) {
+	order := []string{"requests.cpu", "requests.memory", "limits.cpu", "limits.memory"}
+	slices.SortFunc(recs, func(a, b QuotaResourceRecommendation) int {
+		return slices.Index(order, a.Resource) - slices.Index(order, b.Resource)
+	})
+}
@@ -0,0 +1,39 @@
+package suggestions
+
+import "testing"
+
+func TestRecommendQuota(t *testing.T) {
+	const gib = 1024 * mib
+	tests := []struct {
+		name          string
+		in            QuotaInput
+		wantSuggested int64
+		wantAction    string
+	}{
+		{"oversized requests quota", QuotaInput{Resource: "requests.cpu", Hard: 20000, Used: 2000, P95: 800}, 2500, "reduce"},
+		{"usage above requests drives the size", QuotaInput{Resource: "requests.cpu", Hard: 20000, Used: 1000, P95: 3000}, 4000, "reduce"},
+		{"limits use the limit headroom", QuotaInput{Resource: "limits.memory", Hard: 64 * gib, Used: 8 * gib, P95: 8 * gib}, 12 * gib, "reduce"},
+		{"quota too small", QuotaInput{Resource: "requests.memory", Hard: 4 * gib, Used: 4 * gib}, 6 * gib, "increase"},
+		{"small saving is not proposed", QuotaInput{Resource: "requests.cpu", Hard: 2750, Used: 2000}, 2750, "keep"},
+		{"empty namespace keeps its quota", QuotaInput{Resource: "requests.cpu", Hard: 4000}, 4000, "keep"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := RecommendQuota(tt.in)
+			if got.Suggested != tt.wantSuggested || got.Action != tt.wantAction {
+				t.Errorf("RecommendQuota(%+v) = %d (%s), want %d (%s)", tt.in, got.Suggested, got.Action, tt.wantSuggested, tt.wantAction)
+			}
+		})
+	}
+}
+
+func TestSortQuotaResources(t *testing.T) {
+	recs := []QuotaResourceRecommendation{{Resource: "limits.memory"}, {Resource: "requests.cpu"}, {Resource: "limits.cpu"}, {Resource: "requests.memory"}}
+	SortQuotaResources(recs)
+	want := []string{"requests.cpu", "requests.memory", "limits.cpu", "limits.memory"}
+	for i, r := range recs {
+		if r.Resource != want[i] {
+			t.Errorf("position %d: got %s, want %s", i, r.Resource, want[i])
+		}
+	}
+}
@@ -0,0 +1,162 @@
+// Package suggestions is the server-side counterpart of frontend/src/lib/suggestions.ts:
+// it turns observed usage into right-sized requests and limits using the same thresholds,
+// multipliers and rounding steps as the dashboard.
+package suggestions
+
+import "math"
+
+// Thresholds are the ratios that classify a container as critical, warning or over-provisioned.
+type Thresholds struct {
+	Danger        float64 `json:"danger"`        // usage / limit at or above → critical
+	Warning       float64 `json:"warning"`       // usage / limit at or above → warning
+	Overkill      float64 `json:"overkill"`      // mean usage / request at or below → over-provisioned
+	LimitOverkill float64 `json:"limitOverkill"` // limit / P95 usage at or above → limit over-provisioned
+}
+
+// DefaultThresholds mirrors the values hardcoded in the dashboard.
+func DefaultThresholds() Thresholds {
+	return Thresholds{Danger: 0.90, Warning: 0.70, Overkill: 0.35, LimitOverkill: 3}
+}
+
+// Multipliers applied on top of observed usage when suggesting new values.
+const (
+	requestHeadroom      = 1.3 // new request = usage × 1.3
+	limitHeadroom        = 1.5 // new limit = P95 × 1.5 (unset or over-provisioned limit)
+	nearLimitHeadroom    = 1.4 // new limit = P95 × 1.4 (usage close to limit)
+	requestTooLowTrigger = 1.1 // P95 above request × 1.1 → request too low
+)
+
+// Usage summarises the observed usage of one resource of one container.
+// Values are millicores for CPU and bytes for memory.
+type Usage struct {
+	P95     float64
+	Mean    float64
+	Samples int // number of history points; ≤1 means a single metrics-server snapshot
+}
+
+// SnapshotUsage builds a Usage from a single live value (no history available).
+func SnapshotUsage(v float64) Usage {
+	return Usage{P95: v, Mean: v, Samples: 1}
+}
+
+// Recommendation holds the suggested request and limit for one resource.
+// Changed is false when current values are already within thresholds.
+type Recommendation struct {
+	Request int64 `json:"request"`
+	Limit   int64 `json:"limit"`
+	Changed bool  `json:"changed"`
+}
+
+// Recommend returns the request and limit the dashboard would suggest for one resource,
+// given current values (0 = not set) and observed usage. Without usage the current values
+// are returned unchanged.
+func Recommend(req, lim int64, u Usage, isCPU bool, th Thresholds) Recommendation {
+	return recommend(req, lim, u, isCPU, th, requestHeadroom)
+}
+
+// recommend is Recommend with a custom request headroom (kubeadjust.io/headroom).
+func recommend(req, lim int64, u Usage, isCPU bool, th Thresholds, headroom float64) Recommendation {
+	rec := Recommendation{Request: req, Limit: lim}
+	if u.P95 <= 0 && u.Mean <= 0 {
+		return rec
+	}
+
+	switch {
+	case req == 0:
+		rec.Request = RoundResource(u.Mean*headroom, isCPU)
+	case u.Mean/float64(req) <= th.Overkill:
+		rec.Request = RoundResource(u.Mean*headroom, isCPU)
+	case u.P95 > float64(req)*requestTooLowTrigger:
+		rec.Request = RoundResource(u.P95*headroom, isCPU)
+	}
+
+	switch {
+	case lim == 0:
+		rec.Limit = RoundResource(u.P95*limitHeadroom, isCPU)
+	case u.P95/float64(lim) >= th.Warning:
+		rec.Limit = RoundResource(u.P95*nearLimitHeadroom, isCPU)
+	case u.P95 > 0 && float64(lim)/u.P95 >= th.LimitOverkill:
+		rec.Limit = RoundResource(u.P95*limitHeadroom, isCPU)
+	}
+	// A suggested limit below the request would be rejected by the API server.
+	if rec.Limit > 0 && rec.Limit < rec.Request {
+		rec.Limit = rec.Request
+	}
+
+	rec.Changed = rec.Request != req || rec.Limit != lim
+	return rec
+}
+
+// Suggestion kinds, as in the dashboard.
+const (
+	KindDanger   = "danger"
+	KindWarning  = "warning"
+	KindOverkill = "overkill"
+)
+
+// Kinds returns the kind of each suggestion the dashboard shows for one resource of one
+// container: missing request or limit, usage close to the limit, over-provisioned request
+// or limit, request too low. Trend, OOMKill and throttling suggestions need the history
+// and are not reported. Values at or below floor (kubeadjust.io/min-memory, 0 for none)
+// are never over-provisioned. Without usage, nothing is reported.
+func Kinds(req, lim int64, u Usage, th Thresholds, floor int64) []string {
+	if u.P95 <= 0 && u.Mean <= 0 {
+		return nil
+	}
+	var kinds []string
+	if req == 0 {
+		kinds = append(kinds, KindWarning)
+	}
+	if lim == 0 {
+		kinds = append(kinds, KindWarning)
+	} else if pct := u.P95 / float64(lim); pct >= th.Danger {
+		kinds = append(kinds, KindDanger)
+	} else if pct >= th.Warning {
+		kinds = append(kinds, KindWarning)
+	}
+	requestOverkill := req > 0 && u.Mean/float64(req) <= th.Overkill
+	if requestOverkill && req > floor {
+		kinds = append(kinds, KindOverkill)
+	}
+	if lim > 0 && u.P95 > 0 && float64(lim)/u.P95 >= th.LimitOverkill && lim > floor {
+		kinds = append(kinds, KindOverkill)
+	}
+	if req > 0 && !requestOverkill && u.P95 > float64(req)*requestTooLowTrigger {
+		if u.P95/float64(req) >= 2 {
+			kinds = append(kinds, KindDanger)
+		} else {
+			kinds = append(kinds, KindWarning)
+		}
+	}
+	return kinds
+}
+
+const mib = 1024 * 1024
+
+// memorySteps are the standard binary memory steps (MiB): powers of 2 plus common thirds.
+var memorySteps = []int64{
+	64, 128, 192, 256, 384, 512, 768, 1024, 1536, 2048,
+	3072, 4096, 6144, 8192, 12288, 16384, 24576, 32768,
+}
+
+// RoundResource rounds a raw value up to the nearest "clean" step:
+// CPU to a multiple of 50m (≤1000m) or 250m (>1000m), memory to a standard binary step
+// (64Mi … 32Gi), then to whole 32Gi blocks.
+func RoundResource(raw float64, isCPU bool) int64 {
+	if raw <= 0 {
+		return 0
+	}
+	if isCPU {
+		if raw <= 1000 {
+			return int64(math.Ceil(raw/50)) * 50
+		}
+		return int64(math.Ceil(raw/250)) * 250
+	}
+	for _, step := range memorySteps {
+		if float64(step*mib) >= raw {
+			return step * mib
+		}
+	}
+	last := memorySteps[len(memorySteps)-1] * mib
+	return int64(math.Ceil(raw/float64(last))) * last
+}
@@ -0,0 +1,80 @@
+package suggestions
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestRoundResource(t *testing.T) {
+	tests := []struct {
+		name  string
+		raw   float64
+		isCPU bool
+		want  int64
+	}{
+		{"zero", 0, true, 0},
+		{"cpu 50m step", 123, true, 150},
+		{"cpu exact step", 1000, true, 1000},
+		{"cpu 250m step above 1 core", 1100, true, 1250},
+		{"memory first step", 10 * mib, false, 64 * mib},
+		{"memory third step", 600 * mib, false, 768 * mib},
+		{"memory above 32Gi", 40 * 1024 * mib, false, 64 * 1024 * mib},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RoundResource(tt.raw, tt.isCPU); got != tt.want {
+				t.Errorf("RoundResource(%v, %v) = %d, want %d", tt.raw, tt.isCPU, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRecommend(t *testing.T) {
+	th := DefaultThresholds()
+	tests := []struct {
+		name     string
+		req, lim int64
+		u        Usage
+		want     Recommendation
+	}{
+		{"no usage keeps values", 500, 1000, Usage{}, Recommendation{Request: 500, Limit: 1000}},
+		{"healthy unchanged", 500, 1000, SnapshotUsage(400), Recommendation{Request: 500, Limit: 1000}},
+		{"over-provisioned request", 1000, 2000, SnapshotUsage(100), Recommendation{Request: 150, Limit: 150, Changed: true}},
+		{"request too low", 100, 2000, Usage{P95: 800, Mean: 400, Samples: 20}, Recommendation{Request: 1250, Limit: 2000, Changed: true}},
+		{"near limit", 500, 1000, SnapshotUsage(950), Recommendation{Request: 1250, Limit: 1500, Changed: true}},
+		{"no request no limit", 0, 0, SnapshotUsage(200), Recommendation{Request: 300, Limit: 300, Changed: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Recommend(tt.req, tt.lim, tt.u, true, th); got != tt.want {
+				t.Errorf("Recommend() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKinds(t *testing.T) {
+	th := DefaultThresholds()
+	tests := []struct {
+		name     string
+		req, lim int64
+		u        Usage
+		floor    int64
+		want     []string
+	}{
+		{"no usage", 0, 0, Usage{}, 0, nil},
+		{"healthy", 500, 1000, SnapshotUsage(400), 0, nil},
+		{"no request no limit", 0, 0, SnapshotUsage(200), 0, []string{KindWarning, KindWarning}},
+		{"near limit", 500, 1000, SnapshotUsage(950), 0, []string{KindDanger, KindWarning}},
+		{"over-provisioned request and limit", 1000, 2000, SnapshotUsage(100), 0, []string{KindOverkill, KindOverkill}},
+		{"at floor", 1000, 2000, SnapshotUsage(100), 2000, nil},
+		{"request far too low", 100, 2000, Usage{P95: 800, Mean: 400, Samples: 20}, 0, []string{KindDanger}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Kinds(tt.req, tt.lim, tt.u, th, tt.floor); !slices.Equal(got, tt.want) {
+				t.Errorf("Kinds() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
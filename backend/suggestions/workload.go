@@ -0,0 +1,94 @@
+package suggestions
+
+import (
+	"math"
+	"slices"
+
+	"github.com/devops-kubeadjust/backend/k8s"
+	"github.com/devops-kubeadjust/backend/resources"
+)
+
+// UsageFromSeries summarises a usage time series the way the dashboard does:
+// P95 (nearest rank) and arithmetic mean.
+func UsageFromSeries(values []float64) Usage {
+	if len(values) == 0 {
+		return Usage{}
+	}
+	sorted := slices.Clone(values)
+	slices.Sort(sorted)
+	idx := max(int(math.Ceil(float64(len(sorted))*0.95))-1, 0)
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	return Usage{P95: sorted[idx], Mean: sum / float64(len(values)), Samples: len(values)}
+}
+
+// Merge combines the usage of the same container across replicas, keeping the highest
+// P95 and mean so a recommendation fits the busiest replica.
+func (u Usage) Merge(o Usage) Usage {
+	return Usage{P95: max(u.P95, o.P95), Mean: max(u.Mean, o.Mean), Samples: max(u.Samples, o.Samples)}
+}
+
+// ContainerUsage is the observed CPU (millicores) and memory (bytes) usage of one container.
+type ContainerUsage struct {
+	CPU    Usage
+	Memory Usage
+}
+
+// ContainerRecommendation holds the recommendations for one container of a pod template.
+type ContainerRecommendation struct {
+	Container string         `json:"container"`
+	CPU       Recommendation `json:"cpu"`
+	Memory    Recommendation `json:"memory"`
+}
+
+// Changed reports whether any value differs from the pod template.
+func (r ContainerRecommendation) Changed() bool {
+	return r.CPU.Changed || r.Memory.Changed
+}
+
+// RecommendContainers evaluates every app container of a pod template against its observed
+// usage, in spec order. Containers without usage are returned unchanged, and so is every
+// container of an ignored workload. sizing (nil for none) sets the request headroom and a
+// floor below which memory is never suggested.
+func RecommendContainers(spec k8s.PodSpec, usage map[string]ContainerUsage, th Thresholds, sizing *resources.SizingPolicy) []ContainerRecommendation {
+	headroom := requestHeadroom
+	var minMemory int64
+	if sizing != nil {
+		if sizing.Headroom > 0 {
+			headroom = sizing.Headroom
+		}
+		if sizing.MinMemory != nil {
+			minMemory = sizing.MinMemory.Bytes
+		}
+	}
+	out := make([]ContainerRecommendation, 0, len(spec.Containers))
+	for _, c := range spec.Containers {
+		cpuReq, cpuLim := resources.ParseCPUMillicores(c.Resources.Requests["cpu"]), resources.ParseCPUMillicores(c.Resources.Limits["cpu"])
+		memReq, memLim := resources.ParseMemoryBytes(c.Resources.Requests["memory"]), resources.ParseMemoryBytes(c.Resources.Limits["memory"])
+		if sizing != nil && sizing.Ignore {
+			out = append(out, ContainerRecommendation{
+				Container: c.Name,
+				CPU:       Recommendation{Request: cpuReq, Limit: cpuLim},
+				Memory:    Recommendation{Request: memReq, Limit: memLim},
+			})
+			continue
+		}
+		u := usage[c.Name]
+		mem := recommend(memReq, memLim, u.Memory, false, th, headroom)
+		if minMemory > 0 && mem.Changed {
+			mem.Request = max(mem.Request, minMemory)
+			if mem.Limit > 0 {
+				mem.Limit = max(mem.Limit, mem.Request)
+			}
+			mem.Changed = mem.Request != memReq || mem.Limit != memLim
+		}
+		out = append(out, ContainerRecommendation{
+			Container: c.Name,
+			CPU:       recommend(cpuReq, cpuLim, u.CPU, true, th, headroom),
+			Memory:    mem,
+		})
+	}
+	return out
+}
@@ -0,0 +1,71 @@
+package suggestions
+
+import (
+	"testing"
+
+	"github.com/devops-kubeadjust/backend/k8s"
+	"github.com/devops-kubeadjust/backend/resources"
+)
+
+func TestUsageFromSeries(t *testing.T) {
+	values := make([]float64, 20)
+	for i := range values {
+		values[i] = float64(i + 1) // 1..20
+	}
+	u := UsageFromSeries(values)
+	if u.P95 != 19 || u.Mean != 10.5 || u.Samples != 20 {
+		t.Errorf("got %+v, want P95 19, mean 10.5, 20 samples", u)
+	}
+	if u := UsageFromSeries(nil); u != (Usage{}) {
+		t.Errorf("empty series: got %+v", u)
+	}
+}
+
+func TestRecommendContainers(t *testing.T) {
+	spec := k8s.PodSpec{Containers: []k8s.Container{
+		{Name: "app", Resources: k8s.ResourceRequire{
+			Requests: map[string]string{"cpu": "1", "memory": "1Gi"},
+			Limits:   map[string]string{"cpu": "2", "memory": "2Gi"},
+		}},
+		{Name: "idle"},
+	}}
+	usage := map[string]ContainerUsage{
+		"app": {CPU: SnapshotUsage(100).Merge(SnapshotUsage(120)), Memory: SnapshotUsage(800 * mib)},
+	}
+	recs := RecommendContainers(spec, usage, DefaultThresholds(), nil)
+	if len(recs) != 2 || recs[0].Container != "app" {
+		t.Fatalf("got %+v, want both containers in spec order", recs)
+	}
+	if !recs[0].CPU.Changed || recs[0].CPU.Request != 200 {
+		t.Errorf("app CPU: got %+v, want request reduced to 200m (busiest replica 120m × 1.3)", recs[0].CPU)
+	}
+	if recs[0].Memory.Changed {
+		t.Errorf("app memory: got %+v, want unchanged", recs[0].Memory)
+	}
+	if recs[1].Changed() {
+		t.Errorf("container without usage should be unchanged: %+v", recs[1])
+	}
+}
+
+func TestRecommendContainersSizing(t *testing.T) {
+	spec := k8s.PodSpec{Containers: []k8s.Container{{Name: "jvm", Resources: k8s.ResourceRequire{
+		Requests: map[string]string{"cpu": "1", "memory": "4Gi"},
+		Limits:   map[string]string{"cpu": "2", "memory": "4Gi"},
+	}}}}
+	usage := map[string]ContainerUsage{"jvm": {CPU: SnapshotUsage(100), Memory: SnapshotUsage(500 * mib)}}
+
+	if rec := RecommendContainers(spec, usage, DefaultThresholds(), &resources.SizingPolicy{Ignore: true})[0]; rec.Changed() || rec.Memory.Request != 4096*mib {
+		t.Errorf("ignored: got %+v, want current values unchanged", rec)
+	}
+
+	// 500Mi × 1.3 → 768Mi without a floor; the 3Gi heap floor wins
+	floor := &resources.SizingPolicy{MinMemory: &resources.ResourceValue{Raw: "3Gi", Bytes: 3072 * mib}}
+	if rec := RecommendContainers(spec, usage, DefaultThresholds(), floor)[0]; rec.Memory.Request != 3072*mib || rec.Memory.Limit < rec.Memory.Request {
+		t.Errorf("min-memory: got %+v, want request 3Gi", rec.Memory)
+	}
+
+	// 100m × 2 → 200m instead of 100m × 1.3 → 150m
+	if rec := RecommendContainers(spec, usage, DefaultThresholds(), &resources.SizingPolicy{Headroom: 2})[0]; rec.CPU.Request != 200 {
+		t.Errorf("headroom: got CPU %+v, want request 200m", rec.CPU)
+	}
+}
@@ -2,7 +2,7 @@
 
 import { useEffect, useState, useCallback, useRef, useMemo } from "react";
 import { useRouter, useSearchParams } from "next/navigation";
-import { api, fmtRawValue, type ClusterItem, type NamespaceItem, type NamespaceStats, type DeploymentDetail, type NodeOverview, type ContainerHistory } from "@/lib/api";
+import { api, fmtRawValue, type ClusterItem, type NamespaceItem, type NamespaceStats, type DeploymentDetail, type NodeOverview, type ContainerHistory, type QuotaStatus, type Thresholds } from "@/lib/api";
 import { useSessionState, AUTO_REFRESH_MS, type View } from "@/hooks/useSessionState";
 import { STORAGE_KEYS, MANAGED_TOKEN, safeGetItem, safeSetItem, safeRemoveItem, tokenKey } from "@/lib/storage";
 import DeploymentCard from "@/components/DeploymentCard";
@@ -35,6 +35,8 @@ export default function DashboardPage() {
   const [namespaces, setNamespaces] = useState<NamespaceItem[]>([]);
   const [nsStats, setNsStats] = useState<Map<string, NamespaceStats>>(new Map());
   const [deployments, setDeployments] = useState<DeploymentDetail[]>([]);
+  const [quotas, setQuotas] = useState<QuotaStatus[]>([]);
+  const [thresholds, setThresholds] = useState<Thresholds | undefined>(undefined);
   const [metricsAvailable, setMetricsAvailable] = useState(true);
   const [prometheusAvailable, setPrometheusAvailable] = useState(false);
   const [loadingNs, setLoadingNs] = useState(true);
@@ -123,11 +125,12 @@ export default function DashboardPage() {
 
   const loadDeployments = useCallback(async (ns: string, silent = false) => {
     if (!token || !ns) return;
-    if (!silent) { setLoadingDeps(true); setError(""); setDeployments([]); setNsHistory([]); }
+    if (!silent) { setLoadingDeps(true); setError(""); setDeployments([]); setQuotas([]); setNsHistory([]); }
     loadingRef.current = true;
     try {
       const resp = await api.deployments(token, ns);
       setDeployments(resp.workloads);
+      setQuotas(resp.quotas ?? []);
       setMetricsAvailable(resp.metricsAvailable);
       setPrometheusAvailable(resp.prometheusAvailable);
       setLastRefresh(new Date());
@@ -168,6 +171,17 @@ export default function DashboardPage() {
     if (token && view === "nodes" && nodes.length === 0) loadNodes();
   }, [view, token, loadNodes, nodes.length]);
 
+  // Status thresholds of the cluster, overridden by the namespace's kubeadjust.io/thresholds annotation
+  useEffect(() => {
+    if (!token || !selectedNs || view !== "namespaces") return;
+    api.thresholds(token, selectedNs)
+      .then((t) => {
+        if (t.error) console.warn(`namespace ${selectedNs}: ${t.error}`);
+        setThresholds(t);
+      })
+      .catch((e) => { console.warn("thresholds unavailable, using defaults:", e); setThresholds(undefined); });
+  }, [token, cluster, selectedNs, view]);
+
   // Re-fetch history when time range changes
   useEffect(() => {
     if (!token || !selectedNs || !prometheusAvailable || view !== "namespaces") return;
@@ -546,6 +560,7 @@ export default function DashboardPage() {
                       prometheusAvailable={prometheusAvailable}
                       token={token}
                       timeRange={timeRange}
+                      thresholds={thresholds}
                       openCards={openCards}
                       onToggleCard={(id) => setOpenCards((prev) => {
                         const next = new Set(prev);
@@ -567,6 +582,8 @@ export default function DashboardPage() {
             <SuggestionPanel
               deployments={visibleDeployments}
               history={nsHistory}
+              quotas={quotas}
+              thresholds={thresholds}
               onOpenCards={handleOpenCards}
               searchQuery={workloadSearch}
             />
@@ -1,6 +1,6 @@
 "use client";
 
-import type { DeploymentDetail, TimeRange } from "@/lib/api";
+import { fmtCost, type DeploymentDetail, type Thresholds, type TimeRange } from "@/lib/api";
 import PodRow from "./PodRow";
 import styles from "./DeploymentCard.module.css";
 
@@ -12,11 +12,12 @@ interface DeploymentCardProps {
   timeRange?: TimeRange;
   openCards?: Set<string>;
   onToggleCard?: (id: string) => void;
+  thresholds?: Thresholds;
 }
 
 export default function DeploymentCard({
   dep, namespace, prometheusAvailable, token, timeRange,
-  openCards, onToggleCard,
+  openCards, onToggleCard, thresholds,
 }: DeploymentCardProps) {
   const cardId = `dep:${dep.name}`;
   const open = openCards?.has(cardId) ?? false;
@@ -35,6 +36,14 @@ export default function DeploymentCard({
         <span className={styles.replicas} style={{ color: statusColor }}>
           {dep.readyReplicas}/{dep.replicas} ready
         </span>
+        {dep.events && dep.events.length > 0 && (
+          <span className={styles.eventBadge}>⚠ {dep.events.length}</span>
+        )}
+        {dep.cost && (
+          <span className={styles.cost} title={`${fmtCost(dep.cost.wasted, dep.cost.currency)}/mo wasted`}>
+            {fmtCost(dep.cost.requested, dep.cost.currency)}/mo
+          </span>
+        )}
         <span className={styles.pods}>
           {(dep.pods ?? []).length} pod{(dep.pods ?? []).length !== 1 ? "s" : ""}
         </span>
@@ -42,6 +51,17 @@ export default function DeploymentCard({
 
       {open && (
         <div className={styles.body}>
+          {dep.events && dep.events.length > 0 && (
+            <ul className={styles.events}>
+              {dep.events.map((ev) => (
+                <li key={`${ev.object}/${ev.reason}/${ev.lastSeen}`} title={ev.lastSeen}>
+                  <span className={styles.eventReason}>{ev.reason}</span>
+                  {ev.count > 1 && <span className={styles.eventCount}>×{ev.count}</span>}
+                  {" "}{ev.message}
+                </li>
+              ))}
+            </ul>
+          )}
           {!dep.pods || dep.pods.length === 0 ? (
             <p className={styles.empty}>No pods found.</p>
           ) : (
@@ -56,6 +76,7 @@ export default function DeploymentCard({
                 openCards={openCards}
                 onToggleCard={onToggleCard}
                 deploymentName={dep.name}
+                thresholds={thresholds}
               />
             ))
           )}
@@ -1,7 +1,7 @@
 "use client";
 
 import { useState, useEffect, useRef } from "react";
-import type { PodDetail, HistoryResponse, EphemeralStorageInfo, ResourceValue, TimeRange, DataPoint } from "@/lib/api";
+import type { PodDetail, HistoryResponse, EphemeralStorageInfo, ResourceValue, TimeRange, DataPoint, Thresholds } from "@/lib/api";
 import { api, fmtStorage, storagePct } from "@/lib/api";
 import { resourceStatus, storageStatus } from "@/lib/suggestions";
 import { STATUS_COLOR, shortPodName } from "@/lib/status";
@@ -28,11 +28,12 @@ interface PodRowProps {
   openCards?: Set<string>;
   onToggleCard?: (id: string) => void;
   deploymentName?: string;
+  thresholds?: Thresholds;
 }
 
 export default function PodRow({
   pod, namespace, prometheusAvailable, token, timeRange = "1h",
-  openCards, onToggleCard, deploymentName,
+  openCards, onToggleCard, deploymentName, thresholds,
 }: PodRowProps) {
   const podId = `pod:${pod.name}`;
   const open = openCards?.has(podId) ?? false;
@@ -92,16 +93,31 @@ export default function PodRow({
         <div className={styles.body}>
           {pod.containers.map((c) => {
             const hist = history[c.name];
-            const cpuStatus = resourceStatus(c.usage?.cpu, c.requests.cpu, c.limits.cpu, true);
-            const memStatus = resourceStatus(c.usage?.memory, c.requests.memory, c.limits.memory, false);
+            const cpuStatus = resourceStatus(c.usage?.cpu, c.requests.cpu, c.limits.cpu, true, thresholds);
+            const memStatus = resourceStatus(c.usage?.memory, c.requests.memory, c.limits.memory, false, thresholds);
             const containerId = deploymentName ? `container-${deploymentName}-${pod.name}-${c.name}` : undefined;
             return (
               <div key={c.name} id={containerId} className={styles.container}>
-                <div className={styles.containerName}>{c.name}</div>
+                <div className={styles.containerName}>
+                  {c.name}
+                  {(c.restartCount ?? 0) > 0 && (
+                    <span
+                      className={styles.restarts}
+                      title={c.lastTermination
+                        ? `Last terminated: ${c.lastTermination.reason} (exit ${c.lastTermination.exitCode})${c.lastTermination.finishedAt ? ` at ${c.lastTermination.finishedAt}` : ""}`
+                        : undefined}
+                    >
+                      ↻ {c.restartCount}{c.lastTermination ? ` · ${c.lastTermination.reason}` : ""}
+                    </span>
+                  )}
+                  {c.state === "waiting" && c.stateReason && (
+                    <span className={styles.restarts}>{c.stateReason}</span>
+                  )}
+                </div>
 
                 <div className={styles.resources}>
                   <div className={styles.resourceRow}>
-                    <ResourceBar label="CPU" request={c.requests.cpu} limit={c.limits.cpu} usage={c.usage?.cpu} isCPU={true} />
+                    <ResourceBar label="CPU" request={c.requests.cpu} limit={c.limits.cpu} usage={c.usage?.cpu} isCPU={true} thresholds={thresholds} />
                     {hist && hist.cpu.length >= 2 && (
                       <Sparkline
                         points={hist.cpu.map((p) => p.v)}
@@ -117,7 +133,7 @@ export default function PodRow({
                     )}
                   </div>
                   <div className={styles.resourceRow}>
-                    <ResourceBar label="Memory" request={c.requests.memory} limit={c.limits.memory} usage={c.usage?.memory} isCPU={false} />
+                    <ResourceBar label="Memory" request={c.requests.memory} limit={c.limits.memory} usage={c.usage?.memory} isCPU={false} thresholds={thresholds} />
                     {hist && hist.memory.length >= 2 && (
                       <Sparkline
                         points={hist.memory.map((p) => p.v)}
@@ -1,6 +1,6 @@
 "use client";
 
-import type { ResourceValue } from "@/lib/api";
+import type { ResourceValue, Thresholds } from "@/lib/api";
 import { fmtCPU, fmtMemory } from "@/lib/api";
 import { resourceStatus } from "@/lib/suggestions";
 import { STATUS_COLOR, STATUS_LABEL } from "@/lib/status";
@@ -12,11 +12,12 @@ interface Props {
   limit: ResourceValue;
   usage?: ResourceValue;
   isCPU: boolean;
+  thresholds?: Thresholds;
 }
 
-export default function ResourceBar({ label, request, limit, usage, isCPU }: Props) {
+export default function ResourceBar({ label, request, limit, usage, isCPU, thresholds }: Props) {
   const fmt = isCPU ? fmtCPU : fmtMemory;
-  const status = resourceStatus(usage, request, limit, isCPU);
+  const status = resourceStatus(usage, request, limit, isCPU, thresholds);
   const color = STATUS_COLOR[status];
 
   const limitVal = isCPU ? (limit.millicores ?? 0) : (limit.bytes ?? 0);
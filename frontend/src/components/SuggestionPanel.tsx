@@ -1,7 +1,7 @@
 "use client";
 
 import { useState, useMemo, useCallback } from "react";
-import type { DeploymentDetail, ContainerHistory } from "@/lib/api";
+import type { DeploymentDetail, ContainerHistory, QuotaStatus, Thresholds } from "@/lib/api";
 import { computeSuggestions, toKubectlCmd, type Suggestion, type SuggestionKind } from "@/lib/suggestions";
 import styles from "./SuggestionPanel.module.css";
 
@@ -107,6 +107,12 @@ function SuggestionItem({ s, onOpenCards }: { s: Suggestion; onOpenCards?: (ids:
         <span className={styles.resourceTag}>{s.resource}</span>
       </div>
       <p className={styles.itemMsg}>{s.message}</p>
+      {(s.exceedsQuota || s.fromLimitRange) && (
+        <div className={styles.itemFlags}>
+          {s.exceedsQuota && <span className={styles.quotaFlag}>exceeds namespace quota</span>}
+          {s.fromLimitRange && <span className={styles.limitRangeFlag}>current value from LimitRange default</span>}
+        </div>
+      )}
       <div className={styles.itemAction}>
         <span className={styles.actionLabel}>{s.action}</span>
         <span className={styles.arrow}>→</span>
@@ -164,11 +170,13 @@ function SuggestionGroup({ kind, items, open, onToggle, onOpenCards }: Suggestio
 interface SuggestionPanelProps {
   deployments: DeploymentDetail[];
   history?: ContainerHistory[];
+  quotas?: QuotaStatus[];
+  thresholds?: Thresholds;
   onOpenCards?: (ids: string[], scrollTarget: string) => void;
   searchQuery?: string;
 }
 
-export default function SuggestionPanel({ deployments, history, onOpenCards, searchQuery }: SuggestionPanelProps) {
+export default function SuggestionPanel({ deployments, history, quotas, thresholds, onOpenCards, searchQuery }: SuggestionPanelProps) {
   // --- Open/close per kind group (useCallback prevents stale closure on rapid re-renders) ---
   const [openGroups, setOpenGroups] = useState<Map<string, boolean>>(new Map());
   const [exportCopied, setExportCopied] = useState(false);
@@ -200,7 +208,7 @@ export default function SuggestionPanel({ deployments, history, onOpenCards, sea
   }, []);
 
   // --- Compute ---
-  const allSuggestions = useMemo(() => computeSuggestions(deployments, history), [deployments, history]);
+  const allSuggestions = useMemo(() => computeSuggestions(deployments, history, quotas, thresholds), [deployments, history, quotas, thresholds]);
 
   const searchFiltered = useMemo(() => {
     const q = searchQuery?.toLowerCase() ?? "";
@@ -30,20 +30,57 @@ export interface VolumeDetail {
   available?: ResourceValue;
 }
 
+export interface ContainerTermination {
+  reason: string; // e.g. "OOMKilled", "Error", "Completed"
+  exitCode: number;
+  finishedAt?: string; // RFC3339
+}
+
 export interface ContainerResources {
   name: string;
   requests: ResourcePair;
   limits: ResourcePair;
   usage?: ResourcePair;
   ephemeralStorage?: EphemeralStorageInfo;
+  state?: "running" | "waiting" | "terminated";
+  stateReason?: string; // e.g. "CrashLoopBackOff", "OOMKilled"
+  restartCount?: number;
+  lastTermination?: ContainerTermination;
+  cost?: Cost; // set when pricing is configured
+  defaulted?: string[]; // values injected by a LimitRange, e.g. ["requests.cpu", "limits.memory"]
+}
+
+/** Monthly cost (730 h). used/wasted are 0 when live usage is unavailable. */
+export interface Cost {
+  currency: string;
+  requested: number;
+  used: number;
+  wasted: number; // requested but unused
+}
+
+export interface WorkloadEvent {
+  reason: string; // FailedScheduling | Evicted | BackOff
+  message: string;
+  count: number;
+  lastSeen: string; // RFC3339
+  object: string;   // e.g. "Pod/web-7b5f8c6d4-x9j2k"
 }
 
 export interface PodDetail {
   name: string;
   namespace?: string;
   phase: string;
+  restarts?: number; // sum of container restart counts
   containers: ContainerResources[];
   volumes?: VolumeDetail[];
+  events?: WorkloadEvent[];
+}
+
+/** Per-workload tuning from the kubeadjust.io/ignore, min-memory and headroom annotations. */
+export interface SizingPolicy {
+  ignore?: boolean;         // no suggestions at all (intentionally over-provisioned)
+  minMemory?: ResourceValue; // memory is never suggested below this
+  headroom?: number;        // request = usage × headroom (default 1.3)
 }
 
 export interface DeploymentDetail {
@@ -54,6 +91,9 @@ export interface DeploymentDetail {
   readyReplicas: number;
   availableReplicas: number;
   pods: PodDetail[];
+  events?: WorkloadEvent[]; // workload + pod Warning events, newest first
+  cost?: Cost; // sum of container costs
+  sizing?: SizingPolicy;
 }
 
 export interface NodeResources {
@@ -79,12 +119,124 @@ export interface NodeOverview {
   usage?: NodeResources;
   podCount: number;
   maxPods: number;
+  labels?: Record<string, string>;
+  unschedulable?: boolean; // cordoned
+  pool?: string;
+  zone?: string;
+  region?: string;
+  instanceType?: string;
   kernelVersion?: string;
   osImage?: string;
   age?: string;
   diskPressure: boolean;
   memoryPressure: boolean;
   pidPressure: boolean;
+  kubelet?: NodeKubeletStats; // absent when the kubelet cannot be reached
+  reserved: NodeReserved;
+  podSlotRatio: number; // podCount / maxPods
+  ephemeralStorage: NodeStorage;
+  bottleneck?: "pods" | "ephemeral-storage"; // limits scheduling before CPU or memory
+}
+
+export interface NodeStorage {
+  capacity: ResourceValue;
+  allocatable: ResourceValue;
+  requested: ResourceValue;
+  used?: ResourceValue; // kubelet root filesystem
+}
+
+// capacity - allocatable; parts only when the kubelet configz is readable
+export interface NodeReserved {
+  total: NodeResources;
+  cpuRatio: number;    // of capacity
+  memoryRatio: number;
+  kubeReserved?: NodeResources;
+  systemReserved?: NodeResources;
+  evictionMemory?: ResourceValue; // evictionHard memory.available
+}
+
+export interface FsUsage {
+  capacity: ResourceValue;
+  used: ResourceValue;
+  available: ResourceValue;
+  usedRatio: number;        // 1 - available/capacity
+  inodesUsedRatio?: number;
+}
+
+export interface NodeKubeletStats {
+  fs?: FsUsage;      // root filesystem: ephemeral storage, logs, emptyDirs
+  imageFs?: FsUsage; // images and writable layers
+  pids?: { running: number; max: number; ratio: number };
+  network?: { interface: string; rxBytes: number; txBytes: number; rxErrors: number; txErrors: number };
+  systemContainers?: { name: string; cpu?: ResourceValue; memory?: ResourceValue }[];
+}
+
+export interface NodePool {
+  name: string;  // "" for nodes without a pool label
+  label: string; // node label the pool was read from
+  nodeCount: number;
+  readyNodes: number;
+  capacity: NodeResources;
+  allocatable: NodeResources;
+  requested: NodeResources;
+  limited: NodeResources;
+  usage?: NodeResources; // every node reported by metrics-server
+  podCount: number;
+  maxPods: number;
+}
+
+export interface ZoneOverview {
+  zone: string; // "" for nodes without a zone label
+  region?: string;
+  nodeCount: number;
+  instanceTypes?: Record<string, number>;
+  allocatable: NodeResources;
+  requested: NodeResources;
+  usage?: NodeResources; // every node reported by metrics-server
+  podCount: number;
+  requestRatio: number; // max of CPU and memory requested / allocatable
+  fuller: boolean;
+}
+
+export interface ZoneWorkload {
+  namespace: string;
+  kind: "Deployment" | "StatefulSet";
+  name: string;
+  zone: string;
+  replicas: number;
+}
+
+export interface TopologyResponse {
+  zones: ZoneOverview[];
+  singleZoneWorkloads: ZoneWorkload[];
+}
+
+export interface PendingPod {
+  name: string;
+  namespace: string;
+  owner?: string; // e.g. "ReplicaSet/web-7b5f8c6d4"
+  createdAt?: string;
+  reason?: string;  // PodScheduled condition reason
+  message?: string; // e.g. "0/5 nodes are available: 3 Insufficient cpu."
+  requested: NodeResources;
+  fitsAsRequested: boolean;
+  estimate?: NodeResources; // requests if they matched running siblings' P95 usage
+  estimateSource?: "prometheus" | "metrics-server";
+  fitsAtEstimate: boolean;
+  candidateNodes?: string[];
+}
+
+export interface NodeFree {
+  name: string;
+  freeCpuM: number;
+  freeMemB: number;
+}
+
+export interface PendingResponse {
+  pods: PendingPod[];
+  nodes: NodeFree[];
+  estimateRange?: string;
+  prometheusAvailable: boolean;
 }
 
 export interface NamespaceItem {
@@ -101,6 +253,50 @@ export interface NamespaceStats {
   memUsageB: number; // 0 if metrics-server unavailable
   cpuRatio: number; // lim/req; 0 = no requests set
   memRatio: number;
+  cost?: Cost; // set when pricing is configured
+  quotas?: QuotaStatus[];
+  limitRanges?: LimitRangeDefaults[];
+  defaultedContainers?: number; // containers with LimitRange-injected values
+}
+
+export interface QuotaResourceRecommendation {
+  resource: string; // "requests.cpu" | "requests.memory" | "limits.cpu" | "limits.memory"
+  hard: number;     // millicores for CPU, bytes for memory
+  used: number;
+  p95Usage?: number;
+  suggested: number;
+  action: "reduce" | "increase" | "keep";
+  reason: string;
+}
+
+export interface QuotaRecommendation {
+  namespace: string;
+  quota: string;
+  resources: QuotaResourceRecommendation[];
+}
+
+export interface QuotaRecommendationResponse {
+  recommendations: QuotaRecommendation[];
+  usageSource: "prometheus" | "metrics-server" | "none";
+  range?: string;
+}
+
+export interface AllocationGroup {
+  keys: Record<string, string>; // groupBy token → value ("" when the label is missing)
+  pods: number;
+  cpuRequestedM: number;
+  cpuLimitedM: number;
+  memRequestedB: number;
+  memLimitedB: number;
+  cpuUsageM: number;
+  memUsageB: number;
+  cost?: Cost;
+}
+
+export interface AllocationResponse {
+  groupBy: string[];
+  groups: AllocationGroup[];
+  metricsAvailable: boolean;
 }
 
 /** Typed error thrown by apiFetch when the backend returns a non-2xx status. */
@@ -177,6 +373,8 @@ export interface DataPoint {
 export interface HistoryResponse {
   cpu: DataPoint[];
   memory: DataPoint[];
+  cpuThrottling?: DataPoint[]; // throttled / total CFS periods (0–1)
+  oomKilled?: boolean;         // OOMKilled at least once in the last 7 days
 }
 
 export type TimeRange = "1h" | "6h" | "24h" | "7d";
@@ -186,20 +384,59 @@ export interface ContainerHistory {
   container: string;
   cpu: DataPoint[];
   memory: DataPoint[];
+  cpuThrottling?: DataPoint[];
+  oomKilled?: boolean;
 }
 
 export interface NamespaceHistoryResponse {
   containers: ContainerHistory[];
 }
 
+export interface QuotaUsage {
+  hard: ResourceValue;
+  used: ResourceValue;
+}
+
+/** ResourceQuota compute resources keyed by "requests.cpu" | "requests.memory" | "limits.cpu" | "limits.memory". */
+export interface QuotaStatus {
+  name: string;
+  namespace?: string;
+  resources: Record<string, QuotaUsage>;
+}
+
+export interface LimitRangeDefaults {
+  name: string;
+  defaultRequest?: ResourcePair;
+  default?: ResourcePair; // default limits
+}
+
 export interface WorkloadResponse {
   workloads: DeploymentDetail[];
   metricsAvailable: boolean;
   prometheusAvailable: boolean;
+  quotas?: QuotaStatus[];
+  skippedNamespaces?: string[]; // /workloads: forbidden or deleted namespaces
+}
+
+/** Status ratios — usage/limit for danger and warning, mean usage/request for overkill,
+ *  limit/P95 usage for limitOverkill. */
+export interface Thresholds {
+  danger: number;
+  warning: number;
+  overkill: number;
+  limitOverkill: number;
+}
+
+export interface ThresholdsResponse extends Thresholds {
+  source: "global" | "cluster" | "namespace";
+  cluster: string;
+  namespace?: string;
+  error?: string; // invalid kubeadjust.io/thresholds annotation, ignored
 }
 
 export interface NodesResponse {
   nodes: NodeOverview[];
+  pools: NodePool[];
   prometheusAvailable: boolean;
 }
 
@@ -212,12 +449,24 @@ export const api = {
     apiFetch<NamespaceItem[]>("/namespaces", token),
   namespaceStats: (token: string) =>
     apiFetch<NamespaceStats[]>("/namespaces/stats", token),
+  quotaRecommendations: (token: string, range?: TimeRange) =>
+    apiFetch<QuotaRecommendationResponse>(`/namespaces/quota-recommendations${range ? `?range=${range}` : ""}`, token),
+  allocation: (token: string, groupBy: string) =>
+    apiFetch<AllocationResponse>(`/allocation?groupBy=${encodeURIComponent(groupBy)}`, token),
   deployments: (token: string, namespace: string) =>
     apiFetch<WorkloadResponse>(`/namespaces/${namespace}/deployments`, token),
+  workloads: (token: string, namespaces: string[], namespaceSelector?: string) =>
+    apiFetch<WorkloadResponse>(`/workloads?namespaces=${namespaces.map(encodeURIComponent).join(",")}${namespaceSelector ? `&namespaceSelector=${encodeURIComponent(namespaceSelector)}` : ""}`, token),
+  thresholds: (token: string, namespace?: string) =>
+    apiFetch<ThresholdsResponse>(`/config/thresholds${namespace ? `?namespace=${encodeURIComponent(namespace)}` : ""}`, token),
   nodes: (token: string) =>
     apiFetch<NodesResponse>("/nodes", token),
+  topology: (token: string) =>
+    apiFetch<TopologyResponse>("/topology", token),
   nodePods: (token: string, nodeName: string) =>
     apiFetch<PodDetail[]>(`/nodes/${encodeURIComponent(nodeName)}/pods`, token),
+  pendingPods: (token: string, range?: TimeRange) =>
+    apiFetch<PendingResponse>(`/pods/pending${range ? `?range=${range}` : ""}`, token),
   containerHistory: (token: string, namespace: string, pod: string, container: string, range?: TimeRange) =>
     apiFetch<HistoryResponse>(`/namespaces/${namespace}/prometheus/${encodeURIComponent(pod)}/${encodeURIComponent(container)}${range ? `?range=${range}` : ""}`, token),
   namespaceHistory: (token: string, namespace: string, range?: TimeRange) =>
@@ -264,6 +513,18 @@ export function fmtStorage(rv: ResourceValue | undefined): string {
   return fmtMemory(rv);
 }
 
+/** Formats a monthly cost amount with its currency, e.g. "$1,234" or "12.50 EUR". */
+export function fmtCost(amount: number, currency: string): string {
+  const digits = amount >= 100 ? 0 : 2;
+  try {
+    return new Intl.NumberFormat("en-US", {
+      style: "currency", currency, minimumFractionDigits: digits, maximumFractionDigits: digits,
+    }).format(amount);
+  } catch {
+    return `${amount.toFixed(2)} ${currency}`; // not an ISO 4217 code
+  }
+}
+
 /** Returns usage as a percentage of limit (0–100), or null if either value is missing/zero. */
 export function usagePct(
   usage: ResourceValue | undefined,
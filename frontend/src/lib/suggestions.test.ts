@@ -5,7 +5,7 @@ import {
   buildHistoryMap,
   computeSuggestions,
 } from "./suggestions";
-import type { ResourceValue, ContainerResources, DeploymentDetail, ContainerHistory } from "./api";
+import type { ResourceValue, ContainerResources, DeploymentDetail, ContainerHistory, QuotaStatus } from "./api";
 
 // --- helpers ---
 
@@ -83,6 +83,12 @@ describe("resourceStatus", () => {
     const MiB = 1024 * 1024;
     expect(resourceStatus(mem(900 * MiB), mem(500 * MiB), mem(1000 * MiB), false)).toBe("danger");
   });
+
+  it("uses custom thresholds", () => {
+    const batch = { danger: 0.98, warning: 0.95, overkill: 0.35, limitOverkill: 3 };
+    expect(resourceStatus(cpu(950), cpu(500), cpu(1000), true, batch)).toBe("warning");
+    expect(resourceStatus(cpu(900), cpu(500), cpu(1000), true, batch)).toBe("healthy");
+  });
 });
 
 // --- storageStatus ---
@@ -160,6 +166,12 @@ describe("computeSuggestions", () => {
     expect(cpuDanger?.action).toBe("Increase limit");
   });
 
+  it("honours custom thresholds", () => {
+    const dep = deployment("app", [container("c", { cpuReq: 900, cpuLim: 1000, cpuUse: 950, memUse: 1 })]);
+    const batch = { danger: 0.98, warning: 0.96, overkill: 0.35, limitOverkill: 3 };
+    expect(computeSuggestions([dep], undefined, undefined, batch).find((s) => s.resource === "CPU")).toBeUndefined();
+  });
+
   it("flags warning when usage moderately near limit", () => {
     const dep = deployment("app", [container("c", { cpuReq: 500, cpuLim: 1000, cpuUse: 750, memUse: 1 })]);
     const suggestions = computeSuggestions([dep]);
@@ -220,4 +232,65 @@ describe("computeSuggestions", () => {
     const danger = suggestions.find((s) => s.resource === "CPU" && s.kind === "danger");
     expect(danger).toBeDefined();
   });
+
+  it("flags OOMKilled containers even when current memory looks fine", () => {
+    const hist: ContainerHistory[] = [{ pod: "pod-1", container: "c", cpu: [], memory: [], oomKilled: true }];
+    const dep = deployment("app", [container("c", { memReq: 100, memLim: 200, memUse: 50 })]);
+    const oom = computeSuggestions([dep], hist).find((s) => s.resource === "Memory — OOMKilled");
+    expect(oom).toBeDefined();
+    expect(oom?.kind).toBe("danger");
+  });
+
+  it("flags CPU throttling from history", () => {
+    const cpuThrottling = Array.from({ length: 20 }, (_, i) => ({ t: i, v: 0.3 }));
+    const hist: ContainerHistory[] = [{ pod: "pod-1", container: "c", cpu: [], memory: [], cpuThrottling }];
+    const dep = deployment("app", [container("c", { cpuReq: 100, cpuLim: 200, cpuUse: 50, memUse: 1 })]);
+    const throttled = computeSuggestions([dep], hist).find((s) => s.resource === "CPU — throttled");
+    expect(throttled?.kind).toBe("warning");
+  });
+
+  it("flags OOMKilled from container last termination without Prometheus", () => {
+    const c = { ...container("c", { memReq: 100, memLim: 200, memUse: 50 }),
+      restartCount: 4, lastTermination: { reason: "OOMKilled", exitCode: 137 } };
+    const oom = computeSuggestions([deployment("app", [c])]).find((s) => s.resource === "Memory — OOMKilled");
+    expect(oom?.kind).toBe("danger");
+    expect(oom?.message).toContain("4 restarts");
+  });
+
+  it("flags increases that exceed the namespace quota headroom", () => {
+    const quotas: QuotaStatus[] = [{ name: "team", resources: {
+      "limits.cpu": { hard: cpu(2000), used: cpu(1900) },
+    } }];
+    const dep = deployment("app", [container("c", { cpuReq: 500, cpuLim: 1000, cpuUse: 950, memUse: 1 })]);
+    const inc = computeSuggestions([dep], undefined, quotas).find((s) => s.action === "Increase limit");
+    expect(inc?.exceedsQuota).toBe(true);
+  });
+
+  it("skips workloads annotated kubeadjust.io/ignore", () => {
+    const dep = { ...deployment("app", [container("c", { cpuReq: 1000, cpuLim: 2000, cpuUse: 50, memUse: 1 })]), sizing: { ignore: true } };
+    expect(computeSuggestions([dep])).toHaveLength(0);
+  });
+
+  it("applies kubeadjust.io/headroom and min-memory", () => {
+    const MiB = 1024 * 1024;
+    const c = container("c", { cpuReq: 1000, cpuLim: 2000, cpuUse: 100, memReq: 4096 * MiB, memLim: 4096 * MiB, memUse: 500 * MiB });
+    const dep = { ...deployment("app", [c]), sizing: { headroom: 2, minMemory: mem(3072 * MiB) } };
+    const out = computeSuggestions([dep]);
+    expect(out.find((s) => s.resource === "CPU" && s.action === "Reduce request")?.suggestedRaw).toBe(200);
+    expect(out.find((s) => s.resource === "Memory" && s.action === "Reduce request")?.suggestedRaw).toBe(3072 * MiB);
+  });
+
+  it("drops memory reductions below a min-memory floor at the current value", () => {
+    const MiB = 1024 * 1024;
+    const c = container("c", { cpuReq: 100, cpuLim: 200, cpuUse: 100, memReq: 2048 * MiB, memLim: 2048 * MiB, memUse: 100 * MiB });
+    const dep = { ...deployment("app", [c]), sizing: { minMemory: mem(2048 * MiB) } };
+    expect(computeSuggestions([dep]).filter((s) => s.resource === "Memory")).toHaveLength(0);
+  });
+
+  it("marks suggestions on LimitRange-defaulted values", () => {
+    const c = { ...container("c", { cpuReq: 1000, cpuLim: 2000, cpuUse: 50, memUse: 1 }), defaulted: ["requests.cpu"] };
+    const reduce = computeSuggestions([deployment("app", [c])]).find((s) => s.action === "Reduce request" && s.resource === "CPU");
+    expect(reduce?.fromLimitRange).toBe(true);
+    expect(reduce?.exceedsQuota).toBeUndefined();
+  });
 });
@@ -1,8 +1,11 @@
 import { fmtRawValue } from "./api";
-import type { DataPoint, DeploymentDetail, ContainerResources, ResourceValue, VolumeDetail, ContainerHistory } from "./api";
+import type { DataPoint, DeploymentDetail, ContainerResources, ResourceValue, VolumeDetail, ContainerHistory, QuotaStatus, Thresholds, SizingPolicy } from "./api";
 
 export type SuggestionKind = "danger" | "warning" | "overkill";
 
+/** Built-in thresholds, used until /api/config/thresholds answers (same as the backend defaults). */
+export const DEFAULT_THRESHOLDS: Thresholds = { danger: 0.90, warning: 0.70, overkill: 0.35, limitOverkill: 3 };
+
 export interface Suggestion {
   deployment: string;
   namespace: string;
@@ -15,6 +18,8 @@ export interface Suggestion {
   current: string;
   suggested: string;
   suggestedRaw: number;
+  exceedsQuota?: boolean;   // the increase does not fit in the namespace ResourceQuota headroom
+  fromLimitRange?: boolean; // the current value was injected by a LimitRange default
 }
 
 /** Map of "pod/container" → ContainerHistory for quick lookup. */
@@ -115,9 +120,11 @@ function suggest(raw: number, isCPU: boolean): { suggested: string; suggestedRaw
 }
 
 /** Generates CPU and memory suggestions for a container: danger/warning when near limit, overkill when far below request.
- *  When Prometheus history is available, uses P95 for danger/warning thresholds and mean for overkill detection. */
-function analyzeCpuMem(c: ContainerResources, depName: string, depNamespace: string, podName: string, hist?: ContainerHistory): Suggestion[] {
+ *  When Prometheus history is available, uses P95 for danger/warning thresholds and mean for overkill detection.
+ *  The workload's sizing annotations replace the ×1.3 request headroom and floor memory reductions. */
+function analyzeCpuMem(c: ContainerResources, depName: string, depNamespace: string, podName: string, th: Thresholds, hist?: ContainerHistory, sizing?: SizingPolicy): Suggestion[] {
   const results: Suggestion[] = [];
+  const headroom = sizing?.headroom ?? 1.3;
   for (const isCPU of [true, false]) {
     const label = isCPU ? "CPU" : "Memory";
     const req = val(isCPU ? c.requests.cpu : c.requests.memory, isCPU);
@@ -135,13 +142,14 @@ function analyzeCpuMem(c: ContainerResources, depName: string, depNamespace: str
     const confidence = !hasHistory ? "" : histPoints.length >= 400 ? " · high confidence" : histPoints.length >= 60 ? " · medium confidence" : " · low confidence";
 
     const base = { deployment: depName, namespace: depNamespace, pod: podName, container: c.name };
+    const floor = isCPU ? 0 : (sizing?.minMemory?.bytes ?? 0);
 
     // No request defined — flag it
     if (req === 0) {
       results.push({ ...base, resource: `${label} — no request`, kind: "warning",
         action: "Set request",
         message: `No ${label} request set — scheduler cannot guarantee resources`,
-        current: "none", ...suggest((meanUse > 0 ? meanUse : snapshotUse) * 1.3, isCPU) });
+        current: "none", ...suggest((meanUse > 0 ? meanUse : snapshotUse) * headroom, isCPU) });
     }
     // No limit defined — flag it
     if (lim === 0) {
@@ -152,12 +160,12 @@ function analyzeCpuMem(c: ContainerResources, depName: string, depNamespace: str
     }
     if (lim > 0) {
       const pct = p95Use / lim;
-      if (pct >= 0.90) {
+      if (pct >= th.danger) {
         results.push({ ...base, resource: label, kind: "danger",
           action: "Increase limit",
           message: `${label} P95 usage at ${Math.round(pct * 100)}% of limit${confidence}`,
           current: fmtRawValue(lim, isCPU), ...suggest(p95Use * 1.4, isCPU) });
-      } else if (pct >= 0.70) {
+      } else if (pct >= th.warning) {
         results.push({ ...base, resource: label, kind: "warning",
           action: "Increase limit",
           message: `${label} P95 usage at ${Math.round(pct * 100)}% of limit${confidence}`,
@@ -177,19 +185,22 @@ function analyzeCpuMem(c: ContainerResources, depName: string, depNamespace: str
         }
       }
     }
-    const requestOverkill = req > 0 && meanUse / req <= 0.35;
-    if (requestOverkill) {
+    const requestOverkill = req > 0 && meanUse / req <= th.overkill;
+    // A min-memory floor at or above the current value leaves nothing to reduce
+    const reducedRequest = suggest(Math.max(meanUse * headroom, floor), isCPU);
+    if (requestOverkill && (floor === 0 || reducedRequest.suggestedRaw < req)) {
       results.push({ ...base, resource: label, kind: "overkill",
         action: "Reduce request",
         message: `${label} ${source} request is ${(req / meanUse).toFixed(1)}× actual usage${confidence}`,
-        current: fmtRawValue(req, isCPU), ...suggest(meanUse * 1.3, isCPU) });
+        current: fmtRawValue(req, isCPU), ...reducedRequest });
     }
-    // Limit over-provisioned: limit is more than 3× P95 usage
-    if (lim > 0 && p95Use > 0 && lim / p95Use >= 3) {
+    // Limit over-provisioned: limit is more than limitOverkill× P95 usage
+    const reducedLimit = suggest(Math.max(p95Use * 1.5, floor), isCPU);
+    if (lim > 0 && p95Use > 0 && lim / p95Use >= th.limitOverkill && (floor === 0 || reducedLimit.suggestedRaw < lim)) {
       results.push({ ...base, resource: label, kind: "overkill",
         action: "Reduce limit",
         message: `${label} limit is ${(lim / p95Use).toFixed(1)}× P95 usage${confidence}`,
-        current: fmtRawValue(lim, isCPU), ...suggest(p95Use * 1.5, isCPU) });
+        current: fmtRawValue(lim, isCPU), ...reducedLimit });
     }
     // Request too low: P95 usage consistently exceeds request (only when not already flagged as overkill)
     if (req > 0 && !requestOverkill && p95Use > req * 1.1) {
@@ -198,14 +209,51 @@ function analyzeCpuMem(c: ContainerResources, depName: string, depNamespace: str
       results.push({ ...base, resource: label, kind,
         action: "Increase request",
         message: `${label} ${source} usage is ${ratio.toFixed(1)}× the request — pod may be throttled or evicted${confidence}`,
-        current: fmtRawValue(req, isCPU), ...suggest(p95Use * 1.3, isCPU) });
+        current: fmtRawValue(req, isCPU), ...suggest(p95Use * headroom, isCPU) });
+    }
+  }
+  return results;
+}
+
+/** Generates suggestions from failure signals that snapshot usage cannot show:
+ *  OOM kills (memory limit too low even if current usage looks fine) and CPU throttling.
+ *  OOM kills come from Prometheus (last 7 days) or, without Prometheus, from the container's last termination. */
+function analyzeSignals(c: ContainerResources, depName: string, depNamespace: string, podName: string, hist?: ContainerHistory): Suggestion[] {
+  const results: Suggestion[] = [];
+  const base = { deployment: depName, namespace: depNamespace, pod: podName, container: c.name };
+
+  const oomFromStatus = c.lastTermination?.reason === "OOMKilled" && (c.restartCount ?? 0) > 0;
+  if (hist?.oomKilled || oomFromStatus) {
+    const lim = val(c.limits.memory, false);
+    const memPoints = (hist?.memory ?? []).map((p) => p.v);
+    const peak = memPoints.length > 0 ? Math.max(...memPoints) : val(c.usage?.memory, false);
+    const restarts = c.restartCount ? ` (${c.restartCount} restart${c.restartCount !== 1 ? "s" : ""})` : "";
+    results.push({ ...base, resource: "Memory — OOMKilled", kind: "danger",
+      action: "Increase limit",
+      message: hist?.oomKilled
+        ? `Container was OOMKilled in the last 7 days${restarts}`
+        : `Container was last terminated by OOMKilled${restarts}`,
+      current: lim > 0 ? fmtRawValue(lim, false) : "unlimited",
+      ...suggest(Math.max(lim, peak) * 1.5, false) });
+  }
+
+  if (!hist) return results;
+  const throttling = (hist.cpuThrottling ?? []).map((p) => p.v);
+  if (throttling.length >= 2) {
+    const p95 = percentile95(throttling);
+    const lim = val(c.limits.cpu, true);
+    if (p95 >= 0.25 && lim > 0) {
+      results.push({ ...base, resource: "CPU — throttled", kind: p95 >= 0.5 ? "danger" : "warning",
+        action: "Increase limit",
+        message: `CPU throttled in ${Math.round(p95 * 100)}% of CFS periods (P95)`,
+        current: fmtRawValue(lim, true), ...suggest(lim * 1.5, true) });
     }
   }
   return results;
 }
 
 /** Generates ephemeral storage suggestions: flags missing limits, warns near capacity. */
-function analyzeEphemeral(c: ContainerResources, depName: string, depNamespace: string, podName: string): Suggestion[] {
+function analyzeEphemeral(c: ContainerResources, depName: string, depNamespace: string, podName: string, th: Thresholds): Suggestion[] {
   const eph = c.ephemeralStorage;
   if (!eph?.usage) return [];
   const use = eph.usage.bytes ?? 0;
@@ -222,12 +270,12 @@ function analyzeEphemeral(c: ContainerResources, depName: string, depNamespace:
       current: "unlimited", ...suggest(use * 2, false) });
   } else {
     const pct = use / lim;
-    if (pct >= 0.90) {
+    if (pct >= th.danger) {
       results.push({ ...base, resource: "Ephemeral", kind: "danger",
         action: "Increase limit",
         message: `Ephemeral usage at ${Math.round(pct * 100)}% of limit`,
         current: fmtRawValue(lim, false), ...suggest(use * 1.5, false) });
-    } else if (pct >= 0.70) {
+    } else if (pct >= th.warning) {
       results.push({ ...base, resource: "Ephemeral", kind: "warning",
         action: "Increase limit",
         message: `Ephemeral usage at ${Math.round(pct * 100)}% of limit`,
@@ -271,17 +319,54 @@ function analyzeVolumes(volumes: VolumeDetail[], depName: string, depNamespace:
   return results;
 }
 
+/** Returns the smallest remaining headroom (hard − used) per quota key across all quotas. */
+export function quotaHeadroom(quotas: QuotaStatus[]): Map<string, number> {
+  const headroom = new Map<string, number>();
+  for (const q of quotas) {
+    for (const [key, { hard, used }] of Object.entries(q.resources)) {
+      const isCPU = key.endsWith(".cpu");
+      const free = val(hard, isCPU) - val(used, isCPU);
+      headroom.set(key, Math.min(free, headroom.get(key) ?? Infinity));
+    }
+  }
+  return headroom;
+}
+
+/** Flags CPU/memory suggestions whose increase would not fit in the quota headroom, and those
+ *  whose current value came from a LimitRange default rather than the workload spec. */
+function annotateQuota(suggestions: Suggestion[], c: ContainerResources, headroom?: Map<string, number>): Suggestion[] {
+  for (const s of suggestions) {
+    const isCPU = s.resource.startsWith("CPU");
+    if (!isCPU && !s.resource.startsWith("Memory")) continue;
+    const isRequest = s.action.toLowerCase().includes("request");
+    const key = `${isRequest ? "requests" : "limits"}.${isCPU ? "cpu" : "memory"}`;
+    if (c.defaulted?.includes(key)) s.fromLimitRange = true;
+    const free = headroom?.get(key);
+    if (free === undefined) continue;
+    const current = val(isRequest ? (isCPU ? c.requests.cpu : c.requests.memory) : (isCPU ? c.limits.cpu : c.limits.memory), isCPU);
+    const delta = s.suggestedRaw - current;
+    if (delta > 0 && delta > free) s.exceedsQuota = true;
+  }
+  return suggestions;
+}
+
 /** Computes all suggestions across all workloads, sorted by severity (danger → warning → overkill).
- *  When history is provided, suggestions are weighted with Prometheus P95/mean data. */
-export function computeSuggestions(deployments: DeploymentDetail[], history?: ContainerHistory[]): Suggestion[] {
+ *  When history is provided, suggestions are weighted with Prometheus P95/mean data.
+ *  When quotas are provided, increases that would not fit in the namespace quota are flagged.
+ *  Thresholds default to DEFAULT_THRESHOLDS; pass the namespace's from /api/config/thresholds.
+ *  Workloads annotated kubeadjust.io/ignore are skipped. */
+export function computeSuggestions(deployments: DeploymentDetail[], history?: ContainerHistory[], quotas?: QuotaStatus[], th: Thresholds = DEFAULT_THRESHOLDS): Suggestion[] {
   const histMap = history && history.length > 0 ? buildHistoryMap(history) : undefined;
+  const headroom = quotas && quotas.length > 0 ? quotaHeadroom(quotas) : undefined;
   const out: Suggestion[] = [];
   for (const dep of deployments) {
+    if (dep.sizing?.ignore) continue;
     for (const pod of dep.pods ?? []) {
       for (const c of pod.containers) {
         const hist = histMap?.get(`${pod.name}/${c.name}`);
-        out.push(...analyzeCpuMem(c, dep.name, dep.namespace, pod.name, hist));
-        out.push(...analyzeEphemeral(c, dep.name, dep.namespace, pod.name));
+        out.push(...annotateQuota(analyzeCpuMem(c, dep.name, dep.namespace, pod.name, th, hist, dep.sizing), c, headroom));
+        out.push(...analyzeSignals(c, dep.name, dep.namespace, pod.name, hist));
+        out.push(...analyzeEphemeral(c, dep.name, dep.namespace, pod.name, th));
       }
       out.push(...analyzeVolumes(pod.volumes ?? [], dep.name, dep.namespace, pod.name));
     }
@@ -310,15 +395,16 @@ export function resourceStatus(
   req: ResourceValue | undefined,
   lim: ResourceValue | undefined,
   isCPU: boolean,
+  th: Thresholds = DEFAULT_THRESHOLDS,
 ): "danger" | "warning" | "overkill" | "healthy" | "none" {
   if (!use) return "none";
   const u = isCPU ? (use.millicores ?? 0) : (use.bytes ?? 0);
   const l = lim ? (isCPU ? (lim.millicores ?? 0) : (lim.bytes ?? 0)) : 0;
   const r = req ? (isCPU ? (req.millicores ?? 0) : (req.bytes ?? 0)) : 0;
   if (u === 0) return "none";
-  if (l > 0 && u / l >= 0.90) return "danger";
-  if (l > 0 && u / l >= 0.70) return "warning";
-  if (r > 0 && u / r <= 0.35) return "overkill";
+  if (l > 0 && u / l >= th.danger) return "danger";
+  if (l > 0 && u / l >= th.warning) return "warning";
+  if (r > 0 && u / r <= th.overkill) return "overkill";
   return "healthy";
 }
 